@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"timeline/internal/config"
+	"timeline/internal/libs/passwd"
+	"timeline/internal/repository"
+	"timeline/internal/repository/database/postgres"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `usage: admin -email <email> create
+
+commands:
+  create    create a platform admin. Password is taken from ADMIN_PASSWORD or read from stdin`
+
+const minPasswordLen = 12
+
+func main() {
+	var email string
+	flag.StringVar(&email, "email", "", "admin email")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+
+	if email == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch cmd := flag.Arg(0); cmd {
+	case "create":
+		if err := godotenv.Load(); err != nil {
+			log.Fatal("No .env file found")
+		}
+		cfg := config.MustLoad()
+		password, err := readPassword()
+		if err != nil {
+			log.Fatal("readPassword: ", err)
+		}
+		if len(password) < minPasswordLen {
+			log.Fatalf("password must be at least %d characters", minPasswordLen)
+		}
+		hash, err := passwd.GetHash(password)
+		if err != nil {
+			log.Fatal("GetHash: ", err)
+		}
+		db, err := repository.GetDB(os.Getenv("DB"), cfg.DB)
+		if err != nil {
+			log.Fatal("GetDB: ", err)
+		}
+		if err = db.Open(); err != nil {
+			log.Fatal("Open: ", err)
+		}
+		defer db.Close()
+		adminID, err := db.AdminSave(context.Background(), strings.TrimSpace(email), hash)
+		if err != nil {
+			if errors.Is(err, postgres.ErrAdminExists) {
+				log.Fatalf("admin %s already exists", email)
+			}
+			log.Fatal("AdminSave: ", err)
+		}
+		fmt.Println(adminID)
+	default:
+		log.Printf("unknown command %q", cmd)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// Пароль не передается флагом, чтобы не остаться в истории команд
+func readPassword() (string, error) {
+	if password := os.Getenv("ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+	fmt.Fprint(os.Stderr, "password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"timeline/internal/libs/envars"
+	"timeline/internal/libs/secret"
+)
+
+const usage = `usage: keys -dir <path> <command>
+
+commands:
+  list             show keys of the ring
+  generate         create a new key (published in JWKS, not used for signing yet)
+  promote <kid>    make the key active, the previous one is retired
+  prune            delete keys retired more than -grace ago
+
+Running services pick up the changes after restart.`
+
+func main() {
+	var dir string
+	var grace time.Duration
+	flag.StringVar(&dir, "dir", "", "path to the keys folder (default SECRET_KEYS_DIR)")
+	flag.DurationVar(&grace, "grace", 24*time.Hour, "how long retired keys stay valid (token.key_grace)")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	// путь из окружения трактуется так же, как в сервисе
+	if dir == "" && os.Getenv("SECRET_KEYS_DIR") != "" {
+		dir = envars.GetPath("SECRET_KEYS_DIR")
+	}
+
+	if dir == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch cmd := flag.Arg(0); cmd {
+	case "list":
+		manifest, err := secret.ReadManifest(dir)
+		if err != nil {
+			log.Fatal("ReadManifest: ", err)
+		}
+		for _, key := range manifest.Keys {
+			status := "verify"
+			switch {
+			case key.ID == manifest.Active:
+				status = "active"
+			case key.RetiredAt != nil:
+				status = "retired " + key.RetiredAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%s\t%s\t%s\n", key.ID, key.CreatedAt.Format(time.RFC3339), status)
+		}
+	case "generate":
+		kid, err := secret.GenerateKey(dir)
+		if err != nil {
+			log.Fatal("GenerateKey: ", err)
+		}
+		fmt.Println(kid)
+	case "promote":
+		if flag.NArg() < 2 {
+			log.Fatal("promote: kid is required")
+		}
+		if err := secret.Promote(dir, flag.Arg(1)); err != nil {
+			log.Fatal("Promote: ", err)
+		}
+	case "prune":
+		removed, err := secret.Prune(dir, grace)
+		if err != nil {
+			log.Fatal("Prune: ", err)
+		}
+		for _, kid := range removed {
+			fmt.Println("removed", kid)
+		}
+	default:
+		log.Printf("unknown command %q", cmd)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -59,7 +59,7 @@ func main() {
 	defer post.Shutdown()
 
 	App := app.New(cfg.App, Logs)
-	err = App.SetupControllers(cfg.Token, db, post)
+	err = App.SetupControllers(cfg.Token, cfg.Throttle, cfg.OIDC, db, post)
 	if err != nil {
 		Logs.Fatal(
 			"failed setup controllers",
@@ -67,7 +67,7 @@ func main() {
 		)
 	}
 
-	s := cronjob.InitCronScheduler(db)
+	s := cronjob.InitCronScheduler(db, cfg.Privacy.Retention)
 	defer s.Shutdown()
 	s.Start()
 
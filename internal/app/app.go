@@ -4,17 +4,25 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"timeline/internal/config"
 	"timeline/internal/controller"
 	authctrl "timeline/internal/controller/auth"
+	"timeline/internal/controller/domens/admin"
 	"timeline/internal/controller/domens/orgs"
 	"timeline/internal/controller/domens/records"
 	"timeline/internal/controller/domens/users"
 	validation "timeline/internal/controller/validation"
+	"timeline/internal/libs/oidc"
+	"timeline/internal/libs/reqinfo"
 	"timeline/internal/libs/secret"
+	"timeline/internal/libs/throttle"
 	"timeline/internal/repository"
 	"timeline/internal/repository/mail"
+	"timeline/internal/usecase/admincase"
+	"timeline/internal/usecase/audit"
 	auth "timeline/internal/usecase/auth"
+	"timeline/internal/usecase/auth/access"
 	"timeline/internal/usecase/auth/middleware"
 	"timeline/internal/usecase/orgcase"
 	"timeline/internal/usecase/recordcase"
@@ -26,6 +34,7 @@ import (
 
 type App struct {
 	httpServer http.Server
+	proxies    []string
 	log        *zap.Logger
 }
 
@@ -37,7 +46,8 @@ func New(cfgApp config.Application, logger *zap.Logger) *App {
 			WriteTimeout: cfgApp.Timeout,
 			IdleTimeout:  cfgApp.IdleTimeout,
 		},
-		log: logger,
+		proxies: cfgApp.TrustedProxies,
+		log:     logger,
 	}
 	return app
 }
@@ -53,17 +63,70 @@ func (a *App) Stop(ctx context.Context) {
 	a.httpServer.Shutdown(ctx)
 }
 
-func (a *App) SetupControllers(tokenCfg config.Token, storage repository.Repository, mailService mail.Post /*redis*/) error {
-	privateKey, err := secret.LoadPrivateKey()
+func (a *App) SetupControllers(tokenCfg config.Token, throttleCfg config.Throttle, oidcCfg config.OIDC, storage repository.Repository, mailService mail.Post /*redis*/) error {
+	keys, err := secret.LoadKeyRing(tokenCfg.KeyGrace)
 	if err != nil {
 		return err
 	}
+	proxies, err := reqinfo.ParseTrustedProxies(a.proxies)
+	if err != nil {
+		return err
+	}
+	// Ограничение неудачных попыток. Счетчики в памяти процесса
+	attempts := throttle.NewMemoryStore()
+	limits := auth.Limits{
+		Account: throttle.New(attempts, throttle.Policy{
+			MaxFailures: throttleCfg.MaxFailures,
+			Lockout:     throttleCfg.Lockout,
+			BaseDelay:   throttleCfg.BaseDelay,
+			MaxDelay:    throttleCfg.MaxDelay,
+		}),
+		IP: throttle.New(attempts, throttle.Policy{
+			MaxFailures: throttleCfg.IPMaxFailures,
+			Lockout:     throttleCfg.Lockout,
+			BaseDelay:   throttleCfg.BaseDelay,
+			MaxDelay:    throttleCfg.MaxDelay,
+		}),
+		// Одно письмо на почту за паузу: первая же отправка блокирует следующие
+		Cooldown: throttle.New(throttle.NewMemoryStore(), throttle.Policy{
+			MaxFailures: 1,
+			Lockout:     throttleCfg.SendCooldown,
+		}),
+	}
+	// Провайдеры входа. Секреты клиентов берутся из окружения
+	sso := auth.SSO{StateTTL: oidcCfg.StateTTL}
+	for _, p := range oidcCfg.Providers {
+		sso.Providers = append(sso.Providers, oidc.New(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: os.Getenv(p.ClientSecretEnv),
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil))
+	}
+	// Журнал действий общий для всех usecase
+	auditRecorder := audit.New(storage, a.log)
+
 	// Инициализация Auth
 	usecaseAuth := auth.New(
-		privateKey,
+		keys,
+		storage,
+		storage,
+		storage,
+		storage,
+		storage,
+		storage,
+		storage,
+		storage,
 		storage,
 		storage,
 		storage,
+		storage,
+		storage,
+		auditRecorder,
+		limits,
+		sso,
 		mailService,
 		tokenCfg,
 		a.log,
@@ -73,7 +136,7 @@ func (a *App) SetupControllers(tokenCfg config.Token, storage repository.Reposit
 
 	authAPI := authctrl.New(
 		usecaseAuth,
-		middleware.New(privateKey, a.log),
+		middleware.New(keys, storage, proxies, a.log),
 		a.log,
 		json,
 		validator,
@@ -84,6 +147,7 @@ func (a *App) SetupControllers(tokenCfg config.Token, storage repository.Reposit
 		storage,
 		storage,
 		storage,
+		auditRecorder,
 		a.log,
 	)
 
@@ -98,6 +162,8 @@ func (a *App) SetupControllers(tokenCfg config.Token, storage repository.Reposit
 	usecaseOrg := orgcase.New(
 		storage,
 		storage,
+		storage,
+		auditRecorder,
 		a.log,
 	)
 
@@ -112,6 +178,8 @@ func (a *App) SetupControllers(tokenCfg config.Token, storage repository.Reposit
 		storage,
 		storage,
 		storage,
+		auditRecorder,
+		mailService,
 		a.log,
 	)
 
@@ -122,11 +190,28 @@ func (a *App) SetupControllers(tokenCfg config.Token, storage repository.Reposit
 		validator,
 	)
 
+	// Инициализация Admin
+	usecaseAdmin := admincase.New(
+		storage,
+		storage,
+		auditRecorder,
+		a.log,
+	)
+
+	adminAPI := admin.NewAdminCtrl(
+		usecaseAdmin,
+		a.log,
+		json,
+		validator,
+	)
+
 	controllerSet := &controller.Controllers{
 		Auth:   authAPI,
 		User:   userAPI,
 		Org:    orgAPI,
 		Record: recordAPI,
+		Admin:  adminAPI,
+		Access: access.New(storage),
 	}
 
 	a.httpServer.Handler = controller.InitRouter(controllerSet)
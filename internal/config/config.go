@@ -14,6 +14,12 @@ type Config struct {
 	DB    Database
 	Mail  Mail
 	Token Token `yaml:"token"`
+	// Ограничение неудачных попыток входа и ввода кодов
+	Throttle Throttle `yaml:"throttle"`
+	// Вход через внешних провайдеров OpenID Connect
+	OIDC OIDC `yaml:"oidc"`
+	// Хранение данных удаленных аккаунтов
+	Privacy Privacy `yaml:"privacy"`
 }
 
 type Application struct {
@@ -26,6 +32,8 @@ type HTTPServer struct {
 	Port        string        `yaml:"port" env-default:"8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"5m"`
+	// Адреса или сети обратных прокси. Только от них принимается X-Forwarded-For
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type Database struct {
@@ -48,6 +56,49 @@ type Mail struct {
 type Token struct {
 	AccessTTL  time.Duration `yaml:"access_ttl" env-default:"1m"`
 	RefreshTTL time.Duration `yaml:"refresh_ttl" env-default:"5m"`
+	// Время на ввод второго фактора после пароля
+	MFATTL time.Duration `yaml:"mfa_ttl" env-default:"5m"`
+	// Время жизни ссылки входа без пароля
+	LinkTTL time.Duration `yaml:"link_ttl" env-default:"10m"`
+	// Страница фронтенда, на которую ведет ссылка входа. Токен передается параметром token.
+	// Если не задана, в письме будет только токен
+	LinkURL string `yaml:"link_url"`
+	// Сколько выведенный из оборота ключ подписи принимается для проверки.
+	// Должно быть не меньше refresh_ttl
+	KeyGrace time.Duration `yaml:"key_grace" env-default:"24h"`
+	// Секрет для хеширования кодов из писем
+	CodeSecret string `env:"CODE_SECRET" env-required:"true"`
+}
+
+type Throttle struct {
+	MaxFailures   int           `yaml:"max_failures" env-default:"5"`
+	IPMaxFailures int           `yaml:"ip_max_failures" env-default:"50"`
+	Lockout       time.Duration `yaml:"lockout" env-default:"15m"`
+	BaseDelay     time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay      time.Duration `yaml:"max_delay" env-default:"30s"`
+	// Пауза между письмами на одну почту (восстановление пароля)
+	SendCooldown time.Duration `yaml:"send_cooldown" env-default:"1m"`
+}
+
+type OIDC struct {
+	// Время на возврат пользователя от провайдера
+	StateTTL  time.Duration  `yaml:"state_ttl" env-default:"10m"`
+	Providers []OIDCProvider `yaml:"providers"`
+}
+
+type OIDCProvider struct {
+	Name     string `yaml:"name"` // имя в пути /auth/oidc/{provider}
+	Issuer   string `yaml:"issuer"`
+	ClientID string `yaml:"client_id"`
+	// Имя переменной окружения с секретом клиента. Публичным клиентам не нужен
+	ClientSecretEnv string   `yaml:"client_secret_env"`
+	RedirectURL     string   `yaml:"redirect_url"`
+	Scopes          []string `yaml:"scopes"`
+}
+
+type Privacy struct {
+	// Сколько хранятся сессии и строка удаленного аккаунта до окончательной очистки
+	Retention time.Duration `yaml:"retention" env-default:"720h"`
 }
 
 func MustLoad() Config {
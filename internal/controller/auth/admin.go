@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"net/http"
+	"timeline/internal/entity/dto/authdto"
+)
+
+// @Summary Admin login
+// @Description Authorizes a platform admin and returns a token pair
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   request body authdto.AdminLoginReq true "Admin Login Request"
+// @Success 200 {object} authdto.TokenPair
+// @Failure 400
+// @Failure 429
+// @Failure 500
+// @Router /auth/admins/login [post]
+func (a *AuthCtrl) AdminLogin(w http.ResponseWriter, r *http.Request) {
+	var req authdto.AdminLoginReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if a.validator.Struct(&req) != nil {
+		http.Error(w, "Data is not valid", http.StatusBadRequest)
+		return
+	}
+	data, err := a.usecase.AdminLogin(r.Context(), &req)
+	if err != nil {
+		if throttled(w, err) {
+			return
+		}
+		http.Error(w, "Invalid username or password", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
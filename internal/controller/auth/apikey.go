@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"timeline/internal/controller/validation"
+	"timeline/internal/entity/dto/authdto"
+	authcase "timeline/internal/usecase/auth"
+
+	"github.com/gorilla/mux"
+)
+
+// @Summary Create API key
+// @Description Creates an API key for server integrations. The key is returned only once, pass it as "Authorization: Bearer <key>". Scopes: records:read, records:write, slots:write
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   request body authdto.APIKeyReq true "Key name, scopes and optional expiry"
+// @Success 201 {object} authdto.APIKeyCreated
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/apikeys [post]
+func (a *AuthCtrl) APIKeyCreate(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &authdto.APIKeyReq{}
+	if a.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	if err := a.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := a.usecase.APIKeyCreate(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, authcase.ErrAPIKeyExpired):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if a.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary API keys
+// @Description List API keys of the organization with last usage. Keys themselves are not shown
+// @Tags Auth
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Success 200 {object} authdto.APIKeyList
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/apikeys [get]
+func (a *AuthCtrl) APIKeyList(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := a.usecase.APIKeyList(r.Context(), path["orgID"])
+	if err != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Revoke API key
+// @Description Revokes the API key. Requests with it are rejected immediately
+// @Tags Auth
+// @Param   orgID path int true "org_id"
+// @Param   keyID path int true "key_id"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /orgs/{orgID}/apikeys/{keyID} [delete]
+func (a *AuthCtrl) APIKeyRevoke(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "keyID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.usecase.APIKeyRevoke(r.Context(), path["orgID"], path["keyID"]); err != nil {
+		switch {
+		case errors.Is(err, authcase.ErrAPIKeyNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
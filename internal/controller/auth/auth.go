@@ -2,8 +2,16 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"timeline/internal/entity"
 	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/secret"
+	"timeline/internal/libs/throttle"
+	authcase "timeline/internal/usecase/auth"
+	"timeline/internal/usecase/auth/access"
 
 	"github.com/go-playground/validator"
 	"github.com/golang-jwt/jwt/v5"
@@ -12,18 +20,47 @@ import (
 )
 
 type Auth interface {
-	Login(ctx context.Context, req *authdto.LoginReq) (*authdto.TokenPair, error)
+	Login(ctx context.Context, req *authdto.LoginReq) (*authdto.LoginResp, error)
+	LoginMFA(ctx context.Context, req *authdto.MFALoginReq) (*authdto.TokenPair, error)
+	LoginLinkSend(ctx context.Context, req *authdto.LoginLinkReq) error
+	LoginLinkVerify(ctx context.Context, req *authdto.LoginLinkVerifyReq) (*authdto.TokenPair, error)
+	OIDCProviders(ctx context.Context) *authdto.OIDCProviders
+	OIDCStart(ctx context.Context, provider string) (*authdto.OIDCStartResp, error)
+	OIDCCallback(ctx context.Context, req *authdto.OIDCCallbackReq) (*authdto.TokenPair, error)
+	APIKeyCreate(ctx context.Context, req *authdto.APIKeyReq) (*authdto.APIKeyCreated, error)
+	APIKeyList(ctx context.Context, orgID int) (*authdto.APIKeyList, error)
+	APIKeyRevoke(ctx context.Context, orgID, keyID int) error
+	AccountExport(ctx context.Context, metadata *entity.TokenMetadata) (*authdto.AccountExport, error)
+	AccountErase(ctx context.Context, metadata *entity.TokenMetadata) error
 	UserRegister(ctx context.Context, req *authdto.UserRegisterReq) (*authdto.RegisterResp, error)
 	OrgRegister(ctx context.Context, req *authdto.OrgRegisterReq) (*authdto.RegisterResp, error)
-	SendCodeRetry(ctx context.Context, req *authdto.SendCodeReq)
+	SendCodeRetry(ctx context.Context, req *authdto.SendCodeReq) error
 	VerifyCode(ctx context.Context, req *authdto.VerifyCodeReq) (*authdto.TokenPair, error)
-	UpdateAccessToken(ctx context.Context, req *jwt.Token) (*authdto.AccessToken, error)
+	UpdateAccessToken(ctx context.Context, req *jwt.Token) (*authdto.TokenPair, error)
+	Logout(ctx context.Context, metadata *entity.TokenMetadata) error
+	LogoutAll(ctx context.Context, metadata *entity.TokenMetadata) error
+	Sessions(ctx context.Context, metadata *entity.TokenMetadata) (*authdto.SessionList, error)
+	WorkerInvite(ctx context.Context, req *authdto.WorkerInviteReq) error
+	WorkerAccept(ctx context.Context, req *authdto.WorkerAcceptReq) (*authdto.TokenPair, error)
+	WorkerLogin(ctx context.Context, req *authdto.WorkerLoginReq) (*authdto.TokenPair, error)
+	AdminLogin(ctx context.Context, req *authdto.AdminLoginReq) (*authdto.TokenPair, error)
+	ForgotPassword(ctx context.Context, req *authdto.ForgotPasswordReq) error
+	ResetPassword(ctx context.Context, req *authdto.ResetPasswordReq) error
+	ChangePassword(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.ChangePasswordReq) error
+	EmailChangeRequest(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.EmailChangeReq) error
+	EmailChangeConfirm(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.EmailConfirmReq) error
+	TOTPSetup(ctx context.Context, metadata *entity.TokenMetadata) (*authdto.TOTPSetupResp, error)
+	TOTPConfirm(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.TOTPCodeReq) (*authdto.RecoveryCodes, error)
+	TOTPDisable(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.TOTPCodeReq) error
+	JWKS(ctx context.Context) *secret.JWKSet
 }
 
 type Middleware interface {
 	ExtractToken(w http.ResponseWriter, r *http.Request) (*jwt.Token, error)
 	IsTokenValid(next http.Handler) http.Handler
+	Authorize(policy access.Policy, next http.HandlerFunc) http.HandlerFunc
 	HandlerLogs(next http.Handler) http.Handler
+	ClientInfo(next http.Handler) http.Handler
 }
 
 type AuthCtrl struct {
@@ -45,13 +82,15 @@ func New(usecase Auth, middleware Middleware, logger *zap.Logger, jsoniter jsoni
 }
 
 // @Summary Login
-// @Description Authorizes a user and returns a token pair
+// @Description Authorizes a user and returns a token pair. Organizations with MFA get an MFA challenge token instead
 // @Tags Auth
 // @Accept  json
 // @Produce json
 // @Param   request body authdto.LoginReq true "Login Request"
-// @Success 200 {object} authdto.TokenPair
+// @Success 200 {object} authdto.LoginResp
 // @Failure 400
+// @Failure 403 "Account blocked"
+// @Failure 429
 // @Failure 500
 // @Router /auth/login [post]
 func (a *AuthCtrl) Login(w http.ResponseWriter, r *http.Request) {
@@ -69,6 +108,13 @@ func (a *AuthCtrl) Login(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	data, err := a.usecase.Login(ctx, &req)
 	if err != nil {
+		if throttled(w, err) {
+			return
+		}
+		if errors.Is(err, authcase.ErrAccountBlocked) {
+			http.Error(w, "Account blocked", http.StatusForbidden)
+			return
+		}
 		http.Error(w, "Invalid username or password", http.StatusBadRequest)
 		return
 	}
@@ -165,6 +211,7 @@ func (a *AuthCtrl) OrgRegister(w http.ResponseWriter, r *http.Request) {
 // @Param   request body authdto.SendCodeReq true "Send Code Request"
 // @Success 201 {string} string "Code resent successfully"
 // @Failure 400
+// @Failure 429
 // @Failure 500
 // @Router /auth/codes/send [post]
 func (a *AuthCtrl) SendCodeRetry(w http.ResponseWriter, r *http.Request) {
@@ -181,7 +228,17 @@ func (a *AuthCtrl) SendCodeRetry(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	a.usecase.SendCodeRetry(ctx, &req)
+	if err := a.usecase.SendCodeRetry(ctx, &req); err != nil {
+		if throttled(w, err) {
+			return
+		}
+		if errors.Is(err, authcase.ErrCodeCooldown) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -193,6 +250,7 @@ func (a *AuthCtrl) SendCodeRetry(w http.ResponseWriter, r *http.Request) {
 // @Param   request body authdto.VerifyCodeReq true "Verify Code Request"
 // @Success 200 {object} authdto.TokenPair
 // @Failure 400
+// @Failure 429
 // @Failure 500
 // @Router /auth/codes/verify [post]
 func (a *AuthCtrl) VerifyCode(w http.ResponseWriter, r *http.Request) {
@@ -211,6 +269,9 @@ func (a *AuthCtrl) VerifyCode(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	data, err := a.usecase.VerifyCode(ctx, &req)
 	if err != nil {
+		if throttled(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -224,12 +285,12 @@ func (a *AuthCtrl) VerifyCode(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Update Access Token
-// @Description Updates the access token using a refresh token
+// @Description Rotates the refresh token and returns a new token pair. Reusing an already rotated refresh token revokes the whole session
 // @Tags Auth
 // @Accept  json
 // @Produce  json
 // @Param   refresh_token header string true "Refresh Token"
-// @Success 200 {object} authdto.AccessToken "New Access Token"
+// @Success 200 {object} authdto.TokenPair "New Token Pair"
 // @Failure 400
 // @Failure 401
 // @Failure 500
@@ -249,7 +310,7 @@ func (a *AuthCtrl) UpdateAccessToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	ctx := r.Context()
-	refreshedAccessToken, err := a.usecase.UpdateAccessToken(ctx, token)
+	refreshedTokens, err := a.usecase.UpdateAccessToken(ctx, token)
 	if err != nil {
 		a.Logger.Error(
 			"failed update access token",
@@ -262,8 +323,98 @@ func (a *AuthCtrl) UpdateAccessToken(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	// Отправляем JSON-ответ
-	if err := a.json.NewEncoder(w).Encode(refreshedAccessToken); err != nil {
+	if err := a.json.NewEncoder(w).Encode(refreshedTokens); err != nil {
 		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
 		return
 	}
 }
+
+// @Summary Logout
+// @Description Revokes the current session
+// @Tags Auth
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Router /auth/logout [post]
+func (a *AuthCtrl) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	if err := a.usecase.Logout(ctx, metadata); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Logout All
+// @Description Revokes all sessions of the account on every device
+// @Tags Auth
+// @Success 204
+// @Failure 401
+// @Failure 500
+// @Router /auth/logout/all [post]
+func (a *AuthCtrl) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	if err := a.usecase.LogoutAll(ctx, metadata); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Sessions
+// @Description Returns active sessions of the account with device and last-seen info
+// @Tags Auth
+// @Produce  json
+// @Success 200 {object} authdto.SessionList
+// @Failure 401
+// @Failure 500
+// @Router /auth/sessions [get]
+func (a *AuthCtrl) Sessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	data, err := a.usecase.Sessions(ctx, metadata)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary JWKS
+// @Description Public keys for verifying Timeline tokens. Tokens carry the key id in the kid header
+// @Tags Auth
+// @Produce  json
+// @Success 200 {object} secret.JWKSet
+// @Failure 500
+// @Router /.well-known/jwks.json [get]
+func (a *AuthCtrl) JWKS(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := a.usecase.JWKS(ctx)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Отвечает 429 с Retry-After, если запрос отклонен ограничителем попыток
+func throttled(w http.ResponseWriter, err error) bool {
+	var limited *throttle.Error
+	if !errors.As(err, &limited) {
+		return false
+	}
+	retry := int(math.Ceil(limited.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	http.Error(w, limited.Error(), http.StatusTooManyRequests)
+	return true
+}
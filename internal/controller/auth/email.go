@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"timeline/internal/entity/dto/authdto"
+	authcase "timeline/internal/usecase/auth"
+	"timeline/internal/usecase/auth/access"
+)
+
+// @Summary Request Email Change
+// @Description Sends a confirmation code to the new email and a notification to the current one. Requires the account password
+// @Tags Auth
+// @Accept  json
+// @Param   request body authdto.EmailChangeReq true "Email Change Request"
+// @Success 202
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Failure 429
+// @Router /auth/email [post]
+func (a *AuthCtrl) EmailChangeRequest(w http.ResponseWriter, r *http.Request) {
+	var req authdto.EmailChangeReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	if err := a.usecase.EmailChangeRequest(ctx, metadata, &req); err != nil {
+		switch {
+		case errors.Is(err, authcase.ErrEmailTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, authcase.ErrCodeCooldown):
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		default:
+			http.Error(w, "Invalid password", http.StatusBadRequest)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Confirm Email Change
+// @Description Confirms the new email with the code sent to it and swaps the account email
+// @Tags Auth
+// @Accept  json
+// @Param   request body authdto.EmailConfirmReq true "Email Confirm Request"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Router /auth/email/confirm [post]
+func (a *AuthCtrl) EmailChangeConfirm(w http.ResponseWriter, r *http.Request) {
+	var req authdto.EmailConfirmReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	if err := a.usecase.EmailChangeConfirm(ctx, metadata, &req); err != nil {
+		if errors.Is(err, authcase.ErrEmailTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"timeline/internal/entity/dto/authdto"
+	authcase "timeline/internal/usecase/auth"
+)
+
+// @Summary Send login link
+// @Description Sends a one-time login link to the user's email. The response does not reveal whether the account exists
+// @Tags Auth
+// @Accept  json
+// @Param   request body authdto.LoginLinkReq true "User email"
+// @Success 202
+// @Failure 400
+// @Failure 429
+// @Failure 500
+// @Router /auth/login/link [post]
+func (a *AuthCtrl) LoginLinkSend(w http.ResponseWriter, r *http.Request) {
+	var req authdto.LoginLinkReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if a.validator.Struct(&req) != nil {
+		http.Error(w, "Data is not valid", http.StatusBadRequest)
+		return
+	}
+	if err := a.usecase.LoginLinkSend(r.Context(), &req); err != nil {
+		if throttled(w, err) {
+			return
+		}
+		http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Verify login link
+// @Description Exchanges the token from the login link for a token pair. The link can be used once
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   request body authdto.LoginLinkVerifyReq true "Token from the link"
+// @Success 200 {object} authdto.TokenPair
+// @Failure 400
+// @Failure 403 "Account blocked"
+// @Failure 429
+// @Failure 500
+// @Router /auth/login/link/verify [post]
+func (a *AuthCtrl) LoginLinkVerify(w http.ResponseWriter, r *http.Request) {
+	var req authdto.LoginLinkVerifyReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if a.validator.Struct(&req) != nil {
+		http.Error(w, "Data is not valid", http.StatusBadRequest)
+		return
+	}
+	data, err := a.usecase.LoginLinkVerify(r.Context(), &req)
+	if err != nil {
+		if throttled(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, authcase.ErrAccountBlocked):
+			http.Error(w, "Account blocked", http.StatusForbidden)
+		case errors.Is(err, authcase.ErrLinkInvalid):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"timeline/internal/entity/dto/authdto"
+	authcase "timeline/internal/usecase/auth"
+	"timeline/internal/usecase/auth/access"
+)
+
+// @Summary Login MFA
+// @Description Exchanges the MFA challenge token from login and a TOTP or recovery code for a token pair
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   request body authdto.MFALoginReq true "MFA Login Request"
+// @Success 200 {object} authdto.TokenPair
+// @Failure 400
+// @Failure 401
+// @Failure 429
+// @Router /auth/login/mfa [post]
+func (a *AuthCtrl) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req authdto.MFALoginReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if a.validator.Struct(&req) != nil {
+		http.Error(w, "Data is not valid", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	data, err := a.usecase.LoginMFA(ctx, &req)
+	if err != nil {
+		if errors.Is(err, authcase.ErrMFALocked) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, "Invalid code", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Setup TOTP
+// @Description Generates a new TOTP secret and otpauth URI for the organization. MFA is enabled only after confirmation
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} authdto.TOTPSetupResp
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /auth/mfa/totp [post]
+func (a *AuthCtrl) TOTPSetup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	data, err := a.usecase.TOTPSetup(ctx, metadata)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Confirm TOTP
+// @Description Enables MFA with the first code from the authenticator app and returns one-time recovery codes
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   request body authdto.TOTPCodeReq true "TOTP Code"
+// @Success 200 {object} authdto.RecoveryCodes
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /auth/mfa/totp/confirm [post]
+func (a *AuthCtrl) TOTPConfirm(w http.ResponseWriter, r *http.Request) {
+	var req authdto.TOTPCodeReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	data, err := a.usecase.TOTPConfirm(ctx, metadata, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Disable TOTP
+// @Description Disables MFA. Requires a valid TOTP or recovery code
+// @Tags Auth
+// @Accept  json
+// @Param   request body authdto.TOTPCodeReq true "TOTP Code"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Router /auth/mfa/totp [delete]
+func (a *AuthCtrl) TOTPDisable(w http.ResponseWriter, r *http.Request) {
+	var req authdto.TOTPCodeReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	if err := a.usecase.TOTPDisable(ctx, metadata, &req); err != nil {
+		if errors.Is(err, authcase.ErrMFALocked) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"timeline/internal/entity/dto/authdto"
+	authcase "timeline/internal/usecase/auth"
+
+	"github.com/gorilla/mux"
+)
+
+// @Summary Identity providers
+// @Description List of OpenID Connect providers available for login
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} authdto.OIDCProviders
+// @Failure 500
+// @Router /auth/oidc [get]
+func (a *AuthCtrl) OIDCProviders(w http.ResponseWriter, r *http.Request) {
+	data := a.usecase.OIDCProviders(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Start provider login
+// @Description Returns the provider login page URL (authorization code flow with PKCE). After login the provider redirects the user to the configured redirect_url with code and state
+// @Tags Auth
+// @Produce json
+// @Param   provider path string true "Provider name"
+// @Success 200 {object} authdto.OIDCStartResp
+// @Failure 404
+// @Failure 502
+// @Router /auth/oidc/{provider}/start [get]
+func (a *AuthCtrl) OIDCStart(w http.ResponseWriter, r *http.Request) {
+	data, err := a.usecase.OIDCStart(r.Context(), mux.Vars(r)["provider"])
+	if err != nil {
+		switch {
+		case errors.Is(err, authcase.ErrProviderNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "Identity provider is unavailable", http.StatusBadGateway)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Finish provider login
+// @Description Exchanges code and state returned by the provider for a token pair. The identity is linked to the user with the same verified email, otherwise a new verified user is created
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   provider path string true "Provider name"
+// @Param   request body authdto.OIDCCallbackReq true "Code and state from the redirect"
+// @Success 200 {object} authdto.TokenPair
+// @Failure 400
+// @Failure 403 "Account blocked"
+// @Failure 404
+// @Failure 500
+// @Router /auth/oidc/{provider}/callback [post]
+func (a *AuthCtrl) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	var req authdto.OIDCCallbackReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if a.validator.Struct(&req) != nil {
+		http.Error(w, "Data is not valid", http.StatusBadRequest)
+		return
+	}
+	req.Provider = mux.Vars(r)["provider"]
+	data, err := a.usecase.OIDCCallback(r.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, authcase.ErrProviderNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, authcase.ErrAccountBlocked):
+			http.Error(w, "Account blocked", http.StatusForbidden)
+		case errors.Is(err, authcase.ErrOIDCState), errors.Is(err, authcase.ErrOIDCFailed), errors.Is(err, authcase.ErrOIDCEmail):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
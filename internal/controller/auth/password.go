@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"net/http"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/usecase/auth/access"
+)
+
+// @Summary Forgot Password
+// @Description Sends a single-use password reset token to the account email
+// @Tags Auth
+// @Accept  json
+// @Param   request body authdto.ForgotPasswordReq true "Forgot Password Request"
+// @Success 202
+// @Failure 400
+// @Failure 429
+// @Router /auth/password/forgot [post]
+func (a *AuthCtrl) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req authdto.ForgotPasswordReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	// ответ не зависит от того, существует ли аккаунт
+	if err := a.usecase.ForgotPassword(ctx, &req); throttled(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Reset Password
+// @Description Sets a new password by the reset token and revokes all sessions of the account
+// @Tags Auth
+// @Accept  json
+// @Param   request body authdto.ResetPasswordReq true "Reset Password Request"
+// @Success 204
+// @Failure 400
+// @Router /auth/password/reset [post]
+func (a *AuthCtrl) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req authdto.ResetPasswordReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	if err := a.usecase.ResetPassword(ctx, &req); err != nil {
+		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Change Password
+// @Description Changes the password of the authorized account. Requires the old password, other sessions are revoked
+// @Tags Auth
+// @Accept  json
+// @Param   request body authdto.ChangePasswordReq true "Change Password Request"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Router /auth/password [put]
+func (a *AuthCtrl) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req authdto.ChangePasswordReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	if err := a.usecase.ChangePassword(ctx, metadata, &req); err != nil {
+		http.Error(w, "Invalid password", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"archive/zip"
+	"errors"
+	"net/http"
+	"timeline/internal/controller/validation"
+	authcase "timeline/internal/usecase/auth"
+	"timeline/internal/usecase/auth/access"
+)
+
+// @Summary Export personal data
+// @Description Returns all personal data of the account: profile, records, feedbacks and sessions. format=zip returns the same JSON packed into export.zip
+// @Tags Auth
+// @Produce json
+// @Produce application/zip
+// @Param   format query string false "json (default) or zip"
+// @Success 200 {object} authdto.AccountExport
+// @Failure 400
+// @Failure 500
+// @Router /users/me/export [get]
+// @Router /orgs/me/export [get]
+func (a *AuthCtrl) AccountExport(w http.ResponseWriter, r *http.Request) {
+	if !validation.IsQueryValid(r, map[string]bool{"format": false}) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		http.Error(w, "format must be json or zip", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	data, err := a.usecase.AccountExport(ctx, metadata)
+	if err != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		return
+	}
+	if format != "zip" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="export.json"`)
+		w.WriteHeader(http.StatusOK)
+		if a.json.NewEncoder(w).Encode(data) != nil {
+			http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="export.zip"`)
+	w.WriteHeader(http.StatusOK)
+	archive := zip.NewWriter(w)
+	file, err := archive.Create("export.json")
+	if err != nil {
+		return
+	}
+	if a.json.NewEncoder(file).Encode(data) != nil {
+		return
+	}
+	archive.Close()
+}
+
+// @Summary Delete account
+// @Description Deletes the account: personal fields are anonymized immediately, records stay for the accounting of organizations. Upcoming records must be cancelled first
+// @Tags Auth
+// @Success 204
+// @Failure 404
+// @Failure 409
+// @Failure 500
+// @Router /users/me [delete]
+// @Router /orgs/me [delete]
+func (a *AuthCtrl) AccountErase(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	metadata, _ := access.FromContext(ctx)
+	if err := a.usecase.AccountErase(ctx, metadata); err != nil {
+		switch {
+		case errors.Is(err, authcase.ErrActiveRecords):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, authcase.ErrAccountNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"timeline/internal/controller/validation"
+	"timeline/internal/entity/dto/authdto"
+	authcase "timeline/internal/usecase/auth"
+
+	"github.com/gorilla/mux"
+)
+
+// @Summary Invite worker
+// @Description Creates a staff account for the worker and sends an invite token to the given email. A repeated invite replaces the previous one
+// @Tags Auth
+// @Accept  json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   request body authdto.WorkerInviteReq true "Worker Invite Request"
+// @Success 202
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Router /orgs/{orgID}/workers/{workerID}/invite [post]
+func (a *AuthCtrl) WorkerInvite(w http.ResponseWriter, r *http.Request) {
+	params, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req authdto.WorkerInviteReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = params["orgID"]
+	req.WorkerID = params["workerID"]
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.usecase.WorkerInvite(r.Context(), &req); err != nil {
+		switch {
+		case errors.Is(err, authcase.ErrWorkerNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, authcase.ErrEmailTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Accept worker invite
+// @Description Sets the worker password by the invite token and returns a token pair
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   request body authdto.WorkerAcceptReq true "Worker Accept Request"
+// @Success 200 {object} authdto.TokenPair
+// @Failure 400
+// @Failure 500
+// @Router /auth/workers/accept [post]
+func (a *AuthCtrl) WorkerAccept(w http.ResponseWriter, r *http.Request) {
+	var req authdto.WorkerAcceptReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := a.usecase.WorkerAccept(r.Context(), &req)
+	if err != nil {
+		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Worker login
+// @Description Authorizes a worker of an organization and returns a token pair
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   request body authdto.WorkerLoginReq true "Worker Login Request"
+// @Success 200 {object} authdto.TokenPair
+// @Failure 400
+// @Failure 429
+// @Failure 500
+// @Router /auth/workers/login [post]
+func (a *AuthCtrl) WorkerLogin(w http.ResponseWriter, r *http.Request) {
+	var req authdto.WorkerLoginReq
+	if a.json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	if a.validator.Struct(&req) != nil {
+		http.Error(w, "Data is not valid", http.StatusBadRequest)
+		return
+	}
+	data, err := a.usecase.WorkerLogin(r.Context(), &req)
+	if err != nil {
+		if throttled(w, err) {
+			return
+		}
+		http.Error(w, "Invalid username or password", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(&data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
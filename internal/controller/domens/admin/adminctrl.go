@@ -0,0 +1,290 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"timeline/internal/controller/validation"
+	"timeline/internal/entity/dto/admindto"
+	"timeline/internal/libs/custom"
+	"timeline/internal/usecase/admincase"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+type Admin interface {
+	Accounts(ctx context.Context, isOrg bool, req *admindto.AccountSearchReq) (*admindto.AccountList, error)
+	AccountBlock(ctx context.Context, req *admindto.BlockReq) error
+	FeedbackHide(ctx context.Context, req *admindto.FeedbackHiddenReq) error
+	Audit(ctx context.Context, req *admindto.AuditReq) (*admindto.AuditList, error)
+}
+
+type AdminCtrl struct {
+	usecase   Admin
+	Logger    *zap.Logger
+	json      jsoniter.API
+	validator *validator.Validate
+}
+
+func NewAdminCtrl(usecase Admin, logger *zap.Logger, jsoniter jsoniter.API, validator *validator.Validate) *AdminCtrl {
+	return &AdminCtrl{
+		usecase:   usecase,
+		Logger:    logger,
+		json:      jsoniter,
+		validator: validator,
+	}
+}
+
+// @Summary Users
+// @Description Search users by email or name, including blocked and deleted ones
+// @Tags Admin
+// @Produce json
+// @Param limit query int true "Limit the number of results"
+// @Param page query int true "Page number for pagination"
+// @Param query query string false "Part of email or name"
+// @Success 200 {object} admindto.AccountList
+// @Failure 400
+// @Failure 403
+// @Failure 500
+// @Router /admin/users [get]
+func (a *AdminCtrl) Users(w http.ResponseWriter, r *http.Request) {
+	a.accounts(w, r, false)
+}
+
+// @Summary Organizations
+// @Description Search organizations by email or name, including blocked and deleted ones
+// @Tags Admin
+// @Produce json
+// @Param limit query int true "Limit the number of results"
+// @Param page query int true "Page number for pagination"
+// @Param query query string false "Part of email or name"
+// @Success 200 {object} admindto.AccountList
+// @Failure 400
+// @Failure 403
+// @Failure 500
+// @Router /admin/orgs [get]
+func (a *AdminCtrl) Orgs(w http.ResponseWriter, r *http.Request) {
+	a.accounts(w, r, true)
+}
+
+func (a *AdminCtrl) accounts(w http.ResponseWriter, r *http.Request, isOrg bool) {
+	query := map[string]bool{
+		"limit": true,
+		"page":  true,
+		"query": false,
+	}
+	if !validation.IsQueryValid(r, query) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	queryParams, err := custom.QueryParamsConv(map[string]string{"limit": "int", "page": "int", "query": "string"}, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req := &admindto.AccountSearchReq{
+		Limit: queryParams["limit"].(int),
+		Page:  queryParams["page"].(int),
+		Query: queryParams["query"].(string),
+	}
+	if err := a.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := a.usecase.Accounts(r.Context(), isOrg, req)
+	if err != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Block user
+// @Description Blocks or unblocks the user. Blocking revokes all sessions and forbids login
+// @Tags Admin
+// @Accept  json
+// @Param   userID path int true "user_id"
+// @Param   request body admindto.BlockReq true "Block state and reason"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /admin/users/{userID}/block [put]
+func (a *AdminCtrl) UserBlock(w http.ResponseWriter, r *http.Request) {
+	a.accountBlock(w, r, "userID", false)
+}
+
+// @Summary Block organization
+// @Description Blocks or unblocks the organization. Blocking revokes all sessions, forbids login and hides the organization from search
+// @Tags Admin
+// @Accept  json
+// @Param   orgID path int true "org_id"
+// @Param   request body admindto.BlockReq true "Block state and reason"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /admin/orgs/{orgID}/block [put]
+func (a *AdminCtrl) OrgBlock(w http.ResponseWriter, r *http.Request) {
+	a.accountBlock(w, r, "orgID", true)
+}
+
+func (a *AdminCtrl) accountBlock(w http.ResponseWriter, r *http.Request, param string, isOrg bool) {
+	path, err := validation.FetchPathID(mux.Vars(r), param)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &admindto.BlockReq{}
+	if a.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.ID = path[param]
+	req.IsOrg = isOrg
+	if err := a.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.usecase.AccountBlock(r.Context(), req); err != nil {
+		switch {
+		case errors.Is(err, admincase.ErrAccountNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Hide feedback
+// @Description Hides the feedback from listings or shows it again
+// @Tags Admin
+// @Accept  json
+// @Param   recordID path int true "record_id"
+// @Param   request body admindto.FeedbackHiddenReq true "Hidden state"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /admin/feedbacks/{recordID}/hidden [put]
+func (a *AdminCtrl) FeedbackHide(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "recordID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &admindto.FeedbackHiddenReq{}
+	if a.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.RecordID = path["recordID"]
+	if err := a.usecase.FeedbackHide(r.Context(), req); err != nil {
+		switch {
+		case errors.Is(err, admincase.ErrFeedbackNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Audit log
+// @Description Get platform audit log, newest first
+// @Tags Admin
+// @Produce json
+// @Param limit query int true "Limit the number of results"
+// @Param page query int true "Page number for pagination"
+// @Param org_id query int false "Organization the action belongs to"
+// @Param entity query string false "Entity type, e.g. user, org, feedback"
+// @Param entity_id query int false "Entity ID"
+// @Param from query string false "Start of the range, RFC3339"
+// @Param to query string false "End of the range (exclusive), RFC3339"
+// @Success 200 {object} admindto.AuditList
+// @Failure 400
+// @Failure 403
+// @Failure 500
+// @Router /admin/audit [get]
+func (a *AdminCtrl) Audit(w http.ResponseWriter, r *http.Request) {
+	query := map[string]bool{
+		"limit":     true,
+		"page":      true,
+		"org_id":    false,
+		"entity":    false,
+		"entity_id": false,
+		"from":      false,
+		"to":        false,
+	}
+	if !validation.IsQueryValid(r, query) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req, err := AuditQuery(r)
+	if err != nil {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	if err := a.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := a.usecase.Audit(r.Context(), req)
+	if err != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if a.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Фильтры журнала из query параметров. Границы периода в RFC3339
+func AuditQuery(r *http.Request) (*admindto.AuditReq, error) {
+	params, err := custom.QueryParamsConv(map[string]string{
+		"limit":     "int",
+		"page":      "int",
+		"org_id":    "int",
+		"entity":    "string",
+		"entity_id": "int",
+	}, r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	req := &admindto.AuditReq{
+		Limit:    params["limit"].(int),
+		Page:     params["page"].(int),
+		OrgID:    params["org_id"].(int),
+		Entity:   params["entity"].(string),
+		EntityID: params["entity_id"].(int),
+	}
+	if from := r.URL.Query().Get("from"); from != "" {
+		if req.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, err
+		}
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		if req.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
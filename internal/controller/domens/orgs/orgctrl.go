@@ -3,7 +3,9 @@ package orgs
 import (
 	"context"
 	"net/http"
+	"timeline/internal/controller/domens/admin"
 	"timeline/internal/controller/validation"
+	"timeline/internal/entity/dto/admindto"
 	"timeline/internal/entity/dto/orgdto"
 
 	"github.com/go-playground/validator"
@@ -15,11 +17,14 @@ import (
 type Org interface {
 	Organization(ctx context.Context, id int) (*orgdto.Organization, error)
 	OrgUpdate(ctx context.Context, org *orgdto.OrgUpdateReq) error
+	Audit(ctx context.Context, req *admindto.AuditReq) (*admindto.AuditList, error)
 	Timetable
 	Workers
 	Services
 	Slots
 	Schedule
+	Staff
+	Resources
 }
 
 type OrgCtrl struct {
@@ -89,3 +94,60 @@ func (o *OrgCtrl) UpdateOrg(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// @Summary Organization audit log
+// @Description Get audit log of the organization, newest first
+// @Tags Organization
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param limit query int true "Limit the number of results"
+// @Param page query int true "Page number for pagination"
+// @Param entity query string false "Entity type, e.g. worker, service, record"
+// @Param entity_id query int false "Entity ID"
+// @Param from query string false "Start of the range, RFC3339"
+// @Param to query string false "End of the range (exclusive), RFC3339"
+// @Success 200 {object} admindto.AuditList
+// @Failure 400
+// @Failure 403
+// @Failure 500
+// @Router /orgs/{orgID}/audit [get]
+func (o *OrgCtrl) Audit(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := map[string]bool{
+		"limit":     true,
+		"page":      true,
+		"entity":    false,
+		"entity_id": false,
+		"from":      false,
+		"to":        false,
+	}
+	if !validation.IsQueryValid(r, query) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req, err := admin.AuditQuery(r)
+	if err != nil {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.Audit(r.Context(), req)
+	if err != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,143 @@
+package orgs
+
+import (
+	"context"
+	"net/http"
+	"timeline/internal/controller/validation"
+	"timeline/internal/entity/dto/orgdto"
+
+	"github.com/gorilla/mux"
+)
+
+type Resources interface {
+	Resources(ctx context.Context, orgID int) (*orgdto.ResourceList, error)
+	ResourceAdd(ctx context.Context, req *orgdto.Resource) (*orgdto.ResourceResp, error)
+	ResourceUpdate(ctx context.Context, req *orgdto.Resource) error
+	ResourceDelete(ctx context.Context, orgID, resourceID int) error
+}
+
+// @Summary Resources
+// @Description Get organization resources (rooms, equipment) with their weekly availability.
+// @Description A resource without hours is available whenever the worker is
+// @Tags organization / resources
+// @Produce json
+// @Param orgID path int true "org_id"
+// @Success 200 {object} orgdto.ResourceList
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/resources [get]
+func (o *OrgCtrl) Resources(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.Resources(r.Context(), path["orgID"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Add resource
+// @Description Add a room or a piece of equipment that services can require
+// @Tags organization / resources
+// @Accept json
+// @Produce json
+// @Param orgID path int true "org_id"
+// @Param request body orgdto.Resource true "Resource"
+// @Success 201 {object} orgdto.ResourceResp
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/resources [post]
+func (o *OrgCtrl) ResourceAdd(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.Resource{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.ResourceAdd(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update resource
+// @Description Update resource info. Availability hours are replaced as a whole
+// @Tags organization / resources
+// @Accept json
+// @Param orgID path int true "org_id"
+// @Param resourceID path int true "resource_id"
+// @Param request body orgdto.Resource true "Resource"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/resources/{resourceID} [put]
+func (o *OrgCtrl) ResourceUpdate(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "resourceID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.Resource{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	req.ResourceID = path["resourceID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.ResourceUpdate(r.Context(), req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Delete resource
+// @Description Delete resource. Services stop requiring it, existing records stay
+// @Tags organization / resources
+// @Param orgID path int true "org_id"
+// @Param resourceID path int true "resource_id"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/resources/{resourceID} [delete]
+func (o *OrgCtrl) ResourceDelete(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "resourceID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.ResourceDelete(r.Context(), path["orgID"], path["resourceID"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -25,6 +25,7 @@ type Schedule interface {
 // @Param   orgID path int true "org_id"
 // @Param   workerID query int true "Returned schedule for specified worker, otherwise for all org's workers"
 // @Param weekday query int false "weekday"
+// @Param date query string false "Return the schedule version in effect on this date (2006-01-02), today by default"
 // @Param limit query int true "Limit the number of results"
 // @Param page query int true "Page number for pagination"
 // @Success 200 {object} orgdto.ScheduleList
@@ -40,6 +41,7 @@ func (o *OrgCtrl) WorkerSchedule(w http.ResponseWriter, r *http.Request) {
 	query := map[string]string{
 		"worker_id": "int",
 		"weekday":   "int",
+		"date":      "string",
 		"limit":     "int",
 		"page":      "int",
 	}
@@ -53,6 +55,7 @@ func (o *OrgCtrl) WorkerSchedule(w http.ResponseWriter, r *http.Request) {
 		WorkerID: queryParams["worker_id"].(int),
 		OrgID:    params["orgID"],
 		Weekday:  queryParams["weekday"].(int),
+		Date:     queryParams["date"].(string),
 		Limit:    queryParams["limit"].(int),
 		Page:     queryParams["page"].(int),
 	}
@@ -115,7 +118,7 @@ func (o *OrgCtrl) DeleteWorkerSchedule(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Update worker schedule
-// @Description Update the schedule for a specific worker in an organization
+// @Description Update the schedule for a specific worker in an organization. Periods of the same weekday must not overlap
 // @Tags organization/schedule
 // @Accept json
 // @Param   schedule body orgdto.WorkerSchedule true "Schedule data"
@@ -141,7 +144,7 @@ func (o *OrgCtrl) UpdateWorkerSchedule(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Add worker schedule
-// @Description Add a new schedule for a specific worker in an organization
+// @Description Add a new schedule for a specific worker in an organization. A weekday may have several non-overlapping working periods (split shifts). valid_from/valid_to publish a schedule version in advance: on each day the latest started version in effect replaces the previous one
 // @Tags organization/schedule
 // @Accept json
 // @Produce json
@@ -76,7 +76,7 @@ func (o *OrgCtrl) ServiceWorkerList(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Add service
-// @Description Add service for specified organization
+// @Description Add service for specified organization. Resources lists required resources (rooms, equipment)
 // @Tags organization/services
 // @Accept json
 // @Produce json
@@ -102,7 +102,7 @@ func (o *OrgCtrl) ServiceAdd(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Update service
-// @Description Update specified service for specified organization
+// @Description Update specified service for specified organization. Resources, when given, replace the required resources
 // @Tags organization/services
 // @Accept json
 // @Param   request body orgdto.UpdateServiceReq true "service info"
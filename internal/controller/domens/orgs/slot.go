@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"timeline/internal/controller/validation"
 	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/libs/custom"
 
 	"github.com/gorilla/mux"
 )
@@ -12,14 +13,21 @@ import (
 type Slots interface {
 	Slots(ctx context.Context, req *orgdto.SlotReq) ([]*orgdto.SlotResp, error)
 	UpdateSlot(ctx context.Context, req *orgdto.SlotUpdate) error
+	GenerateSlots(ctx context.Context, req *orgdto.SlotReq) (*orgdto.SlotGenerateResp, error)
+	SlotHorizon(ctx context.Context, orgID int) (*orgdto.SlotHorizon, error)
+	SlotHorizonUpdate(ctx context.Context, req *orgdto.SlotHorizon) error
+	SlotConflicts(ctx context.Context, req *orgdto.SlotReq) ([]*orgdto.SlotConflict, error)
+	Availability(ctx context.Context, req *orgdto.AvailabilityReq) (*orgdto.Availability, error)
+	SlotAttendees(ctx context.Context, req *orgdto.AttendeesReq) (*orgdto.Attendees, error)
 }
 
 // @Summary Get slots
-// @Description Get all slots for specified worker
+// @Description Get all slots for specified worker with remaining seats. Empty slots without own capacity count seats by service_id, one seat otherwise
 // @Tags organization/slots
 // @Produce json
 // @Param   workerID path int true "worker_id"
 // @Param   orgID path int true "org_id"
+// @Param   service_id query int false "service_id"
 // @Success 200 {array} orgdto.SlotResp
 // @Failure 400
 // @Failure 500
@@ -30,7 +38,12 @@ func (o *OrgCtrl) Slots(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	req := &orgdto.SlotReq{WorkerID: params["workerID"], OrgID: params["orgID"]}
+	query, err := custom.QueryParamsConv(map[string]string{"service_id": "int"}, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.SlotReq{WorkerID: params["workerID"], OrgID: params["orgID"], ServiceID: query["service_id"].(int)}
 	if err = o.validator.Struct(req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -49,7 +62,7 @@ func (o *OrgCtrl) Slots(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Update slots
-// @Description Update specified slot for specified worker
+// @Description Update specified slot for specified worker. capacity sets the number of seats, it can't be lower than seats already taken
 // @Tags organization/slots
 // @Accept json
 // @Param   request body orgdto.SlotUpdate true "slots info"
@@ -58,11 +71,17 @@ func (o *OrgCtrl) Slots(w http.ResponseWriter, r *http.Request) {
 // @Failure 500
 // @Router /orgs/{orgID}/slots [put]
 func (o *OrgCtrl) UpdateSlot(w http.ResponseWriter, r *http.Request) {
+	params, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	req := &orgdto.SlotUpdate{}
 	if o.json.NewDecoder(r.Body).Decode(req) != nil {
 		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
 		return
 	}
+	req.OrgID = params["orgID"]
 	if err := o.validator.Struct(req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -72,3 +91,270 @@ func (o *OrgCtrl) UpdateSlot(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// @Summary Generate slots
+// @Description Generate free slots for specified worker up to the org booking horizon. Already existing sessions are skipped
+// @Tags organization/slots
+// @Produce json
+// @Param   workerID path int true "worker_id"
+// @Param   orgID path int true "org_id"
+// @Success 200 {object} orgdto.SlotGenerateResp
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/slots/workers/{workerID} [post]
+func (o *OrgCtrl) GenerateSlots(w http.ResponseWriter, r *http.Request) {
+	params, err := validation.FetchPathID(mux.Vars(r), "workerID", "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query, err := custom.QueryParamsConv(map[string]string{"service_id": "int"}, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.SlotReq{WorkerID: params["workerID"], OrgID: params["orgID"], ServiceID: query["service_id"].(int)}
+	if err = o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.GenerateSlots(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get booking horizon
+// @Description Get how many days ahead slots are generated for the organization
+// @Tags organization/slots
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Success 200 {object} orgdto.SlotHorizon
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/slots/horizon [get]
+func (o *OrgCtrl) SlotHorizon(w http.ResponseWriter, r *http.Request) {
+	params, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.SlotHorizon(r.Context(), params["orgID"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update booking horizon
+// @Description Set how many days ahead (1-90) slots are generated. Extending the horizon generates missing slots immediately
+// @Tags organization/slots
+// @Accept json
+// @Param   orgID path int true "org_id"
+// @Param   request body orgdto.SlotHorizon true "horizon in days"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/slots/horizon [put]
+func (o *OrgCtrl) SlotHorizonUpdate(w http.ResponseWriter, r *http.Request) {
+	params, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.SlotHorizon{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = params["orgID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.SlotHorizonUpdate(r.Context(), req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Slot conflicts
+// @Description Get busy slots that no longer fit the worker schedule or org timetable. These customers have to be rescheduled
+// @Tags organization/slots
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   worker_id query int false "worker_id"
+// @Success 200 {array} orgdto.SlotConflict
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/slots/conflicts [get]
+func (o *OrgCtrl) SlotConflicts(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validation.IsQueryValid(r, map[string]bool{"worker_id": false}) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	params, err := custom.QueryParamsConv(map[string]string{"worker_id": "int"}, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.SlotReq{WorkerID: params["worker_id"].(int), OrgID: path["orgID"]}
+	data, err := o.usecase.SlotConflicts(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Worker availability
+// @Description Get free session starts of the worker for the service on the given date. Computed from the worker's working hours minus existing bookings and service buffers. Sessions where a resource required by the service is busy or unavailable are skipped
+// @Tags organization/slots
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   service_id query int true "service_id"
+// @Param   date query string true "date, YYYY-MM-DD"
+// @Success 200 {object} orgdto.Availability
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/slots/workers/{workerID}/availability [get]
+func (o *OrgCtrl) Availability(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validation.IsQueryValid(r, map[string]bool{"service_id": true, "date": true}) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	params, err := custom.QueryParamsConv(map[string]string{"service_id": "int", "date": "string"}, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.AvailabilityReq{
+		OrgID:     path["orgID"],
+		WorkerID:  path["workerID"],
+		ServiceID: params["service_id"].(int),
+		Date:      params["date"].(string),
+	}
+	if err = o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.Availability(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Service availability without a worker
+// @Description Get free session starts of a resource-only service on the given date. Computed from the org working hours where every resource required by the service is free and available
+// @Tags organization/slots
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   serviceID path int true "service_id"
+// @Param   date query string true "date, YYYY-MM-DD"
+// @Success 200 {object} orgdto.Availability
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/slots/services/{serviceID}/availability [get]
+func (o *OrgCtrl) ServiceAvailability(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "serviceID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validation.IsQueryValid(r, map[string]bool{"date": true}) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	params, err := custom.QueryParamsConv(map[string]string{"date": "string"}, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.AvailabilityReq{
+		OrgID:     path["orgID"],
+		ServiceID: path["serviceID"],
+		Date:      params["date"].(string),
+	}
+	if err = o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.Availability(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Slot attendees
+// @Description Get customers booked into the slot with the seats left
+// @Tags organization/slots
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   slotID path int true "slot_id"
+// @Success 200 {object} orgdto.Attendees
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/slots/{slotID}/attendees [get]
+func (o *OrgCtrl) SlotAttendees(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "slotID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.AttendeesReq{OrgID: path["orgID"], SlotID: path["slotID"]}
+	data, err := o.usecase.SlotAttendees(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
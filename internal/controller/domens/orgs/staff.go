@@ -0,0 +1,337 @@
+package orgs
+
+import (
+	"context"
+	"net/http"
+	"timeline/internal/controller/validation"
+	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/entity/dto/recordto"
+	"timeline/internal/libs/custom"
+
+	"github.com/gorilla/mux"
+)
+
+// Ручки, доступные самому сотруднику и его организации
+type Staff interface {
+	WorkerRecords(ctx context.Context, params *recordto.RecordListParams) (*recordto.RecordList, error)
+	RecordAttendance(ctx context.Context, req *recordto.AttendanceReq) error
+	TimeOffRequest(ctx context.Context, req *orgdto.TimeOffReq) (*orgdto.TimeOffResp, error)
+	TimeOffList(ctx context.Context, orgID, workerID int) (*orgdto.TimeOffList, error)
+	TimeOffDecide(ctx context.Context, req *orgdto.TimeOffDecision) error
+	TimeOff(ctx context.Context, orgID, workerID, timeoffID int) (*orgdto.TimeOff, error)
+	TimeOffUpdate(ctx context.Context, req *orgdto.TimeOffReq) error
+	TimeOffDelete(ctx context.Context, orgID, workerID, timeoffID int) error
+	TimeOffCollisions(ctx context.Context, orgID, workerID, timeoffID int) (*orgdto.TimeOffCollisionList, error)
+}
+
+// @Summary Worker records
+// @Description Get records of the specified worker. Available to the worker and the organization
+// @Tags organization/staff
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   fresh query bool false "True - only current & future records. False/NotGiven - olds"
+// @Success 200 {object} recordto.RecordList
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/records [get]
+func (o *OrgCtrl) WorkerRecords(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := map[string]bool{
+		"fresh": false,
+	}
+	if !validation.IsQueryValid(r, query) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	queryParams, err := custom.QueryParamsConv(map[string]string{"fresh": "bool"}, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid query parameters"+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &recordto.RecordListParams{
+		OrgID:    path["orgID"],
+		WorkerID: path["workerID"],
+		Fresh:    queryParams["fresh"].(bool),
+	}
+	data, err := o.usecase.WorkerRecords(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Mark attendance
+// @Description Marks whether the client came to the record of the specified worker
+// @Tags organization/staff
+// @Accept  json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   recordID path int true "record_id"
+// @Param   request body recordto.AttendanceReq true "Attendance"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/records/{recordID}/attendance [put]
+func (o *OrgCtrl) RecordAttendance(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID", "recordID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &recordto.AttendanceReq{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	req.WorkerID = path["workerID"]
+	req.RecordID = path["recordID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.RecordAttendance(r.Context(), req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Request time off
+// @Description Vacation, sick day or other absence: whole days or a time interval on each day (time_from/time_to). A request from the worker waits for the organization decision, time off created by the organization is approved at once. Approved time off blocks slot generation
+// @Tags organization/staff
+// @Accept  json
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   request body orgdto.TimeOffReq true "Time off dates, format 2006-01-02, times 15:04"
+// @Success 201 {object} orgdto.TimeOffResp
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/timeoff [post]
+func (o *OrgCtrl) TimeOffRequest(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.TimeOffReq{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	req.WorkerID = path["workerID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.TimeOffRequest(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Time off list
+// @Description Get time off requests of the specified worker
+// @Tags organization/staff
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Success 200 {object} orgdto.TimeOffList
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/timeoff [get]
+func (o *OrgCtrl) TimeOffList(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.TimeOffList(r.Context(), path["orgID"], path["workerID"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Decide time off
+// @Description The organization approves or rejects a pending time off request
+// @Tags organization/staff
+// @Accept  json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   timeoffID path int true "timeoff_id"
+// @Param   request body orgdto.TimeOffDecision true "approved or rejected"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/timeoff/{timeoffID} [put]
+func (o *OrgCtrl) TimeOffDecide(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID", "timeoffID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.TimeOffDecision{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	req.WorkerID = path["workerID"]
+	req.TimeOffID = path["timeoffID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.TimeOffDecide(r.Context(), req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Get time off
+// @Description Get time off of the specified worker
+// @Tags organization/staff
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   timeoffID path int true "timeoff_id"
+// @Success 200 {object} orgdto.TimeOff
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/timeoff/{timeoffID} [get]
+func (o *OrgCtrl) TimeOff(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID", "timeoffID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.TimeOff(r.Context(), path["orgID"], path["workerID"], path["timeoffID"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Edit time off
+// @Description Change dates, interval, kind or reason. The worker can edit only a pending request, the organization - any time off
+// @Tags organization/staff
+// @Accept  json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   timeoffID path int true "timeoff_id"
+// @Param   request body orgdto.TimeOffReq true "Time off dates, format 2006-01-02, times 15:04"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/timeoff/{timeoffID} [patch]
+func (o *OrgCtrl) TimeOffUpdate(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID", "timeoffID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.TimeOffReq{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	req.WorkerID = path["workerID"]
+	req.TimeOffID = path["timeoffID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.TimeOffUpdate(r.Context(), req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Delete time off
+// @Description The worker can withdraw only a pending request, the organization can delete any time off and its slots are generated again
+// @Tags organization/staff
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   timeoffID path int true "timeoff_id"
+// @Success 204
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/timeoff/{timeoffID} [delete]
+func (o *OrgCtrl) TimeOffDelete(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID", "timeoffID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.TimeOffDelete(r.Context(), path["orgID"], path["workerID"], path["timeoffID"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Time off collisions
+// @Description Customers whose upcoming records fall on the time off, with contacts to notify or reschedule them
+// @Tags organization/staff
+// @Produce json
+// @Param   orgID path int true "org_id"
+// @Param   workerID path int true "worker_id"
+// @Param   timeoffID path int true "timeoff_id"
+// @Success 200 {object} orgdto.TimeOffCollisionList
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/workers/{workerID}/timeoff/{timeoffID}/collisions [get]
+func (o *OrgCtrl) TimeOffCollisions(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "workerID", "timeoffID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.TimeOffCollisions(r.Context(), path["orgID"], path["workerID"], path["timeoffID"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -15,6 +15,10 @@ type Timetable interface {
 	TimetableAdd(ctx context.Context, newTimetable *orgdto.Timetable) error
 	TimetableUpdate(ctx context.Context, newTimetable *orgdto.Timetable) error
 	TimetableDelete(ctx context.Context, orgID, weekday int) error
+	TimetableExceptions(ctx context.Context, params *orgdto.TimetableExceptionParams) (*orgdto.TimetableExceptionList, error)
+	TimetableExceptionAdd(ctx context.Context, req *orgdto.TimetableException) (*orgdto.TimetableExceptionResp, error)
+	TimetableExceptionUpdate(ctx context.Context, req *orgdto.TimetableException) error
+	TimetableExceptionDelete(ctx context.Context, orgID, exceptionID int) error
 }
 
 // @Summary Get timetable
@@ -47,7 +51,7 @@ func (o *OrgCtrl) Timetable(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Add timetable
-// @Description Add organization timetable
+// @Description Add organization timetable. Each weekday may list several non-overlapping breaks in "breaks"
 // @Tags organization / timetables
 // @Accept  json
 // @Param   request body orgdto.Timetable true "New org info"
@@ -128,3 +132,143 @@ func (o *OrgCtrl) TimetableDelete(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// @Summary Get timetable exceptions
+// @Description Get holidays and special hours of the organization. By default - for a year ahead from today
+// @Tags organization / timetables
+// @Produce json
+// @Param orgID path int true "org_id"
+// @Param from query string false "Start date, 2006-01-02"
+// @Param to query string false "End date (inclusive), 2006-01-02"
+// @Success 200 {object} orgdto.TimetableExceptionList
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/timetable/exceptions [get]
+func (o *OrgCtrl) TimetableExceptions(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validation.IsQueryValid(r, map[string]bool{"from": false, "to": false}) {
+		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.TimetableExceptionParams{
+		OrgID: path["orgID"],
+		From:  r.URL.Query().Get("from"),
+		To:    r.URL.Query().Get("to"),
+	}
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.TimetableExceptions(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Add timetable exception
+// @Description Declare a holiday (closed=true) or special hours for a date. Slots of that day are regenerated, busy slots outside new hours are reported in slot conflicts
+// @Tags organization / timetables
+// @Accept json
+// @Produce json
+// @Param orgID path int true "org_id"
+// @Param request body orgdto.TimetableException true "Special day"
+// @Success 201 {object} orgdto.TimetableExceptionResp
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/timetable/exceptions [post]
+func (o *OrgCtrl) TimetableExceptionAdd(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.TimetableException{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := o.usecase.TimetableExceptionAdd(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if o.json.NewEncoder(w).Encode(data) != nil {
+		http.Error(w, "An error occurred while processing the response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update timetable exception
+// @Description Update holiday or special hours
+// @Tags organization / timetables
+// @Accept json
+// @Param orgID path int true "org_id"
+// @Param exceptionID path int true "exception_id"
+// @Param request body orgdto.TimetableException true "Special day"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/timetable/exceptions/{exceptionID} [put]
+func (o *OrgCtrl) TimetableExceptionUpdate(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "exceptionID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &orgdto.TimetableException{}
+	if o.json.NewDecoder(r.Body).Decode(req) != nil {
+		http.Error(w, "An error occurred while processing the request", http.StatusBadRequest)
+		return
+	}
+	req.OrgID = path["orgID"]
+	req.ExceptionID = path["exceptionID"]
+	if err := o.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.TimetableExceptionUpdate(r.Context(), req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Delete timetable exception
+// @Description Delete holiday or special hours, the weekly timetable applies again
+// @Tags organization / timetables
+// @Param orgID path int true "org_id"
+// @Param exceptionID path int true "exception_id"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /orgs/{orgID}/timetable/exceptions/{exceptionID} [delete]
+func (o *OrgCtrl) TimetableExceptionDelete(w http.ResponseWriter, r *http.Request) {
+	path, err := validation.FetchPathID(mux.Vars(r), "orgID", "exceptionID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := o.usecase.TimetableExceptionDelete(r.Context(), path["orgID"], path["exceptionID"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -216,6 +216,7 @@ func (o *OrgCtrl) WorkerDelete(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := o.usecase.WorkerDelete(r.Context(), path["workerID"], path["orgID"]); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
@@ -119,7 +119,7 @@ func (rec *RecordCtrl) RecordList(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Add record
-// @Description Add record with id components of other order details
+// @Description Add record with id components of other order details. Without slot_id the record is made for date and begin taken from the worker availability, the session lasts as long as the service. Every record takes a seat in the slot, group services share the slot until its capacity is reached. Without worker_id the record is made for a resource-only service and reserves only its resources
 // @Tags Records
 // @Accept  json
 // @Param record body recordto.Record true "Record data"
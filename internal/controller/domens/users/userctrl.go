@@ -174,7 +174,7 @@ func (u *UserCtrl) OrganizationInArea(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get user
-// @Description Get user by his id
+// @Description Get user by his id. Available to the user and to organizations the user has records with
 // @Tags User
 // @Accept  json
 // @Produce  json
@@ -2,9 +2,12 @@ package controller
 
 import (
 	"timeline/internal/controller/auth"
+	"timeline/internal/controller/domens/admin"
 	"timeline/internal/controller/domens/orgs"
 	"timeline/internal/controller/domens/records"
 	"timeline/internal/controller/domens/users"
+	"timeline/internal/entity"
+	"timeline/internal/usecase/auth/access"
 
 	"github.com/gorilla/mux"
 )
@@ -14,12 +17,15 @@ type Controllers struct {
 	User   *users.UserCtrl
 	Org    *orgs.OrgCtrl
 	Record *records.RecordCtrl
+	Admin  *admin.AdminCtrl
+	Access *access.Access
 }
 
 // General
 const (
 	health = "/health"
 	v1     = "/v1"
+	jwks   = "/.well-known/jwks.json"
 )
 
 // Auth
@@ -31,6 +37,36 @@ const (
 	authRefreshToken  = "/tokens/refresh"
 	authSendCodeRetry = "/codes/send"
 	authVerifyCode    = "/codes/verify"
+	authLogout        = "/logout"
+	authLogoutAll     = "/logout/all"
+	authSessions      = "/sessions"
+	authPassword      = "/password"
+	authPasswdForgot  = "/password/forgot"
+	authPasswdReset   = "/password/reset"
+	authEmail         = "/email"
+	authEmailConfirm  = "/email/confirm"
+	authLoginMFA      = "/login/mfa"
+	authLoginLink     = "/login/link"
+	authLinkVerify    = "/login/link/verify"
+	authOIDC          = "/oidc"
+	authOIDCStart     = "/oidc/{provider}/start"
+	authOIDCCallback  = "/oidc/{provider}/callback"
+	authTOTP          = "/mfa/totp"
+	authTOTPConfirm   = "/mfa/totp/confirm"
+	authWorkerLogin   = "/workers/login"
+	authWorkerAccept  = "/workers/accept"
+	authAdminLogin    = "/admins/login"
+)
+
+// Admin
+const (
+	adminPrefix     = "/admin"
+	adminUsers      = "/users"
+	adminOrgs       = "/orgs"
+	adminUserBlock  = "/users/{userID}/block"
+	adminOrgBlock   = "/orgs/{orgID}/block"
+	adminFeedbackID = "/feedbacks/{recordID}/hidden"
+	adminAudit      = "/audit"
 )
 
 // User
@@ -40,35 +76,61 @@ const (
 	userSearchOrgs = "/search/orgs"
 	userUpdate     = "/update"
 	userGetInfo    = "/info/{id}"
+	userMe         = "/me"
+	userMeExport   = "/me/export"
 )
 
 // Org
 const (
-	orgPrefix  = "/orgs"
-	orgGetInfo = "/info/{id}"
-	orgUpdate  = "/update"
+	orgPrefix   = "/orgs"
+	orgGetInfo  = "/info/{id}"
+	orgUpdate   = "/update"
+	orgMe       = "/me"
+	orgMeExport = "/me/export"
+	orgAudit    = "/{orgID}/audit"
 	// Timetables
 	timetable   = "/timetable"
 	timetableID = "/{orgID}/timetable"
+	// Особые дни: праздники и измененные часы
+	timetableExceptions  = "/{orgID}/timetable/exceptions"
+	timetableExceptionID = "/{orgID}/timetable/exceptions/{exceptionID}"
 	// Workers
 	worker         = "/workers"
 	workerID       = "/{orgID}/workers/{workerID}"
 	workerList     = "/{orgID}/workers"
 	workerAssign   = "/workers/service"
 	workerUnAssign = "/{orgID}/workers/service/{workerID}/{serviceID}"
+	// Staff: ручки сотрудника
+	workerInvite     = "/{orgID}/workers/{workerID}/invite"
+	workerRecords    = "/{orgID}/workers/{workerID}/records"
+	workerAttendance = "/{orgID}/workers/{workerID}/records/{recordID}/attendance"
+	workerTimeOff    = "/{orgID}/workers/{workerID}/timeoff"
+	workerTimeOffID  = "/{orgID}/workers/{workerID}/timeoff/{timeoffID}"
+	workerCollisions = "/{orgID}/workers/{workerID}/timeoff/{timeoffID}/collisions"
+	// API keys
+	apiKeys  = "/{orgID}/apikeys"
+	apiKeyID = "/{orgID}/apikeys/{keyID}"
 	// Services
 	service        = "/services"
 	serviceID      = "/{orgID}/services/{serviceID}"
 	serviceWorkers = "/{orgID}/services/{serviceID}/workers"
 	serviceList    = "/{orgID}/services"
+	// Resources: кабинеты и оборудование
+	resources  = "/{orgID}/resources"
+	resourceID = "/{orgID}/resources/{resourceID}"
 	// Schedule
 	schedule        = "/schedules"
 	scheduleWorkers = "/{orgID}/schedules"
 	scheduleDelete  = "/{orgID}/schedules/{workerID}"
 
 	// Slots
-	slots       = "/{orgID}/slots"
-	slotsWorker = "/{orgID}/slots/workers/{workerID}"
+	slots         = "/{orgID}/slots"
+	slotsWorker   = "/{orgID}/slots/workers/{workerID}"
+	slotHorizon   = "/{orgID}/slots/horizon"
+	slotConflicts = "/{orgID}/slots/conflicts"
+	slotsFree     = "/{orgID}/slots/workers/{workerID}/availability"
+	slotsResource = "/{orgID}/slots/services/{serviceID}/availability"
+	slotAttendees = "/{orgID}/slots/{slotID}/attendees"
 )
 
 // Record
@@ -78,8 +140,9 @@ const (
 	recordID   = "/info/{recordID}"
 	recordList = "/list"
 	// Feedback
-	feedback   = "/feedbacks"
-	feedbackID = "/feedbacks/info"
+	feedback       = "/feedbacks"
+	feedbackID     = "/feedbacks/info"
+	feedbackDelete = "/feedbacks/{recordID}"
 )
 
 func InitRouter(controllersSet *Controllers) *mux.Router {
@@ -90,76 +153,153 @@ func InitRouter(controllersSet *Controllers) *mux.Router {
 	user := controllersSet.User
 	org := controllersSet.Org
 	rec := controllersSet.Record
+	adm := controllersSet.Admin
+	// Политики доступа к ручкам
+	acc := controllersSet.Access
+	guard := auth.Middleware.Authorize
 
 	r.Use(auth.Middleware.HandlerLogs)
+	r.Use(auth.Middleware.ClientInfo)
 	r.HandleFunc(health, HealthCheck)
+	// Ключи для проверки токенов другими сервисами, вне версии API
+	r.HandleFunc(jwks, auth.JWKS).Methods("GET")
 
 	v1 := r.NewRoute().PathPrefix(v1).Subrouter()
-	// !!!! Пока версия не продовая все ручки доступны без токенов !!!!
 	// Auth
 	authRouter := v1.NewRoute().PathPrefix(authPrefix).Subrouter()
 	authRouter.HandleFunc(authLogin, auth.Login).Methods("POST")
+	authRouter.HandleFunc(authLoginMFA, auth.LoginMFA).Methods("POST")
+	authRouter.HandleFunc(authLoginLink, auth.LoginLinkSend).Methods("POST")
+	authRouter.HandleFunc(authLinkVerify, auth.LoginLinkVerify).Methods("POST")
+	authRouter.HandleFunc(authOIDC, auth.OIDCProviders).Methods("GET")
+	authRouter.HandleFunc(authOIDCStart, auth.OIDCStart).Methods("GET")
+	authRouter.HandleFunc(authOIDCCallback, auth.OIDCCallback).Methods("POST")
 	authRouter.HandleFunc(authRegisterOrg, auth.OrgRegister).Methods("POST")
 	authRouter.HandleFunc(authRegisterUser, auth.UserRegister).Methods("POST")
 	authRouter.HandleFunc(authRefreshToken, auth.UpdateAccessToken).Methods("PUT")
 	authRouter.HandleFunc(authVerifyCode, auth.VerifyCode).Methods("POST")
-
-	authProtectedRouter := v1.NewRoute().PathPrefix("/auth").Subrouter()
-	// authProtectedRouter.Use(auth.Middleware.IsTokenValid)
-	authProtectedRouter.HandleFunc(authSendCodeRetry, auth.SendCodeRetry).Methods("POST")
+	// Аккаунт еще не подтвержден, поэтому токена у него нет
+	authRouter.HandleFunc(authSendCodeRetry, auth.SendCodeRetry).Methods("POST")
+	authRouter.HandleFunc(authPasswdForgot, auth.ForgotPassword).Methods("POST")
+	authRouter.HandleFunc(authPasswdReset, auth.ResetPassword).Methods("POST")
+	authRouter.HandleFunc(authWorkerLogin, auth.WorkerLogin).Methods("POST")
+	authRouter.HandleFunc(authWorkerAccept, auth.WorkerAccept).Methods("POST")
+	authRouter.HandleFunc(authAdminLogin, auth.AdminLogin).Methods("POST")
+	// Управление сессиями по access токену
+	authRouter.Handle(authLogout, auth.Middleware.IsTokenValid(guard(access.Authenticated, auth.Logout))).Methods("POST")
+	authRouter.Handle(authLogoutAll, auth.Middleware.IsTokenValid(guard(access.Authenticated, auth.LogoutAll))).Methods("POST")
+	authRouter.Handle(authSessions, auth.Middleware.IsTokenValid(guard(access.Authenticated, auth.Sessions))).Methods("GET")
+	// Паролем и почтой сотрудника управляет организация через приглашение
+	account := access.AnyOf(access.UserOnly, access.OrgOnly)
+	authRouter.Handle(authPassword, auth.Middleware.IsTokenValid(guard(account, auth.ChangePassword))).Methods("PUT")
+	authRouter.Handle(authEmail, auth.Middleware.IsTokenValid(guard(account, auth.EmailChangeRequest))).Methods("POST")
+	authRouter.Handle(authEmailConfirm, auth.Middleware.IsTokenValid(guard(account, auth.EmailChangeConfirm))).Methods("POST")
+	// MFA доступна только организациям
+	authRouter.Handle(authTOTP, auth.Middleware.IsTokenValid(guard(access.OrgOnly, auth.TOTPSetup))).Methods("POST")
+	authRouter.Handle(authTOTP, auth.Middleware.IsTokenValid(guard(access.OrgOnly, auth.TOTPDisable))).Methods("DELETE")
+	authRouter.Handle(authTOTPConfirm, auth.Middleware.IsTokenValid(guard(access.OrgOnly, auth.TOTPConfirm))).Methods("POST")
 
 	// User
 	userRouter := v1.NewRoute().PathPrefix(userPrefix).Subrouter()
-	// userRouter.Use(auth.Middleware.IsTokenValid)
-	userRouter.HandleFunc(userMapOrgs, user.OrganizationInArea).Methods("GET")
-	userRouter.HandleFunc(userSearchOrgs, user.SearchOrganization).Methods("GET")
-	userRouter.HandleFunc(userGetInfo, user.GetUserByID).Methods("GET")
-	userRouter.HandleFunc(userUpdate, user.UpdateUser).Methods("PUT")
+	userRouter.Use(auth.Middleware.IsTokenValid)
+	userRouter.HandleFunc(userMapOrgs, guard(access.Authenticated, user.OrganizationInArea)).Methods("GET")
+	userRouter.HandleFunc(userSearchOrgs, guard(access.Authenticated, user.SearchOrganization)).Methods("GET")
+	userRouter.HandleFunc(userGetInfo, guard(access.AnyOf(access.UserPath("id"), acc.ClientPath("id")), user.GetUserByID)).Methods("GET")
+	userRouter.HandleFunc(userUpdate, guard(access.UserBody("id"), user.UpdateUser)).Methods("PUT")
+	// Персональные данные: выгрузка и удаление аккаунта
+	userRouter.HandleFunc(userMeExport, guard(access.UserOnly, auth.AccountExport)).Methods("GET")
+	userRouter.HandleFunc(userMe, guard(access.UserOnly, auth.AccountErase)).Methods("DELETE")
 	// Org
 	orgRouter := v1.NewRoute().PathPrefix(orgPrefix).Subrouter()
-	// orgRouter.Use(auth.Middleware.IsTokenValid)
-	orgRouter.HandleFunc(orgGetInfo, org.GetOrgByID).Methods("GET")
-	orgRouter.HandleFunc(orgUpdate, org.UpdateOrg).Methods("PUT")
+	orgRouter.Use(auth.Middleware.IsTokenValid)
+	orgRouter.HandleFunc(orgGetInfo, guard(access.Authenticated, org.GetOrgByID)).Methods("GET")
+	orgRouter.HandleFunc(orgUpdate, guard(access.OrgBody("org_id"), org.UpdateOrg)).Methods("PUT")
+	orgRouter.HandleFunc(orgMeExport, guard(access.OrgOnly, auth.AccountExport)).Methods("GET")
+	orgRouter.HandleFunc(orgMe, guard(access.OrgOnly, auth.AccountErase)).Methods("DELETE")
+	orgRouter.HandleFunc(orgAudit, guard(access.OrgPath("orgID"), org.Audit)).Methods("GET")
 	// Timetable
-	orgRouter.HandleFunc(timetable, org.TimetableAdd).Methods("POST")
-	orgRouter.HandleFunc(timetable, org.TimetableUpdate).Methods("PUT")
-	orgRouter.HandleFunc(timetableID, org.Timetable).Methods("GET")
-	orgRouter.HandleFunc(timetableID, org.TimetableDelete).Methods("DELETE")
+	orgRouter.HandleFunc(timetable, guard(access.OrgBody("org_id"), org.TimetableAdd)).Methods("POST")
+	orgRouter.HandleFunc(timetable, guard(access.OrgBody("org_id"), org.TimetableUpdate)).Methods("PUT")
+	orgRouter.HandleFunc(timetableID, guard(access.Authenticated, org.Timetable)).Methods("GET")
+	orgRouter.HandleFunc(timetableID, guard(access.OrgPath("orgID"), org.TimetableDelete)).Methods("DELETE")
+	orgRouter.HandleFunc(timetableExceptions, guard(access.Authenticated, org.TimetableExceptions)).Methods("GET")
+	orgRouter.HandleFunc(timetableExceptions, guard(access.OrgPath("orgID"), org.TimetableExceptionAdd)).Methods("POST")
+	orgRouter.HandleFunc(timetableExceptionID, guard(access.OrgPath("orgID"), org.TimetableExceptionUpdate)).Methods("PUT")
+	orgRouter.HandleFunc(timetableExceptionID, guard(access.OrgPath("orgID"), org.TimetableExceptionDelete)).Methods("DELETE")
 
 	// Workers
-	orgRouter.HandleFunc(worker, org.WorkerAdd).Methods("POST")
-	orgRouter.HandleFunc(worker, org.WorkerUpdate).Methods("PUT")
-	orgRouter.HandleFunc(workerID, org.WorkerDelete).Methods("DELETE")
-	orgRouter.HandleFunc(workerID, org.Worker).Methods("GET")
-	orgRouter.HandleFunc(workerList, org.WorkerList).Methods("GET")
-	orgRouter.HandleFunc(workerAssign, org.WorkerAssignService).Methods("POST")
-	orgRouter.HandleFunc(workerUnAssign, org.WorkerUnAssignService).Methods("DELETE")
+	orgRouter.HandleFunc(worker, guard(access.OrgBody("org_id"), org.WorkerAdd)).Methods("POST")
+	orgRouter.HandleFunc(worker, guard(access.OrgBody("org_id"), org.WorkerUpdate)).Methods("PUT")
+	orgRouter.HandleFunc(workerID, guard(access.OrgPath("orgID"), org.WorkerDelete)).Methods("DELETE")
+	orgRouter.HandleFunc(workerID, guard(access.Authenticated, org.Worker)).Methods("GET")
+	orgRouter.HandleFunc(workerList, guard(access.Authenticated, org.WorkerList)).Methods("GET")
+	orgRouter.HandleFunc(workerAssign, guard(access.OrgBody("org_id"), org.WorkerAssignService)).Methods("POST")
+	orgRouter.HandleFunc(workerUnAssign, guard(access.OrgPath("orgID"), org.WorkerUnAssignService)).Methods("DELETE")
+	// Staff
+	staff := access.AnyOf(access.OrgPath("orgID"), access.WorkerPath("orgID", "workerID"))
+	orgRouter.HandleFunc(workerInvite, guard(access.OrgPath("orgID"), auth.WorkerInvite)).Methods("POST")
+	orgRouter.HandleFunc(workerRecords, guard(access.AnyOf(staff, access.KeyPath(entity.ScopeRecordsRead, "orgID")), org.WorkerRecords)).Methods("GET")
+	orgRouter.HandleFunc(workerAttendance, guard(access.AnyOf(staff, access.KeyPath(entity.ScopeRecordsWrite, "orgID")), org.RecordAttendance)).Methods("PUT")
+	orgRouter.HandleFunc(workerTimeOff, guard(staff, org.TimeOffRequest)).Methods("POST")
+	orgRouter.HandleFunc(workerTimeOff, guard(staff, org.TimeOffList)).Methods("GET")
+	orgRouter.HandleFunc(workerTimeOffID, guard(staff, org.TimeOff)).Methods("GET")
+	orgRouter.HandleFunc(workerTimeOffID, guard(staff, org.TimeOffUpdate)).Methods("PATCH")
+	orgRouter.HandleFunc(workerTimeOffID, guard(staff, org.TimeOffDelete)).Methods("DELETE")
+	orgRouter.HandleFunc(workerTimeOffID, guard(access.OrgPath("orgID"), org.TimeOffDecide)).Methods("PUT")
+	orgRouter.HandleFunc(workerCollisions, guard(staff, org.TimeOffCollisions)).Methods("GET")
 	// Services
-	orgRouter.HandleFunc(service, org.ServiceAdd).Methods("POST")
-	orgRouter.HandleFunc(service, org.ServiceUpdate).Methods("PUT")
-	orgRouter.HandleFunc(serviceID, org.Service).Methods("GET")
-	orgRouter.HandleFunc(serviceID, org.ServiceDelete).Methods("DELETE")
-	orgRouter.HandleFunc(serviceWorkers, org.ServiceWorkerList).Methods("GET")
-	orgRouter.HandleFunc(serviceList, org.ServiceList).Methods("GET")
+	orgRouter.HandleFunc(service, guard(access.OrgBody("org_id"), org.ServiceAdd)).Methods("POST")
+	orgRouter.HandleFunc(service, guard(access.OrgBody("org_id"), org.ServiceUpdate)).Methods("PUT")
+	orgRouter.HandleFunc(serviceID, guard(access.Authenticated, org.Service)).Methods("GET")
+	orgRouter.HandleFunc(serviceID, guard(access.OrgPath("orgID"), org.ServiceDelete)).Methods("DELETE")
+	orgRouter.HandleFunc(serviceWorkers, guard(access.Authenticated, org.ServiceWorkerList)).Methods("GET")
+	orgRouter.HandleFunc(serviceList, guard(access.Authenticated, org.ServiceList)).Methods("GET")
+	// Resources
+	orgRouter.HandleFunc(resources, guard(access.Authenticated, org.Resources)).Methods("GET")
+	orgRouter.HandleFunc(resources, guard(access.OrgPath("orgID"), org.ResourceAdd)).Methods("POST")
+	orgRouter.HandleFunc(resourceID, guard(access.OrgPath("orgID"), org.ResourceUpdate)).Methods("PUT")
+	orgRouter.HandleFunc(resourceID, guard(access.OrgPath("orgID"), org.ResourceDelete)).Methods("DELETE")
 	// Schedule
-	orgRouter.HandleFunc(schedule, org.AddWorkerSchedule).Methods("POST")
-	orgRouter.HandleFunc(schedule, org.UpdateWorkerSchedule).Methods("PUT")
-	orgRouter.HandleFunc(scheduleWorkers, org.WorkerSchedule).Methods("GET")
-	orgRouter.HandleFunc(scheduleDelete, org.DeleteWorkerSchedule).Methods("DELETE")
+	orgRouter.HandleFunc(schedule, guard(access.OrgBody("org_id"), org.AddWorkerSchedule)).Methods("POST")
+	orgRouter.HandleFunc(schedule, guard(access.OrgBody("org_id"), org.UpdateWorkerSchedule)).Methods("PUT")
+	orgRouter.HandleFunc(scheduleWorkers, guard(access.Authenticated, org.WorkerSchedule)).Methods("GET")
+	orgRouter.HandleFunc(scheduleDelete, guard(access.OrgPath("orgID"), org.DeleteWorkerSchedule)).Methods("DELETE")
 	// Slots
-	orgRouter.HandleFunc(slotsWorker, org.Slots).Methods("GET")
-	orgRouter.HandleFunc(slots, org.UpdateSlot).Methods("PUT")
+	orgRouter.HandleFunc(slotsWorker, guard(access.Authenticated, org.Slots)).Methods("GET")
+	orgRouter.HandleFunc(slotsWorker, guard(access.OrgPath("orgID"), org.GenerateSlots)).Methods("POST")
+	orgRouter.HandleFunc(slotHorizon, guard(access.OrgPath("orgID"), org.SlotHorizon)).Methods("GET")
+	orgRouter.HandleFunc(slotHorizon, guard(access.OrgPath("orgID"), org.SlotHorizonUpdate)).Methods("PUT")
+	orgRouter.HandleFunc(slotConflicts, guard(access.OrgPath("orgID"), org.SlotConflicts)).Methods("GET")
+	orgRouter.HandleFunc(slotsFree, guard(access.Authenticated, org.Availability)).Methods("GET")
+	orgRouter.HandleFunc(slotsResource, guard(access.Authenticated, org.ServiceAvailability)).Methods("GET")
+	orgRouter.HandleFunc(slotAttendees, guard(access.AnyOf(access.OrgPath("orgID"), access.KeyPath(entity.ScopeRecordsRead, "orgID")), org.SlotAttendees)).Methods("GET")
+	orgRouter.HandleFunc(slots, guard(access.AnyOf(access.OrgPath("orgID"), access.KeyPath(entity.ScopeSlotsWrite, "orgID")), org.UpdateSlot)).Methods("PUT")
+	// API keys: выпускает и отзывает только сама организация
+	orgRouter.HandleFunc(apiKeys, guard(access.OrgPath("orgID"), auth.APIKeyCreate)).Methods("POST")
+	orgRouter.HandleFunc(apiKeys, guard(access.OrgPath("orgID"), auth.APIKeyList)).Methods("GET")
+	orgRouter.HandleFunc(apiKeyID, guard(access.OrgPath("orgID"), auth.APIKeyRevoke)).Methods("DELETE")
 
 	// Records
 	recRouter := v1.NewRoute().PathPrefix(record).Subrouter()
-	recRouter.HandleFunc(recordAdd, rec.RecordAdd).Methods("POST")
-	recRouter.HandleFunc(recordID, rec.Record).Methods("GET")
-	recRouter.HandleFunc(recordList, rec.RecordList).Methods("GET")
-	recRouter.HandleFunc(recordID, rec.RecordDelete).Methods("DELETE")
+	recRouter.Use(auth.Middleware.IsTokenValid)
+	recRouter.HandleFunc(recordAdd, guard(access.UserBody("user_id"), rec.RecordAdd)).Methods("POST")
+	recRouter.HandleFunc(recordID, guard(access.AnyOf(acc.RecordPath("recordID"), acc.RecordKeyPath(entity.ScopeRecordsRead, "recordID")), rec.Record)).Methods("GET")
+	recRouter.HandleFunc(recordList, guard(access.AnyOf(access.UserQuery("user_id"), access.OrgQuery("org_id"), access.KeyQuery(entity.ScopeRecordsRead, "org_id")), rec.RecordList)).Methods("GET")
+	recRouter.HandleFunc(recordID, guard(access.AnyOf(acc.RecordPath("recordID"), acc.RecordKeyPath(entity.ScopeRecordsWrite, "recordID")), rec.RecordDelete)).Methods("DELETE")
 	// Feedbacks
-	recRouter.HandleFunc(feedback, rec.FeedbackSet).Methods("POST")
-	recRouter.HandleFunc(feedback, rec.FeedbackUpdate).Methods("PUT")
-	recRouter.HandleFunc(feedbackID, rec.Feedbacks).Methods("GET")
-	recRouter.HandleFunc(feedbackID, rec.FeedbackDelete).Methods("DELETE")
+	recRouter.HandleFunc(feedback, guard(acc.RecordUserBody("record_id"), rec.FeedbackSet)).Methods("POST")
+	recRouter.HandleFunc(feedback, guard(acc.RecordUserBody("record_id"), rec.FeedbackUpdate)).Methods("PUT")
+	recRouter.HandleFunc(feedbackID, guard(access.Authenticated, rec.Feedbacks)).Methods("GET")
+	recRouter.HandleFunc(feedbackDelete, guard(acc.RecordUserPath("recordID"), rec.FeedbackDelete)).Methods("DELETE")
+
+	// Admin
+	adminRouter := v1.NewRoute().PathPrefix(adminPrefix).Subrouter()
+	adminRouter.Use(auth.Middleware.IsTokenValid)
+	adminRouter.HandleFunc(adminUsers, guard(access.AdminOnly, adm.Users)).Methods("GET")
+	adminRouter.HandleFunc(adminOrgs, guard(access.AdminOnly, adm.Orgs)).Methods("GET")
+	adminRouter.HandleFunc(adminUserBlock, guard(access.AdminOnly, adm.UserBlock)).Methods("PUT")
+	adminRouter.HandleFunc(adminOrgBlock, guard(access.AdminOnly, adm.OrgBlock)).Methods("PUT")
+	adminRouter.HandleFunc(adminFeedbackID, guard(access.AdminOnly, adm.FeedbackHide)).Methods("PUT")
+	adminRouter.HandleFunc(adminAudit, guard(access.AdminOnly, adm.Audit)).Methods("GET")
 	return r
 }
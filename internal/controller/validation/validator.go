@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	dateFormat = "2001-01-01"
+	dateFormat = "2006-01-02"
 	timeFormat = "15:04"
 )
 
@@ -28,12 +28,23 @@ func validDate(fl validator.FieldLevel) bool {
 	return err == nil
 }
 
+// Имя часового пояса IANA, например Asia/Novosibirsk
+func validTimeZone(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
 func NewCustomValidator() *validator.Validate {
 	validate := validator.New()
 
 	// Регистрация кастомных функций валидации полей
 	validate.RegisterValidation("time", validTime)
 	validate.RegisterValidation("date", validDate)
+	validate.RegisterValidation("timezone", validTimeZone)
 
 	return validate
 }
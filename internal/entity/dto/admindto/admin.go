@@ -0,0 +1,71 @@
+package admindto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AccountSearchReq struct {
+	Page  int    `json:"page" validate:"required,min=1"`
+	Limit int    `json:"limit" validate:"required,min=1,max=100"`
+	Query string `json:"query,omitempty"` // почта или имя
+}
+
+type Account struct {
+	ID            int       `json:"id"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	City          string    `json:"city"`
+	Verified      bool      `json:"verified"`
+	IsDelete      bool      `json:"is_delete"`
+	IsBlocked     bool      `json:"is_blocked"`
+	BlockedReason string    `json:"blocked_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type AccountList struct {
+	Found    int        `json:"found"`
+	Accounts []*Account `json:"accounts"`
+}
+
+type BlockReq struct {
+	ID      int    `json:"-"`
+	IsOrg   bool   `json:"-"`
+	Blocked bool   `json:"blocked"`
+	Reason  string `json:"reason" validate:"max=255"`
+}
+
+type FeedbackHiddenReq struct {
+	RecordID int  `json:"-"`
+	Hidden   bool `json:"hidden"`
+}
+
+type AuditReq struct {
+	OrgID    int
+	Entity   string
+	EntityID int
+	From     time.Time
+	To       time.Time
+	Page     int `validate:"required,min=1"`
+	Limit    int `validate:"required,min=1,max=100"`
+}
+
+type AuditEntry struct {
+	ID        int64           `json:"audit_id"`
+	ActorType string          `json:"actor_type"`
+	ActorID   int             `json:"actor_id"`
+	OrgID     int             `json:"org_id,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditList struct {
+	Found   int           `json:"found"`
+	Entries []*AuditEntry `json:"entries"`
+}
@@ -1,6 +1,11 @@
 package authdto
 
-import "timeline/internal/entity"
+import (
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/entity/dto/recordto"
+)
 
 type SendCodeReq struct {
 	ID    int    `json:"id" validate:"required,gt=0"`
@@ -26,6 +31,15 @@ type LoginReq struct {
 	IsOrg bool `json:"is_org"`
 }
 
+// Вход по ссылке доступен только пользователям
+type LoginLinkReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type LoginLinkVerifyReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
 type UserRegisterReq struct {
 	Credentials
 	entity.User
@@ -48,3 +62,140 @@ type TokenPair struct {
 type AccessToken struct {
 	Token string `json:"access_token"`
 }
+
+type Session struct {
+	SessionID int       `json:"session_id"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	StartedAt time.Time `json:"started_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	Current   bool      `json:"current"` // Сессия, из которой сделан запрос
+}
+
+type SessionList struct {
+	List []*Session `json:"session_list"`
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email" validate:"required,email"`
+	IsOrg bool   `json:"is_org"`
+}
+
+type ResetPasswordReq struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=12,max=64"`
+}
+
+type ChangePasswordReq struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=12,max=64"`
+}
+
+type EmailChangeReq struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type EmailConfirmReq struct {
+	Code string `json:"code" validate:"required,min=3"`
+}
+
+// Ответ на вход по паролю. Если у организации включена MFA,
+// вместо токенов возвращается mfa_token для POST /auth/login/mfa
+type LoginResp struct {
+	*TokenPair
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+type MFALoginReq struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"` // TOTP код или код восстановления
+}
+
+type TOTPSetupResp struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TOTPCodeReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type WorkerInviteReq struct {
+	OrgID    int    `json:"-"`
+	WorkerID int    `json:"-"`
+	Email    string `json:"email" validate:"required,email"`
+}
+
+type WorkerAcceptReq struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=12,max=64"`
+}
+
+type WorkerLoginReq struct {
+	Credentials
+}
+
+type AdminLoginReq struct {
+	Credentials
+}
+
+type OIDCProviders struct {
+	Providers []string `json:"providers"`
+}
+
+type OIDCStartResp struct {
+	AuthURL string `json:"auth_url"`
+}
+
+// Параметры, с которыми провайдер вернул пользователя на redirect_url
+type OIDCCallbackReq struct {
+	Provider string `json:"-"`
+	Code     string `json:"code" validate:"required"`
+	State    string `json:"state" validate:"required"`
+}
+
+type APIKeyReq struct {
+	OrgID     int        `json:"-"`
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=records:read records:write slots:write"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // без срока, если не указан
+}
+
+type APIKey struct {
+	KeyID      int        `json:"key_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // начало ключа, чтобы отличать ключи в списке
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	Revoked    bool       `json:"revoked"`
+}
+
+// Ключ целиком показывается только при создании
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyList struct {
+	Keys []*APIKey `json:"api_keys"`
+}
+
+// Выгрузка персональных данных аккаунта. Заполнен либо user, либо org
+type AccountExport struct {
+	ExportedAt time.Time               `json:"exported_at"`
+	Email      string                  `json:"email"`
+	User       *entity.User            `json:"user,omitempty"`
+	Org        *orgdto.Organization    `json:"org,omitempty"`
+	Records    []*recordto.RecordScrap `json:"records"`
+	Feedbacks  []*recordto.Feedback    `json:"feedbacks"`
+	Sessions   []*Session              `json:"sessions"`
+}
@@ -6,6 +6,8 @@ type Organization struct {
 	OrgID     int                 `json:"id"`
 	Info      *entity.OrgInfo     `json:"info"`
 	Timetable []*entity.OpenHours `json:"timetable,omitempty"`
+	// Ближайшие особые дни: праздники и измененные часы
+	Exceptions []*TimetableException `json:"exceptions,omitempty"`
 }
 
 type OrgUpdateReq struct {
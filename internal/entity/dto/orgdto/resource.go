@@ -0,0 +1,25 @@
+package orgdto
+
+// Ресурс организации: кабинет, оборудование, корт.
+// Без часов доступен все время работы сотрудника
+type Resource struct {
+	ResourceID  int              `json:"resource_id"`
+	OrgID       int              `json:"-"`
+	Name        string           `json:"name" validate:"required,max=255"`
+	Description string           `json:"description,omitempty" validate:"max=1000"`
+	Hours       []*ResourceHours `json:"hours,omitempty" validate:"omitempty,dive"`
+}
+
+type ResourceHours struct {
+	Weekday int    `json:"weekday" validate:"min=1,max=7"`
+	Open    string `json:"open" validate:"time"`
+	Close   string `json:"close" validate:"time"`
+}
+
+type ResourceResp struct {
+	ResourceID int `json:"resource_id"`
+}
+
+type ResourceList struct {
+	List []*Resource `json:"resources"`
+}
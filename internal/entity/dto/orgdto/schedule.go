@@ -13,9 +13,10 @@ type ScheduleList struct {
 }
 
 type ScheduleParams struct {
-	WorkerID int `json:"worker_id"`
-	OrgID    int `json:"org_id" validate:"required"`
-	Weekday  int `json:"weekday"`
+	WorkerID int    `json:"worker_id"`
+	OrgID    int    `json:"org_id" validate:"required"`
+	Weekday  int    `json:"weekday"`
+	Date     string `json:"date" validate:"omitempty,date"` // по умолчанию сегодня
 	Limit    int
 	Page     int
 }
@@ -25,4 +26,8 @@ type Schedule struct {
 	Weekday          int    `json:"weekday"`
 	Start            string `json:"start" validate:"time"`
 	Over             string `json:"over" validate:"time"`
+	// Версия расписания действует с valid_from по valid_to включительно.
+	// Пустые - без ограничения, при обновлении пустые не меняются
+	ValidFrom string `json:"valid_from,omitempty" validate:"omitempty,date"`
+	ValidTo   string `json:"valid_to,omitempty" validate:"omitempty,date"`
 }
@@ -7,6 +7,8 @@ type Slot struct {
 	Begin            string `json:"begin,omitempty" validate:"time"`
 	End              string `json:"end,omitempty" validate:"time"`
 	Busy             bool   `json:"busy,omitempty"`
+	Capacity         int    `json:"capacity,omitempty"`
+	SeatsLeft        int    `json:"seats_left"`
 }
 
 type SlotReq struct {
@@ -14,6 +16,7 @@ type SlotReq struct {
 	WorkerID         int `json:"worker_id" validate:"required"`
 	OrgID            int `json:"org_id" validate:"required"`
 	WorkerScheduleID int `json:"worker_schedule_id"`
+	ServiceID        int `json:"service_id"` // места пустых слотов считаются по этой услуге
 }
 
 type SlotResp struct {
@@ -24,6 +27,82 @@ type SlotResp struct {
 type SlotUpdate struct {
 	SlotID   int `json:"slot_id" validate:"required"`
 	WorkerID int `json:"worker_id" validate:"required"`
+	OrgID    int `json:"-"`
 	//WorkerScheduleID int  `json:"worker_schedule_id"`
 	Busy bool `json:"busy" validate:"required"`
+	// Мест в слоте. 0 - не менять, иначе не меньше уже занятых
+	Capacity int `json:"capacity,omitempty" validate:"min=0,max=500"`
+}
+
+type SlotHorizon struct {
+	OrgID int `json:"-"`
+	Days  int `json:"days" validate:"required,min=1,max=90"`
+}
+
+type SlotGenerateResp struct {
+	Created int `json:"created"`
+}
+
+// Занятый слот вне рабочего времени: клиента нужно перенести
+type SlotConflict struct {
+	SlotID   int    `json:"slot_id"`
+	WorkerID int    `json:"worker_id"`
+	RecordID int    `json:"record_id,omitempty"`
+	UserID   int    `json:"user_id,omitempty"`
+	Date     string `json:"date"`
+	Begin    string `json:"begin"`
+	End      string `json:"end"`
+}
+
+type AvailabilityReq struct {
+	OrgID     int    `json:"-"`
+	WorkerID  int    `json:"-"` // 0 - услуга без сотрудника
+	ServiceID int    `json:"service_id" validate:"required"`
+	Date      string `json:"date" validate:"required,date"`
+}
+
+// Свободное время сотрудника на день под услугу
+type Availability struct {
+	WorkerID     int        `json:"worker_id,omitempty"` // нет у услуги без сотрудника
+	ServiceID    int        `json:"service_id"`
+	Date         string     `json:"date"`
+	Duration     int        `json:"duration"`
+	BufferBefore int        `json:"buffer_before"`
+	BufferAfter  int        `json:"buffer_after"`
+	Sessions     []*Session `json:"sessions"`
+	TimeZone     string     `json:"time_zone"` // часы сеансов указаны в этом поясе
+}
+
+type Session struct {
+	SlotID    int    `json:"slot_id,omitempty"` // начатый групповой сеанс
+	Begin     string `json:"begin"`
+	End       string `json:"end"`
+	SeatsLeft int    `json:"seats_left"`
+}
+
+type AttendeesReq struct {
+	OrgID  int `json:"-"`
+	SlotID int `json:"-"`
+}
+
+// Клиенты, записанные в слот
+type Attendees struct {
+	SlotID    int         `json:"slot_id"`
+	WorkerID  int         `json:"worker_id"`
+	Date      string      `json:"date"`
+	Begin     string      `json:"begin"`
+	End       string      `json:"end"`
+	Capacity  int         `json:"capacity"`
+	SeatsLeft int         `json:"seats_left"`
+	List      []*Attendee `json:"attendees"`
+}
+
+type Attendee struct {
+	RecordID  int    `json:"record_id"`
+	UserID    int    `json:"user_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Telephone string `json:"telephone,omitempty"`
+	ServiceID int    `json:"service_id"`
+	Attended  *bool  `json:"attended,omitempty"` // не отмечено - отсутствует
 }
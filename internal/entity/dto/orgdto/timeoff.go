@@ -0,0 +1,60 @@
+package orgdto
+
+type TimeOffReq struct {
+	TimeOffID int    `json:"-"`
+	OrgID     int    `json:"-"`
+	WorkerID  int    `json:"-"`
+	From      string `json:"from" validate:"required,date"` // 2006-01-02
+	To        string `json:"to" validate:"required,date"`
+	// Часть дня, 15:04. Не заданы - отсутствие на весь день
+	TimeFrom string `json:"time_from,omitempty" validate:"omitempty,time"`
+	TimeTo   string `json:"time_to,omitempty" validate:"omitempty,time"`
+	Kind     string `json:"kind,omitempty" validate:"omitempty,oneof=vacation sick other"`
+	Reason   string `json:"reason" validate:"max=500"`
+}
+
+type TimeOffDecision struct {
+	TimeOffID int    `json:"-"`
+	OrgID     int    `json:"-"`
+	WorkerID  int    `json:"-"`
+	Status    string `json:"status" validate:"required,oneof=approved rejected"`
+}
+
+type TimeOff struct {
+	TimeOffID int    `json:"timeoff_id"`
+	WorkerID  int    `json:"worker_id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	TimeFrom  string `json:"time_from,omitempty"`
+	TimeTo    string `json:"time_to,omitempty"`
+	Kind      string `json:"kind"`
+	Reason    string `json:"reason,omitempty"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+type TimeOffResp struct {
+	TimeOffID int `json:"timeoff_id"`
+}
+
+type TimeOffList struct {
+	List []*TimeOff `json:"timeoff_list"`
+}
+
+// Клиент, чья запись попадает на отсутствие сотрудника
+type TimeOffCollision struct {
+	RecordID  int    `json:"record_id"`
+	SlotID    int    `json:"slot_id"`
+	UserID    int    `json:"user_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Telephone string `json:"telephone,omitempty"`
+	Date      string `json:"date"`
+	Begin     string `json:"begin"`
+	End       string `json:"end"`
+}
+
+type TimeOffCollisionList struct {
+	List []*TimeOffCollision `json:"collisions"`
+}
@@ -6,3 +6,33 @@ type Timetable struct {
 	OrgID     int                 `json:"org_id" validate:"required"`
 	Timetable []*entity.OpenHours `json:"timetable" validate:"required"`
 }
+
+// Особый день: праздник, сокращенный день или разовый рабочий день.
+// Closed - выходной, иначе часы этого дня вместо недельного расписания
+type TimetableException struct {
+	ExceptionID int    `json:"exception_id"`
+	OrgID       int    `json:"-"`
+	Date        string `json:"date" validate:"required,date"` // 2006-01-02
+	Closed      bool   `json:"closed"`
+	Open        string `json:"open,omitempty" validate:"omitempty,time"`
+	Close       string `json:"close,omitempty" validate:"omitempty,time"`
+	BreakStart  string `json:"break_start,omitempty" validate:"omitempty,time"`
+	BreakEnd    string `json:"break_end,omitempty" validate:"omitempty,time"`
+	Reason      string `json:"reason,omitempty" validate:"max=255"`
+	// Сотрудники, которые выходят в рабочий день в его часы, даже если по расписанию не работают
+	Workers []int `json:"workers,omitempty" validate:"omitempty,max=100,unique,dive,min=1"`
+}
+
+type TimetableExceptionResp struct {
+	ExceptionID int `json:"exception_id"`
+}
+
+type TimetableExceptionList struct {
+	List []*TimetableException `json:"exceptions"`
+}
+
+type TimetableExceptionParams struct {
+	OrgID int    `json:"-"`
+	From  string `validate:"omitempty,date"` // по умолчанию сегодня
+	To    string `validate:"omitempty,date"` // по умолчанию через год от From
+}
@@ -11,13 +11,18 @@ type Record struct {
 	UserID    int  `json:"user_id"`
 	SlotID    int  `json:"slot_id"`
 	ServiceID int  `json:"service_id"`
-	WorkerID  int  `json:"worker_id"`
+	WorkerID  int  `json:"worker_id"` // 0 - услуга без сотрудника
 	Reviewed  bool `json:"reviewed"`
+	// Запись без slot_id: день и начало сеанса из свободного времени сотрудника
+	// или, без сотрудника, ресурсов услуги
+	Date  string `json:"date,omitempty" validate:"omitempty,date"`
+	Begin string `json:"begin,omitempty" validate:"omitempty,time"`
 }
 
 type RecordListParams struct {
 	OrgID    int  `json:"org_id"`
 	UserID   int  `json:"user_id"`
+	WorkerID int  `json:"worker_id"`
 	Fresh    bool `json:"fresh"`
 	Reviewed bool `json:"reviewed"`
 }
@@ -25,6 +30,7 @@ type RecordListParams struct {
 type RecordScrap struct {
 	RecordID int             `json:"record_id"`
 	Reviewed bool            `json:"reviewed"`
+	Attended *bool           `json:"attended,omitempty"` // не отмечено - отсутствует
 	Org      *entity.OrgInfo `json:"org,omitempty"`
 	User     *entity.User    `json:"user,omitempty"`
 	Slot     *orgdto.Slot    `json:"slot,omitempty"`
@@ -33,6 +39,13 @@ type RecordScrap struct {
 	Feedback *Feedback       `json:"feedback,omitempty"`
 }
 
+type AttendanceReq struct {
+	RecordID int   `json:"-"`
+	OrgID    int   `json:"-"`
+	WorkerID int   `json:"-"`
+	Attended *bool `json:"attended" validate:"required"`
+}
+
 type RecordList struct {
 	List  []*RecordScrap `json:"record_list"`
 	Found int            `json:"found"`
@@ -7,9 +7,34 @@ type HashCreds struct {
 	PasswdHash string
 }
 
+// Роли субъекта токена помимо пользователя и организации
+const (
+	RoleWorker = "worker" // сотрудник организации, ID - worker_id
+	RoleAdmin  = "admin"  // администратор платформы, ID - admin_id
+	RoleAPIKey = "apikey" // ключ API организации, ID - key_id
+)
+
+// Ключи API передаются в заголовке Authorization как Bearer и отличаются от JWT префиксом
+const APIKeyPrefix = "tl_"
+
+// Права ключей API. Публичные данные, доступные любому токену, ключ читает без отдельных прав
+const (
+	ScopeRecordsRead  = "records:read"
+	ScopeRecordsWrite = "records:write"
+	ScopeSlotsWrite   = "slots:write"
+)
+
 type TokenMetadata struct {
 	ID    uint64 `json:"id"`     // ID пользователя или организации
 	IsOrg bool   `json:"is_org"` // Является ли это организациями (true - организация, false - пользователь)
+	// Роль субъекта. Пусто - пользователь или организация по IsOrg
+	Role string `json:"role,omitempty"`
+	// Организация, к которой относится сотрудник или ключ API
+	OrgID uint64 `json:"org_id,omitempty"`
+	// Права ключа API
+	Scopes []string `json:"scopes,omitempty"`
+	// Сессия, к которой относится токен
+	SessionID string `json:"sid,omitempty"`
 }
 
 type Coordinates struct {
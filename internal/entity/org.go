@@ -8,6 +8,8 @@ type OrgInfo struct {
 	Telephone string  `json:"telephone,omitempty" validate:"e164"`
 	City      string  `json:"city,omitempty" validate:"required"`
 	About     string  `json:"about,omitempty" validate:"max=1500"`
+	// Пустой - Europe/Moscow
+	TimeZone string `json:"time_zone,omitempty" validate:"omitempty,timezone"`
 	Coordinates
 }
 
@@ -36,6 +38,13 @@ type OpenHours struct {
 	Close      string `json:"close,omitempty" validate:"time"`
 	BreakStart string `json:"break_start,omitempty" validate:"time"`
 	BreakEnd   string `json:"break_end,omitempty" validate:"time"`
+	// Все перерывы дня. Если не заданы, перерывом считается break_start-break_end
+	Breaks []*Break `json:"breaks,omitempty" validate:"omitempty,dive"`
+}
+
+type Break struct {
+	Start string `json:"start" validate:"time"`
+	End   string `json:"end" validate:"time"`
 }
 
 // type OrgAddInfo struct {
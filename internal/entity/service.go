@@ -4,4 +4,14 @@ type Service struct {
 	Name        string  `json:"name,omitempty"`
 	Cost        float64 `json:"cost,omitempty"`
 	Description string  `json:"description,omitempty"`
+	// Минуты. Без длительности используется длительность сеанса сотрудника
+	Duration     int `json:"duration,omitempty" validate:"min=0,max=1440"`
+	BufferBefore int `json:"buffer_before,omitempty" validate:"min=0,max=240"`
+	BufferAfter  int `json:"buffer_after,omitempty" validate:"min=0,max=240"`
+	// Мест на один сеанс. Без вместимости - индивидуальная услуга
+	Capacity int `json:"capacity,omitempty" validate:"min=0,max=500"`
+	// Ресурсы, без которых услугу не оказать. Не передан при обновлении - не меняется
+	Resources []int `json:"resources,omitempty" validate:"omitempty,max=10,unique,dive,min=1"`
+	// Услуга без сотрудника: бронируются только ресурсы. Нужны своя длительность и ресурсы
+	ResourceOnly bool `json:"resource_only,omitempty"`
 }
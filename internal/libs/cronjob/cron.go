@@ -2,16 +2,19 @@ package cronjob
 
 import (
 	"context"
+	"time"
 	"timeline/internal/repository"
+	"timeline/internal/repository/models/orgmodel"
 
 	gocron "github.com/go-co-op/gocron/v2"
 )
 
 // Database:
-//   - slots: генерирует и удаляет стухшие
-//   - user_codes, org_codes: удаляет стухшие
-//   - users, orgs: удаляет стухшие
-func InitCronScheduler(db repository.Repository) gocron.Scheduler {
+//   - slots: генерирует на горизонт организации и удаляет стухшие
+//   - user_codes, org_codes, password_resets, email_changes, oidc_states, mfa_used_tokens: удаляет стухшие
+//   - users, orgs: удаляет стухшие, очищает удаленные дольше retention
+//   - sessions: удаляет стухшие
+func InitCronScheduler(db repository.Repository, retention time.Duration) gocron.Scheduler {
 	s, err := gocron.NewScheduler()
 	if err != nil {
 		panic(err.Error())
@@ -25,11 +28,13 @@ func InitCronScheduler(db repository.Repository) gocron.Scheduler {
 			func(slots repository.SlotRepository) {
 				ctx := context.Background()
 				slots.DeleteExpiredSlots(ctx)
-				slots.GenerateSlots(ctx)
+				slots.GenerateSlots(ctx, &orgmodel.SlotsMeta{})
 			},
 			db,
 		),
 		gocron.WithName("Database > Slots > Delete expired and Generate"),
+		// горизонт заполняется сразу после деплоя, не дожидаясь расписания
+		gocron.WithStartAt(gocron.WithStartImmediately()),
 	)
 	s.NewJob(
 		gocron.DailyJob(
@@ -37,11 +42,15 @@ func InitCronScheduler(db repository.Repository) gocron.Scheduler {
 			gocron.NewAtTimes(gocron.NewAtTime(00, 00, 00)),
 		),
 		gocron.NewTask(
-			func(codes repository.CodeRepository) {
+			func(codes repository.CodeRepository, passwords repository.PasswordRepository, emails repository.EmailRepository, identities repository.IdentityRepository, mfa repository.MFARepository) {
 				ctx := context.Background()
 				codes.DeleteExpiredCodes(ctx)
+				passwords.DeleteExpiredPasswordResets(ctx)
+				emails.DeleteExpiredEmailChanges(ctx)
+				identities.DeleteExpiredOIDCStates(ctx)
+				mfa.DeleteExpiredMFATokens(ctx)
 			},
-			db,
+			db, db, db, db, db,
 		),
 		gocron.WithName("Database > Codes > Delete expired"),
 	)
@@ -51,15 +60,29 @@ func InitCronScheduler(db repository.Repository) gocron.Scheduler {
 			gocron.NewAtTimes(gocron.NewAtTime(00, 00, 00)),
 		),
 		gocron.NewTask(
-			func(users repository.UserRepository, orgs repository.OrgRepository) {
+			func(users repository.UserRepository, orgs repository.OrgRepository, privacy repository.PrivacyRepository) {
 				ctx := context.Background()
 				users.UserDeleteExpired(ctx)
 				orgs.OrgDeleteExpired(ctx)
-
+				privacy.AccountPurge(ctx, retention)
 			},
-			db, db,
+			db, db, db,
 		),
 		gocron.WithName("Database > Accounts > Delete expired"),
 	)
+	s.NewJob(
+		gocron.DailyJob(
+			1,
+			gocron.NewAtTimes(gocron.NewAtTime(00, 00, 00)),
+		),
+		gocron.NewTask(
+			func(sessions repository.SessionRepository) {
+				ctx := context.Background()
+				sessions.DeleteExpiredSessions(ctx)
+			},
+			db,
+		),
+		gocron.WithName("Database > Sessions > Delete expired"),
+	)
 	return s
 }
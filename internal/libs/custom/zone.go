@@ -0,0 +1,37 @@
+package custom
+
+import (
+	"sync"
+	"time"
+	// база часовых поясов внутри бинарника: в контейнере ее может не быть
+	_ "time/tzdata"
+)
+
+// Часовой пояс организации по умолчанию
+const DefaultTimeZone = "Europe/Moscow"
+
+var zones sync.Map
+
+// Часовой пояс по имени IANA. Пустое или неизвестное имя - пояс по умолчанию
+func Location(name string) *time.Location {
+	if name == "" {
+		name = DefaultTimeZone
+	}
+	if loc, ok := zones.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		if name == DefaultTimeZone {
+			return time.UTC
+		}
+		return Location(DefaultTimeZone)
+	}
+	zones.Store(name, loc)
+	return loc
+}
+
+// Настенное время t (дата и часы) в часовом поясе loc
+func InZone(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
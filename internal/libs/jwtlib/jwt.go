@@ -1,12 +1,13 @@
 package jwtlib
 
 import (
-	"crypto/rsa"
 	"errors"
+	"fmt"
 	"time"
 	"timeline/internal/config"
 	"timeline/internal/entity"
 	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/secret"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -15,12 +16,13 @@ var (
 	ErrInvalidTokenType = errors.New("invalid token type")
 )
 
-func NewTokenPair(secret *rsa.PrivateKey, cfg config.Token, metadata *entity.TokenMetadata) (*authdto.TokenPair, error) {
-	AccessToken, err := NewToken(secret, cfg, metadata, "access")
+// refreshJTI - идентификатор refresh токена, под которым он сохранен в сессиях
+func NewTokenPair(keys *secret.KeyRing, cfg config.Token, metadata *entity.TokenMetadata, refreshJTI string) (*authdto.TokenPair, error) {
+	AccessToken, err := NewToken(keys, cfg, metadata, "access")
 	if err != nil {
 		return nil, err
 	}
-	RefreshToken, err := NewToken(secret, cfg, metadata, "refresh")
+	RefreshToken, err := newToken(keys, cfg, metadata, "refresh", refreshJTI)
 	if err != nil {
 		return nil, err
 	}
@@ -30,25 +32,83 @@ func NewTokenPair(secret *rsa.PrivateKey, cfg config.Token, metadata *entity.Tok
 	}, nil
 }
 
-func NewToken(secret *rsa.PrivateKey, cfg config.Token, metadata *entity.TokenMetadata, tokenType string) (string, error) {
+func NewToken(keys *secret.KeyRing, cfg config.Token, metadata *entity.TokenMetadata, tokenType string) (string, error) {
+	return newToken(keys, cfg, metadata, tokenType, "")
+}
+
+// Токен ссылки входа. Одноразовость обеспечивается хранением jti
+func NewLinkToken(keys *secret.KeyRing, cfg config.Token, metadata *entity.TokenMetadata, jti string) (string, error) {
+	return newToken(keys, cfg, metadata, "link", jti)
+}
+
+// Токен второго шага входа. Одноразовость обеспечивается хранением jti при обмене
+func NewMFAToken(keys *secret.KeyRing, cfg config.Token, metadata *entity.TokenMetadata, jti string) (string, error) {
+	return newToken(keys, cfg, metadata, "mfa", jti)
+}
+
+func newToken(keys *secret.KeyRing, cfg config.Token, metadata *entity.TokenMetadata, tokenType, jti string) (string, error) {
 	var exp int64
 	switch tokenType {
 	case "access":
 		exp = time.Now().Add(cfg.AccessTTL).Unix()
 	case "refresh":
 		exp = time.Now().Add(cfg.RefreshTTL).Unix()
+	case "mfa":
+		exp = time.Now().Add(cfg.MFATTL).Unix()
+	case "link":
+		exp = time.Now().Add(cfg.LinkTTL).Unix()
 	default:
 		return "", ErrInvalidTokenType
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+	claims := jwt.MapClaims{
 		"id":     metadata.ID,
 		"is_org": metadata.IsOrg,
 		"type":   tokenType,
 		"exp":    exp,
-	})
+	}
+	if metadata.Role != "" {
+		claims["role"] = metadata.Role
+		claims["org_id"] = metadata.OrgID
+	}
+	if metadata.SessionID != "" {
+		claims["sid"] = metadata.SessionID
+	}
+	if jti != "" {
+		claims["jti"] = jti
+	}
+	kid, secret := keys.Active()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
 	tokenEncoded, err := token.SignedString(secret)
 	if err != nil {
 		return "", err
 	}
 	return tokenEncoded, nil
 }
+
+// Данные субъекта из проверенных claims. Поля id и is_org должны быть уже провалидированы
+func Metadata(claims jwt.MapClaims) *entity.TokenMetadata {
+	metadata := &entity.TokenMetadata{
+		ID:    uint64(claims["id"].(float64)),
+		IsOrg: claims["is_org"].(bool),
+	}
+	metadata.Role, _ = claims["role"].(string)
+	if orgID, ok := claims["org_id"].(float64); ok {
+		metadata.OrgID = uint64(orgID)
+	}
+	// токены, выданные до появления сессий, ее не содержат
+	metadata.SessionID, _ = claims["sid"].(string)
+	return metadata
+}
+
+// Разбор токена с проверкой подписи ключом из заголовка kid
+func ParseToken(keys *secret.KeyRing, tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Проверяем алгоритм подписи токена
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("неверный метод подписи: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return keys.PublicKey(kid)
+	})
+}
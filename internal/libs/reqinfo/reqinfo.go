@@ -0,0 +1,107 @@
+package reqinfo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Сведения о клиенте, отправившем запрос
+type Info struct {
+	IP        string
+	UserAgent string
+}
+
+type ctxKey struct{}
+
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// Если сведений нет, то вернет пустую структуру
+func From(ctx context.Context) Info {
+	info, _ := ctx.Value(ctxKey{}).(Info)
+	return info
+}
+
+// Сети прокси, которым доверяется X-Forwarded-For и X-Real-IP
+type TrustedProxies []*net.IPNet
+
+// Принимает CIDR или отдельные адреса
+func ParseTrustedProxies(list []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(list))
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", v)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+func (t TrustedProxies) trusted(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipNet := range t {
+		if ipNet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Заголовки прокси учитываются, только если соединение пришло от доверенного прокси
+func FromRequest(r *http.Request, proxies TrustedProxies) Info {
+	return Info{
+		IP:        proxies.clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// Клиент - самый правый недоверенный адрес цепочки X-Forwarded-For.
+// Левее него адреса дописаны самим клиентом и могут быть подделаны
+func (t TrustedProxies) clientIP(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !t.trusted(peer) {
+		return peer
+	}
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// мусор в цепочке: дальше доверять нечему
+			return peer
+		}
+		if !t.trusted(hop) || i == 0 {
+			return hop
+		}
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return peer
+}
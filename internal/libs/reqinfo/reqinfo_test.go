@@ -0,0 +1,65 @@
+package reqinfo
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "", "203.0.113.7"},
+		{"direct client spoofs forwarded", "203.0.113.7:5000", []string{"1.1.1.1"}, "", "203.0.113.7"},
+		{"direct client spoofs real ip", "203.0.113.7:5000", nil, "1.1.1.1", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:5000", []string{"203.0.113.7"}, "", "203.0.113.7"},
+		{"client prepends fake hop", "10.0.0.2:5000", []string{"1.1.1.1, 203.0.113.7"}, "", "203.0.113.7"},
+		{"chain of trusted proxies", "10.0.0.2:5000", []string{"1.1.1.1, 203.0.113.7, 192.168.1.1, 10.0.0.3"}, "", "203.0.113.7"},
+		{"several headers", "10.0.0.2:5000", []string{"1.1.1.1", "203.0.113.7, 10.0.0.3"}, "", "203.0.113.7"},
+		{"only trusted hops", "10.0.0.2:5000", []string{"10.0.0.4, 10.0.0.3"}, "", "10.0.0.4"},
+		{"garbage hop", "10.0.0.2:5000", []string{"203.0.113.7, unknown"}, "", "10.0.0.2"},
+		{"real ip from trusted proxy", "192.168.1.1:5000", nil, "203.0.113.7", "203.0.113.7"},
+		{"trusted proxy without headers", "[::1]:5000", nil, "", "::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := FromRequest(r, proxies).IP; got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, v := range []string{"not an ip", "10.0.0.0/33"} {
+		if _, err := ParseTrustedProxies([]string{v}); err == nil {
+			t.Fatalf("expected error for %q", v)
+		}
+	}
+	proxies, err := ParseTrustedProxies(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "127.0.0.1:5000"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1")
+	if got := FromRequest(r, proxies).IP; got != "127.0.0.1" {
+		t.Fatalf("without trusted proxies headers must be ignored, got %s", got)
+	}
+}
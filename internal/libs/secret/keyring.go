@@ -0,0 +1,175 @@
+package secret
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+	"timeline/internal/libs/envars"
+)
+
+var (
+	ErrKeyNotFound = errors.New("signing key not found")
+	ErrNoActiveKey = errors.New("no active signing key")
+)
+
+// Набор ключей подписи: активный ключ подписывает новые токены,
+// выведенные из оборота ключи принимаются для проверки в течение grace периода
+type KeyRing struct {
+	activeID string
+	active   *rsa.PrivateKey
+	public   map[string]*rsa.PublicKey
+	order    []string             // порядок публикации в JWKS
+	retired  map[string]time.Time // когда ключ выведен из оборота
+	grace    time.Duration
+	now      func() time.Time
+}
+
+// Если задан SECRET_KEYS_DIR - грузит набор ключей по манифесту из каталога,
+// иначе единственный ключ из SECRET_PATH
+func LoadKeyRing(grace time.Duration) (*KeyRing, error) {
+	if os.Getenv("SECRET_KEYS_DIR") == "" {
+		key, err := LoadPrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		return SingleKeyRing(key)
+	}
+	return loadDir(envars.GetPath("SECRET_KEYS_DIR"), grace)
+}
+
+func loadDir(dir string, grace time.Duration) (*KeyRing, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Active == "" {
+		return nil, ErrNoActiveKey
+	}
+	ring := &KeyRing{
+		public:  make(map[string]*rsa.PublicKey, len(manifest.Keys)),
+		retired: make(map[string]time.Time),
+		grace:   grace,
+		now:     time.Now,
+	}
+	for _, meta := range manifest.Keys {
+		if meta.RetiredAt != nil {
+			// ключ вышел из grace периода еще до запуска
+			if ring.expired(*meta.RetiredAt) {
+				continue
+			}
+			ring.retired[meta.ID] = *meta.RetiredAt
+		}
+		key, err := readPrivateKey(keyPath(dir, meta.ID))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", meta.ID, err)
+		}
+		ring.public[meta.ID] = &key.PublicKey
+		ring.order = append(ring.order, meta.ID)
+		if meta.ID == manifest.Active {
+			ring.activeID = meta.ID
+			ring.active = key
+		}
+	}
+	if ring.active == nil {
+		return nil, ErrNoActiveKey
+	}
+	return ring, nil
+}
+
+// Набор из одного ключа. kid - отпечаток ключа по RFC 7638
+func SingleKeyRing(key *rsa.PrivateKey) (*KeyRing, error) {
+	kid, err := Thumbprint(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyRing{
+		activeID: kid,
+		active:   key,
+		public:   map[string]*rsa.PublicKey{kid: &key.PublicKey},
+		order:    []string{kid},
+		now:      time.Now,
+	}, nil
+}
+
+// Ключ для подписи новых токенов
+func (k *KeyRing) Active() (string, *rsa.PrivateKey) {
+	return k.activeID, k.active
+}
+
+// Ключ для проверки подписи. Пустой kid - токены, выпущенные до появления kid.
+// Выведенный из оборота ключ перестает приниматься по окончании grace периода
+func (k *KeyRing) PublicKey(kid string) (*rsa.PublicKey, error) {
+	if kid == "" {
+		return &k.active.PublicKey, nil
+	}
+	key, ok := k.public[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	if retiredAt, ok := k.retired[kid]; ok && k.expired(retiredAt) {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (k *KeyRing) expired(retiredAt time.Time) bool {
+	return k.now().UTC().Sub(retiredAt) > k.grace
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Публичные ключи в формате JWKS (RFC 7517)
+func (k *KeyRing) JWKS() *JWKSet {
+	set := &JWKSet{Keys: make([]JWK, 0, len(k.order))}
+	for _, kid := range k.order {
+		if retiredAt, ok := k.retired[kid]; ok && k.expired(retiredAt) {
+			continue
+		}
+		key := k.public[kid]
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	return set
+}
+
+// Отпечаток публичного ключа по RFC 7638
+func Thumbprint(key *rsa.PublicKey) (string, error) {
+	// члены упорядочены лексикографически, как требует RFC
+	raw, err := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,78 @@
+package secret
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetiredKeyGrace(t *testing.T) {
+	dir := t.TempDir()
+	oldKID, err := GenerateKey(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKID, err := GenerateKey(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Promote(dir, newKID); err != nil {
+		t.Fatal(err)
+	}
+	grace := time.Hour
+	ring, err := loadDir(dir, grace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid, _ := ring.Active(); kid != newKID {
+		t.Fatalf("expected active %s, got %s", newKID, kid)
+	}
+	retiredAt := ring.retired[oldKID]
+	tests := []struct {
+		name    string
+		at      time.Time
+		kid     string
+		wantErr error
+		jwks    int
+	}{
+		{"retired key in grace", retiredAt.Add(grace - time.Second), oldKID, nil, 2},
+		{"retired key at grace end", retiredAt.Add(grace), oldKID, nil, 2},
+		{"retired key after grace", retiredAt.Add(grace + time.Second), oldKID, ErrKeyNotFound, 1},
+		{"active key after grace", retiredAt.Add(grace + time.Second), newKID, nil, 1},
+		{"unknown key", retiredAt, "unknown", ErrKeyNotFound, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring.now = func() time.Time { return tt.at }
+			if _, err := ring.PublicKey(tt.kid); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if got := len(ring.JWKS().Keys); got != tt.jwks {
+				t.Fatalf("expected %d keys in jwks, got %d", tt.jwks, got)
+			}
+		})
+	}
+}
+
+func TestLoadSkipsExpiredKeys(t *testing.T) {
+	dir := t.TempDir()
+	oldKID, err := GenerateKey(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKID, err := GenerateKey(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Promote(dir, newKID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	ring, err := loadDir(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ring.public[oldKID]; ok {
+		t.Fatal("key retired before start must not be loaded")
+	}
+}
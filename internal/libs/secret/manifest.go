@@ -0,0 +1,153 @@
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	manifestName = "keys.json"
+	keyBits      = 2048
+)
+
+// Каталог ключей:
+//   - keys.json - манифест: активный ключ и даты вывода из оборота
+//   - <kid>.pem - закрытые ключи в PKCS8
+type Manifest struct {
+	Active string    `json:"active"`
+	Keys   []KeyMeta `json:"keys"`
+}
+
+type KeyMeta struct {
+	ID        string     `json:"kid"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+func ReadManifest(dir string) (*Manifest, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Manifest{}, nil
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest Manifest
+	if err = json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// Запись через временный файл, чтобы сервис не прочитал недописанный манифест
+func WriteManifest(dir string, manifest *Manifest) error {
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, manifestName+".tmp")
+	if err = os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(dir, manifestName))
+}
+
+// Создает новый ключ. Он сразу публикуется в JWKS, но подписывать начнет только после Promote
+func GenerateKey(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return "", err
+	}
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	kid, err := Thumbprint(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	block := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err = os.WriteFile(keyPath(dir, kid), block, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write key: %w", err)
+	}
+	manifest.Keys = append(manifest.Keys, KeyMeta{ID: kid, CreatedAt: time.Now().UTC()})
+	// первый ключ в каталоге сразу становится активным
+	if manifest.Active == "" {
+		manifest.Active = kid
+	}
+	return kid, WriteManifest(dir, manifest)
+}
+
+// Делает ключ активным. Предыдущий активный ключ выводится из оборота
+// и принимается для проверки еще grace период
+func Promote(dir, kid string) error {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+	idx := manifest.index(kid)
+	if idx < 0 {
+		return ErrKeyNotFound
+	}
+	if manifest.Active == kid {
+		return nil
+	}
+	now := time.Now().UTC()
+	if prev := manifest.index(manifest.Active); prev >= 0 {
+		manifest.Keys[prev].RetiredAt = &now
+	}
+	manifest.Keys[idx].RetiredAt = nil
+	manifest.Active = kid
+	return WriteManifest(dir, manifest)
+}
+
+// Удаляет ключи, выведенные из оборота раньше чем grace назад. Возвращает удаленные kid
+func Prune(dir string, grace time.Duration) ([]string, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	kept := manifest.Keys[:0]
+	removed := make([]string, 0)
+	for _, meta := range manifest.Keys {
+		if meta.RetiredAt != nil && now.Sub(*meta.RetiredAt) > grace {
+			if err = os.Remove(keyPath(dir, meta.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			removed = append(removed, meta.ID)
+			continue
+		}
+		kept = append(kept, meta)
+	}
+	manifest.Keys = kept
+	return removed, WriteManifest(dir, manifest)
+}
+
+func (m *Manifest) index(kid string) int {
+	for i, meta := range m.Keys {
+		if meta.ID == kid {
+			return i
+		}
+	}
+	return -1
+}
+
+func keyPath(dir, kid string) string {
+	return filepath.Join(dir, kid+".pem")
+}
@@ -19,7 +19,12 @@ func LoadPrivateKey() (*rsa.PrivateKey, error) {
 	if _, err := os.Stat(pathToSecret); os.IsNotExist(err) {
 		return nil, fmt.Errorf("file with secret does not exist: %w", err)
 	}
-	key, err := os.ReadFile(pathToSecret)
+	return readPrivateKey(pathToSecret)
+}
+
+// Читает закрытый RSA ключ в PKCS8
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	key, err := os.ReadFile(path)
 	if err != nil {
 		fmt.Println(key)
 		return nil, fmt.Errorf("failed to read file: %w", err)
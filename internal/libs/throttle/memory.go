@@ -0,0 +1,73 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	Entry
+	expiresAt time.Time
+}
+
+// Хранилище в памяти процесса. Подходит для одного экземпляра сервиса
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return nil, nil
+	}
+	entry := e.Entry
+	return &entry, nil
+}
+
+func (m *MemoryStore) Incr(ctx context.Context, key string, at time.Time, ttl time.Duration) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(ttl)
+	e, ok := m.entries[key]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		e = &memoryEntry{}
+		m.entries[key] = e
+	}
+	e.Failures++
+	e.LastFailure = at
+	e.expiresAt = time.Now().Add(ttl)
+	entry := e.Entry
+	return &entry, nil
+}
+
+func (m *MemoryStore) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// Удаляет стухшие счетчики не чаще раза в ttl, чтобы память не росла
+func (m *MemoryStore) sweep(ttl time.Duration) {
+	now := time.Now()
+	if now.Sub(m.lastSweep) < ttl {
+		return
+	}
+	for key, e := range m.entries {
+		if !now.Before(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,112 @@
+// Ограничение частоты неудачных попыток (вход, ввод кода и т.п.)
+// с экспоненциальной задержкой и временной блокировкой
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Счетчик неудач по ключу
+type Entry struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// Хранилище счетчиков. Реализация должна быть потокобезопасной,
+// Incr - атомарным, чтобы хранилище можно было вынести, например, в Redis
+type Store interface {
+	// Текущий счетчик. Если неудач не было - nil
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Учитывает неудачу в момент at. Счетчик живет ttl с последней неудачи
+	Incr(ctx context.Context, key string, at time.Time, ttl time.Duration) (*Entry, error)
+	Reset(ctx context.Context, key string) error
+}
+
+type Policy struct {
+	MaxFailures int           // неудач до блокировки
+	Lockout     time.Duration // время блокировки
+	BaseDelay   time.Duration // задержка после первой неудачи, дальше удваивается
+	MaxDelay    time.Duration // предел задержки
+}
+
+// Попытка отклонена. RetryAfter - через сколько можно повторить
+type Error struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *Error) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many attempts, locked for %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+type Limiter struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+func New(store Store, policy Policy) *Limiter {
+	return &Limiter{
+		store:  store,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Можно ли делать попытку по ключу прямо сейчас
+func (l *Limiter) Allow(ctx context.Context, key string) error {
+	entry, err := l.store.Get(ctx, key)
+	if err != nil || entry == nil {
+		// недоступность хранилища не должна блокировать вход
+		return nil
+	}
+	now := l.now()
+	if entry.Failures >= l.policy.MaxFailures {
+		if until := entry.LastFailure.Add(l.policy.Lockout); now.Before(until) {
+			return &Error{RetryAfter: until.Sub(now), Locked: true}
+		}
+		return nil
+	}
+	if until := entry.LastFailure.Add(l.delay(entry.Failures)); now.Before(until) {
+		return &Error{RetryAfter: until.Sub(now)}
+	}
+	return nil
+}
+
+// Учитывает неудачу. locked - именно эта неудача привела к блокировке
+func (l *Limiter) Fail(ctx context.Context, key string) (bool, error) {
+	entry, err := l.store.Incr(ctx, key, l.now(), l.policy.Lockout+l.policy.MaxDelay)
+	if err != nil {
+		return false, err
+	}
+	return entry.Failures == l.policy.MaxFailures, nil
+}
+
+// Успешная попытка сбрасывает счетчик
+func (l *Limiter) Success(ctx context.Context, key string) error {
+	return l.store.Reset(ctx, key)
+}
+
+// Задержка после failures неудач: BaseDelay * 2^(failures-1), но не больше MaxDelay
+func (l *Limiter) delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	delay := l.policy.BaseDelay
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= l.policy.MaxDelay {
+			return l.policy.MaxDelay
+		}
+	}
+	return min(delay, l.policy.MaxDelay)
+}
+
+func (l *Limiter) Lockout() time.Duration {
+	return l.policy.Lockout
+}
@@ -0,0 +1,98 @@
+// Одноразовые пароли по времени (RFC 6238) поверх HOTP (RFC 4226).
+// Текущее время передается явно, чтобы проверку можно было вести по любым часам
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	Period     = 30 * time.Second // длительность шага
+	Digits     = 6                // длина кода
+	secretSize = 20               // 160 бит, как рекомендует RFC 4226
+)
+
+var (
+	ErrInvalidSecret = errors.New("invalid totp secret")
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Новый секрет в base32 без паддинга
+func GenerateSecret() (string, error) {
+	buf := make([]byte, secretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(buf), nil
+}
+
+// otpauth:// URI для QR-кода в приложении-аутентификаторе
+func URI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period.Seconds())))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Номер шага для момента времени
+func Step(at time.Time) int64 {
+	return at.Unix() / int64(Period.Seconds())
+}
+
+// Код для момента времени
+func Code(secret string, at time.Time) (string, error) {
+	return codeAt(secret, Step(at))
+}
+
+// Проверяет код с допуском skew шагов в обе стороны.
+// Возвращает шаг, которому соответствует код, чтобы не принимать его повторно
+func Validate(secret, code string, at time.Time, skew int) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+	current := Step(at)
+	for i := -skew; i <= skew; i++ {
+		expected, err := codeAt(secret, current+int64(i))
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return current + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+func codeAt(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(key) == 0 {
+		return "", ErrInvalidSecret
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	// динамическое усечение, RFC 4226 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod), nil
+}
@@ -0,0 +1,90 @@
+package totp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Секрет "12345678901234567890" из приложения B RFC 6238 в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Векторы SHA1 из RFC 6238, усеченные до Digits знаков
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		step int64
+		want string
+	}{
+		{59, 0x1, "287082"},
+		{1111111109, 0x23523EC, "081804"},
+		{1111111111, 0x23523ED, "050471"},
+		{1234567890, 0x273EF07, "005924"},
+		{2000000000, 0x3F940AA, "279037"},
+		{20000000000, 0x27BC86AA, "353130"},
+	}
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0).UTC()
+		if got := Step(at); got != tt.step {
+			t.Fatalf("Step(%d) = %x, want %x", tt.unix, got, tt.step)
+		}
+		got, err := Code(rfcSecret, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Fatalf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", "050471", 1, 0x23523ED, true},
+		{"previous step in skew", "081804", 1, 0x23523EC, true},
+		{"previous step without skew", "081804", 0, 0, false},
+		{"far step", "005924", 1, 0, false},
+		{"wrong code", "000000", 1, 0, false},
+		{"short code", "50471", 1, 0, false},
+		{"long code", "0504710", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, at, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Fatalf("Validate(%s) = %x, %v, want %x, %v", tt.code, step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", time.Now()); !errors.Is(err, ErrInvalidSecret) {
+		t.Fatalf("expected %v, got %v", ErrInvalidSecret, err)
+	}
+	if _, ok := Validate("", "123456", time.Now(), 1); ok {
+		t.Fatal("empty secret must not validate")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step, ok := Validate(secret, code, now, 0); !ok || step != Step(now) {
+		t.Fatalf("generated secret does not validate own code")
+	}
+}
@@ -1,14 +1,18 @@
 package verification
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"math/big"
 	"strconv"
 )
 
 func GenerateCode() (string, error) {
-	min := 1000
-	max := 9999
+	min := 100000
+	max := 999999
 	rangeVal := max - min + 1
 
 	n, err := rand.Int(rand.Reader, big.NewInt(int64(rangeVal)))
@@ -16,7 +20,30 @@ func GenerateCode() (string, error) {
 		return "", err
 	}
 
-	// Приводим результат к 4-значному числу и преобразуем его в строку
+	// Приводим результат к 6-значному числу и преобразуем его в строку
 	code := int(n.Int64()) + min
 	return strconv.Itoa(code), nil
 }
+
+// Случайная строка для одноразовых ссылок и идентификаторов токенов
+func GenerateToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Хеш токена для хранения в БД. Сами токены в БД не сохраняются
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Хеш кода из письма для хранения в БД. Кодов всего миллион,
+// поэтому без секрета сервера хеш обращается перебором
+func HashCode(secret []byte, code string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
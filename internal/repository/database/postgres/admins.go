@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timeline/internal/repository/models"
+)
+
+var (
+	ErrAdminExists   = errors.New("admin already exists")
+	ErrAdminNotFound = errors.New("admin not found")
+)
+
+func (p *PostgresRepo) AdminSave(ctx context.Context, email, passwdHash string) (int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO admins (email, passwd_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (email) DO NOTHING
+		RETURNING admin_id;
+	`
+	var adminID int
+	if err = tx.QueryRowContext(ctx, query, email, passwdHash).Scan(&adminID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrAdminExists
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to save admin: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return adminID, nil
+}
+
+func (p *PostgresRepo) AdminByEmail(ctx context.Context, email string) (*models.Admin, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT admin_id, email, passwd_hash, created_at
+		FROM admins
+		WHERE LOWER(email) = LOWER($1);
+	`
+	var admin models.Admin
+	if err = tx.GetContext(ctx, &admin, query, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, fmt.Errorf("failed to get admin: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &admin, nil
+}
+
+// Поиск пользователей или организаций по почте и имени. Возвращает страницу и число найденных
+func (p *PostgresRepo) AdminAccounts(ctx context.Context, isOrg bool, params *models.AccountSearch) ([]*models.AccountBrief, int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var count, query string
+	switch isOrg {
+	case false:
+		count = `
+		SELECT COUNT(*) FROM users
+		WHERE ($1 = '' OR email ILIKE '%' || $1 || '%' OR (first_name || ' ' || last_name) ILIKE '%' || $1 || '%');
+		`
+		query = `
+		SELECT
+			user_id AS id,
+			email,
+			first_name || ' ' || last_name AS name,
+			city,
+			COALESCE(verified, false) AS verified,
+			COALESCE(is_delete, false) AS is_delete,
+			is_blocked,
+			blocked_reason,
+			created_at
+		FROM users
+		WHERE ($1 = '' OR email ILIKE '%' || $1 || '%' OR (first_name || ' ' || last_name) ILIKE '%' || $1 || '%')
+		ORDER BY user_id
+		LIMIT $2
+		OFFSET $3;
+		`
+	case true:
+		count = `
+		SELECT COUNT(*) FROM orgs
+		WHERE ($1 = '' OR email ILIKE '%' || $1 || '%' OR name ILIKE '%' || $1 || '%');
+		`
+		query = `
+		SELECT
+			org_id AS id,
+			email,
+			name,
+			city,
+			COALESCE(verified, false) AS verified,
+			COALESCE(is_delete, false) AS is_delete,
+			is_blocked,
+			blocked_reason,
+			created_at
+		FROM orgs
+		WHERE ($1 = '' OR email ILIKE '%' || $1 || '%' OR name ILIKE '%' || $1 || '%')
+		ORDER BY org_id
+		LIMIT $2
+		OFFSET $3;
+		`
+	}
+	var found int
+	if err = tx.QueryRowContext(ctx, count, params.Query).Scan(&found); err != nil {
+		return nil, 0, fmt.Errorf("failed to count accounts: %w", err)
+	}
+	accounts := make([]*models.AccountBrief, 0, params.Limit)
+	if err = tx.SelectContext(ctx, &accounts, query, params.Query, params.Limit, params.Offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to search accounts: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return accounts, found, nil
+}
+
+// Блокирует или разблокирует аккаунт. Возвращает состояние до изменения.
+// При блокировке организации отзываются ее ключи API и сессии ее сотрудников
+func (p *PostgresRepo) AccountBlock(ctx context.Context, id int, isOrg bool, blocked bool, reason string) (*models.AccountBrief, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var query, update string
+	var notFound error
+	switch isOrg {
+	case false:
+		query = `
+		SELECT
+			user_id AS id,
+			email,
+			first_name || ' ' || last_name AS name,
+			city,
+			COALESCE(verified, false) AS verified,
+			COALESCE(is_delete, false) AS is_delete,
+			is_blocked,
+			blocked_reason,
+			created_at
+		FROM users
+		WHERE user_id = $1
+		FOR UPDATE;
+		`
+		update = `
+		UPDATE users
+		SET
+			is_blocked = $2,
+			blocked_reason = $3
+		WHERE user_id = $1;
+		`
+		notFound = ErrUserNotFound
+	case true:
+		query = `
+		SELECT
+			org_id AS id,
+			email,
+			name,
+			city,
+			COALESCE(verified, false) AS verified,
+			COALESCE(is_delete, false) AS is_delete,
+			is_blocked,
+			blocked_reason,
+			created_at
+		FROM orgs
+		WHERE org_id = $1
+		FOR UPDATE;
+		`
+		update = `
+		UPDATE orgs
+		SET
+			is_blocked = $2,
+			blocked_reason = $3
+		WHERE org_id = $1;
+		`
+		notFound = ErrOrgNotFound
+	}
+	var before models.AccountBrief
+	if err = tx.GetContext(ctx, &before, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFound
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, update, id, blocked, reason); err != nil {
+		return nil, fmt.Errorf("failed to block account: %w", err)
+	}
+	if blocked && isOrg {
+		queries := []string{
+			`UPDATE api_keys SET revoked = true WHERE org_id = $1;`,
+			`UPDATE sessions SET revoked = true
+			WHERE role = 'worker'
+			AND subject_id IN (SELECT worker_id FROM worker_accounts WHERE org_id = $1);`,
+		}
+		for _, q := range queries {
+			if _, err = tx.ExecContext(ctx, q, id); err != nil {
+				return nil, fmt.Errorf("failed to revoke org access: %w", err)
+			}
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &before, nil
+}
+
+// Скрывает отзыв из выдачи или возвращает его
+func (p *PostgresRepo) FeedbackHide(ctx context.Context, recordID int, hidden bool) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE feedbacks
+		SET
+			is_hidden = $2
+		WHERE record_id = $1;
+	`
+	res, err := tx.ExecContext(ctx, query, recordID, hidden)
+	if err != nil {
+		return fmt.Errorf("failed to hide feedback: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrFeedbackNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timeline/internal/repository/models"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+func (p *PostgresRepo) APIKeySave(ctx context.Context, key *models.APIKey) (int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO api_keys (org_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING key_id;
+	`
+	var keyID int
+	if err = tx.QueryRowContext(ctx, query,
+		key.OrgID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		key.ExpiresAt,
+	).Scan(&keyID); err != nil {
+		return 0, fmt.Errorf("failed to save api key: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return keyID, nil
+}
+
+// Ключи организации, включая отозванные и истекшие
+func (p *PostgresRepo) APIKeyList(ctx context.Context, orgID int) ([]*models.APIKey, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT key_id, org_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, last_used_ip, revoked
+		FROM api_keys
+		WHERE org_id = $1
+		ORDER BY key_id;
+	`
+	keys := make([]*models.APIKey, 0, 1)
+	if err = tx.SelectContext(ctx, &keys, query, orgID); err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return keys, nil
+}
+
+func (p *PostgresRepo) APIKeyRevoke(ctx context.Context, orgID, keyID int) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE api_keys
+		SET revoked = true
+		WHERE org_id = $1
+		AND key_id = $2;
+	`
+	res, err := tx.ExecContext(ctx, query, orgID, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrAPIKeyNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Действующий ключ по хешу. Отмечает использование не чаще раза в минуту,
+// чтобы не писать в БД на каждый запрос
+func (p *PostgresRepo) APIKeyUse(ctx context.Context, keyHash, ip string) (*models.APIKey, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT k.key_id, k.org_id, k.name, k.prefix, k.key_hash, k.scopes, k.created_at, k.expires_at, k.last_used_at, k.last_used_ip, k.revoked
+		FROM api_keys k
+		JOIN orgs o ON o.org_id = k.org_id
+		WHERE k.key_hash = $1
+		AND k.revoked = false
+		AND (k.expires_at IS NULL OR k.expires_at > CURRENT_TIMESTAMP)
+		AND o.is_delete = false
+		AND o.is_blocked = false;
+	`
+	var key models.APIKey
+	if err = tx.GetContext(ctx, &key, query, keyHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	query = `
+		UPDATE api_keys
+		SET
+			last_used_at = CURRENT_TIMESTAMP,
+			last_used_ip = $2
+		WHERE key_id = $1
+		AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute' OR last_used_ip <> $2);
+	`
+	if _, err = tx.ExecContext(ctx, query, key.KeyID, ip); err != nil {
+		return nil, fmt.Errorf("failed to track api key usage: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &key, nil
+}
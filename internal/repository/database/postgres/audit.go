@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"timeline/internal/repository/models"
+)
+
+func (p *PostgresRepo) AuditSave(ctx context.Context, entry *models.AuditEntry) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO audit_log (actor_type, actor_id, org_id, action, entity, entity_id, before, after, ip, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+	`
+	if _, err = tx.ExecContext(ctx, query,
+		entry.ActorType,
+		entry.ActorID,
+		entry.OrgID,
+		entry.Action,
+		entry.Entity,
+		entry.EntityID,
+		entry.Before,
+		entry.After,
+		entry.IP,
+		entry.UserAgent,
+	); err != nil {
+		return fmt.Errorf("failed to save audit entry: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Журнал по фильтрам, новые записи первыми. Возвращает страницу и число найденных
+func (p *PostgresRepo) AuditList(ctx context.Context, params *models.AuditParams) ([]*models.AuditEntry, int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	filter := `
+		WHERE ($1 <= 0 OR org_id = $1)
+		AND ($2 = '' OR entity = $2)
+		AND ($3 <= 0 OR entity_id = $3)
+		AND ($4::timestamp IS NULL OR created_at >= $4)
+		AND ($5::timestamp IS NULL OR created_at < $5)
+	`
+	args := []any{
+		params.OrgID,
+		params.Entity,
+		params.EntityID,
+		nullTime(params.From),
+		nullTime(params.To),
+	}
+	var found int
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+filter, args...).Scan(&found); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+	query := `
+		SELECT audit_id, actor_type, actor_id, org_id, action, entity, entity_id, before, after, ip, user_agent, created_at
+		FROM audit_log
+	` + filter + `
+		ORDER BY created_at DESC, audit_id DESC
+		LIMIT $6
+		OFFSET $7;
+	`
+	entries := make([]*models.AuditEntry, 0, params.Limit)
+	if err = tx.SelectContext(ctx, &entries, query, append(args, params.Limit, params.Offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return entries, found, nil
+}
+
+// Нулевое время передается в запрос как NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,483 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"timeline/internal/libs/custom"
+	"timeline/internal/repository/models/orgmodel"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Шаг, с которым предлагается начало сеанса
+const availabilityStep = 15 * time.Minute
+
+var ErrSessionUnavailable = errors.New("session time is not available")
+
+// Свободное время сотрудника на день под услугу. Считается на лету из рабочих
+// интервалов сотрудника за вычетом уже сделанных записей вместе с их буферами
+// и времени, когда заняты или недоступны нужные услуге ресурсы
+func (p *PostgresRepo) Availability(ctx context.Context, params *orgmodel.AvailabilityParams) (*orgmodel.Availability, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	resp, err := availability(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return resp, nil
+}
+
+func availability(ctx context.Context, tx *sqlx.Tx, params *orgmodel.AvailabilityParams) (*orgmodel.Availability, error) {
+	if params.WorkerID <= 0 {
+		return resourceAvailability(ctx, tx, params)
+	}
+	service, err := workerService(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	resp := &orgmodel.Availability{
+		TimeZone:     service.TimeZone,
+		WorkerID:     params.WorkerID,
+		ServiceID:    params.ServiceID,
+		Date:         params.Date,
+		Duration:     service.Duration,
+		BufferBefore: service.BufferBefore,
+		BufferAfter:  service.BufferAfter,
+		Sessions:     make([]*orgmodel.Session, 0),
+	}
+	if service.Duration <= 0 {
+		return resp, nil
+	}
+	meta := &orgmodel.SlotsMeta{OrgID: params.OrgID, WorkerID: params.WorkerID}
+	day := sql.NullTime{Time: params.Date, Valid: true}
+	days, err := workDays(ctx, tx, meta, day)
+	if err != nil {
+		return nil, err
+	}
+	booked, err := bookings(ctx, tx, meta, day)
+	if err != nil {
+		return nil, err
+	}
+	// Ресурсы, без которых услугу не оказать, должны быть свободны вместе с сотрудником
+	resources, err := resourceDays(ctx, tx, params, custom.Location(service.TimeZone))
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Duration(service.Duration) * time.Minute
+	before := time.Duration(service.BufferBefore) * time.Minute
+	after := time.Duration(service.BufferAfter) * time.Minute
+	now := time.Now()
+	// У сотрудника может быть несколько строк расписания на день: сеансы не повторяются
+	seen := make(map[int64]bool)
+	// Групповые сеансы с местами предлагаются раньше новых: к ним можно присоединиться
+	joinable, err := groupSessions(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range joinable {
+		seen[v.Begin.Unix()] = true
+		resp.Sessions = append(resp.Sessions, v)
+	}
+	for _, d := range days {
+		for _, v := range d.Intervals {
+			for begin := v.Begin; !begin.Add(duration).After(v.End); begin = begin.Add(availabilityStep) {
+				end := begin.Add(duration)
+				if !begin.After(now) || seen[begin.Unix()] {
+					continue
+				}
+				if collides(booked[params.WorkerID], begin.Add(-before), end.Add(after)) {
+					continue
+				}
+				if !resourcesFree(resources, begin, end, before, after) {
+					continue
+				}
+				seen[begin.Unix()] = true
+				resp.Sessions = append(resp.Sessions, &orgmodel.Session{Begin: begin, End: end, SeatsLeft: service.Capacity})
+			}
+		}
+	}
+	sort.Slice(resp.Sessions, func(i, j int) bool {
+		return resp.Sessions[i].Begin.Before(resp.Sessions[j].Begin)
+	})
+	return resp, nil
+}
+
+// Свободное время услуги без сотрудника: часы работы организации в этот день,
+// в которые свободны и доступны все нужные услуге ресурсы
+func resourceAvailability(ctx context.Context, tx *sqlx.Tx, params *orgmodel.AvailabilityParams) (*orgmodel.Availability, error) {
+	service, err := resourceService(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	resp := &orgmodel.Availability{
+		TimeZone:     service.TimeZone,
+		ServiceID:    params.ServiceID,
+		Date:         params.Date,
+		Duration:     service.Duration,
+		BufferBefore: service.BufferBefore,
+		BufferAfter:  service.BufferAfter,
+		Sessions:     make([]*orgmodel.Session, 0),
+	}
+	loc := custom.Location(service.TimeZone)
+	resources, err := resourceDays(ctx, tx, params, loc)
+	if err != nil {
+		return nil, err
+	}
+	// Без ресурсов бронировать нечего
+	if service.Duration <= 0 || len(resources) == 0 {
+		return resp, nil
+	}
+	hours, err := orgHours(ctx, tx, params.OrgID, params.Date, loc)
+	if err != nil {
+		return nil, err
+	}
+	joinable, err := groupSessions(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	resp.Sessions = resourceSessions(&service.Service, hours, resources, joinable, time.Now())
+	return resp, nil
+}
+
+// Сеансы услуги без сотрудника: начатые групповые сеансы с местами и новые сеансы
+// в часах организации, на которые свободны все ресурсы. Только начинающиеся после now
+func resourceSessions(service *orgmodel.Service, hours []interval, resources []*resourceDay, joinable []*orgmodel.Session, now time.Time) []*orgmodel.Session {
+	duration := time.Duration(service.Duration) * time.Minute
+	before := time.Duration(service.BufferBefore) * time.Minute
+	after := time.Duration(service.BufferAfter) * time.Minute
+	sessions := make([]*orgmodel.Session, 0, len(joinable))
+	seen := make(map[int64]bool)
+	for _, v := range joinable {
+		seen[v.Begin.Unix()] = true
+		sessions = append(sessions, v)
+	}
+	for _, v := range hours {
+		for begin := v.Begin; !begin.Add(duration).After(v.End); begin = begin.Add(availabilityStep) {
+			end := begin.Add(duration)
+			if !begin.After(now) || seen[begin.Unix()] {
+				continue
+			}
+			if !resourcesFree(resources, begin, end, before, after) {
+				continue
+			}
+			seen[begin.Unix()] = true
+			sessions = append(sessions, &orgmodel.Session{Begin: begin, End: end, SeatsLeft: service.Capacity})
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Begin.Before(sessions[j].Begin)
+	})
+	return sessions
+}
+
+// Часы работы организации в день за вычетом перерывов, с учетом особых дней
+func orgHours(ctx context.Context, tx *sqlx.Tx, orgID int, day time.Time, loc *time.Location) ([]interval, error) {
+	query := `SELECT open, close FROM org_day_hours($1, $2::date);`
+	rows := make([]*struct {
+		Open  time.Time `db:"open"`
+		Close time.Time `db:"close"`
+	}, 0, 1)
+	if err := tx.SelectContext(ctx, &rows, query, orgID, day); err != nil {
+		return nil, fmt.Errorf("failed to get org hours: %w", err)
+	}
+	hours := make([]interval, 0, len(rows))
+	for _, v := range rows {
+		if open, closed := onDay(day, v.Open, loc), onDay(day, v.Close, loc); open.Before(closed) {
+			hours = append(hours, interval{Begin: open, End: closed})
+		}
+	}
+	query = `SELECT break_start, break_end FROM org_day_breaks($1, $2::date);`
+	breaks := make([]*struct {
+		Start time.Time `db:"break_start"`
+		End   time.Time `db:"break_end"`
+	}, 0, 1)
+	if err := tx.SelectContext(ctx, &breaks, query, orgID, day); err != nil {
+		return nil, fmt.Errorf("failed to get org breaks: %w", err)
+	}
+	for _, b := range breaks {
+		hours = subtract(hours, onDay(day, b.Start, loc), onDay(day, b.End, loc))
+	}
+	return hours, nil
+}
+
+// Начатые групповые сеансы сотрудника на день по услуге, в которых остались места.
+// Без сотрудника - сеансы организации по услуге без сотрудника
+func groupSessions(ctx context.Context, tx *sqlx.Tx, params *orgmodel.AvailabilityParams) ([]*orgmodel.Session, error) {
+	query := `
+		SELECT s.slot_id, s.session_begin, s.session_end,
+			slot_capacity(s.slot_id, $3) - s.seats_taken AS seats_left
+		FROM slots s
+		WHERE (($1 > 0 AND s.worker_id = $1) OR ($1 <= 0 AND s.worker_id IS NULL AND s.org_id = $4))
+		AND s.date = $2::date
+		AND s.session_begin > now()
+		AND s.seats_taken > 0
+		AND COALESCE(s.busy, false) = false
+		AND slot_capacity(s.slot_id, $3) > s.seats_taken
+		AND NOT EXISTS (
+			SELECT 1 FROM records r
+			WHERE r.slot_id = s.slot_id
+			AND r.service_id <> $3
+		);
+	`
+	rows := make([]*struct {
+		SlotID    int       `db:"slot_id"`
+		Begin     time.Time `db:"session_begin"`
+		End       time.Time `db:"session_end"`
+		SeatsLeft int       `db:"seats_left"`
+	}, 0, 1)
+	if err := tx.SelectContext(ctx, &rows, query, params.WorkerID, params.Date, params.ServiceID, params.OrgID); err != nil {
+		return nil, fmt.Errorf("failed to get group sessions: %w", err)
+	}
+	sessions := make([]*orgmodel.Session, 0, len(rows))
+	for _, v := range rows {
+		sessions = append(sessions, &orgmodel.Session{SlotID: v.SlotID, Begin: v.Begin, End: v.End, SeatsLeft: v.SeatsLeft})
+	}
+	return sessions, nil
+}
+
+// Услуга сотрудника в поясе организации
+type zonedService struct {
+	orgmodel.Service
+	TimeZone string `db:"timezone"`
+}
+
+// Услуга, которую оказывает сотрудник. Без своей длительности берется длительность сеанса сотрудника
+func workerService(ctx context.Context, tx *sqlx.Tx, params *orgmodel.AvailabilityParams) (*zonedService, error) {
+	query := `
+		SELECT s.service_id, s.org_id,
+			COALESCE(s.duration, w.session_duration, 0) AS duration,
+			s.buffer_before, s.buffer_after, s.capacity, o.timezone
+		FROM services s
+		JOIN worker_services ws ON ws.service_id = s.service_id
+		JOIN workers w ON w.worker_id = ws.worker_id
+		JOIN orgs o ON o.org_id = s.org_id
+		WHERE s.is_delete = false
+		AND s.resource_only = false
+		AND ws.is_delete = false
+		AND w.is_delete = false
+		AND s.service_id = $1
+		AND s.org_id = $2
+		AND w.worker_id = $3;
+	`
+	service := &zonedService{}
+	if err := tx.GetContext(ctx, service, query, params.ServiceID, params.OrgID, params.WorkerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrServiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	return service, nil
+}
+
+// Услуга организации без сотрудника
+func resourceService(ctx context.Context, tx *sqlx.Tx, params *orgmodel.AvailabilityParams) (*zonedService, error) {
+	query := `
+		SELECT s.service_id, s.org_id, COALESCE(s.duration, 0) AS duration,
+			s.buffer_before, s.buffer_after, s.capacity, s.resource_only, o.timezone
+		FROM services s
+		JOIN orgs o ON o.org_id = s.org_id
+		WHERE s.is_delete = false
+		AND o.is_delete = false
+		AND s.resource_only = true
+		AND s.service_id = $1
+		AND s.org_id = $2;
+	`
+	service := &zonedService{}
+	if err := tx.GetContext(ctx, service, query, params.ServiceID, params.OrgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrServiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	return service, nil
+}
+
+// Задевает ли [begin, end) хотя бы одну запись с ее буферами
+func collides(booked []*booking, begin, end time.Time) bool {
+	for _, v := range booked {
+		if v.span().overlaps(begin, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Подбирает слот под запись на произвольное начало сеанса: проверяет, что время
+// все еще свободно, убирает свободные слоты, перекрытые сеансом с буферами, и создает слот под сеанс.
+// В начатый групповой сеанс запись идет в его слот. Место занимает сама запись.
+// Без сотрудника слот создается за организацией, а занимаются только ресурсы.
+// begin - настенное время организации. Возвращает slot_id
+func bookSession(ctx context.Context, tx *sqlx.Tx, params *orgmodel.AvailabilityParams, begin time.Time) (int, error) {
+	// Параллельные записи к одному сотруднику или ресурсу выполняются по очереди
+	if err := lockBooking(ctx, tx, params); err != nil {
+		return 0, err
+	}
+	free, err := availability(ctx, tx, params)
+	if err != nil {
+		return 0, err
+	}
+	begin = custom.InZone(begin, custom.Location(free.TimeZone))
+	var session *orgmodel.Session
+	for _, v := range free.Sessions {
+		if v.Begin.Equal(begin) {
+			session = v
+			break
+		}
+	}
+	if session == nil {
+		return 0, ErrSessionUnavailable
+	}
+	if session.SlotID != 0 {
+		return session.SlotID, nil
+	}
+	var slotID int
+	if params.WorkerID <= 0 {
+		query := `
+			INSERT INTO slots (date, session_begin, session_end, busy, org_id)
+			VALUES ($1, $2, $3, false, $4)
+			RETURNING slot_id;
+		`
+		if err = tx.QueryRowContext(ctx, query, params.Date, session.Begin, session.End, params.OrgID).Scan(&slotID); err != nil {
+			return 0, fmt.Errorf("failed to insert slot: %w", err)
+		}
+		return slotID, nil
+	}
+	// Свободные слоты, задевающие сеанс вместе с буферами услуги, записать уже нельзя
+	query := `
+		DELETE FROM slots s
+		WHERE s.worker_id = $1
+		AND s.session_begin < $3
+		AND s.session_end > $2
+		AND COALESCE(s.busy, false) = false
+		AND NOT EXISTS (SELECT 1 FROM records r WHERE r.slot_id = s.slot_id);
+	`
+	span := interval{
+		Begin: session.Begin.Add(-time.Duration(free.BufferBefore) * time.Minute),
+		End:   session.End.Add(time.Duration(free.BufferAfter) * time.Minute),
+	}
+	if _, err = tx.ExecContext(ctx, query, params.WorkerID, span.Begin, span.End); err != nil {
+		return 0, fmt.Errorf("failed to free slots: %w", err)
+	}
+	query = `
+		INSERT INTO slots
+		(date, session_begin, session_end, busy, worker_schedule_id, worker_id, org_id)
+		SELECT $1, $2, $3, false, ws.worker_schedule_id, $4, ws.org_id
+		FROM worker_day_shifts($4, $1::date) ws
+		JOIN orgs o ON o.org_id = ws.org_id
+		-- при сменной работе - интервал, в который попадает сеанс
+		ORDER BY (ws.start::time <= ($2::timestamptz AT TIME ZONE o.timezone)::time
+			AND ws.over::time >= ($3::timestamptz AT TIME ZONE o.timezone)::time) DESC, ws.start
+		LIMIT 1
+		RETURNING slot_id;
+	`
+	if err = tx.QueryRowContext(ctx, query, params.Date, session.Begin, session.End, params.WorkerID).Scan(&slotID); err != nil {
+		return 0, fmt.Errorf("failed to insert slot: %w", err)
+	}
+	return slotID, nil
+}
+
+// Проверяет запись услуги в готовый слот: сеанс слота длится ровно столько, сколько услуга,
+// а вместе с ее буферами не задевает другие записи сотрудника. Сам слот (групповой сеанс)
+// и слот from, из которого переносится единственная в нем запись, не мешают
+func slotFits(ctx context.Context, tx *sqlx.Tx, slotID, from int, params *orgmodel.AvailabilityParams) error {
+	// Как и запись на произвольное время, проверка идет под блокировкой сотрудника или ресурсов
+	if err := lockBooking(ctx, tx, params); err != nil {
+		return err
+	}
+	if params.WorkerID <= 0 {
+		return resourceSlotFits(ctx, tx, slotID, params)
+	}
+	query := `SELECT date, session_begin, session_end FROM slots WHERE slot_id = $1 AND worker_id = $2;`
+	var (
+		day        time.Time
+		begin, end time.Time
+	)
+	if err := tx.QueryRowContext(ctx, query, slotID, params.WorkerID).Scan(&day, &begin, &end); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSlotNotFound
+		}
+		return fmt.Errorf("failed to get slot: %w", err)
+	}
+	service, err := workerService(ctx, tx, params)
+	if err != nil {
+		return err
+	}
+	if end.Sub(begin) != time.Duration(service.Duration)*time.Minute {
+		return ErrSessionUnavailable
+	}
+	booked, err := bookings(ctx, tx, &orgmodel.SlotsMeta{OrgID: params.OrgID, WorkerID: params.WorkerID}, sql.NullTime{Time: day, Valid: true})
+	if err != nil {
+		return err
+	}
+	others := make([]*booking, 0, len(booked[params.WorkerID]))
+	for _, v := range booked[params.WorkerID] {
+		if v.SlotID == slotID || (v.SlotID == from && v.Seats <= 1) {
+			continue
+		}
+		others = append(others, v)
+	}
+	before := time.Duration(service.BufferBefore) * time.Minute
+	after := time.Duration(service.BufferAfter) * time.Minute
+	if collides(others, begin.Add(-before), end.Add(after)) {
+		return ErrSessionUnavailable
+	}
+	return nil
+}
+
+// Блокирует сотрудника записи, а без сотрудника - ресурсы услуги
+func lockBooking(ctx context.Context, tx *sqlx.Tx, params *orgmodel.AvailabilityParams) error {
+	if params.WorkerID <= 0 {
+		_, err := lockResources(ctx, tx, params.ServiceID)
+		return err
+	}
+	query := `SELECT worker_id FROM workers WHERE worker_id = $1 AND org_id = $2 AND is_delete = false FOR UPDATE;`
+	var workerID int
+	if err := tx.QueryRowContext(ctx, query, params.WorkerID, params.OrgID).Scan(&workerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWorkerNotFound
+		}
+		return fmt.Errorf("failed to lock worker: %w", err)
+	}
+	return nil
+}
+
+// Запись услуги без сотрудника в готовый слот организации: слот тоже без сотрудника
+// и длится столько же, сколько услуга. Занятость ресурсов проверит их резерв
+func resourceSlotFits(ctx context.Context, tx *sqlx.Tx, slotID int, params *orgmodel.AvailabilityParams) error {
+	query := `
+		SELECT session_begin, session_end
+		FROM slots
+		WHERE slot_id = $1
+		AND worker_id IS NULL
+		AND org_id = $2;
+	`
+	var begin, end time.Time
+	if err := tx.QueryRowContext(ctx, query, slotID, params.OrgID).Scan(&begin, &end); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSlotNotFound
+		}
+		return fmt.Errorf("failed to get slot: %w", err)
+	}
+	service, err := resourceService(ctx, tx, params)
+	if err != nil {
+		return err
+	}
+	if end.Sub(begin) != time.Duration(service.Duration)*time.Minute {
+		return ErrSessionUnavailable
+	}
+	return nil
+}
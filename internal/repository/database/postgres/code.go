@@ -7,15 +7,13 @@ import (
 	"fmt"
 	"time"
 	"timeline/internal/repository/models"
-
-	"github.com/jackc/pgx/v5"
 )
 
 var (
 	ErrCodeNotFound = errors.New("given code not found")
 )
 
-// Сохранить код отправленный на почту
+// Сохранить код отправленный на почту. Ранее выданные коды аккаунта с тем же назначением становятся недействительными
 func (p *PostgresRepo) SaveVerifyCode(ctx context.Context, Info *models.CodeInfo) error {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -27,21 +25,37 @@ func (p *PostgresRepo) SaveVerifyCode(ctx context.Context, Info *models.CodeInfo
 		}
 	}()
 
-	var query string
+	var invalidate, query string
 	switch Info.IsOrg {
 	case false:
+		invalidate = `
+		UPDATE users_verify
+		SET used = true
+		WHERE used = false
+		AND user_id = $1
+		AND purpose = $2;
+		`
 		query = `
-		INSERT INTO user_verify (code, user_id)
-        VALUES ($1, $2);
+		INSERT INTO users_verify (code, user_id, ip, expires_at, purpose, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6);
 		`
 	case true:
+		invalidate = `
+		UPDATE orgs_verify
+		SET used = true
+		WHERE used = false
+		AND org_id = $1
+		AND purpose = $2;
+		`
 		query = `
-		INSERT INTO orgs_verify (code, org_id)
-        VALUES ($1, $2);
+		INSERT INTO orgs_verify (code, org_id, ip, expires_at, purpose, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6);
 	`
 	}
-
-	if err = tx.QueryRowContext(ctx, query, Info.Code, Info.ID).Err(); err != nil {
+	if _, err = tx.ExecContext(ctx, invalidate, Info.ID, Info.Purpose); err != nil {
+		return fmt.Errorf("failed to invalidate codes: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, query, Info.Code, Info.ID, Info.IP, Info.ExpiresAt, Info.Purpose, Info.CreatedAt); err != nil {
 		return fmt.Errorf("failed to save code: %w", err)
 	}
 	if err = tx.Commit(); err != nil {
@@ -51,11 +65,11 @@ func (p *PostgresRepo) SaveVerifyCode(ctx context.Context, Info *models.CodeInfo
 	return nil
 }
 
-// Получает код и id, если находит код, возвращает его время сгорания
-func (p *PostgresRepo) VerifyCode(ctx context.Context, Info *models.CodeInfo) (time.Time, error) {
+// Последний действующий (не погашенный) код аккаунта с заданным назначением
+func (p *PostgresRepo) ActiveCode(ctx context.Context, id int, IsOrg bool, purpose string) (*models.VerifyCode, error) {
 	tx, err := p.db.Beginx()
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to start tx: %w", err)
+		return nil, fmt.Errorf("failed to start tx: %w", err)
 	}
 	defer func() {
 		if err != nil {
@@ -63,31 +77,164 @@ func (p *PostgresRepo) VerifyCode(ctx context.Context, Info *models.CodeInfo) (t
 		}
 	}()
 	var query string
-	switch Info.IsOrg {
+	switch IsOrg {
 	case false:
 		query = `
-		SELECT expires_at FROM user_verify
-        WHERE code = $1 AND user_id = $2;
+		SELECT user_verify_id AS code_id, code, attempts, created_at, expires_at
+		FROM users_verify
+		WHERE used = false
+		AND user_id = $1
+		AND purpose = $2
+		ORDER BY created_at DESC
+		LIMIT 1;
 		`
 	case true:
 		query = `
-		SELECT expires_at FROM orgs_verify
-        WHERE code = $1 AND org_id = $2;
+		SELECT org_verify_id AS code_id, code, attempts, created_at, expires_at
+		FROM orgs_verify
+		WHERE used = false
+		AND org_id = $1
+		AND purpose = $2
+		ORDER BY created_at DESC
+		LIMIT 1;
 	`
 	}
 
-	var expires_at time.Time
-	err = tx.QueryRowContext(ctx, query, Info.Code, Info.ID).Scan(&expires_at)
-	if err != nil {
+	var code models.VerifyCode
+	if err = tx.GetContext(ctx, &code, query, id, purpose); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return time.Time{}, ErrCodeNotFound
+			return nil, ErrCodeNotFound
+		}
+		return nil, fmt.Errorf("failed to find code: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &code, nil
+}
+
+// Учитывает неудачную попытку ввода кода
+func (p *PostgresRepo) CodeAttempt(ctx context.Context, codeID int, IsOrg bool) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var query string
+	switch IsOrg {
+	case false:
+		query = `
+		UPDATE users_verify
+		SET attempts = attempts + 1
+		WHERE user_verify_id = $1;
+		`
+	case true:
+		query = `
+		UPDATE orgs_verify
+		SET attempts = attempts + 1
+		WHERE org_verify_id = $1;
+		`
+	}
+	if _, err = tx.ExecContext(ctx, query, codeID); err != nil {
+		return fmt.Errorf("failed to count attempt: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Погашает код, если он не использован и попытки не исчерпаны
+func (p *PostgresRepo) CodeUse(ctx context.Context, codeID int, IsOrg bool, maxAttempts int) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var query string
+	switch IsOrg {
+	case false:
+		query = `
+		UPDATE users_verify
+		SET used = true
+		WHERE used = false
+		AND attempts < $2
+		AND user_verify_id = $1;
+		`
+	case true:
+		query = `
+		UPDATE orgs_verify
+		SET used = true
+		WHERE used = false
+		AND attempts < $2
+		AND org_verify_id = $1;
+		`
+	}
+	res, err := tx.ExecContext(ctx, query, codeID, maxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to use code: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrCodeNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Когда аккаунту последний раз отправлялся код и сколько кодов отправлено с IP начиная с since
+func (p *PostgresRepo) CodeSendStats(ctx context.Context, id int, IsOrg bool, ip string, since time.Time) (*models.CodeSendStats, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
 		}
-		return time.Time{}, fmt.Errorf("failed to find code: %w", err)
+	}()
+	var query string
+	switch IsOrg {
+	case false:
+		query = `
+		SELECT MAX(created_at) FROM users_verify
+		WHERE user_id = $1;
+		`
+	case true:
+		query = `
+		SELECT MAX(created_at) FROM orgs_verify
+		WHERE org_id = $1;
+		`
+	}
+	var stats models.CodeSendStats
+	var lastSent sql.NullTime
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&lastSent); err != nil {
+		return nil, fmt.Errorf("failed to get last code: %w", err)
+	}
+	stats.LastSentAt = lastSent.Time
+	// коды пользователей и организаций с одного IP считаются вместе
+	query = `
+		SELECT
+			(SELECT COUNT(*) FROM users_verify WHERE ip = $1 AND created_at > $2)
+			+ (SELECT COUNT(*) FROM orgs_verify WHERE ip = $1 AND created_at > $2);
+	`
+	if err = tx.QueryRowContext(ctx, query, ip, since).Scan(&stats.SentFromIP); err != nil {
+		return nil, fmt.Errorf("failed to count codes by ip: %w", err)
 	}
 	if err = tx.Commit(); err != nil {
-		return time.Time{}, fmt.Errorf("failed to commit tx: %w", err)
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
 	}
-	return expires_at, nil
+	return &stats, nil
 }
 
 // Устанавливает поле verified у сущности в true
@@ -145,22 +292,25 @@ func (p *PostgresRepo) AccountExpiration(ctx context.Context, email string, IsOr
 	switch IsOrg {
 	case false:
 		query = `
-        SELECT user_id, passwd_hash, created_at, verified FROM users
+        SELECT user_id, passwd_hash, created_at, verified, is_blocked FROM users
         WHERE is_delete = false 
 		AND email = $1;
     	`
 	case true:
 		query = `
-        SELECT org_id, passwd_hash, created_at, verified FROM orgs
+        SELECT org_id, passwd_hash, created_at, verified, is_blocked FROM orgs
         WHERE is_delete = false
 		AND email = $1;
     	`
 	}
 
 	var Data models.ExpInfo
-	if err = tx.QueryRowContext(ctx, query, email).Scan(&Data.ID, &Data.Hash, &Data.CreatedAt, &Data.Verified); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrOrgNotFound
+	if err = tx.QueryRowContext(ctx, query, email).Scan(&Data.ID, &Data.Hash, &Data.CreatedAt, &Data.Verified, &Data.Blocked); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if IsOrg {
+				return nil, ErrOrgNotFound
+			}
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get meta info: %w", err)
 	}
@@ -172,7 +322,7 @@ func (p *PostgresRepo) AccountExpiration(ctx context.Context, email string, IsOr
 	return &Data, nil
 }
 
-// [CRON]: удаление стухших кодов
+// [CRON]: удаление стухших кодов. Сутки после сгорания они нужны для ограничения частоты отправки
 func (p *PostgresRepo) DeleteExpiredCodes(ctx context.Context) error {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -188,11 +338,11 @@ func (p *PostgresRepo) DeleteExpiredCodes(ctx context.Context) error {
 	query := `
 		DELETE
 		FROM users_verify
-		WHERE expires_at <= (CURRENT_TIMESTAMP - INTERVAL '5 minute');
+		WHERE expires_at <= (CURRENT_TIMESTAMP - INTERVAL '1 day');
 
 		DELETE
 		FROM orgs_verify
-		WHERE expires_at <= (CURRENT_TIMESTAMP - INTERVAL '5 minute');
+		WHERE expires_at <= (CURRENT_TIMESTAMP - INTERVAL '1 day');
 		`
 	if _, err = tx.ExecContext(ctx, query); err != nil {
 		return fmt.Errorf("failed to delete expired codes: %w", err)
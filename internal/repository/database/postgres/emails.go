@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timeline/internal/repository/models"
+)
+
+var (
+	ErrEmailTaken          = errors.New("email already in use")
+	ErrEmailChangeNotFound = errors.New("email change not found")
+)
+
+// Почта аккаунта
+func (p *PostgresRepo) AccountEmail(ctx context.Context, id int, isOrg bool) (string, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return "", fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var query string
+	switch isOrg {
+	case false:
+		query = `
+		SELECT email FROM users
+		WHERE is_delete = false
+		AND user_id = $1;
+		`
+	case true:
+		query = `
+		SELECT email FROM orgs
+		WHERE is_delete = false
+		AND org_id = $1;
+		`
+	}
+	var email string
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if isOrg {
+				return "", ErrOrgNotFound
+			}
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to get email: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return email, nil
+}
+
+// Занята ли почта среди пользователей или среди организаций
+func (p *PostgresRepo) EmailInUse(ctx context.Context, email string, isOrg bool) (bool, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var query string
+	switch isOrg {
+	case false:
+		query = `SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1));`
+	case true:
+		query = `SELECT EXISTS(SELECT 1 FROM orgs WHERE LOWER(email) = LOWER($1));`
+	}
+	var exists bool
+	if err = tx.QueryRowContext(ctx, query, email).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return exists, nil
+}
+
+// Сохранить заявку на смену почты. Предыдущие заявки аккаунта становятся недействительными
+func (p *PostgresRepo) EmailChangeSave(ctx context.Context, change *models.EmailChange) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE email_changes
+		SET used = true
+		WHERE used = false
+		AND subject_id = $1
+		AND is_org = $2;
+	`
+	if _, err = tx.ExecContext(ctx, query, change.SubjectID, change.IsOrg); err != nil {
+		return fmt.Errorf("failed to invalidate email changes: %w", err)
+	}
+	query = `
+		INSERT INTO email_changes (subject_id, is_org, new_email, code, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+	if _, err = tx.ExecContext(ctx, query,
+		change.SubjectID,
+		change.IsOrg,
+		change.NewEmail,
+		change.Code,
+		change.CreatedAt,
+		change.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("failed to save email change: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Последняя заявка аккаунта, в т.ч. погашенная. Нужна для ограничения частоты отправки
+func (p *PostgresRepo) EmailChangeLast(ctx context.Context, id int, isOrg bool) (*models.EmailChange, error) {
+	return p.emailChange(ctx, id, isOrg, false)
+}
+
+// Действующая заявка аккаунта
+func (p *PostgresRepo) EmailChangeActive(ctx context.Context, id int, isOrg bool) (*models.EmailChange, error) {
+	return p.emailChange(ctx, id, isOrg, true)
+}
+
+func (p *PostgresRepo) emailChange(ctx context.Context, id int, isOrg bool, onlyActive bool) (*models.EmailChange, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT change_id, subject_id, is_org, new_email, code, attempts, created_at, expires_at
+		FROM email_changes
+		WHERE subject_id = $1
+		AND is_org = $2
+		AND (used = false OR NOT $3)
+		ORDER BY created_at DESC
+		LIMIT 1;
+	`
+	var change models.EmailChange
+	if err = tx.GetContext(ctx, &change, query, id, isOrg, onlyActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEmailChangeNotFound
+		}
+		return nil, fmt.Errorf("failed to get email change: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &change, nil
+}
+
+// Учитывает неудачную попытку ввода кода
+func (p *PostgresRepo) EmailChangeAttempt(ctx context.Context, changeID int) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE email_changes
+		SET attempts = attempts + 1
+		WHERE change_id = $1;
+	`
+	if _, err = tx.ExecContext(ctx, query, changeID); err != nil {
+		return fmt.Errorf("failed to count attempt: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Погашает заявку и меняет почту аккаунта.
+// Если почта за время подтверждения стала занята - ErrEmailTaken
+func (p *PostgresRepo) EmailChangeApply(ctx context.Context, changeID int, maxAttempts int) (*models.EmailChange, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE email_changes
+		SET used = true
+		WHERE used = false
+		AND attempts < $2
+		AND expires_at > CURRENT_TIMESTAMP
+		AND change_id = $1
+		RETURNING change_id, subject_id, is_org, new_email, code, attempts, created_at, expires_at;
+	`
+	var change models.EmailChange
+	if err = tx.GetContext(ctx, &change, query, changeID, maxAttempts); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEmailChangeNotFound
+		}
+		return nil, fmt.Errorf("failed to use email change: %w", err)
+	}
+	var check, update string
+	switch change.IsOrg {
+	case false:
+		check = `SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1));`
+		update = `
+		UPDATE users
+		SET email = $1
+		WHERE is_delete = false
+		AND user_id = $2;
+		`
+	case true:
+		check = `SELECT EXISTS(SELECT 1 FROM orgs WHERE LOWER(email) = LOWER($1));`
+		update = `
+		UPDATE orgs
+		SET email = $1
+		WHERE is_delete = false
+		AND org_id = $2;
+		`
+	}
+	var taken bool
+	if err = tx.QueryRowContext(ctx, check, change.NewEmail).Scan(&taken); err != nil {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if taken {
+		err = ErrEmailTaken
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, update, change.NewEmail, change.SubjectID); err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &change, nil
+}
+
+// [CRON]: удаление стухших заявок на смену почты
+func (p *PostgresRepo) DeleteExpiredEmailChanges(ctx context.Context) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE
+		FROM email_changes
+		WHERE expires_at <= (CURRENT_TIMESTAMP - INTERVAL '1 day');
+	`
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to delete expired email changes: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
@@ -29,7 +29,8 @@ func (p *PostgresRepo) FeedbackList(ctx context.Context, params *recordmodel.Fee
 		FROM records r
 		JOIN feedbacks f
 		ON r.record_id = f.record_id AND reviewed = true
-		WHERE ($1 <= 0 OR f.record_id = $1)
+		WHERE f.is_hidden = false
+		AND ($1 <= 0 OR f.record_id = $1)
 		AND ($2 <= 0 OR r.user_id = $2)
 		AND ($3 <= 0 OR r.org_id = $3);
 	`
@@ -44,7 +45,8 @@ func (p *PostgresRepo) FeedbackList(ctx context.Context, params *recordmodel.Fee
 		FROM feedbacks f
 		JOIN records r
 		ON r.record_id = f.record_id AND r.reviewed = true
-		WHERE ($1 <= 0 OR f.record_id = $1)
+		WHERE f.is_hidden = false
+		AND ($1 <= 0 OR f.record_id = $1)
 		AND ($2 <= 0 OR r.user_id = $2)
 		AND ($3 <= 0 OR r.org_id = $3);
 	`
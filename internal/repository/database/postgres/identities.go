@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timeline/internal/repository/models"
+)
+
+var (
+	ErrIdentityNotFound  = errors.New("identity not found")
+	ErrOIDCStateNotFound = errors.New("oidc state not found")
+)
+
+func (p *PostgresRepo) OIDCStateSave(ctx context.Context, state *models.OIDCState) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO oidc_states (state_hash, provider, verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4, $5);
+	`
+	if _, err = tx.ExecContext(ctx, query,
+		state.StateHash,
+		state.Provider,
+		state.Verifier,
+		state.Nonce,
+		state.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("failed to save oidc state: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Забирает state. Повторно тот же state использовать нельзя
+func (p *PostgresRepo) OIDCStateUse(ctx context.Context, stateHash string) (*models.OIDCState, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE FROM oidc_states
+		WHERE state_hash = $1
+		AND expires_at > CURRENT_TIMESTAMP
+		RETURNING state_hash, provider, verifier, nonce, expires_at;
+	`
+	var state models.OIDCState
+	if err = tx.GetContext(ctx, &state, query, stateHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOIDCStateNotFound
+		}
+		return nil, fmt.Errorf("failed to use oidc state: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &state, nil
+}
+
+// Пользователь, к которому привязана учетная запись провайдера. Удаленные не учитываются
+func (p *PostgresRepo) IdentityUser(ctx context.Context, provider, subject string) (int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT i.user_id
+		FROM user_identities i
+		JOIN users u ON u.user_id = i.user_id
+		WHERE i.provider = $1
+		AND i.subject = $2
+		AND u.is_delete = false;
+	`
+	var userID int
+	if err = tx.QueryRowContext(ctx, query, provider, subject).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrIdentityNotFound
+		}
+		return 0, fmt.Errorf("failed to get identity: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return userID, nil
+}
+
+// Привязывает учетную запись провайдера. Прежняя привязка той же записи заменяется
+func (p *PostgresRepo) IdentityLink(ctx context.Context, identity *models.Identity) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, subject) DO UPDATE
+		SET
+			user_id = EXCLUDED.user_id,
+			email = EXCLUDED.email;
+	`
+	if _, err = tx.ExecContext(ctx, query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+	); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// [CRON]: удаление незавершенных входов через провайдера
+func (p *PostgresRepo) DeleteExpiredOIDCStates(ctx context.Context) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE
+		FROM oidc_states
+		WHERE expires_at <= CURRENT_TIMESTAMP;
+	`
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to delete expired oidc states: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"timeline/internal/repository/models"
+)
+
+var (
+	ErrMFANotFound      = errors.New("mfa not configured")
+	ErrMFAAlreadyOn     = errors.New("mfa already enabled")
+	ErrMFAStepUsed      = errors.New("totp code already used")
+	ErrRecoveryNotFound = errors.New("recovery code not found")
+	ErrMFATokenUsed     = errors.New("mfa token already used")
+)
+
+// Сохраняет новый секрет. Для уже включенной MFA секрет не меняется - ErrMFAAlreadyOn
+func (p *PostgresRepo) MFASecretSave(ctx context.Context, orgID int, secret string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO orgs_mfa (org_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (org_id) DO UPDATE
+		SET
+			secret = EXCLUDED.secret,
+			last_step = 0,
+			failed_attempts = 0,
+			created_at = CURRENT_TIMESTAMP
+		WHERE orgs_mfa.enabled = false;
+	`
+	res, err := tx.ExecContext(ctx, query, orgID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrMFAAlreadyOn
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresRepo) MFAByOrg(ctx context.Context, orgID int) (*models.OrgMFA, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT org_id, secret, enabled, last_step, failed_attempts, last_failed_at
+		FROM orgs_mfa
+		WHERE org_id = $1;
+	`
+	var mfa models.OrgMFA
+	if err = tx.GetContext(ctx, &mfa, query, orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMFANotFound
+		}
+		return nil, fmt.Errorf("failed to get mfa: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &mfa, nil
+}
+
+// Включает MFA и заменяет коды восстановления на новые
+func (p *PostgresRepo) MFAEnable(ctx context.Context, orgID int, step int64, recoveryHashes []string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE orgs_mfa
+		SET
+			enabled = true,
+			last_step = $2
+		WHERE enabled = false
+		AND org_id = $1;
+	`
+	res, err := tx.ExecContext(ctx, query, orgID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrMFAAlreadyOn
+		return err
+	}
+	query = `
+		DELETE FROM orgs_recovery_codes
+		WHERE org_id = $1;
+	`
+	if _, err = tx.ExecContext(ctx, query, orgID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	query = `
+		INSERT INTO orgs_recovery_codes (org_id, code_hash)
+		VALUES ($1, $2);
+	`
+	for _, hash := range recoveryHashes {
+		if _, err = tx.ExecContext(ctx, query, orgID, hash); err != nil {
+			return fmt.Errorf("failed to save recovery code: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresRepo) MFADisable(ctx context.Context, orgID int) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE FROM orgs_recovery_codes
+		WHERE org_id = $1;
+	`
+	if _, err = tx.ExecContext(ctx, query, orgID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	query = `
+		DELETE FROM orgs_mfa
+		WHERE org_id = $1;
+	`
+	if _, err = tx.ExecContext(ctx, query, orgID); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Принимает шаг TOTP, только если он новее последнего принятого, и сбрасывает счетчик ошибок
+func (p *PostgresRepo) MFAStepAccept(ctx context.Context, orgID int, step int64) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE orgs_mfa
+		SET
+			last_step = $2,
+			failed_attempts = 0
+		WHERE last_step < $2
+		AND org_id = $1;
+	`
+	res, err := tx.ExecContext(ctx, query, orgID, step)
+	if err != nil {
+		return fmt.Errorf("failed to accept totp step: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrMFAStepUsed
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Учитывает неверный второй фактор
+func (p *PostgresRepo) MFAFail(ctx context.Context, orgID int, at time.Time) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE orgs_mfa
+		SET
+			failed_attempts = failed_attempts + 1,
+			last_failed_at = $2
+		WHERE org_id = $1;
+	`
+	if _, err = tx.ExecContext(ctx, query, orgID, at); err != nil {
+		return fmt.Errorf("failed to count mfa fail: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Погашает код восстановления и сбрасывает счетчик ошибок
+func (p *PostgresRepo) MFARecoveryUse(ctx context.Context, orgID int, codeHash string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE orgs_recovery_codes
+		SET used = true
+		WHERE used = false
+		AND org_id = $1
+		AND code_hash = $2;
+	`
+	res, err := tx.ExecContext(ctx, query, orgID, codeHash)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrRecoveryNotFound
+		return err
+	}
+	query = `
+		UPDATE orgs_mfa
+		SET failed_attempts = 0
+		WHERE org_id = $1;
+	`
+	if _, err = tx.ExecContext(ctx, query, orgID); err != nil {
+		return fmt.Errorf("failed to reset mfa fails: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Погашает mfa_token по хешу его jti. Повторное погашение - ErrMFATokenUsed
+func (p *PostgresRepo) MFATokenUse(ctx context.Context, jtiHash string, expiresAt time.Time) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO mfa_used_tokens (jti_hash, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti_hash) DO NOTHING;
+	`
+	res, err := tx.ExecContext(ctx, query, jtiHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to use mfa token: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrMFATokenUsed
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// [CRON]: удаление погашенных mfa_token с истекшим сроком
+func (p *PostgresRepo) DeleteExpiredMFATokens(ctx context.Context) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE
+		FROM mfa_used_tokens
+		WHERE expires_at <= CURRENT_TIMESTAMP;
+	`
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to delete expired mfa tokens: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
@@ -30,8 +30,8 @@ func (p *PostgresRepo) OrgSave(ctx context.Context, org *orgmodel.OrgRegister) (
 	}()
 
 	query := `
-		INSERT INTO orgs (email, passwd_hash, name, type, city, address, telephone, lat, long, about)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO orgs (email, passwd_hash, name, type, city, address, telephone, lat, long, about, timezone)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE(NULLIF($11, ''), 'Europe/Moscow'))
         RETURNING org_id;
 	`
 	orgID := 0
@@ -47,6 +47,7 @@ func (p *PostgresRepo) OrgSave(ctx context.Context, org *orgmodel.OrgRegister) (
 		org.Lat,
 		org.Long,
 		org.About,
+		org.TimeZone,
 	).Scan(&orgID); err != nil {
 		// TODO: пока отдаем фулл ошибку, а вообще нельзя внутренние ошибки отдавать наружу
 		return 0, fmt.Errorf("failed to save org: %w", err)
@@ -71,7 +72,7 @@ func (p *PostgresRepo) OrgByEmail(ctx context.Context, email string) (*orgmodel.
 	}()
 
 	query := `
-		SELECT org_id, name, rating, type, city, address, telephone, lat, long, about 
+		SELECT org_id, name, rating, type, city, address, telephone, lat, long, about, timezone
 		FROM orgs
         WHERE is_delete = false
 		AND email = $1;
@@ -101,9 +102,10 @@ func (p *PostgresRepo) OrgByID(ctx context.Context, id int) (*orgmodel.Organizat
 		}
 	}()
 	query := `
-		SELECT org_id, name, rating, type, city, address, telephone, lat, long, about 
+		SELECT org_id, name, rating, type, city, address, telephone, lat, long, about, timezone
 		FROM orgs
         WHERE is_delete = false 
+		AND is_blocked = false
 		AND org_id = $1;
 	`
 	var org orgmodel.Organization
@@ -124,6 +126,22 @@ func (p *PostgresRepo) OrgByID(ctx context.Context, id int) (*orgmodel.Organizat
 		}
 		return nil, fmt.Errorf("failed to get org timetable by id: %w", err)
 	}
+	if err = timetableBreaks(ctx, tx, id, org.Timetable); err != nil {
+		return nil, err
+	}
+	query = `
+		SELECT exception_id, org_id, date, closed, open, close, break_start, break_end, reason
+		FROM timetable_exceptions
+		WHERE org_id = $1
+		AND date >= org_today($1)
+		ORDER BY date;
+	`
+	if err = tx.SelectContext(ctx, &org.Exceptions, query, id); err != nil {
+		return nil, fmt.Errorf("failed to get org timetable exceptions by id: %w", err)
+	}
+	if err = exceptionWorkers(ctx, tx, org.Exceptions...); err != nil {
+		return nil, err
+	}
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit tx: %w", err)
 	}
@@ -141,7 +159,7 @@ func (p *PostgresRepo) OrgsInArea(ctx context.Context, area *orgmodel.AreaParams
 			tx.Rollback()
 		}
 	}()
-	// Запрос с расписанием на текущий день
+	// Запрос с расписанием на текущий день с учетом особых дней
 	query := `SELECT
 		o.org_id,
 		o.name,
@@ -155,10 +173,9 @@ func (p *PostgresRepo) OrgsInArea(ctx context.Context, area *orgmodel.AreaParams
 		t.break_start, 
 		t.break_end
 	FROM orgs o
-	LEFT JOIN timetables t
-		ON o.org_id = t.org_id
-		AND t.weekday = EXTRACT(ISODOW FROM CURRENT_DATE)
+	LEFT JOIN LATERAL org_day_hours(o.org_id, org_today(o.org_id)) t ON true
 	WHERE o.is_delete = false 
+	AND o.is_blocked = false
 	AND o.lat BETWEEN $1 AND $2
 	AND o.long BETWEEN $3 AND $4;
 	`
@@ -203,6 +220,7 @@ func (p *PostgresRepo) OrgsBySearch(ctx context.Context, params *orgmodel.Search
 			COUNT(*)
 		FROM orgs 
 		WHERE is_delete = false 
+		AND is_blocked = false
 		AND ($1 = '' OR name ILIKE '%' || $1 || '%') 
 		AND ($2 = '' OR type ILIKE '%' || $2 || '%')
 	`
@@ -227,10 +245,9 @@ func (p *PostgresRepo) OrgsBySearch(ctx context.Context, params *orgmodel.Search
 			t.break_start, 
 			t.break_end
 		FROM orgs o
-		LEFT JOIN timetables t
-		ON t.org_id = o.org_id
-		AND t.weekday = EXTRACT(ISODOW FROM CURRENT_DATE)
+		LEFT JOIN LATERAL org_day_hours(o.org_id, org_today(o.org_id)) t ON true
 		WHERE o.is_delete = false
+		AND o.is_blocked = false
 		AND ($1 = '' OR name ILIKE '%' || $1 || '%')
 		AND ($2 = '' OR type ILIKE '%' || $2 || '%')
 		LIMIT $3
@@ -268,7 +285,8 @@ func (p *PostgresRepo) OrgUpdate(ctx context.Context, new *orgmodel.Organization
 			telephone = $5,
 			lat = $6,
 			long = $7,
-			about = $8
+			about = $8,
+			timezone = COALESCE(NULLIF($10, ''), timezone)
 		WHERE is_delete = false
 		AND org_id = $9;
 	`
@@ -282,6 +300,7 @@ func (p *PostgresRepo) OrgUpdate(ctx context.Context, new *orgmodel.Organization
 		new.Long,
 		new.About,
 		new.OrgID,
+		new.TimeZone,
 	)
 	if err != nil {
 		if _, errNoRowsAffected := res.RowsAffected(); errNoRowsAffected != nil {
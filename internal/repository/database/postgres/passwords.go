@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timeline/internal/repository/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrResetTokenNotFound = errors.New("reset token not found")
+)
+
+// Сохранить токен сброса пароля. Ранее выданные токены аккаунта становятся недействительными
+func (p *PostgresRepo) PasswordResetSave(ctx context.Context, reset *models.PasswordReset) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE password_resets
+		SET
+			used = true
+		WHERE used = false
+		AND subject_id = $1
+		AND is_org = $2;
+	`
+	if _, err = tx.ExecContext(ctx, query, reset.SubjectID, reset.IsOrg); err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+	query = `
+		INSERT INTO password_resets (token_hash, subject_id, is_org, expires_at)
+		VALUES ($1, $2, $3, $4);
+	`
+	if _, err = tx.ExecContext(ctx, query, reset.TokenHash, reset.SubjectID, reset.IsOrg, reset.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to save reset token: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Погашает токен сброса пароля и в той же транзакции устанавливает новый пароль аккаунта.
+// Использованный или стухший токен - ErrResetTokenNotFound
+func (p *PostgresRepo) PasswordResetUse(ctx context.Context, tokenHash, passwdHash string) (*models.PasswordReset, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE password_resets
+		SET
+			used = true
+		WHERE used = false
+		AND expires_at > CURRENT_TIMESTAMP
+		AND token_hash = $1
+		RETURNING reset_id, token_hash, subject_id, is_org, expires_at, used;
+	`
+	var reset models.PasswordReset
+	if err = tx.GetContext(ctx, &reset, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrResetTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to use reset token: %w", err)
+	}
+	if err = passwordUpdate(ctx, tx, reset.SubjectID, reset.IsOrg, passwdHash); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &reset, nil
+}
+
+// Хеш текущего пароля аккаунта
+func (p *PostgresRepo) PasswordHash(ctx context.Context, id int, isOrg bool) (string, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return "", fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var query string
+	switch isOrg {
+	case false:
+		query = `
+		SELECT passwd_hash FROM users
+		WHERE is_delete = false
+		AND user_id = $1;
+		`
+	case true:
+		query = `
+		SELECT passwd_hash FROM orgs
+		WHERE is_delete = false
+		AND org_id = $1;
+		`
+	}
+	var hash string
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if isOrg {
+				return "", ErrOrgNotFound
+			}
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to get password hash: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return hash, nil
+}
+
+func (p *PostgresRepo) PasswordUpdate(ctx context.Context, id int, isOrg bool, hash string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = passwordUpdate(ctx, tx, id, isOrg, hash); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+func passwordUpdate(ctx context.Context, tx *sqlx.Tx, id int, isOrg bool, hash string) error {
+	var query string
+	switch isOrg {
+	case false:
+		query = `
+		UPDATE users
+		SET passwd_hash = $1
+		WHERE is_delete = false
+		AND user_id = $2;
+		`
+	case true:
+		query = `
+		UPDATE orgs
+		SET passwd_hash = $1
+		WHERE is_delete = false
+		AND org_id = $2;
+		`
+	}
+	res, err := tx.ExecContext(ctx, query, hash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		if isOrg {
+			return ErrOrgNotFound
+		}
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// [CRON]: удаление стухших и использованных токенов сброса пароля
+func (p *PostgresRepo) DeleteExpiredPasswordResets(ctx context.Context) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE
+		FROM password_resets
+		WHERE used = true
+		OR expires_at <= CURRENT_TIMESTAMP;
+	`
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to delete expired reset tokens: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrActiveRecords = errors.New("account has upcoming records")
+)
+
+// Обезличивает аккаунт и помечает его удаленным. Записи остаются для учета организаций,
+// но указывают на обезличенный аккаунт. Пока есть предстоящие записи, удалить нельзя
+func (p *PostgresRepo) AccountErase(ctx context.Context, id int, isOrg bool) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var query string
+	switch isOrg {
+	case false:
+		query = `
+			SELECT COUNT(*) FROM records r
+			JOIN slots s ON r.slot_id = s.slot_id
+			WHERE r.user_id = $1
+			AND s.date >= org_today(r.org_id);
+		`
+	case true:
+		query = `
+			SELECT COUNT(*) FROM records r
+			JOIN slots s ON r.slot_id = s.slot_id
+			WHERE r.org_id = $1
+			AND s.date >= org_today(r.org_id);
+		`
+	}
+	var upcoming int
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&upcoming); err != nil {
+		return fmt.Errorf("failed to count upcoming records: %w", err)
+	}
+	if upcoming > 0 {
+		err = ErrActiveRecords
+		return err
+	}
+	// Название и адрес организации не обезличиваются: они публичны и нужны в истории записей клиентов
+	var queries []string
+	switch isOrg {
+	case false:
+		queries = []string{
+			`UPDATE users SET
+				email = 'deleted-' || user_id || '@erased.invalid',
+				passwd_hash = '',
+				first_name = 'Deleted',
+				last_name = 'user',
+				telephone = NULL,
+				city = '',
+				about = NULL
+			WHERE is_delete = false
+			AND user_id = $1;`,
+			`DELETE FROM user_identities WHERE user_id = $1;`,
+			`DELETE FROM password_resets WHERE subject_id = $1 AND is_org = false;`,
+			`DELETE FROM email_changes WHERE subject_id = $1 AND is_org = false;`,
+			`UPDATE sessions SET revoked = true WHERE subject_id = $1 AND is_org = false AND role = '';`,
+			`DELETE FROM users WHERE user_id = $1;`,
+		}
+	case true:
+		queries = []string{
+			`UPDATE orgs SET
+				email = 'deleted-' || org_id || '@erased.invalid',
+				passwd_hash = '',
+				telephone = NULL,
+				about = NULL
+			WHERE is_delete = false
+			AND org_id = $1;`,
+			`DELETE FROM orgs_recovery_codes WHERE org_id = $1;`,
+			`DELETE FROM orgs_mfa WHERE org_id = $1;`,
+			`UPDATE api_keys SET revoked = true WHERE org_id = $1;`,
+			`UPDATE sessions SET revoked = true
+			WHERE role = 'worker'
+			AND subject_id IN (SELECT worker_id FROM worker_accounts WHERE org_id = $1);`,
+			`DELETE FROM worker_accounts WHERE org_id = $1;`,
+			`DELETE FROM password_resets WHERE subject_id = $1 AND is_org = true;`,
+			`DELETE FROM email_changes WHERE subject_id = $1 AND is_org = true;`,
+			`UPDATE sessions SET revoked = true WHERE subject_id = $1 AND is_org = true;`,
+			// триггер помечает аккаунт, его услуги и сотрудников удаленными
+			`DELETE FROM orgs WHERE org_id = $1;`,
+		}
+	}
+	for i, q := range queries {
+		res, execErr := tx.ExecContext(ctx, q, id)
+		if execErr != nil {
+			err = execErr
+			return fmt.Errorf("failed to erase account: %w", err)
+		}
+		if i > 0 {
+			continue
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			if isOrg {
+				err = ErrOrgNotFound
+				return err
+			}
+			err = ErrUserNotFound
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// [CRON]: окончательная очистка аккаунтов, удаленных дольше retention назад.
+// Удаляются сессии и заявки, а сам аккаунт - если на него не ссылаются записи
+func (p *PostgresRepo) AccountPurge(ctx context.Context, retention time.Duration) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	queries := []string{
+		`DELETE FROM sessions
+		WHERE is_org = false AND role = ''
+		AND subject_id IN (SELECT user_id FROM users WHERE is_delete = true AND deleted_at < $1);`,
+		`DELETE FROM sessions
+		WHERE is_org = true
+		AND subject_id IN (SELECT org_id FROM orgs WHERE is_delete = true AND deleted_at < $1);`,
+		`DELETE FROM sessions
+		WHERE role = 'worker'
+		AND subject_id IN (
+			SELECT w.worker_id FROM workers w
+			JOIN orgs o ON w.org_id = o.org_id
+			WHERE o.is_delete = true AND o.deleted_at < $1
+		);`,
+		`DELETE FROM users u
+		WHERE u.is_delete = true AND u.deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM records r WHERE r.user_id = u.user_id);`,
+		`DELETE FROM orgs o
+		WHERE o.is_delete = true AND o.deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM records r WHERE r.org_id = o.org_id)
+		AND NOT EXISTS (
+			SELECT 1 FROM slots s
+			JOIN workers w ON s.worker_id = w.worker_id
+			WHERE w.org_id = o.org_id
+		);`,
+	}
+	cutoff := time.Now().UTC().Add(-retention)
+	for _, q := range queries {
+		if _, err = tx.ExecContext(ctx, q, cutoff); err != nil {
+			return fmt.Errorf("failed to purge accounts: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
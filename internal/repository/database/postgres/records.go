@@ -2,17 +2,21 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 	"timeline/internal/repository/models/orgmodel"
 	"timeline/internal/repository/models/recordmodel"
 	"timeline/internal/repository/models/usermodel"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
 	ErrRecordsNotFound = errors.New("records not found")
+	ErrSlotFull        = errors.New("no seats left in the slot")
 )
 
 func (p *PostgresRepo) Record(ctx context.Context, recordID int) (*recordmodel.RecordScrap, error) {
@@ -29,23 +33,25 @@ func (p *PostgresRepo) Record(ctx context.Context, recordID int) (*recordmodel.R
 		SELECT 
 			srvc.name AS service_name, 
 			srvc.cost, 
-			w.first_name AS worker_first_name, 
-			w.last_name AS worker_last_name, 
+			COALESCE(w.first_name, '') AS worker_first_name,
+			COALESCE(w.last_name, '') AS worker_last_name,
 			o.name AS org_name,
 			u.first_name AS user_first_name,
 			u.last_name AS user_last_name, 
 			s.date,
 			s.session_begin,
 			s.session_end,
+			o.timezone,
 			f.stars,
 			f.feedback,
-			r.reviewed
+			r.reviewed,
+			r.attended
 		FROM records r
 		JOIN slots s ON r.slot_id = s.slot_id
 		JOIN orgs o ON r.org_id = o.org_id
 		JOIN users u ON r.user_id = u.user_id
 		JOIN services srvc ON r.service_id = srvc.service_id
-		JOIN workers w ON r.worker_id = w.worker_id
+		LEFT JOIN workers w ON r.worker_id = w.worker_id
 		LEFT JOIN feedbacks f ON r.record_id = f.record_id
 		WHERE r.record_id = $1;
 	`
@@ -69,9 +75,11 @@ func (p *PostgresRepo) Record(ctx context.Context, recordID int) (*recordmodel.R
 		&rec.Slot.Date,
 		&rec.Slot.Begin,
 		&rec.Slot.End,
+		&rec.Slot.TimeZone,
 		&rec.Feedback.Stars,
 		&rec.Feedback.Feedback,
 		&rec.Reviewed,
+		&rec.Attended,
 	); err != nil {
 		return nil, err
 	}
@@ -81,6 +89,64 @@ func (p *PostgresRepo) Record(ctx context.Context, recordID int) (*recordmodel.R
 	return rec, nil
 }
 
+// Возвращает пользователя и организацию, к которым относится запись
+func (p *PostgresRepo) RecordOwners(ctx context.Context, recordID int) (*recordmodel.Record, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT record_id, user_id, org_id
+		FROM records
+		WHERE record_id = $1;
+	`
+	var rec recordmodel.Record
+	if err = tx.GetContext(ctx, &rec, query, recordID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordsNotFound
+		}
+		return nil, fmt.Errorf("failed to get record owners: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &rec, nil
+}
+
+// Есть ли у пользователя хотя бы одна запись в организацию
+func (p *PostgresRepo) OrgClient(ctx context.Context, orgID, userID int) (bool, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM records
+			WHERE org_id = $1
+			AND user_id = $2
+		);
+	`
+	var client bool
+	if err = tx.QueryRowContext(ctx, query, orgID, userID).Scan(&client); err != nil {
+		return false, fmt.Errorf("failed to check org client: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return client, nil
+}
+
 func (p *PostgresRepo) RecordList(ctx context.Context, req *recordmodel.RecordListParams) ([]*recordmodel.RecordScrap, int, error) {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -99,66 +165,72 @@ func (p *PostgresRepo) RecordList(ctx context.Context, req *recordmodel.RecordLi
 		JOIN orgs o ON r.org_id = o.org_id
 		JOIN users u ON r.user_id = u.user_id
 		JOIN services srvc ON r.service_id = srvc.service_id
-		JOIN workers w ON r.worker_id = w.worker_id
+		LEFT JOIN workers w ON r.worker_id = w.worker_id
 		LEFT JOIN feedbacks f ON r.record_id = f.record_id
 		WHERE ($1 <= 0 OR r.user_id = $1)
 		AND ($2 <= 0 OR r.org_id = $2)
-		AND ($3 = true AND date >= CURRENT_DATE) 
-		OR ($3 = false AND date < CURRENT_DATE);
+		AND ($4 <= 0 OR r.worker_id = $4)
+		AND (($3 = true AND s.date >= org_today(r.org_id))
+		OR ($3 = false AND s.date < org_today(r.org_id)));
 	`
 	var found int
-	if err = tx.QueryRowxContext(ctx, query, req.UserID, req.OrgID, req.Fresh).Scan(&found); err != nil {
+	if err = tx.QueryRowxContext(ctx, query, req.UserID, req.OrgID, req.Fresh, req.WorkerID).Scan(&found); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, 0, ErrRecordsNotFound
 		}
 		return nil, 0, fmt.Errorf("failed to retrieve found: %w", err)
 	}
+	// LIMIT NULL - без ограничения
 	query = `
 		SELECT 
 			srvc.name AS service_name, 
 			srvc.cost, 
-			w.first_name AS worker_first_name, 
-			w.last_name AS worker_last_name, 
+			COALESCE(w.first_name, '') AS worker_first_name,
+			COALESCE(w.last_name, '') AS worker_last_name,
 			o.name AS org_name,
 			u.first_name AS user_first_name,
 			u.last_name AS user_last_name, 
 			s.date,
 			s.session_begin,
 			s.session_end,
+			o.timezone,
 			f.stars,
 			f.feedback,
 			r.reviewed,
+			r.attended,
 			r.record_id
 		FROM records r
 		JOIN slots s ON r.slot_id = s.slot_id
 		JOIN orgs o ON r.org_id = o.org_id
 		JOIN users u ON r.user_id = u.user_id
 		JOIN services srvc ON r.service_id = srvc.service_id
-		JOIN workers w ON r.worker_id = w.worker_id
+		LEFT JOIN workers w ON r.worker_id = w.worker_id
 		LEFT JOIN feedbacks f ON r.record_id = f.record_id
 		WHERE ($1 <= 0 OR r.user_id = $1)
 		AND ($2 <= 0 OR r.org_id = $2)
-		AND ($3 = true AND date >= CURRENT_DATE) 
-		OR ($3 = false AND date < CURRENT_DATE)
-		LIMIT $4
+		AND ($6 <= 0 OR r.worker_id = $6)
+		AND (($3 = true AND s.date >= org_today(r.org_id))
+		OR ($3 = false AND s.date < org_today(r.org_id)))
+		ORDER BY s.date, s.session_begin
+		LIMIT NULLIF($4, 0)
 		OFFSET $5;
 	`
 	recs := make([]*recordmodel.RecordScrap, 0, 3)
-	rows, err := tx.QueryContext(ctx, query, req.UserID, req.OrgID, req.Fresh, req.Limit, req.Offset)
-	defer rows.Close()
+	rows, err := tx.QueryContext(ctx, query, req.UserID, req.OrgID, req.Fresh, req.Limit, req.Offset, req.WorkerID)
 	if err != nil {
 		return nil, 0, err
 	}
-	rec := &recordmodel.RecordScrap{
-		Org:      &orgmodel.OrgInfo{},
-		User:     &usermodel.UserInfo{},
-		Slot:     &orgmodel.Slot{},
-		Service:  &orgmodel.Service{},
-		Worker:   &orgmodel.Worker{},
-		Feedback: &recordmodel.Feedback{},
-	}
+	defer rows.Close()
 	for rows.Next() {
-		err := rows.Scan(
+		rec := &recordmodel.RecordScrap{
+			Org:      &orgmodel.OrgInfo{},
+			User:     &usermodel.UserInfo{},
+			Slot:     &orgmodel.Slot{},
+			Service:  &orgmodel.Service{},
+			Worker:   &orgmodel.Worker{},
+			Feedback: &recordmodel.Feedback{},
+		}
+		err = rows.Scan(
 			&rec.Service.Name,
 			&rec.Service.Cost,
 			&rec.Worker.FirstName,
@@ -169,9 +241,11 @@ func (p *PostgresRepo) RecordList(ctx context.Context, req *recordmodel.RecordLi
 			&rec.Slot.Date,
 			&rec.Slot.Begin,
 			&rec.Slot.End,
+			&rec.Slot.TimeZone,
 			&rec.Feedback.Stars,
 			&rec.Feedback.Feedback,
 			&rec.Reviewed,
+			&rec.Attended,
 			&rec.RecordID,
 		)
 		if err != nil {
@@ -179,7 +253,7 @@ func (p *PostgresRepo) RecordList(ctx context.Context, req *recordmodel.RecordLi
 		}
 		recs = append(recs, rec)
 	}
-	if err := rows.Err(); err != nil {
+	if err = rows.Err(); err != nil {
 		return nil, 0, err
 	}
 	if err = tx.Commit(); err != nil {
@@ -188,6 +262,43 @@ func (p *PostgresRepo) RecordList(ctx context.Context, req *recordmodel.RecordLi
 	return recs, found, nil
 }
 
+// Отметка о посещении. Запись должна относиться к сотруднику организации
+func (p *PostgresRepo) RecordAttendance(ctx context.Context, req *recordmodel.Attendance) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE records
+		SET
+			attended = $4
+		WHERE record_id = $1
+		AND org_id = $2
+		AND worker_id = $3;
+	`
+	res, err := tx.ExecContext(ctx, query, req.RecordID, req.OrgID, req.WorkerID, req.Attended)
+	if err != nil {
+		return fmt.Errorf("failed to mark attendance: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrRecordsNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Запись к сотруднику. Без slot_id запись делается на произвольное начало сеанса:
+// под нее создается занятый слот длительностью услуги. Готовый слот должен
+// подходить услуге по длительности и буферам. Без worker_id - запись на услугу
+// без сотрудника, занимающую только ресурсы
 func (p *PostgresRepo) RecordAdd(ctx context.Context, req *recordmodel.Record) (*recordmodel.ReminderRecord, error) {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -198,38 +309,54 @@ func (p *PostgresRepo) RecordAdd(ctx context.Context, req *recordmodel.Record) (
 			tx.Rollback()
 		}
 	}()
+	day := req.Begin
+	params := &orgmodel.AvailabilityParams{
+		OrgID:     req.OrgID,
+		WorkerID:  req.WorkerID,
+		ServiceID: req.ServiceID,
+		Date:      time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC),
+	}
+	if req.SlotID == 0 {
+		if req.SlotID, err = bookSession(ctx, tx, params, req.Begin); err != nil {
+			return nil, err
+		}
+	} else if err = slotFits(ctx, tx, req.SlotID, 0, params); err != nil {
+		return nil, err
+	}
+	if err = takeSeat(ctx, tx, req.SlotID, req.ServiceID); err != nil {
+		return nil, err
+	}
+	// Сотрудник и ресурсы занимаются в одной транзакции: нет ресурса - нет и записи
+	if err = reserveResources(ctx, tx, req.SlotID, req.ServiceID); err != nil {
+		return nil, err
+	}
 	query := `INSERT INTO records
-		(record_id, user_id, org_id, service_id, slot_id, worker_id)
-		VALUES($1, $2, $3, $4, $5, $6, $7)
+		(user_id, org_id, service_id, slot_id, worker_id)
+		VALUES($1, $2, $3, $4, NULLIF($5, 0))
+		RETURNING record_id;
 	`
-	rows, err := tx.ExecContext(
+	if err = tx.QueryRowContext(
 		ctx,
 		query,
-		req.RecordID,
 		req.UserID,
 		req.OrgID,
 		req.ServiceID,
 		req.SlotID,
 		req.WorkerID,
-	)
-	if err != nil {
-		return nil, err
-	}
-	if rows != nil {
-		if rowsAffected, _ := rows.RowsAffected(); rowsAffected == 0 {
-			return nil, fmt.Errorf("no rows inserted")
-		}
+	).Scan(&req.RecordID); err != nil {
+		return nil, fmt.Errorf("failed to add record: %w", err)
 	}
 	query = `
 		SELECT 
 			u.email AS user_email,
 			srvc.name AS service_name,
-			srvc.description AS service_description
+			COALESCE(srvc.description, '') AS service_description,
 			o.name AS org_name,
-			o.address AS org_address
+			o.address AS org_address,
 			s.date,
 			s.session_begin,
-			s.session_end
+			s.session_end,
+			o.timezone
 		FROM records r
 		JOIN slots s ON r.slot_id = s.slot_id
 		JOIN orgs o ON r.org_id = o.org_id
@@ -238,7 +365,17 @@ func (p *PostgresRepo) RecordAdd(ctx context.Context, req *recordmodel.Record) (
 		WHERE record_id = $1;
 	`
 	record := &recordmodel.ReminderRecord{}
-	if err := tx.QueryRowContext(ctx, query, req.RecordID).Scan(record); err != nil {
+	if err = tx.QueryRowContext(ctx, query, req.RecordID).Scan(
+		&record.UserEmail,
+		&record.ServiceName,
+		&record.ServiceDescription,
+		&record.OrgName,
+		&record.OrgAddress,
+		&record.Date,
+		&record.Begin,
+		&record.End,
+		&record.TimeZone,
+	); err != nil {
 		return nil, err
 	}
 	if err = tx.Commit(); err != nil {
@@ -257,7 +394,38 @@ func (p *PostgresRepo) RecordPatch(ctx context.Context, req *recordmodel.Record)
 			tx.Rollback()
 		}
 	}()
-	query := `UPDATE records
+	// Перенос в другой слот переносит и место
+	query := `SELECT slot_id, service_id, COALESCE(org_id, 0), COALESCE(worker_id, 0) FROM records WHERE record_id = $1 FOR UPDATE;`
+	var slotID, serviceID, orgID, workerID int
+	if err = tx.QueryRowContext(ctx, query, req.RecordID).Scan(&slotID, &serviceID, &orgID, &workerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRecordsNotFound
+		}
+		return fmt.Errorf("failed to get record: %w", err)
+	}
+	if req.SlotID != 0 && req.SlotID != slotID {
+		newService := serviceID
+		if req.ServiceID != 0 {
+			newService = req.ServiceID
+		}
+		if req.WorkerID != 0 {
+			workerID = req.WorkerID
+		}
+		params := &orgmodel.AvailabilityParams{OrgID: orgID, WorkerID: workerID, ServiceID: newService}
+		if err = slotFits(ctx, tx, req.SlotID, slotID, params); err != nil {
+			return err
+		}
+		if err = releaseSeat(ctx, tx, slotID, serviceID); err != nil {
+			return err
+		}
+		if err = takeSeat(ctx, tx, req.SlotID, newService); err != nil {
+			return err
+		}
+		if err = reserveResources(ctx, tx, req.SlotID, newService); err != nil {
+			return err
+		}
+	}
+	query = `UPDATE records
 		SET
 			user_id = COALESCE(NULLIF($1, 0), user_id),
 			org_id = COALESCE(NULLIF($2, 0), org_id),
@@ -307,22 +475,20 @@ func (p *PostgresRepo) RecordDelete(ctx context.Context, req *recordmodel.Record
 		IN (SELECT r.record_id
 			FROM records r
 			JOIN slots s ON r.slot_id = s.slot_id AND r.record_id = $1
-			WHERE s.date >= CURRENT_DATE
-			AND CURRENT_TIME < (s.session_begin::time - INTERVAL '2 hours')
-		);
+			WHERE now() < s.session_begin - INTERVAL '2 hours'
+		)
+		RETURNING slot_id, service_id;
 	`
-	rows, err := tx.ExecContext(
-		ctx,
-		query,
-		req.RecordID,
-	)
-	if err != nil {
-		return err
-	}
-	if rows != nil {
-		if rowsAffected, _ := rows.RowsAffected(); rowsAffected == 0 {
+	var slotID, serviceID int
+	if err = tx.QueryRowContext(ctx, query, req.RecordID).Scan(&slotID, &serviceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("no rows inserted") // TODO: везде вот такую строку лучше отдавать, т.к err.Error() бывает пустая в таком случае
 		}
+		return err
+	}
+	// Лишний слот уберет пересчет слотов
+	if err = releaseSeat(ctx, tx, slotID, serviceID); err != nil {
+		return err
 	}
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit tx: %w", err)
@@ -330,6 +496,67 @@ func (p *PostgresRepo) RecordDelete(ctx context.Context, req *recordmodel.Record
 	return nil
 }
 
+// Занимает место в слоте. Строка слота блокируется до проверок, так что параллельные
+// записи идут по очереди и видят уже сделанные: не займут больше мест, чем есть,
+// и не запишут в один слот разные услуги
+func takeSeat(ctx context.Context, tx *sqlx.Tx, slotID, serviceID int) error {
+	query := `SELECT slot_id FROM slots WHERE slot_id = $1 FOR UPDATE;`
+	if err := tx.QueryRowContext(ctx, query, slotID).Scan(&slotID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSlotNotFound
+		}
+		return fmt.Errorf("failed to lock slot: %w", err)
+	}
+	query = `
+		UPDATE slots s
+		SET seats_taken = s.seats_taken + 1,
+			busy = s.seats_taken + 1 >= slot_capacity(s.slot_id, $2)
+		WHERE s.slot_id = $1
+		AND COALESCE(s.busy, false) = false
+		AND s.seats_taken < slot_capacity(s.slot_id, $2)
+		AND NOT EXISTS (
+			SELECT 1 FROM records r
+			WHERE r.slot_id = s.slot_id
+			AND r.service_id <> $2
+		);
+	`
+	res, err := tx.ExecContext(ctx, query, slotID, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to take seat: %w", err)
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return ErrSlotFull
+	}
+	return nil
+}
+
+// Освобождает место в слоте. Заполненный слот открывается, закрытый организацией
+// остается закрытым, пока в нем есть записи. Без записей слот отпускает ресурсы
+func releaseSeat(ctx context.Context, tx *sqlx.Tx, slotID, serviceID int) error {
+	query := `
+		UPDATE slots s
+		SET seats_taken = GREATEST(s.seats_taken - 1, 0),
+			busy = s.busy AND s.seats_taken > 1 AND s.seats_taken < slot_capacity(s.slot_id, $2)
+		WHERE s.slot_id = $1;
+	`
+	if _, err := tx.ExecContext(ctx, query, slotID, serviceID); err != nil {
+		return fmt.Errorf("failed to free slot: %w", err)
+	}
+	// Последняя запись ушла - ресурсы слота свободны
+	query = `
+		DELETE FROM slot_resources sr
+		USING slots s
+		WHERE s.slot_id = sr.slot_id
+		AND s.slot_id = $1
+		AND s.seats_taken = 0;
+	`
+	if _, err := tx.ExecContext(ctx, query, slotID); err != nil {
+		return fmt.Errorf("failed to free slot resources: %w", err)
+	}
+	return nil
+}
+
+// Записи, до начала которых осталось не больше двух часов
 func (p *PostgresRepo) UpcomingRecords(ctx context.Context) ([]*recordmodel.ReminderRecord, error) {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -344,45 +571,45 @@ func (p *PostgresRepo) UpcomingRecords(ctx context.Context) ([]*recordmodel.Remi
 		SELECT 
 			u.email AS user_email,
 			srvc.name AS service_name,
-			srvc.description AS service_description
+			COALESCE(srvc.description, '') AS service_description,
 			o.name AS org_name,
-			o.address AS org_address
+			o.address AS org_address,
 			s.date,
 			s.session_begin,
-			s.session_end
+			s.session_end,
+			o.timezone
 		FROM records r
 		JOIN slots s ON r.slot_id = s.slot_id
 		JOIN orgs o ON r.org_id = o.org_id
 		JOIN users u ON r.user_id = u.user_id
 		JOIN services srvc ON r.service_id = srvc.service_id
-		WHERE s.date = CURRENT_DATE
-		AND CURRENT_TIME >= (session_begin::time - INTERVAL '2 hour')
-		AND CURRENT_TIME < session_begin::time;
+		WHERE s.session_begin > now()
+		AND s.session_begin <= now() + INTERVAL '2 hours';
 	`
-	recs := make([]*recordmodel.ReminderRecord, 0, 2)
 	rows, err := tx.QueryContext(ctx, query)
-	defer rows.Close()
 	if err != nil {
 		return nil, err
 	}
-	rec := &recordmodel.ReminderRecord{}
+	defer rows.Close()
+	recs := make([]*recordmodel.ReminderRecord, 0, 2)
 	for rows.Next() {
-		err := rows.Scan(
-			rec.UserEmail,
-			rec.ServiceName,
-			rec.ServiceDescription,
-			rec.OrgName,
-			rec.OrgAddress,
-			rec.Date,
-			rec.Begin,
-			rec.End,
-		)
-		if err != nil {
+		rec := &recordmodel.ReminderRecord{}
+		if err = rows.Scan(
+			&rec.UserEmail,
+			&rec.ServiceName,
+			&rec.ServiceDescription,
+			&rec.OrgName,
+			&rec.OrgAddress,
+			&rec.Date,
+			&rec.Begin,
+			&rec.End,
+			&rec.TimeZone,
+		); err != nil {
 			return nil, err
 		}
 		recs = append(recs, rec)
 	}
-	if err := rows.Err(); err != nil {
+	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 	if err = tx.Commit(); err != nil {
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func sessionBegin() time.Time {
+	return time.Now().UTC().Truncate(time.Hour).Add(48 * time.Hour)
+}
+
+func TestTakeSeat(t *testing.T) {
+	tests := []struct {
+		name         string
+		serviceSeats int
+		slotSeats    sql.NullInt64
+		// Занятость слота после каждой записи, затем отказ
+		busy []bool
+	}{
+		{"single seat", 1, sql.NullInt64{}, []bool{true}},
+		{"service capacity", 3, sql.NullInt64{}, []bool{false, false, true}},
+		{"slot capacity below service", 3, sql.NullInt64{Int64: 2, Valid: true}, []bool{false, true}},
+		{"slot capacity above service", 1, sql.NullInt64{Int64: 2, Valid: true}, []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tx := testTx(t)
+			f := seed(t, tx)
+			serviceID := seedService(t, tx, f.orgID, tt.serviceSeats, false)
+			slotID := seedSlot(t, tx, f.orgID, f.workerID, sessionBegin(), tt.slotSeats)
+			for i, want := range tt.busy {
+				if err := takeSeat(ctx, tx, slotID, serviceID); err != nil {
+					t.Fatalf("seat %d: %v", i+1, err)
+				}
+				seedRecord(t, tx, f, slotID, f.workerID, serviceID)
+				taken, busy := seats(t, tx, slotID)
+				if taken != i+1 || busy != want {
+					t.Fatalf("seat %d: expected %d/%v, got %d/%v", i+1, i+1, want, taken, busy)
+				}
+			}
+			if err := takeSeat(ctx, tx, slotID, serviceID); !errors.Is(err, ErrSlotFull) {
+				t.Fatalf("expected %v, got %v", ErrSlotFull, err)
+			}
+			if taken, _ := seats(t, tx, slotID); taken != len(tt.busy) {
+				t.Fatalf("expected %d seats, got %d", len(tt.busy), taken)
+			}
+		})
+	}
+}
+
+func TestTakeSeatRejects(t *testing.T) {
+	ctx := context.Background()
+	tx := testTx(t)
+	f := seed(t, tx)
+	serviceID := seedService(t, tx, f.orgID, 3, false)
+	otherID := seedService(t, tx, f.orgID, 3, false)
+
+	// В один слот записываются на одну услугу
+	slotID := seedSlot(t, tx, f.orgID, f.workerID, sessionBegin(), sql.NullInt64{})
+	if err := takeSeat(ctx, tx, slotID, serviceID); err != nil {
+		t.Fatal(err)
+	}
+	seedRecord(t, tx, f, slotID, f.workerID, serviceID)
+	if err := takeSeat(ctx, tx, slotID, otherID); !errors.Is(err, ErrSlotFull) {
+		t.Fatalf("other service: expected %v, got %v", ErrSlotFull, err)
+	}
+
+	// Закрытый организацией слот не принимает записи
+	closedID := seedSlot(t, tx, f.orgID, f.workerID, sessionBegin().Add(time.Hour), sql.NullInt64{})
+	if _, err := tx.Exec(`UPDATE slots SET busy = true WHERE slot_id = $1;`, closedID); err != nil {
+		t.Fatal(err)
+	}
+	if err := takeSeat(ctx, tx, closedID, serviceID); !errors.Is(err, ErrSlotFull) {
+		t.Fatalf("closed slot: expected %v, got %v", ErrSlotFull, err)
+	}
+
+	if err := takeSeat(ctx, tx, -1, serviceID); !errors.Is(err, ErrSlotNotFound) {
+		t.Fatalf("missing slot: expected %v, got %v", ErrSlotNotFound, err)
+	}
+}
+
+func TestReleaseSeat(t *testing.T) {
+	tests := []struct {
+		name  string
+		seats int
+		// Слот закрыт организацией, а не заполнен
+		closed bool
+		taken  int
+		busy   bool
+	}{
+		{"full", 3, false, 2, false},
+		{"below full", 2, false, 1, false},
+		{"last seat", 1, false, 0, false},
+		{"closed with records", 2, true, 1, true},
+		{"closed last seat", 1, true, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tx := testTx(t)
+			f := seed(t, tx)
+			serviceID := seedService(t, tx, f.orgID, 3, false)
+			slotID := seedSlot(t, tx, f.orgID, f.workerID, sessionBegin(), sql.NullInt64{})
+			for i := 0; i < tt.seats; i++ {
+				if err := takeSeat(ctx, tx, slotID, serviceID); err != nil {
+					t.Fatal(err)
+				}
+				seedRecord(t, tx, f, slotID, f.workerID, serviceID)
+			}
+			if tt.closed {
+				if _, err := tx.Exec(`UPDATE slots SET busy = true WHERE slot_id = $1;`, slotID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Отмена удаляет запись и освобождает место
+			query := `DELETE FROM records WHERE record_id = (SELECT MAX(record_id) FROM records WHERE slot_id = $1);`
+			if _, err := tx.Exec(query, slotID); err != nil {
+				t.Fatal(err)
+			}
+			if err := releaseSeat(ctx, tx, slotID, serviceID); err != nil {
+				t.Fatal(err)
+			}
+			if taken, busy := seats(t, tx, slotID); taken != tt.taken || busy != tt.busy {
+				t.Fatalf("expected %d/%v, got %d/%v", tt.taken, tt.busy, taken, busy)
+			}
+			// Освободившееся место снова можно занять
+			if !tt.busy {
+				if err := takeSeat(ctx, tx, slotID, serviceID); err != nil {
+					t.Fatalf("expected free seat, got %v", err)
+				}
+			}
+		})
+	}
+}
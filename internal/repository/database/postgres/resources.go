@@ -0,0 +1,456 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"timeline/internal/repository/models/orgmodel"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrResourceBusy     = errors.New("required resource is not available")
+	ErrResourceOnly     = errors.New("service without a worker needs its own duration and at least one resource")
+)
+
+// Ресурсы организации вместе с часами доступности
+func (p *PostgresRepo) Resources(ctx context.Context, orgID int) ([]*orgmodel.Resource, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT resource_id, org_id, name, description
+		FROM resources
+		WHERE is_delete = false
+		AND org_id = $1
+		ORDER BY resource_id;
+	`
+	list := make([]*orgmodel.Resource, 0, 1)
+	if err = tx.SelectContext(ctx, &list, query, orgID); err != nil {
+		return nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+	query = `
+		SELECT h.resource_id, h.weekday, h.open, h.close
+		FROM resource_hours h
+		JOIN resources r ON r.resource_id = h.resource_id
+		WHERE r.is_delete = false
+		AND r.org_id = $1
+		ORDER BY h.weekday, h.open;
+	`
+	hours := make([]*orgmodel.ResourceHours, 0, len(list))
+	if err = tx.SelectContext(ctx, &hours, query, orgID); err != nil {
+		return nil, fmt.Errorf("failed to get resource hours: %w", err)
+	}
+	byID := make(map[int]*orgmodel.Resource, len(list))
+	for _, v := range list {
+		v.Hours = make([]*orgmodel.ResourceHours, 0)
+		byID[v.ResourceID] = v
+	}
+	for _, v := range hours {
+		if res, ok := byID[v.ResourceID]; ok {
+			res.Hours = append(res.Hours, v)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return list, nil
+}
+
+func (p *PostgresRepo) ResourceAdd(ctx context.Context, resource *orgmodel.Resource) (int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO resources (org_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING resource_id;
+	`
+	if err = tx.QueryRowContext(ctx, query, resource.OrgID, resource.Name, resource.Description).Scan(&resource.ResourceID); err != nil {
+		return 0, fmt.Errorf("failed to add resource: %w", err)
+	}
+	if err = replaceResourceHours(ctx, tx, resource); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return resource.ResourceID, nil
+}
+
+// Обновление ресурса. Часы доступности заменяются целиком
+func (p *PostgresRepo) ResourceUpdate(ctx context.Context, resource *orgmodel.Resource) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE resources
+		SET
+			name = $1,
+			description = $2
+		WHERE is_delete = false
+		AND resource_id = $3
+		AND org_id = $4;
+	`
+	res, err := tx.ExecContext(ctx, query, resource.Name, resource.Description, resource.ResourceID, resource.OrgID)
+	if err != nil {
+		return fmt.Errorf("failed to update resource: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrResourceNotFound
+		return err
+	}
+	if err = replaceResourceHours(ctx, tx, resource); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Удаление ресурса. Услуги перестают его требовать, уже сделанные записи остаются
+func (p *PostgresRepo) ResourceDelete(ctx context.Context, orgID, resourceID int) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE resources
+		SET is_delete = true
+		WHERE is_delete = false
+		AND resource_id = $1
+		AND org_id = $2;
+	`
+	res, err := tx.ExecContext(ctx, query, resourceID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to delete resource: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrResourceNotFound
+		return err
+	}
+	query = `DELETE FROM service_resources WHERE resource_id = $1;`
+	if _, err = tx.ExecContext(ctx, query, resourceID); err != nil {
+		return fmt.Errorf("failed to unlink resource: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+func replaceResourceHours(ctx context.Context, tx *sqlx.Tx, resource *orgmodel.Resource) error {
+	query := `DELETE FROM resource_hours WHERE resource_id = $1;`
+	if _, err := tx.ExecContext(ctx, query, resource.ResourceID); err != nil {
+		return fmt.Errorf("failed to delete resource hours: %w", err)
+	}
+	query = `
+		INSERT INTO resource_hours (resource_id, weekday, open, close)
+		VALUES ($1, $2, $3, $4);
+	`
+	for _, v := range resource.Hours {
+		if _, err := tx.ExecContext(ctx, query, resource.ResourceID, v.Weekday, v.Open, v.Close); err != nil {
+			return fmt.Errorf("failed to add resource hours: %w", err)
+		}
+	}
+	return nil
+}
+
+// Заменяет ресурсы, которые требует услуга. Ресурс другой организации или удаленный не найдется
+func setServiceResources(ctx context.Context, tx *sqlx.Tx, service *orgmodel.Service) error {
+	query := `
+		DELETE FROM service_resources sr
+		USING services s
+		WHERE s.service_id = sr.service_id
+		AND sr.service_id = $1
+		AND s.org_id = $2;
+	`
+	if _, err := tx.ExecContext(ctx, query, service.ServiceID, service.OrgID); err != nil {
+		return fmt.Errorf("failed to delete service resources: %w", err)
+	}
+	query = `
+		INSERT INTO service_resources (service_id, resource_id)
+		SELECT s.service_id, r.resource_id
+		FROM services s
+		JOIN resources r ON r.org_id = s.org_id
+		WHERE s.is_delete = false
+		AND r.is_delete = false
+		AND s.service_id = $1
+		AND r.resource_id = $2
+		AND s.org_id = $3
+		ON CONFLICT DO NOTHING;
+	`
+	for _, id := range service.Resources {
+		res, err := tx.ExecContext(ctx, query, service.ServiceID, id, service.OrgID)
+		if err != nil {
+			return fmt.Errorf("failed to add service resource: %w", err)
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return ErrResourceNotFound
+		}
+	}
+	return nil
+}
+
+// Услуге без сотрудника нужны своя длительность и хотя бы один ресурс
+func resourceOnlyValid(ctx context.Context, tx *sqlx.Tx, serviceID int) error {
+	query := `
+		SELECT NOT s.resource_only OR (
+			s.duration IS NOT NULL
+			AND EXISTS (
+				SELECT 1 FROM service_resources sr
+				JOIN resources r ON r.resource_id = sr.resource_id
+				WHERE r.is_delete = false
+				AND sr.service_id = s.service_id
+			)
+		)
+		FROM services s
+		WHERE s.service_id = $1;
+	`
+	var valid bool
+	if err := tx.QueryRowContext(ctx, query, serviceID).Scan(&valid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrServiceNotFound
+		}
+		return fmt.Errorf("failed to check service resources: %w", err)
+	}
+	if !valid {
+		return ErrResourceOnly
+	}
+	return nil
+}
+
+// Ресурсы, которые требует каждая из услуг
+func serviceResources(ctx context.Context, tx *sqlx.Tx, services ...*orgmodel.Service) error {
+	query := `
+		SELECT sr.resource_id
+		FROM service_resources sr
+		JOIN resources r ON r.resource_id = sr.resource_id
+		WHERE r.is_delete = false
+		AND sr.service_id = $1
+		ORDER BY sr.resource_id;
+	`
+	for _, v := range services {
+		v.Resources = make([]int, 0)
+		if err := tx.SelectContext(ctx, &v.Resources, query, v.ServiceID); err != nil {
+			return fmt.Errorf("failed to get service resources: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ресурс услуги на день: часы доступности и занятое записями время.
+// Hours == nil - ресурс доступен все время работы сотрудника (или организации для услуги без сотрудника)
+type resourceDay struct {
+	ResourceID int
+	Hours      []interval
+	Busy       []*booking
+}
+
+// Свободен ли каждый ресурс на [begin, end) с буферами before и after
+func resourcesFree(resources []*resourceDay, begin, end time.Time, before, after time.Duration) bool {
+	for _, v := range resources {
+		if v.Hours != nil && !within(v.Hours, begin, end) {
+			return false
+		}
+		if collides(v.Busy, begin.Add(-before), end.Add(after)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ресурсы, которые требует услуга, с их доступностью на день params.Date
+func resourceDays(ctx context.Context, tx *sqlx.Tx, params *orgmodel.AvailabilityParams, loc *time.Location) ([]*resourceDay, error) {
+	query := `
+		SELECT sr.resource_id
+		FROM service_resources sr
+		JOIN resources r ON r.resource_id = sr.resource_id
+		WHERE r.is_delete = false
+		AND sr.service_id = $1;
+	`
+	ids := make([]int, 0)
+	if err := tx.SelectContext(ctx, &ids, query, params.ServiceID); err != nil {
+		return nil, fmt.Errorf("failed to get service resources: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	days := make(map[int]*resourceDay, len(ids))
+	list := make([]*resourceDay, 0, len(ids))
+	for _, id := range ids {
+		days[id] = &resourceDay{ResourceID: id}
+		list = append(list, days[id])
+	}
+	query = `
+		SELECT h.resource_id, h.weekday, h.open, h.close
+		FROM resource_hours h
+		JOIN service_resources sr ON sr.resource_id = h.resource_id
+		WHERE sr.service_id = $1;
+	`
+	hours := make([]*orgmodel.ResourceHours, 0)
+	if err := tx.SelectContext(ctx, &hours, query, params.ServiceID); err != nil {
+		return nil, fmt.Errorf("failed to get resource hours: %w", err)
+	}
+	weekday := int(params.Date.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	for _, v := range hours {
+		day, ok := days[v.ResourceID]
+		if !ok {
+			continue
+		}
+		// у ресурса есть часы, но не в этот день - он недоступен
+		if day.Hours == nil {
+			day.Hours = make([]interval, 0, 1)
+		}
+		if v.Weekday == weekday {
+			day.Hours = append(day.Hours, interval{
+				Begin: onDay(params.Date, v.Open, loc),
+				End:   onDay(params.Date, v.Close, loc),
+			})
+		}
+	}
+	query = `
+		SELECT sres.resource_id, s.session_begin, s.session_end,
+			COALESCE(MAX(srvc.buffer_before), 0) AS buffer_before,
+			COALESCE(MAX(srvc.buffer_after), 0) AS buffer_after
+		FROM slot_resources sres
+		JOIN service_resources need ON need.resource_id = sres.resource_id
+		JOIN slots s ON s.slot_id = sres.slot_id
+		LEFT JOIN records r ON r.slot_id = s.slot_id
+		LEFT JOIN services srvc ON srvc.service_id = r.service_id
+		WHERE need.service_id = $1
+		AND s.date = $2::date
+		GROUP BY sres.resource_id, s.slot_id;
+	`
+	busy := make([]*struct {
+		ResourceID int `db:"resource_id"`
+		booking
+	}, 0)
+	if err := tx.SelectContext(ctx, &busy, query, params.ServiceID, params.Date); err != nil {
+		return nil, fmt.Errorf("failed to get resource bookings: %w", err)
+	}
+	for _, v := range busy {
+		if day, ok := days[v.ResourceID]; ok {
+			b := v.booking
+			day.Busy = append(day.Busy, &b)
+		}
+	}
+	return list, nil
+}
+
+// Занимает за слотом ресурсы, которые требует услуга. Строки ресурсов блокируются,
+// так что параллельные записи к разным сотрудникам не займут один ресурс дважды.
+// Ресурсы начатого группового сеанса уже заняты его слотом
+func reserveResources(ctx context.Context, tx *sqlx.Tx, slotID, serviceID int) error {
+	ids, err := lockResources(ctx, tx, serviceID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	// Пересечение с другими слотами, занявшими ресурс, с буферами обеих услуг
+	// и выход за часы доступности ресурса
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM slots s
+			JOIN services srvc ON srvc.service_id = $2
+			JOIN service_resources need ON need.service_id = srvc.service_id
+			JOIN resources res ON res.resource_id = need.resource_id AND res.is_delete = false
+			JOIN slot_resources sres ON sres.resource_id = need.resource_id AND sres.slot_id <> s.slot_id
+			JOIN slots other ON other.slot_id = sres.slot_id
+			LEFT JOIN records r ON r.slot_id = other.slot_id
+			LEFT JOIN services os ON os.service_id = r.service_id
+			WHERE s.slot_id = $1
+			AND other.session_begin - make_interval(mins => COALESCE(os.buffer_before, 0))
+				< s.session_end + make_interval(mins => srvc.buffer_after)
+			AND other.session_end + make_interval(mins => COALESCE(os.buffer_after, 0))
+				> s.session_begin - make_interval(mins => srvc.buffer_before)
+		) OR EXISTS (
+			SELECT 1
+			FROM slots s
+			JOIN orgs o ON o.org_id = s.org_id
+			JOIN service_resources need ON need.service_id = $2
+			JOIN resources res ON res.resource_id = need.resource_id AND res.is_delete = false
+			WHERE s.slot_id = $1
+			AND EXISTS (SELECT 1 FROM resource_hours h WHERE h.resource_id = need.resource_id)
+			AND NOT EXISTS (
+				SELECT 1 FROM resource_hours h
+				WHERE h.resource_id = need.resource_id
+				AND h.weekday = EXTRACT(ISODOW FROM s.date)
+				AND h.open::time <= (s.session_begin AT TIME ZONE o.timezone)::time
+				AND h.close::time >= (s.session_end AT TIME ZONE o.timezone)::time
+			)
+		);
+	`
+	var busy bool
+	if err := tx.QueryRowContext(ctx, query, slotID, serviceID).Scan(&busy); err != nil {
+		return fmt.Errorf("failed to check resources: %w", err)
+	}
+	if busy {
+		return ErrResourceBusy
+	}
+	query = `
+		INSERT INTO slot_resources (slot_id, resource_id)
+		SELECT $1, sr.resource_id
+		FROM service_resources sr
+		JOIN resources r ON r.resource_id = sr.resource_id
+		WHERE r.is_delete = false
+		AND sr.service_id = $2
+		ON CONFLICT DO NOTHING;
+	`
+	if _, err := tx.ExecContext(ctx, query, slotID, serviceID); err != nil {
+		return fmt.Errorf("failed to reserve resources: %w", err)
+	}
+	return nil
+}
+
+// Блокирует ресурсы, которые требует услуга, в одном порядке для всех записей
+func lockResources(ctx context.Context, tx *sqlx.Tx, serviceID int) ([]int, error) {
+	query := `
+		SELECT r.resource_id
+		FROM service_resources sr
+		JOIN resources r ON r.resource_id = sr.resource_id
+		WHERE r.is_delete = false
+		AND sr.service_id = $1
+		ORDER BY r.resource_id
+		FOR UPDATE OF r;
+	`
+	ids := make([]int, 0)
+	if err := tx.SelectContext(ctx, &ids, query, serviceID); err != nil {
+		return nil, fmt.Errorf("failed to lock resources: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,252 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"timeline/internal/repository/models/orgmodel"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestResourcesFree(t *testing.T) {
+	// Ресурс занят другим сотрудником или услугой на 10:00 - 11:00
+	shared := &resourceDay{ResourceID: 1, Busy: []*booking{{Begin: at(t, "10:00"), End: at(t, "11:00")}}}
+	buffered := &resourceDay{ResourceID: 1, Busy: []*booking{{Begin: at(t, "10:00"), End: at(t, "11:00"), BufferAfter: 15}}}
+	tests := []struct {
+		name          string
+		resources     []*resourceDay
+		begin, end    string
+		before, after time.Duration
+		want          bool
+	}{
+		{"no resources", nil, "10:00", "11:00", 0, 0, true},
+		{"any hours", []*resourceDay{{ResourceID: 1}}, "07:00", "08:00", 0, 0, true},
+		{"no hours this day", []*resourceDay{{ResourceID: 1, Hours: []interval{}}}, "10:00", "11:00", 0, 0, false},
+		{"within hours", []*resourceDay{{ResourceID: 1, Hours: []interval{span(t, "09:00", "18:00")}}}, "10:00", "11:00", 0, 0, true},
+		{"outside hours", []*resourceDay{{ResourceID: 1, Hours: []interval{span(t, "09:00", "18:00")}}}, "17:30", "18:30", 0, 0, false},
+		{"reserved", []*resourceDay{shared}, "10:30", "11:30", 0, 0, false},
+		{"adjacent", []*resourceDay{shared}, "11:00", "12:00", 0, 0, true},
+		{"own buffer", []*resourceDay{shared}, "11:00", "12:00", 15 * time.Minute, 0, false},
+		{"other buffer", []*resourceDay{buffered}, "11:00", "12:00", 0, 0, false},
+		{"one of two reserved", []*resourceDay{{ResourceID: 2}, shared}, "10:00", "11:00", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resourcesFree(tt.resources, at(t, tt.begin), at(t, tt.end), tt.before, tt.after); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResourceSessions(t *testing.T) {
+	service := &orgmodel.Service{Duration: 60, Capacity: 2}
+	hours := []interval{span(t, "09:00", "11:00")}
+	before := day.Add(-time.Hour)
+	tests := []struct {
+		name      string
+		resources []*resourceDay
+		joinable  []*orgmodel.Session
+		now       time.Time
+		want      []string
+	}{
+		{
+			name:      "free",
+			resources: []*resourceDay{{ResourceID: 1}},
+			now:       before,
+			want:      []string{"09:00", "09:15", "09:30", "09:45", "10:00"},
+		},
+		{
+			name:      "resource reserved",
+			resources: []*resourceDay{{ResourceID: 1, Busy: []*booking{{Begin: at(t, "09:30"), End: at(t, "10:30")}}}},
+			now:       before,
+			want:      []string{},
+		},
+		{
+			name:      "resource hours",
+			resources: []*resourceDay{{ResourceID: 1, Hours: []interval{span(t, "09:30", "11:00")}}},
+			now:       before,
+			want:      []string{"09:30", "09:45", "10:00"},
+		},
+		{
+			name:      "started group session",
+			resources: []*resourceDay{{ResourceID: 1, Busy: []*booking{{Begin: at(t, "09:00"), End: at(t, "10:00")}}}},
+			joinable:  []*orgmodel.Session{{SlotID: 7, Begin: at(t, "09:00"), End: at(t, "10:00"), SeatsLeft: 1}},
+			now:       before,
+			want:      []string{"09:00", "10:00"},
+		},
+		{
+			name:      "past",
+			resources: []*resourceDay{{ResourceID: 1}},
+			now:       at(t, "09:30"),
+			want:      []string{"09:45", "10:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := resourceSessions(service, hours, tt.resources, tt.joinable, tt.now)
+			got := make([]string, 0, len(sessions))
+			for _, v := range sessions {
+				got = append(got, v.Begin.Format("15:04"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// Ресурс, который требуют услуги
+func seedResource(t *testing.T, tx *sqlx.Tx, orgID int, serviceIDs ...int) int {
+	t.Helper()
+	var resourceID int
+	query := `INSERT INTO resources (org_id, name) VALUES ($1, 'test') RETURNING resource_id;`
+	if err := tx.QueryRowx(query, orgID).Scan(&resourceID); err != nil {
+		t.Fatal(err)
+	}
+	for _, serviceID := range serviceIDs {
+		if _, err := tx.Exec(`INSERT INTO service_resources (service_id, resource_id) VALUES ($1, $2);`, serviceID, resourceID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resourceID
+}
+
+// Организация работает каждый день 08:00 - 20:00 без перерыва
+func seedTimetable(t *testing.T, tx *sqlx.Tx, orgID int) {
+	t.Helper()
+	query := `
+		INSERT INTO timetables (org_id, weekday, open, close, break_start, break_end)
+		SELECT $1, d, '2024-01-01 08:00', '2024-01-01 20:00', '2024-01-01 00:00', '2024-01-01 00:00'
+		FROM generate_series(1, 7) AS d;
+	`
+	if _, err := tx.Exec(query, orgID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReserveResourcesShared(t *testing.T) {
+	ctx := context.Background()
+	tx := testTx(t)
+	f := seed(t, tx)
+	otherWorker := seedWorker(t, tx, f.orgID)
+	serviceID := seedService(t, tx, f.orgID, 1, false)
+	otherService := seedService(t, tx, f.orgID, 1, false)
+	seedResource(t, tx, f.orgID, serviceID, otherService)
+	begin := sessionBegin()
+
+	slotID := seedSlot(t, tx, f.orgID, f.workerID, begin, sql.NullInt64{})
+	if err := takeSeat(ctx, tx, slotID, serviceID); err != nil {
+		t.Fatal(err)
+	}
+	if err := reserveResources(ctx, tx, slotID, serviceID); err != nil {
+		t.Fatal(err)
+	}
+	// Групповой сеанс уже держит ресурс за своим слотом
+	if err := reserveResources(ctx, tx, slotID, serviceID); err != nil {
+		t.Fatalf("same slot: %v", err)
+	}
+	// Другой сотрудник с другой услугой на пересекающееся время
+	crossing := seedSlot(t, tx, f.orgID, otherWorker, begin.Add(30*time.Minute), sql.NullInt64{})
+	if err := reserveResources(ctx, tx, crossing, otherService); !errors.Is(err, ErrResourceBusy) {
+		t.Fatalf("crossing: expected %v, got %v", ErrResourceBusy, err)
+	}
+	adjacent := seedSlot(t, tx, f.orgID, otherWorker, begin.Add(time.Hour), sql.NullInt64{})
+	if err := reserveResources(ctx, tx, adjacent, otherService); err != nil {
+		t.Fatalf("adjacent: %v", err)
+	}
+	// Буфер услуги задевает соседний сеанс на ресурсе
+	if _, err := tx.Exec(`UPDATE services SET buffer_before = 15 WHERE service_id = $1;`, serviceID); err != nil {
+		t.Fatal(err)
+	}
+	buffered := seedSlot(t, tx, f.orgID, f.workerID, begin.Add(2*time.Hour), sql.NullInt64{})
+	if err := reserveResources(ctx, tx, buffered, serviceID); !errors.Is(err, ErrResourceBusy) {
+		t.Fatalf("buffer: expected %v, got %v", ErrResourceBusy, err)
+	}
+	// Отмена последней записи отпускает ресурс
+	if err := releaseSeat(ctx, tx, slotID, serviceID); err != nil {
+		t.Fatal(err)
+	}
+	freed := seedSlot(t, tx, f.orgID, otherWorker, begin, sql.NullInt64{})
+	if err := reserveResources(ctx, tx, freed, otherService); err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+}
+
+func TestResourceOnlyBooking(t *testing.T) {
+	ctx := context.Background()
+	tx := testTx(t)
+	f := seed(t, tx)
+	seedTimetable(t, tx, f.orgID)
+	serviceID := seedService(t, tx, f.orgID, 1, true)
+	otherService := seedService(t, tx, f.orgID, 1, true)
+	seedResource(t, tx, f.orgID, serviceID, otherService)
+	date := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 2)
+	begin := date.Add(10 * time.Hour)
+	params := &orgmodel.AvailabilityParams{OrgID: f.orgID, ServiceID: serviceID, Date: date}
+
+	offered := func(serviceID int, when time.Time) bool {
+		t.Helper()
+		free, err := availability(ctx, tx, &orgmodel.AvailabilityParams{OrgID: f.orgID, ServiceID: serviceID, Date: date})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range free.Sessions {
+			if v.Begin.Equal(when) {
+				return true
+			}
+		}
+		return false
+	}
+	if !offered(serviceID, begin) {
+		t.Fatal("expected free session at 10:00")
+	}
+
+	slotID, err := bookSession(ctx, tx, params, begin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var workerID sql.NullInt64
+	var orgID int
+	if err := tx.QueryRowx(`SELECT worker_id, org_id FROM slots WHERE slot_id = $1;`, slotID).Scan(&workerID, &orgID); err != nil {
+		t.Fatal(err)
+	}
+	if workerID.Valid || orgID != f.orgID {
+		t.Fatalf("expected org slot without worker, got worker %v org %d", workerID, orgID)
+	}
+	if err := takeSeat(ctx, tx, slotID, serviceID); err != nil {
+		t.Fatal(err)
+	}
+	if err := reserveResources(ctx, tx, slotID, serviceID); err != nil {
+		t.Fatal(err)
+	}
+	seedRecord(t, tx, f, slotID, 0, serviceID)
+
+	// Ресурс занят для обеих услуг, которым он нужен
+	for _, id := range []int{serviceID, otherService} {
+		for _, v := range []time.Duration{-45 * time.Minute, 0, 45 * time.Minute} {
+			if offered(id, begin.Add(v)) {
+				t.Fatalf("service %d: expected %v to be taken", id, begin.Add(v))
+			}
+		}
+		if !offered(id, begin.Add(time.Hour)) {
+			t.Fatalf("service %d: expected free session at 11:00", id)
+		}
+	}
+	if _, err := bookSession(ctx, tx, &orgmodel.AvailabilityParams{OrgID: f.orgID, ServiceID: otherService, Date: date}, begin); !errors.Is(err, ErrSessionUnavailable) {
+		t.Fatalf("expected %v, got %v", ErrSessionUnavailable, err)
+	}
+
+	// Готовый слот годится только без сотрудника
+	if err := slotFits(ctx, tx, slotID, 0, params); err != nil {
+		t.Fatalf("org slot: %v", err)
+	}
+	workerSlot := seedSlot(t, tx, f.orgID, f.workerID, begin.Add(3*time.Hour), sql.NullInt64{})
+	if err := slotFits(ctx, tx, workerSlot, 0, params); !errors.Is(err, ErrSlotNotFound) {
+		t.Fatalf("worker slot: expected %v, got %v", ErrSlotNotFound, err)
+	}
+}
@@ -7,15 +7,18 @@ import (
 	"timeline/internal/repository/models/orgmodel"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
 	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrScheduleOverlap  = errors.New("schedule overlaps another working period of the day")
 )
 
 // Получение расписания работника.
 // Если weekday = 0, то получаем расписание на всю неделю
 // Иначе заданный день. (1.Пн...7.Вс)
+// Отдается версия расписания, действующая в metainfo.Date (по умолчанию сегодня)
 func (p *PostgresRepo) WorkerSchedule(ctx context.Context, metainfo *orgmodel.ScheduleParams) (*orgmodel.ScheduleList, error) {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -69,17 +72,19 @@ func (p *PostgresRepo) WorkerSchedule(ctx context.Context, metainfo *orgmodel.Sc
 		return nil, err
 	}
 	// Получение для каждого воркера его расписания
+	// Версия расписания, действующая в заданный день
 	query = `
 		SELECT 
 			worker_schedule_id,
 			weekday, 
 			start, 
-			over
-		FROM worker_schedules
-        WHERE is_delete = false
-		AND worker_id = $1 
-		AND org_id = $2
-		AND ($3 <= 0 OR weekday = $3);
+			over,
+			valid_from,
+			valid_to
+		FROM worker_day_schedule($1, COALESCE($4::date, org_today($2)))
+		WHERE org_id = $2
+		AND ($3 <= 0 OR weekday = $3)
+		ORDER BY weekday, start::time;
 	`
 	fmt.Println(workerList)
 	resp := &orgmodel.ScheduleList{
@@ -96,6 +101,7 @@ func (p *PostgresRepo) WorkerSchedule(ctx context.Context, metainfo *orgmodel.Sc
 			worker.WorkerID,
 			metainfo.OrgID,
 			metainfo.Weekday,
+			metainfo.Date,
 		); err != nil {
 			return nil, err
 		}
@@ -132,17 +138,26 @@ func (p *PostgresRepo) AddWorkerSchedule(ctx context.Context, schedule *orgmodel
 			FROM timetables
 			WHERE weekday = $1 AND org_id = $4
 		)
-		INSERT INTO worker_schedules (weekday, start, over, org_id, worker_id)
-		SELECT $1, $2, $3, $4, $5
+		INSERT INTO worker_schedules (weekday, start, over, org_id, worker_id, valid_from, valid_to)
+		SELECT $1, $2, $3, $4, $5, $8, $9
 		FROM orgschedule
-		WHERE
-			$6::time >= orgschedule.open_time 
+		WHERE EXISTS (
+			SELECT 1
+			FROM workers
+			WHERE is_delete = false
+			AND worker_id = $5
+			AND org_id = $4
+		)
+		AND $6::time >= orgschedule.open_time 
 		AND $6::time <= orgschedule.close_time
 		AND $7::time >= orgschedule.open_time 
 		AND $7::time <= orgschedule.close_time;
 	`
 	for _, v := range schedule.Schedule {
-		rows, err := tx.ExecContext(ctx, query, v.Weekday, v.Start, v.Over, schedule.OrgID, schedule.WorkerID, v.Start, v.Over)
+		if err = scheduleOverlap(ctx, tx, schedule.WorkerID, v); err != nil {
+			return err
+		}
+		rows, err := tx.ExecContext(ctx, query, v.Weekday, v.Start, v.Over, schedule.OrgID, schedule.WorkerID, v.Start, v.Over, v.ValidFrom, v.ValidTo)
 		if err != nil {
 			return err
 		}
@@ -158,9 +173,9 @@ func (p *PostgresRepo) AddWorkerSchedule(ctx context.Context, schedule *orgmodel
 			session_duration = COALESCE(NULLIF($1, 0), session_duration)
 		WHERE is_delete = false
 		AND worker_id = $2
-
+		AND org_id = $3;
 	`
-	rows, err := tx.ExecContext(ctx, query, schedule.SessionDuration, schedule.WorkerID)
+	rows, err := tx.ExecContext(ctx, query, schedule.SessionDuration, schedule.WorkerID, schedule.OrgID)
 	if err != nil {
 		return err
 	}
@@ -175,7 +190,8 @@ func (p *PostgresRepo) AddWorkerSchedule(ctx context.Context, schedule *orgmodel
 	return nil
 }
 
-// Обновление расписания работника на всю неделю
+// Обновление расписания работника на всю неделю.
+// Не переданные даты версии сохраняют прежние значения
 func (p *PostgresRepo) UpdateWorkerSchedule(ctx context.Context, schedule *orgmodel.WorkerSchedule) error {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -198,9 +214,13 @@ func (p *PostgresRepo) UpdateWorkerSchedule(ctx context.Context, schedule *orgmo
 			start = $2, 
 			over = $3, 
 			org_id = $4, 
-			worker_id = $5
+			worker_id = $5,
+			valid_from = COALESCE($9, valid_from),
+			valid_to = COALESCE($10, valid_to)
 		WHERE is_delete = false
 		AND worker_schedule_id = $6
+		AND org_id = $4
+		AND worker_id = $5
 		AND EXISTS (
 			SELECT 1
 			FROM orgschedule 
@@ -215,7 +235,10 @@ func (p *PostgresRepo) UpdateWorkerSchedule(ctx context.Context, schedule *orgmo
 		);
 	`
 	for _, v := range schedule.Schedule {
-		rows, err := tx.ExecContext(ctx, query, v.Weekday, v.Start, v.Over, schedule.OrgID, schedule.WorkerID, v.WorkerScheduleID, v.Start, v.Over)
+		if err = scheduleOverlap(ctx, tx, schedule.WorkerID, v); err != nil {
+			return err
+		}
+		rows, err := tx.ExecContext(ctx, query, v.Weekday, v.Start, v.Over, schedule.OrgID, schedule.WorkerID, v.WorkerScheduleID, v.Start, v.Over, v.ValidFrom, v.ValidTo)
 		if err != nil {
 			return err
 		}
@@ -231,6 +254,37 @@ func (p *PostgresRepo) UpdateWorkerSchedule(ctx context.Context, schedule *orgmo
 	return nil
 }
 
+// Интервал не должен пересекаться с другими интервалами сотрудника
+// в тот же день недели той же версии расписания.
+// При обновлении без valid_from версия берется из самой строки
+func scheduleOverlap(ctx context.Context, tx *sqlx.Tx, workerID int, schedule *orgmodel.Schedule) error {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM worker_schedules
+			WHERE is_delete = false
+			AND worker_id = $1
+			AND weekday = $2
+			AND worker_schedule_id <> $3
+			AND start::time < $5::time
+			AND over::time > $4::time
+			AND COALESCE(valid_from, DATE '-infinity') = COALESCE(
+				$6::date,
+				(SELECT valid_from FROM worker_schedules WHERE worker_schedule_id = $3),
+				DATE '-infinity'
+			)
+		);
+	`
+	var overlap bool
+	if err := tx.QueryRowxContext(ctx, query, workerID, schedule.Weekday, schedule.WorkerScheduleID, schedule.Start, schedule.Over, schedule.ValidFrom).Scan(&overlap); err != nil {
+		return fmt.Errorf("failed to check schedule overlap: %w", err)
+	}
+	if overlap {
+		return ErrScheduleOverlap
+	}
+	return nil
+}
+
 // Если weekday = 0, то удаляется расписание на всю неделю
 // Иначе заданный день. (1.Пн...7.Вс)
 func (p *PostgresRepo) DeleteWorkerSchedule(ctx context.Context, metainfo *orgmodel.ScheduleParams) error {
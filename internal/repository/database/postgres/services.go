@@ -26,8 +26,8 @@ func (p *PostgresRepo) ServiceAdd(ctx context.Context, service *orgmodel.Service
 	}()
 	query := `
 		INSERT INTO services
-		(org_id, name, cost, description)
-		VALUES($1, $2, $3, $4)
+		(org_id, name, cost, description, duration, buffer_before, buffer_after, capacity, resource_only)
+		VALUES($1, $2, $3, $4, NULLIF($5, 0), $6, $7, GREATEST($8, 1), $9)
 		RETURNING service_id;
 	`
 	var serviceID int
@@ -36,9 +36,21 @@ func (p *PostgresRepo) ServiceAdd(ctx context.Context, service *orgmodel.Service
 		service.Name,
 		service.Cost,
 		service.Description,
+		service.Duration,
+		service.BufferBefore,
+		service.BufferAfter,
+		service.Capacity,
+		service.ResourceOnly,
 	).Scan(&serviceID); err != nil {
 		return 0, err
 	}
+	service.ServiceID = serviceID
+	if err = setServiceResources(ctx, tx, service); err != nil {
+		return 0, err
+	}
+	if err = resourceOnlyValid(ctx, tx, serviceID); err != nil {
+		return 0, err
+	}
 	if tx.Commit() != nil {
 		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -61,20 +73,38 @@ func (p *PostgresRepo) ServiceUpdate(ctx context.Context, service *orgmodel.Serv
 		SET
 			name = $1,
 			cost = $2,
-			description = $3
+			description = $3,
+			duration = NULLIF($4, 0),
+			buffer_before = $5,
+			buffer_after = $6,
+			capacity = GREATEST($9, 1),
+			resource_only = $10
 		WHERE is_delete = false
-		AND service_id = $4 
-		AND org_id = $5;
+		AND service_id = $7 
+		AND org_id = $8;
 	`
 	if err = tx.QueryRowContext(ctx, query,
 		service.Name,
 		service.Cost,
 		service.Description,
+		service.Duration,
+		service.BufferBefore,
+		service.BufferAfter,
 		service.ServiceID,
 		service.OrgID,
+		service.Capacity,
+		service.ResourceOnly,
 	).Err(); err != nil {
 		return err
 	}
+	if service.Resources != nil {
+		if err = setServiceResources(ctx, tx, service); err != nil {
+			return err
+		}
+	}
+	if err = resourceOnlyValid(ctx, tx, service.ServiceID); err != nil {
+		return err
+	}
 	if tx.Commit() != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -92,7 +122,8 @@ func (p *PostgresRepo) Service(ctx context.Context, ServiceID, OrgID int) (*orgm
 		}
 	}()
 	query := `
-		SELECT service_id, org_id, name, cost, description
+		SELECT service_id, org_id, name, cost, description,
+			COALESCE(duration, 0) AS duration, buffer_before, buffer_after, capacity, resource_only
 		FROM services
 		WHERE is_delete = false 
 		AND service_id = $1
@@ -102,6 +133,9 @@ func (p *PostgresRepo) Service(ctx context.Context, ServiceID, OrgID int) (*orgm
 	if err = tx.GetContext(ctx, &Service, query, &ServiceID, &OrgID); err != nil {
 		return nil, err
 	}
+	if err = serviceResources(ctx, tx, &Service); err != nil {
+		return nil, err
+	}
 	if tx.Commit() != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -133,7 +167,8 @@ func (p *PostgresRepo) ServiceList(ctx context.Context, OrgID int, Limit int, Of
 		return nil, 0, fmt.Errorf("failed to get org's service list: %w", err)
 	}
 	query = `
-		SELECT service_id, org_id, name, cost, description
+		SELECT service_id, org_id, name, cost, description,
+			COALESCE(duration, 0) AS duration, buffer_before, buffer_after, capacity, resource_only
 		FROM services
 		WHERE is_delete = false 
 		AND org_id = $1
@@ -144,6 +179,9 @@ func (p *PostgresRepo) ServiceList(ctx context.Context, OrgID int, Limit int, Of
 	if err = tx.SelectContext(ctx, &Services, query, &OrgID, Limit, Offset); err != nil {
 		return nil, 0, err
 	}
+	if err = serviceResources(ctx, tx, Services...); err != nil {
+		return nil, 0, err
+	}
 	if tx.Commit() != nil {
 		return nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
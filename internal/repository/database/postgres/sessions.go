@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timeline/internal/repository/models"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionReused   = errors.New("refresh token already used")
+)
+
+// Сохраняет выданный refresh токен
+func (p *PostgresRepo) SessionSave(ctx context.Context, session *models.Session) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO sessions (jti, family, subject_id, is_org, role, user_agent, ip, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+	`
+	if _, err = tx.ExecContext(ctx, query,
+		session.JTI,
+		session.Family,
+		session.SubjectID,
+		session.IsOrg,
+		session.Role,
+		session.UserAgent,
+		session.IP,
+		session.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresRepo) SessionByJTI(ctx context.Context, jti string) (*models.Session, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT session_id, jti, family, subject_id, is_org, role, user_agent, ip, started_at, last_seen, expires_at, rotated, revoked
+		FROM sessions
+		WHERE jti = $1;
+	`
+	var session models.Session
+	if err = tx.GetContext(ctx, &session, query, jti); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &session, nil
+}
+
+// Помечает старый refresh токен использованным и сохраняет новый в ту же сессию.
+// Если старый токен уже использован или отозван - ErrSessionReused
+func (p *PostgresRepo) SessionRotate(ctx context.Context, oldJTI string, new *models.Session) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE sessions
+		SET
+			rotated = true
+		WHERE jti = $1
+		AND rotated = false
+		AND revoked = false
+		RETURNING family, started_at;
+	`
+	if err = tx.QueryRowxContext(ctx, query, oldJTI).Scan(&new.Family, &new.StartedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionReused
+		}
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+	query = `
+		INSERT INTO sessions (jti, family, subject_id, is_org, role, user_agent, ip, started_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`
+	if _, err = tx.ExecContext(ctx, query,
+		new.JTI,
+		new.Family,
+		new.SubjectID,
+		new.IsOrg,
+		new.Role,
+		new.UserAgent,
+		new.IP,
+		new.StartedAt,
+		new.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("failed to save rotated session: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Отзывает все токены сессии
+func (p *PostgresRepo) SessionRevokeFamily(ctx context.Context, family string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE sessions
+		SET
+			revoked = true
+		WHERE family = $1;
+	`
+	if _, err = tx.ExecContext(ctx, query, family); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Отзывает все сессии субъекта, кроме keepFamily (если задана)
+func (p *PostgresRepo) SessionRevokeAll(ctx context.Context, subjectID int, isOrg bool, role, keepFamily string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE sessions
+		SET
+			revoked = true
+		WHERE revoked = false
+		AND subject_id = $1
+		AND is_org = $2
+		AND role = $3
+		AND family <> $4;
+	`
+	if _, err = tx.ExecContext(ctx, query, subjectID, isOrg, role, keepFamily); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Активные сессии: по одному действующему refresh токену на сессию
+func (p *PostgresRepo) SessionList(ctx context.Context, subjectID int, isOrg bool, role string) ([]*models.Session, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT session_id, jti, family, subject_id, is_org, role, user_agent, ip, started_at, last_seen, expires_at, rotated, revoked
+		FROM sessions
+		WHERE rotated = false
+		AND revoked = false
+		AND expires_at > CURRENT_TIMESTAMP
+		AND subject_id = $1
+		AND is_org = $2
+		AND role = $3
+		ORDER BY last_seen DESC;
+	`
+	sessions := make([]*models.Session, 0, 1)
+	if err = tx.SelectContext(ctx, &sessions, query, subjectID, isOrg, role); err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return sessions, nil
+}
+
+// [CRON]: удаление стухших и отозванных сессий
+func (p *PostgresRepo) DeleteExpiredSessions(ctx context.Context) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE FROM sessions
+		WHERE family IN (
+			SELECT family
+			FROM sessions
+			GROUP BY family
+			HAVING MAX(expires_at) <= CURRENT_TIMESTAMP
+		);
+	`
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
@@ -2,81 +2,626 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 	"timeline/internal/libs/custom"
 	"timeline/internal/repository/models/orgmodel"
+
+	"github.com/jmoiron/sqlx"
 )
 
+var ErrSlotNotFound = errors.New("slot not found")
+
 // [CRON]:
-// Генерирует свободные слоты на след неделю относительно текущего дня.
-func (p *PostgresRepo) GenerateSlots(ctx context.Context) error {
+// Генерирует свободные слоты от текущего дня на горизонт организации.
+// Повторный запуск безопасен: уже созданные сеансы пропускаются по (worker_id, session_begin).
+// Пустые поля params - по всем организациям и сотрудникам. Возвращает число новых слотов
+func (p *PostgresRepo) GenerateSlots(ctx context.Context, params *orgmodel.SlotsMeta) (int, error) {
 	tx, err := p.db.Beginx()
 	if err != nil {
-		return fmt.Errorf("failed to start tx: %w", err)
+		return 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if params.WorkerID > 0 {
+		query := `
+			SELECT EXISTS (
+				SELECT 1 FROM workers
+				WHERE is_delete = false
+				AND worker_id = $1
+				AND ($2 <= 0 OR org_id = $2)
+			);
+		`
+		var found bool
+		if err = tx.QueryRowContext(ctx, query, params.WorkerID, params.OrgID).Scan(&found); err != nil {
+			return 0, fmt.Errorf("failed to check worker: %w", err)
+		}
+		if !found {
+			err = ErrWorkerNotFound
+			return 0, err
+		}
+	}
+	plan, _, err := planSlots(ctx, tx, params)
+	if err != nil {
+		return 0, err
+	}
+	created, err := insertSlots(ctx, tx, plan)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return created, nil
+}
+
+// Приводит будущие слоты в пределах горизонта к текущему расписанию:
+// свободные слоты, которые больше не подходят, удаляются, недостающие создаются.
+// Занятые слоты не трогаются и возвращаются как конфликты
+func (p *PostgresRepo) ReconcileSlots(ctx context.Context, params *orgmodel.SlotsMeta) ([]*orgmodel.SlotConflict, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
 	}
 	defer func() {
 		if err != nil {
 			tx.Rollback()
 		}
 	}()
+	plan, days, err := planSlots(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := diffSlots(ctx, tx, params, plan, days)
+	if err != nil {
+		return nil, err
+	}
 	query := `
-		SELECT ws.worker_schedule_id, ws.worker_id, ws.org_id, ws.weekday, ws.start, ws.over, w.session_duration, t.break_start, t.break_end
-		FROM worker_schedules ws
-		JOIN workers w ON w.worker_id = ws.worker_id
-		JOIN timetables t ON t.org_id = ws.org_id AND ws.weekday = t.weekday
+		DELETE FROM slots s
+		WHERE s.slot_id = $1
+		AND COALESCE(s.busy, false) = false
+		AND NOT EXISTS (SELECT 1 FROM records r WHERE r.slot_id = s.slot_id);
+	`
+	for _, slotID := range diff.stale {
+		if _, err = tx.ExecContext(ctx, query, slotID); err != nil {
+			return nil, fmt.Errorf("failed to delete slot: %w", err)
+		}
+	}
+	query = `
+		UPDATE slots
+		SET worker_schedule_id = NULLIF($1, 0)
+		WHERE slot_id = $2;
+	`
+	for slotID, scheduleID := range diff.moved {
+		if _, err = tx.ExecContext(ctx, query, scheduleID, slotID); err != nil {
+			return nil, fmt.Errorf("failed to update slot: %w", err)
+		}
+	}
+	if _, err = insertSlots(ctx, tx, diff.missing); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return diff.conflicts, nil
+}
+
+// Занятые слоты, которые не попадают в текущее расписание. Ничего не меняет
+func (p *PostgresRepo) SlotConflicts(ctx context.Context, params *orgmodel.SlotsMeta) ([]*orgmodel.SlotConflict, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	plan, days, err := planSlots(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := diffSlots(ctx, tx, params, plan, days)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return diff.conflicts, nil
+}
+
+// Сеанс, положенный сотруднику по расписанию
+type plannedSlot struct {
+	WorkerScheduleID int
+	WorkerID         int
+	Date             time.Time
+	Begin            time.Time
+	End              time.Time
+}
+
+// Полуоткрытый интервал времени [Begin, End)
+type interval struct {
+	Begin time.Time
+	End   time.Time
+}
+
+func (i interval) overlaps(begin, end time.Time) bool {
+	return begin.Before(i.End) && end.After(i.Begin)
+}
+
+// Рабочий день сотрудника: часы приема в пределах часов организации
+// и интервалы внутри них, свободные от перерыва и отсутствий
+type workDay struct {
+	WorkerScheduleID int
+	WorkerID         int
+	SessionDuration  int
+	Date             time.Time
+	Start            time.Time
+	Over             time.Time
+	Intervals        []interval
+}
+
+// Занятое записью время сотрудника с буферами услуги
+type booking struct {
+	SlotID       int       `db:"slot_id"`
+	Seats        int       `db:"seats"`
+	WorkerID     int       `db:"worker_id"`
+	Begin        time.Time `db:"session_begin"`
+	End          time.Time `db:"session_end"`
+	BufferBefore int       `db:"buffer_before"`
+	BufferAfter  int       `db:"buffer_after"`
+}
+
+// Время, которое запись не дает занять другим: сеанс вместе с буферами
+func (b *booking) span() interval {
+	return interval{
+		Begin: b.Begin.Add(-time.Duration(b.BufferBefore) * time.Minute),
+		End:   b.End.Add(time.Duration(b.BufferAfter) * time.Minute),
+	}
+}
+
+// Сеансы сотрудников по расписанию на горизонт организации вместе с рабочими днями, из которых они нарезаны
+func planSlots(ctx context.Context, tx *sqlx.Tx, params *orgmodel.SlotsMeta) ([]*plannedSlot, []*workDay, error) {
+	days, err := workDays(ctx, tx, params, sql.NullTime{})
+	if err != nil {
+		return nil, nil, err
+	}
+	booked, err := bookings(ctx, tx, params, sql.NullTime{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return cutSlots(days, booked), days, nil
+}
+
+// Нарезает рабочие дни на сеансы длительностью сеанса сотрудника
+func cutSlots(days []*workDay, booked map[int][]*booking) []*plannedSlot {
+	plan := make([]*plannedSlot, 0, len(days))
+	for _, v := range days {
+		if v.SessionDuration <= 0 {
+			continue
+		}
+		session := time.Duration(v.SessionDuration) * time.Minute
+		for begin := v.Start; !begin.Add(session).After(v.Over); begin = begin.Add(session) {
+			end := begin.Add(session)
+			// Сеанс, задевающий перерыв или отсутствие, не создается
+			if !within(v.Intervals, begin, end) {
+				continue
+			}
+			// Как и сеанс поверх записи, сделанной на произвольное время
+			if overlapsBooking(booked[v.WorkerID], begin, end) {
+				continue
+			}
+			plan = append(plan, &plannedSlot{
+				WorkerScheduleID: v.WorkerScheduleID,
+				WorkerID:         v.WorkerID,
+				Date:             v.Date,
+				Begin:            begin,
+				End:              end,
+			})
+		}
+	}
+	return plan
+}
+
+// Рабочие дни сотрудников на горизонт организации, либо только на day, если он задан.
+// На каждый день берется действующая в него версия расписания сотрудника,
+// а в особый рабочий день у вышедших в него сотрудников - часы этого дня.
+// Часы организации берутся с учетом особых дней: в выходной строки нет.
+// Дни и часы - настенное время организации, границы интервалов - моменты в ее поясе
+func workDays(ctx context.Context, tx *sqlx.Tx, params *orgmodel.SlotsMeta, day sql.NullTime) ([]*workDay, error) {
+	query := `
+		SELECT COALESCE(ws.worker_schedule_id, 0) AS worker_schedule_id, w.worker_id, ws.org_id,
+			d.day::date AS day, ws.start, ws.over, w.session_duration, o.timezone, t.open, t.close
+		FROM workers w
+		JOIN orgs o ON o.org_id = w.org_id
+		CROSS JOIN LATERAL generate_series(
+			COALESCE($3::date, org_today(o.org_id)),
+			COALESCE($3::date, org_today(o.org_id) + o.slot_horizon),
+			INTERVAL '1 day'
+		) AS d(day)
+		JOIN LATERAL worker_day_shifts(w.worker_id, d.day::date) ws ON ws.org_id = o.org_id
+		JOIN LATERAL org_day_hours(ws.org_id, d.day::date) t ON true
 		WHERE w.is_delete = false
-		AND ws.is_delete = false;
+		AND o.is_delete = false
+		AND d.day >= org_today(o.org_id)
+		AND d.day <= org_today(o.org_id) + o.slot_horizon
+		AND ($1 <= 0 OR w.org_id = $1)
+		AND ($2 <= 0 OR w.worker_id = $2);
+	`
+	rows := make([]*scheduleDay, 0, 10)
+	if err := tx.SelectContext(ctx, &rows, query, params.OrgID, params.WorkerID, day); err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+	// Перерывы организаций по дням, тоже с учетом особых дней
+	query = `
+		SELECT o.org_id, d.day::date AS day, b.break_start, b.break_end
+		FROM orgs o
+		CROSS JOIN LATERAL generate_series(
+			COALESCE($2::date, org_today(o.org_id)),
+			COALESCE($2::date, org_today(o.org_id) + o.slot_horizon),
+			INTERVAL '1 day'
+		) AS d(day)
+		JOIN LATERAL org_day_breaks(o.org_id, d.day::date) b ON true
+		WHERE o.is_delete = false
+		AND ($1 <= 0 OR o.org_id = $1);
 	`
-	workers := make([]*struct {
-		WorkerScheduleID int       `db:"worker_schedule_id"`
-		WorkerID         int       `db:"worker_id"`
-		OrgID            int       `db:"org_id"`
-		Weekday          int       `db:"weekday"`
-		Start            time.Time `db:"start"`
-		Over             time.Time `db:"over"`
-		SessionDuration  int       `db:"session_duration"`
-		BreakStart       time.Time `db:"break_start"`
-		BreakEnd         time.Time `db:"break_end"`
+	breakRows := make([]*struct {
+		OrgID int       `db:"org_id"`
+		Day   time.Time `db:"day"`
+		Start time.Time `db:"break_start"`
+		End   time.Time `db:"break_end"`
 	}, 0, 10)
-	if err := tx.SelectContext(ctx, &workers, query); err != nil {
-		return err
+	if err := tx.SelectContext(ctx, &breakRows, query, params.OrgID, day); err != nil {
+		return nil, fmt.Errorf("failed to get breaks: %w", err)
+	}
+	type orgDay struct {
+		OrgID int
+		Day   string
 	}
-	// формула для вычисления будущей даты:
-	// new_date = curr_date + should_be_added
-	// should_be_added = (7-curr_weekday) + given_weekday + (curr_weekday-1)*7
-	// e.g: curr_weekday = 1 (Monday), given_weekday = 3 (Wednesday) =>
-	// 		=> should_be_add = (7-1)+3+(1-1)*7 = 9 => 1 + 9 = 10.
-	//		день на след неделе: 10%7 = 3 - (Wednesday) - OK
+	breaks := make(map[orgDay][]interval, len(breakRows))
+	for _, v := range breakRows {
+		key := orgDay{OrgID: v.OrgID, Day: v.Day.Format(time.DateOnly)}
+		breaks[key] = append(breaks[key], interval{Begin: v.Start, End: v.End})
+	}
+	// Одобренные отсутствия сотрудников: в них сотрудник не принимает
 	query = `
+		SELECT t.worker_id, t.date_from, t.date_to, t.time_from, t.time_to
+		FROM worker_timeoff t
+		WHERE t.status = 'approved'
+		AND t.date_to >= org_today(t.org_id)
+		AND ($1 <= 0 OR t.org_id = $1)
+		AND ($2 <= 0 OR t.worker_id = $2);
+	`
+	offs := make([]*orgmodel.TimeOff, 0, 1)
+	if err := tx.SelectContext(ctx, &offs, query, params.OrgID, params.WorkerID); err != nil {
+		return nil, fmt.Errorf("failed to get time off: %w", err)
+	}
+	away := make(map[int][]*orgmodel.TimeOff, len(offs))
+	for _, v := range offs {
+		away[v.WorkerID] = append(away[v.WorkerID], v)
+	}
+	days := make([]*workDay, 0, len(rows))
+	for _, v := range rows {
+		key := orgDay{OrgID: v.OrgID, Day: v.Day.Format(time.DateOnly)}
+		days = append(days, buildWorkDay(v, breaks[key], away[v.WorkerID]))
+	}
+	return days, nil
+}
+
+// Часы приема сотрудника в день расписания вместе с часами организации в этот день
+type scheduleDay struct {
+	WorkerScheduleID int       `db:"worker_schedule_id"`
+	WorkerID         int       `db:"worker_id"`
+	OrgID            int       `db:"org_id"`
+	Day              time.Time `db:"day"`
+	Start            time.Time `db:"start"`
+	Over             time.Time `db:"over"`
+	SessionDuration  int       `db:"session_duration"`
+	TimeZone         string    `db:"timezone"`
+	Open             time.Time `db:"open"`
+	Close            time.Time `db:"close"`
+}
+
+// Рабочий день по часам приема: в пределах часов организации,
+// без ее перерывов в этот день и одобренных отсутствий сотрудника
+func buildWorkDay(v *scheduleDay, breaks []interval, offs []*orgmodel.TimeOff) *workDay {
+	loc := custom.Location(v.TimeZone)
+	start, over := onDay(v.Day, v.Start, loc), onDay(v.Day, v.Over, loc)
+	// Сотрудник не принимает, пока организация закрыта
+	if open := onDay(v.Day, v.Open, loc); start.Before(open) {
+		start = open
+	}
+	if closed := onDay(v.Day, v.Close, loc); over.After(closed) {
+		over = closed
+	}
+	intervals := make([]interval, 0, 2)
+	if start.Before(over) {
+		intervals = append(intervals, interval{Begin: start, End: over})
+	}
+	for _, b := range breaks {
+		intervals = subtract(intervals, onDay(v.Day, b.Begin, loc), onDay(v.Day, b.End, loc))
+	}
+	for _, off := range offs {
+		if v.Day.Before(off.DateFrom) || v.Day.After(off.DateTo) {
+			continue
+		}
+		if !off.TimeFrom.Valid {
+			intervals = nil
+			break
+		}
+		intervals = subtract(intervals, onDay(v.Day, off.TimeFrom.Time, loc), onDay(v.Day, off.TimeTo.Time, loc))
+	}
+	return &workDay{
+		WorkerScheduleID: v.WorkerScheduleID,
+		WorkerID:         v.WorkerID,
+		SessionDuration:  v.SessionDuration,
+		Date:             v.Day,
+		Start:            start,
+		Over:             over,
+		Intervals:        intervals,
+	}
+}
+
+// Будущие записи сотрудников по слотам, либо только на day, если он задан.
+// Буферы берутся из услуги записи
+func bookings(ctx context.Context, tx *sqlx.Tx, params *orgmodel.SlotsMeta, day sql.NullTime) (map[int][]*booking, error) {
+	query := `
+		SELECT s.slot_id, COUNT(r.record_id) AS seats,
+			s.worker_id, s.session_begin, s.session_end,
+			COALESCE(MAX(srvc.buffer_before), 0) AS buffer_before,
+			COALESCE(MAX(srvc.buffer_after), 0) AS buffer_after
+		FROM slots s
+		JOIN workers w ON w.worker_id = s.worker_id
+		LEFT JOIN records r ON r.slot_id = s.slot_id
+		LEFT JOIN services srvc ON srvc.service_id = r.service_id
+		WHERE (s.busy = true OR r.record_id IS NOT NULL)
+		AND s.date >= org_today(w.org_id)
+		AND ($3::date IS NULL OR s.date = $3::date)
+		AND ($1 <= 0 OR w.org_id = $1)
+		AND ($2 <= 0 OR s.worker_id = $2)
+		GROUP BY s.slot_id;
+	`
+	list := make([]*booking, 0, 1)
+	if err := tx.SelectContext(ctx, &list, query, params.OrgID, params.WorkerID, day); err != nil {
+		return nil, fmt.Errorf("failed to get bookings: %w", err)
+	}
+	booked := make(map[int][]*booking, len(list))
+	for _, v := range list {
+		booked[v.WorkerID] = append(booked[v.WorkerID], v)
+	}
+	return booked, nil
+}
+
+// Вычитает [begin, end) из интервалов
+func subtract(list []interval, begin, end time.Time) []interval {
+	res := make([]interval, 0, len(list)+1)
+	for _, v := range list {
+		if !v.overlaps(begin, end) {
+			res = append(res, v)
+			continue
+		}
+		if v.Begin.Before(begin) {
+			res = append(res, interval{Begin: v.Begin, End: begin})
+		}
+		if v.End.After(end) {
+			res = append(res, interval{Begin: end, End: v.End})
+		}
+	}
+	return res
+}
+
+// Целиком ли [begin, end) лежит в одном из интервалов
+func within(list []interval, begin, end time.Time) bool {
+	for _, v := range list {
+		if !begin.Before(v.Begin) && !end.After(v.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// Задевает ли сеанс [begin, end) чужую запись вместе с ее буферами.
+// Запись на ровно этот сеанс - это он сам
+func overlapsBooking(booked []*booking, begin, end time.Time) bool {
+	for _, v := range booked {
+		if v.Begin.Equal(begin) && v.End.Equal(end) {
+			continue
+		}
+		if v.span().overlaps(begin, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Создает свободные слоты, пропуская уже существующие сеансы. Возвращает число новых
+func insertSlots(ctx context.Context, tx *sqlx.Tx, plan []*plannedSlot) (int, error) {
+	query := `
 		INSERT INTO slots
-		(date, session_begin, session_end, busy, worker_schedule_id, worker_id)
-		VALUES
-		(CURRENT_DATE + (7 - EXTRACT(ISODOW FROM CURRENT_DATE)) + $1 + ((EXTRACT(ISODOW FROM CURRENT_DATE)-1) * 7), 
-		$2, 
-		$3, 
-		$4, 
-		$5, 
-		$6);
+		(date, session_begin, session_end, busy, worker_schedule_id, worker_id, org_id)
+		SELECT $1, $2, $3, false, NULLIF($4, 0), w.worker_id, w.org_id
+		FROM workers w
+		WHERE w.worker_id = $5
+		ON CONFLICT (worker_id, session_begin) DO NOTHING;
 	`
-	busy := false
-	for _, v := range workers {
-		periods := int(v.Over.Sub(v.Start) / (time.Duration(v.SessionDuration) * time.Minute))
-		for i := 0; i < periods; i++ {
-			begin := v.Start.Add(time.Duration(i) * time.Duration(v.SessionDuration) * time.Minute) // begin := start + i*session_duration. e.g: 12:00 + 0*60 = 12:00
-			end := v.Start.Add(time.Duration(i+1) * time.Duration(v.SessionDuration) * time.Minute) // end := start + (i+1)*session_duration. e.g: 12:00 + 1*60 = 13:00
-			// Если время начала сеанса лежит в перерыве слот не создается
-			if custom.CompareTime(begin, v.BreakStart) >= 0 && custom.CompareTime(begin, v.BreakStart) <= 0 {
-				continue
+	created := 0
+	for _, v := range plan {
+		res, err := tx.ExecContext(ctx, query, v.Date, v.Begin, v.End, v.WorkerScheduleID, v.WorkerID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert slot: %w", err)
+		}
+		if rows, _ := res.RowsAffected(); rows > 0 {
+			created++
+		}
+	}
+	return created, nil
+}
+
+type slotKey struct {
+	workerID   int
+	begin, end int64
+}
+
+type slotsDiff struct {
+	stale     []int          // свободные слоты вне расписания
+	moved     map[int]int    // slot_id -> новый worker_schedule_id
+	missing   []*plannedSlot // сеансы, которых еще нет
+	conflicts []*orgmodel.SlotConflict
+}
+
+// Сравнивает существующие будущие слоты в пределах горизонта с планом
+func diffSlots(ctx context.Context, tx *sqlx.Tx, params *orgmodel.SlotsMeta, plan []*plannedSlot, days []*workDay) (*slotsDiff, error) {
+	query := `
+		SELECT s.slot_id, s.worker_schedule_id, s.worker_id, s.date, s.session_begin, s.session_end,
+			COALESCE(s.busy, false) AS busy, r.record_id, r.user_id, o.timezone
+		FROM slots s
+		JOIN workers w ON w.worker_id = s.worker_id
+		JOIN orgs o ON o.org_id = w.org_id
+		LEFT JOIN records r ON r.slot_id = s.slot_id
+		WHERE s.date >= org_today(o.org_id)
+		AND s.date <= org_today(o.org_id) + o.slot_horizon
+		AND ($1 <= 0 OR w.org_id = $1)
+		AND ($2 <= 0 OR s.worker_id = $2)
+		ORDER BY s.date, s.session_begin;
+	`
+	existing := make([]*existingSlot, 0, len(plan))
+	if err := tx.SelectContext(ctx, &existing, query, params.OrgID, params.WorkerID); err != nil {
+		return nil, fmt.Errorf("failed to get slots: %w", err)
+	}
+	return compareSlots(existing, plan, days), nil
+}
+
+// Существующий слот вместе с записью в него
+type existingSlot struct {
+	orgmodel.SlotConflict
+	WorkerScheduleID sql.NullInt64 `db:"worker_schedule_id"`
+	Busy             bool          `db:"busy"`
+}
+
+// Раскладывает существующие слоты по плану: что удалить, что перепривязать,
+// что создать и какие записи больше не укладываются в рабочее время
+func compareSlots(existing []*existingSlot, plan []*plannedSlot, days []*workDay) *slotsDiff {
+	planned := make(map[slotKey]*plannedSlot, len(plan))
+	for _, v := range plan {
+		planned[slotKey{v.WorkerID, v.Begin.Unix(), v.End.Unix()}] = v
+	}
+	diff := &slotsDiff{
+		stale:     make([]int, 0),
+		moved:     make(map[int]int),
+		missing:   make([]*plannedSlot, 0),
+		conflicts: make([]*orgmodel.SlotConflict, 0),
+	}
+	found := make(map[slotKey]bool, len(existing))
+	for _, v := range existing {
+		key := slotKey{v.WorkerID, v.Begin.Unix(), v.End.Unix()}
+		if slot, ok := planned[key]; ok {
+			found[key] = true
+			if int64(slot.WorkerScheduleID) != v.WorkerScheduleID.Int64 {
+				diff.moved[v.SlotID] = slot.WorkerScheduleID
 			}
-			_, err = tx.ExecContext(ctx, query, v.Weekday, begin.UTC(), end.UTC(), busy, v.WorkerScheduleID, v.WorkerID)
-			if err != nil {
-				return err
+			continue
+		}
+		if v.Busy || v.RecordID.Valid {
+			// Запись на произвольное время остается в силе, пока укладывается в рабочее время
+			if day := dayOf(days, v.WorkerID, v.Begin, v.End); day != nil {
+				if int64(day.WorkerScheduleID) != v.WorkerScheduleID.Int64 {
+					diff.moved[v.SlotID] = day.WorkerScheduleID
+				}
+				continue
 			}
+			diff.conflicts = append(diff.conflicts, &v.SlotConflict)
+			continue
 		}
+		diff.stale = append(diff.stale, v.SlotID)
 	}
-	if tx.Commit() != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	for _, v := range plan {
+		key := slotKey{v.WorkerID, v.Begin.Unix(), v.End.Unix()}
+		if !found[key] {
+			found[key] = true
+			diff.missing = append(diff.missing, v)
+		}
+	}
+	return diff
+}
+
+// Рабочий день сотрудника, в интервалы которого целиком попадает сеанс
+func dayOf(days []*workDay, workerID int, begin, end time.Time) *workDay {
+	for _, v := range days {
+		if v.WorkerID == workerID && within(v.Intervals, begin, end) {
+			return v
+		}
+	}
+	return nil
+}
+
+// Момент, когда в дне day на часах организации clock. Часы хранятся настенным временем
+func onDay(day, clock time.Time, loc *time.Location) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+}
+
+// Горизонт генерации слотов организации в днях
+func (p *PostgresRepo) SlotHorizon(ctx context.Context, orgID int) (int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT slot_horizon
+		FROM orgs
+		WHERE is_delete = false
+		AND org_id = $1;
+	`
+	var days int
+	if err = tx.QueryRowContext(ctx, query, orgID).Scan(&days); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrOrgNotFound
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to get slot horizon: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return days, nil
+}
+
+func (p *PostgresRepo) SlotHorizonUpdate(ctx context.Context, orgID, days int) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE orgs
+		SET slot_horizon = $1
+		WHERE is_delete = false
+		AND org_id = $2;
+	`
+	res, err := tx.ExecContext(ctx, query, days, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to update slot horizon: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrOrgNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
 	}
 	return nil
 }
@@ -95,9 +640,10 @@ func (p *PostgresRepo) DeleteExpiredSlots(ctx context.Context) error {
 		}
 	}()
 	query := `
-		DELETE FROM slots
-		WHERE date <= CURRENT_DATE
-		AND busy = false;
+		DELETE FROM slots s
+		WHERE s.date < org_today(s.org_id)
+		AND s.busy = false
+		AND NOT EXISTS (SELECT 1 FROM records r WHERE r.slot_id = s.slot_id);
 	`
 	_, err = tx.ExecContext(ctx, query)
 	if err != nil {
@@ -109,7 +655,8 @@ func (p *PostgresRepo) DeleteExpiredSlots(ctx context.Context) error {
 	return nil
 }
 
-// Занять или освободить слот
+// Занять или освободить слот. Вместимость меняется, только если задана
+// и не меньше числа уже занятых мест
 func (p *PostgresRepo) UpdateSlot(ctx context.Context, busy bool, params *orgmodel.SlotsMeta) error {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -123,11 +670,19 @@ func (p *PostgresRepo) UpdateSlot(ctx context.Context, busy bool, params *orgmod
 	query := `
 		UPDATE slots
 		SET
-			busy = $1
+			busy = $1,
+			capacity = COALESCE(NULLIF($5, 0), capacity)
 		WHERE slot_id = $2
-		AND worker_id = $3;
+		AND ($5 <= 0 OR seats_taken <= $5)
+		AND worker_id = (SELECT
+				worker_id
+			FROM workers
+			WHERE is_delete = false
+			AND worker_id = $3
+			AND org_id = $4
+		);
 	`
-	rows, err := tx.ExecContext(ctx, query, busy, params.SlotID, params.WorkerID)
+	rows, err := tx.ExecContext(ctx, query, busy, params.SlotID, params.WorkerID, params.OrgID, params.Capacity)
 	if err != nil {
 		return err
 	}
@@ -153,15 +708,20 @@ func (p *PostgresRepo) Slots(ctx context.Context, params *orgmodel.SlotsMeta) ([
 			tx.Rollback()
 		}
 	}()
-	// TODO: исправить отправляется по UTC
+	// Время переводится в пояс организации в маппере.
+	// Вместимость пустого слота без своей - по услуге из запроса
 	query := `
-		SELECT slot_id, worker_schedule_id, worker_id, date, (session_begin + INTERVAL '3 hours') AS session_begin, (session_end + INTERVAL '3 hours') AS session_end, busy
-		FROM slots
-		WHERE date >= CURRENT_DATE
-		AND ($1 <= 0 OR worker_id = $1);
+		SELECT s.slot_id, COALESCE(s.worker_schedule_id, 0) AS worker_schedule_id, s.worker_id, s.date,
+			s.session_begin, s.session_end, s.busy, o.timezone, slot_capacity(s.slot_id, $2) AS capacity, s.seats_taken
+		FROM slots s
+		JOIN workers w ON w.worker_id = s.worker_id
+		JOIN orgs o ON o.org_id = w.org_id
+		WHERE s.date >= org_today(w.org_id)
+		AND ($1 <= 0 OR s.worker_id = $1)
+		ORDER BY s.date, s.session_begin;
 	`
 	slots := make([]*orgmodel.Slot, 0, 1)
-	if err := tx.SelectContext(ctx, &slots, query, params.WorkerID); err != nil {
+	if err := tx.SelectContext(ctx, &slots, query, params.WorkerID, params.ServiceID); err != nil {
 		return nil, err
 	}
 	if tx.Commit() != nil {
@@ -169,3 +729,48 @@ func (p *PostgresRepo) Slots(ctx context.Context, params *orgmodel.SlotsMeta) ([
 	}
 	return slots, nil
 }
+
+// Клиенты, записанные в слот организации, вместе с занятостью мест
+func (p *PostgresRepo) SlotAttendees(ctx context.Context, params *orgmodel.SlotsMeta) (*orgmodel.Attendees, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT s.slot_id, COALESCE(s.worker_id, 0) AS worker_id, s.date, s.session_begin, s.session_end,
+			slot_capacity(s.slot_id, 0) AS capacity, s.seats_taken, o.timezone
+		FROM slots s
+		JOIN orgs o ON o.org_id = s.org_id
+		WHERE s.slot_id = $1
+		AND s.org_id = $2;
+	`
+	resp := &orgmodel.Attendees{}
+	if err = tx.GetContext(ctx, resp, query, params.SlotID, params.OrgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrSlotNotFound
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get slot: %w", err)
+	}
+	query = `
+		SELECT r.record_id, r.user_id, u.first_name, u.last_name,
+			COALESCE(u.telephone, '') AS telephone, r.service_id, r.attended
+		FROM records r
+		JOIN users u ON u.user_id = r.user_id
+		WHERE r.slot_id = $1
+		ORDER BY r.record_id;
+	`
+	resp.List = make([]*orgmodel.Attendee, 0, resp.SeatsTaken)
+	if err = tx.SelectContext(ctx, &resp.List, query, params.SlotID); err != nil {
+		return nil, fmt.Errorf("failed to get attendees: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return resp, nil
+}
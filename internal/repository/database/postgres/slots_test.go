@@ -0,0 +1,374 @@
+package postgres
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+	"timeline/internal/repository/models/orgmodel"
+)
+
+var day = time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+
+// Время на часах, как его отдает столбец TIME
+func clock(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse("15:04", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// Момент в тестовом дне
+func at(t *testing.T, s string) time.Time {
+	t.Helper()
+	return onDay(day, clock(t, s), time.UTC)
+}
+
+func span(t *testing.T, begin, end string) interval {
+	t.Helper()
+	return interval{Begin: at(t, begin), End: at(t, end)}
+}
+
+func schedule(t *testing.T, start, over, open, close string, duration int) *scheduleDay {
+	t.Helper()
+	return &scheduleDay{
+		WorkerScheduleID: 1,
+		WorkerID:         1,
+		OrgID:            1,
+		Day:              day,
+		Start:            clock(t, start),
+		Over:             clock(t, over),
+		SessionDuration:  duration,
+		TimeZone:         "UTC",
+		Open:             clock(t, open),
+		Close:            clock(t, close),
+	}
+}
+
+func begins(plan []*plannedSlot) []string {
+	res := make([]string, 0, len(plan))
+	for _, v := range plan {
+		res = append(res, v.Begin.Format("15:04"))
+	}
+	return res
+}
+
+func TestSubtract(t *testing.T) {
+	tests := []struct {
+		name       string
+		list       []interval
+		begin, end string
+		want       []interval
+	}{
+		{"outside", []interval{span(t, "09:00", "12:00")}, "12:00", "13:00", []interval{span(t, "09:00", "12:00")}},
+		{"middle", []interval{span(t, "09:00", "12:00")}, "10:00", "11:00", []interval{span(t, "09:00", "10:00"), span(t, "11:00", "12:00")}},
+		{"start", []interval{span(t, "09:00", "12:00")}, "08:00", "10:00", []interval{span(t, "10:00", "12:00")}},
+		{"end", []interval{span(t, "09:00", "12:00")}, "11:30", "13:00", []interval{span(t, "09:00", "11:30")}},
+		{"whole", []interval{span(t, "09:00", "12:00")}, "09:00", "12:00", []interval{}},
+		{"two", []interval{span(t, "09:00", "10:00"), span(t, "11:00", "12:00")}, "09:30", "11:30", []interval{span(t, "09:00", "09:30"), span(t, "11:30", "12:00")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := subtract(tt.list, at(t, tt.begin), at(t, tt.end))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithin(t *testing.T) {
+	list := []interval{span(t, "09:00", "12:00"), span(t, "13:00", "15:00")}
+	tests := []struct {
+		name       string
+		begin, end string
+		want       bool
+	}{
+		{"inside", "10:00", "11:00", true},
+		{"edges", "09:00", "12:00", true},
+		{"second", "13:00", "14:00", true},
+		{"across gap", "11:30", "13:30", false},
+		{"before", "08:30", "09:30", false},
+		{"after", "14:30", "15:30", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := within(list, at(t, tt.begin), at(t, tt.end)); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOverlapsBooking(t *testing.T) {
+	tests := []struct {
+		name       string
+		booked     []*booking
+		begin, end string
+		want       bool
+	}{
+		{"none", nil, "10:00", "11:00", false},
+		{"same session", []*booking{{Begin: at(t, "10:00"), End: at(t, "11:00"), BufferAfter: 30}}, "10:00", "11:00", false},
+		{"adjacent", []*booking{{Begin: at(t, "11:00"), End: at(t, "12:00")}}, "10:00", "11:00", false},
+		{"crossing", []*booking{{Begin: at(t, "10:30"), End: at(t, "11:30")}}, "10:00", "11:00", true},
+		{"buffer before", []*booking{{Begin: at(t, "11:00"), End: at(t, "12:00"), BufferBefore: 15}}, "10:00", "11:00", true},
+		{"buffer after", []*booking{{Begin: at(t, "09:00"), End: at(t, "10:00"), BufferAfter: 15}}, "10:00", "11:00", true},
+		{"buffer short of session", []*booking{{Begin: at(t, "08:00"), End: at(t, "09:00"), BufferAfter: 60}}, "10:00", "11:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overlapsBooking(tt.booked, at(t, tt.begin), at(t, tt.end)); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPlanSlots(t *testing.T) {
+	partOff := &orgmodel.TimeOff{
+		WorkerID: 1,
+		DateFrom: day,
+		DateTo:   day,
+		TimeFrom: sql.NullTime{Time: clock(t, "10:00"), Valid: true},
+		TimeTo:   sql.NullTime{Time: clock(t, "11:00"), Valid: true},
+	}
+	tests := []struct {
+		name     string
+		schedule *scheduleDay
+		breaks   []interval
+		offs     []*orgmodel.TimeOff
+		booked   []*booking
+		want     []string
+	}{
+		{
+			name:     "plain",
+			schedule: schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			want:     []string{"09:00", "10:00", "11:00"},
+		},
+		{
+			name:     "tail shorter than session",
+			schedule: schedule(t, "09:00", "11:30", "08:00", "20:00", 60),
+			want:     []string{"09:00", "10:00"},
+		},
+		{
+			name:     "org hours",
+			schedule: schedule(t, "08:00", "13:00", "10:00", "12:00", 60),
+			want:     []string{"10:00", "11:00"},
+		},
+		{
+			name:     "break",
+			schedule: schedule(t, "09:00", "15:00", "08:00", "20:00", 60),
+			breaks:   []interval{{Begin: clock(t, "12:00"), End: clock(t, "13:00")}},
+			want:     []string{"09:00", "10:00", "11:00", "13:00", "14:00"},
+		},
+		{
+			name:     "break inside session",
+			schedule: schedule(t, "09:00", "15:00", "08:00", "20:00", 60),
+			breaks:   []interval{{Begin: clock(t, "12:30"), End: clock(t, "13:00")}},
+			want:     []string{"09:00", "10:00", "11:00", "13:00", "14:00"},
+		},
+		{
+			name:     "part of day off",
+			schedule: schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			offs:     []*orgmodel.TimeOff{partOff},
+			want:     []string{"09:00", "11:00"},
+		},
+		{
+			name:     "whole day off",
+			schedule: schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			offs:     []*orgmodel.TimeOff{{WorkerID: 1, DateFrom: day.AddDate(0, 0, -1), DateTo: day.AddDate(0, 0, 1)}},
+			want:     []string{},
+		},
+		{
+			name:     "day off on other days",
+			schedule: schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			offs:     []*orgmodel.TimeOff{{WorkerID: 1, DateFrom: day.AddDate(0, 0, 1), DateTo: day.AddDate(0, 0, 3)}},
+			want:     []string{"09:00", "10:00", "11:00"},
+		},
+		{
+			name:     "booked session",
+			schedule: schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			booked:   []*booking{{WorkerID: 1, Begin: at(t, "10:00"), End: at(t, "11:00")}},
+			want:     []string{"09:00", "10:00", "11:00"},
+		},
+		{
+			name:     "booked session buffer",
+			schedule: schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			booked:   []*booking{{WorkerID: 1, Begin: at(t, "10:00"), End: at(t, "11:00"), BufferAfter: 30}},
+			want:     []string{"09:00", "10:00"},
+		},
+		{
+			name:     "booking at arbitrary time",
+			schedule: schedule(t, "09:00", "13:00", "08:00", "20:00", 60),
+			booked:   []*booking{{WorkerID: 1, Begin: at(t, "10:15"), End: at(t, "10:45")}},
+			want:     []string{"09:00", "11:00", "12:00"},
+		},
+		{
+			name:     "booking with buffers",
+			schedule: schedule(t, "09:00", "13:00", "08:00", "20:00", 60),
+			booked:   []*booking{{WorkerID: 1, Begin: at(t, "10:15"), End: at(t, "10:45"), BufferBefore: 30, BufferAfter: 20}},
+			want:     []string{"12:00"},
+		},
+		{
+			name:     "other worker booking",
+			schedule: schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			booked:   []*booking{{WorkerID: 2, Begin: at(t, "10:15"), End: at(t, "10:45")}},
+			want:     []string{"09:00", "10:00", "11:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := []*workDay{buildWorkDay(tt.schedule, tt.breaks, tt.offs)}
+			booked := make(map[int][]*booking)
+			for _, v := range tt.booked {
+				booked[v.WorkerID] = append(booked[v.WorkerID], v)
+			}
+			if got := begins(cutSlots(days, booked)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPlanSlotsRerun(t *testing.T) {
+	days := []*workDay{buildWorkDay(schedule(t, "09:00", "15:00", "08:00", "20:00", 60),
+		[]interval{{Begin: clock(t, "12:00"), End: clock(t, "13:00")}}, nil)}
+	first := cutSlots(days, nil)
+	// Повторная генерация на тот же горизонт дает те же сеансы,
+	// а вставка пропускает их по (worker_id, session_begin)
+	if second := cutSlots(days, nil); !reflect.DeepEqual(first, second) {
+		t.Fatalf("expected %v, got %v", begins(first), begins(second))
+	}
+	// Записанный сеанс остается в плане и не вытесняет соседние
+	booked := map[int][]*booking{1: {{SlotID: 1, WorkerID: 1, Begin: first[1].Begin, End: first[1].End}}}
+	if got := cutSlots(days, booked); !reflect.DeepEqual(got, first) {
+		t.Fatalf("expected %v, got %v", begins(first), begins(got))
+	}
+}
+
+func existing(t *testing.T, slotID int, begin, end string, scheduleID int, busy, record bool) *existingSlot {
+	t.Helper()
+	v := &existingSlot{
+		SlotConflict: orgmodel.SlotConflict{
+			SlotID:   slotID,
+			WorkerID: 1,
+			Date:     day,
+			Begin:    at(t, begin),
+			End:      at(t, end),
+		},
+		WorkerScheduleID: sql.NullInt64{Int64: int64(scheduleID), Valid: scheduleID > 0},
+		Busy:             busy,
+	}
+	if record {
+		v.RecordID = sql.NullInt64{Int64: int64(slotID * 10), Valid: true}
+	}
+	return v
+}
+
+func TestCompareSlots(t *testing.T) {
+	withID := func(v *scheduleDay, id int) *scheduleDay {
+		v.WorkerScheduleID = id
+		return v
+	}
+	hourly := []*existingSlot{
+		existing(t, 1, "09:00", "10:00", 1, false, false),
+		existing(t, 2, "10:00", "11:00", 1, true, true),
+		existing(t, 3, "11:00", "12:00", 1, false, false),
+	}
+	tests := []struct {
+		name      string
+		schedule  *scheduleDay
+		existing  []*existingSlot
+		stale     []int
+		moved     map[int]int
+		missing   []string
+		conflicts []int
+	}{
+		{
+			name:      "unchanged",
+			schedule:  schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			existing:  hourly,
+			stale:     []int{},
+			moved:     map[int]int{},
+			missing:   []string{},
+			conflicts: []int{},
+		},
+		{
+			name:      "duration",
+			schedule:  schedule(t, "09:00", "12:00", "08:00", "20:00", 30),
+			existing:  hourly,
+			stale:     []int{1, 3},
+			moved:     map[int]int{},
+			missing:   []string{"09:00", "09:30", "11:00", "11:30"},
+			conflicts: []int{},
+		},
+		{
+			name:      "schedule",
+			schedule:  withID(schedule(t, "10:00", "12:00", "08:00", "20:00", 60), 2),
+			existing:  append([]*existingSlot{existing(t, 4, "09:00", "10:00", 1, true, false)}, hourly[1:]...),
+			stale:     []int{},
+			moved:     map[int]int{2: 2, 3: 2},
+			missing:   []string{},
+			conflicts: []int{4},
+		},
+		{
+			name:      "schedule drops free slot",
+			schedule:  withID(schedule(t, "10:00", "12:00", "08:00", "20:00", 60), 2),
+			existing:  hourly,
+			stale:     []int{1},
+			moved:     map[int]int{2: 2, 3: 2},
+			missing:   []string{},
+			conflicts: []int{},
+		},
+		{
+			name:     "timetable",
+			schedule: schedule(t, "09:00", "12:00", "08:00", "10:00", 60),
+			existing: hourly,
+			stale:    []int{3},
+			moved:    map[int]int{},
+			missing:  []string{},
+			// Запись 10:00 - 11:00 больше не укладывается в часы организации
+			conflicts: []int{2},
+		},
+		{
+			name:      "record at arbitrary time",
+			schedule:  schedule(t, "09:00", "12:00", "08:00", "20:00", 60),
+			existing:  []*existingSlot{existing(t, 5, "09:15", "09:45", 0, false, true)},
+			stale:     []int{},
+			moved:     map[int]int{5: 1},
+			missing:   []string{"10:00", "11:00"},
+			conflicts: []int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := []*workDay{buildWorkDay(tt.schedule, nil, nil)}
+			// Занятые слоты не дают нарезать поверх себя, как в planSlots
+			booked := make(map[int][]*booking)
+			for _, v := range tt.existing {
+				if v.Busy || v.RecordID.Valid {
+					booked[v.WorkerID] = append(booked[v.WorkerID], &booking{SlotID: v.SlotID, WorkerID: v.WorkerID, Begin: v.Begin, End: v.End})
+				}
+			}
+			diff := compareSlots(tt.existing, cutSlots(days, booked), days)
+			if !reflect.DeepEqual(diff.stale, tt.stale) {
+				t.Fatalf("expected stale %v, got %v", tt.stale, diff.stale)
+			}
+			if !reflect.DeepEqual(diff.moved, tt.moved) {
+				t.Fatalf("expected moved %v, got %v", tt.moved, diff.moved)
+			}
+			if got := begins(diff.missing); !reflect.DeepEqual(got, tt.missing) {
+				t.Fatalf("expected missing %v, got %v", tt.missing, got)
+			}
+			conflicts := make([]int, 0, len(diff.conflicts))
+			for _, v := range diff.conflicts {
+				conflicts = append(conflicts, v.SlotID)
+			}
+			if !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Fatalf("expected conflicts %v, got %v", tt.conflicts, conflicts)
+			}
+		})
+	}
+}
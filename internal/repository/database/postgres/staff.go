@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timeline/internal/repository/models"
+)
+
+var (
+	ErrInviteNotFound        = errors.New("invite not found")
+	ErrWorkerAccountNotFound = errors.New("worker account not found")
+)
+
+// Сохраняет приглашение сотрудника. Повторное приглашение заменяет прежнее
+// и сбрасывает пароль, пока приглашение не будет принято заново.
+// Сотрудник должен принадлежать организации, почта не должна быть занята другим сотрудником
+func (p *PostgresRepo) WorkerInviteSave(ctx context.Context, account *models.WorkerAccount) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM workers
+			WHERE is_delete = false
+			AND worker_id = $1
+			AND org_id = $2
+		);
+	`
+	var exists bool
+	if err = tx.QueryRowContext(ctx, query, account.WorkerID, account.OrgID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check worker: %w", err)
+	}
+	if !exists {
+		err = ErrWorkerNotFound
+		return err
+	}
+	query = `
+		SELECT EXISTS(
+			SELECT 1 FROM worker_accounts
+			WHERE LOWER(email) = LOWER($1)
+			AND worker_id <> $2
+		);
+	`
+	var taken bool
+	if err = tx.QueryRowContext(ctx, query, account.Email, account.WorkerID).Scan(&taken); err != nil {
+		return fmt.Errorf("failed to check email: %w", err)
+	}
+	if taken {
+		err = ErrEmailTaken
+		return err
+	}
+	query = `
+		INSERT INTO worker_accounts (worker_id, org_id, email, invite_hash, invite_expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (worker_id) DO UPDATE
+		SET
+			email = EXCLUDED.email,
+			passwd_hash = '',
+			invite_hash = EXCLUDED.invite_hash,
+			invite_expires_at = EXCLUDED.invite_expires_at,
+			activated = false;
+	`
+	if _, err = tx.ExecContext(ctx, query,
+		account.WorkerID,
+		account.OrgID,
+		account.Email,
+		account.InviteHash,
+		account.InviteExpiresAt,
+	); err != nil {
+		return fmt.Errorf("failed to save invite: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Принимает приглашение: погашает токен, задает пароль и активирует учетную запись.
+// Использованный или стухший токен - ErrInviteNotFound
+func (p *PostgresRepo) WorkerInviteAccept(ctx context.Context, inviteHash, passwdHash string) (*models.WorkerAccount, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE worker_accounts
+		SET
+			passwd_hash = $2,
+			invite_hash = NULL,
+			invite_expires_at = NULL,
+			activated = true
+		WHERE invite_hash = $1
+		AND invite_expires_at > CURRENT_TIMESTAMP
+		RETURNING worker_id, org_id, email, passwd_hash, invite_expires_at, activated, created_at;
+	`
+	var account models.WorkerAccount
+	if err = tx.GetContext(ctx, &account, query, inviteHash, passwdHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, fmt.Errorf("failed to accept invite: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &account, nil
+}
+
+// Активная учетная запись сотрудника по почте.
+// Удаленные сотрудники и сотрудники заблокированных организаций не находятся
+func (p *PostgresRepo) WorkerAccountByEmail(ctx context.Context, email string) (*models.WorkerAccount, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT wa.worker_id, wa.org_id, wa.email, wa.passwd_hash, wa.invite_expires_at, wa.activated, wa.created_at
+		FROM worker_accounts wa
+		JOIN workers w ON w.worker_id = wa.worker_id
+		JOIN orgs o ON o.org_id = wa.org_id
+		WHERE w.is_delete = false
+		AND o.is_delete = false
+		AND o.is_blocked = false
+		AND wa.activated = true
+		AND LOWER(wa.email) = LOWER($1);
+	`
+	var account models.WorkerAccount
+	if err = tx.GetContext(ctx, &account, query, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkerAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get worker account: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &account, nil
+}
@@ -0,0 +1,286 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timeline/internal/repository/models/orgmodel"
+)
+
+var (
+	ErrTimeOffNotFound = errors.New("time off not found")
+)
+
+// Заявка сотрудника на отгул. Сотрудник должен принадлежать организации
+func (p *PostgresRepo) TimeOffAdd(ctx context.Context, timeoff *orgmodel.TimeOff) (int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		INSERT INTO worker_timeoff (worker_id, org_id, date_from, date_to, time_from, time_to, kind, reason, status)
+		SELECT worker_id, org_id, $3, $4, $5, $6, $7, $8, $9
+		FROM workers
+		WHERE is_delete = false
+		AND worker_id = $1
+		AND org_id = $2
+		RETURNING timeoff_id;
+	`
+	var timeoffID int
+	if err = tx.QueryRowContext(ctx, query,
+		timeoff.WorkerID,
+		timeoff.OrgID,
+		timeoff.DateFrom,
+		timeoff.DateTo,
+		timeoff.TimeFrom,
+		timeoff.TimeTo,
+		timeoff.Kind,
+		timeoff.Reason,
+		timeoff.Status,
+	).Scan(&timeoffID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrWorkerNotFound
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to add time off: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return timeoffID, nil
+}
+
+// Отгулы сотрудника, начиная с ближайших
+func (p *PostgresRepo) TimeOffList(ctx context.Context, orgID, workerID int) ([]*orgmodel.TimeOff, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT timeoff_id, worker_id, org_id, date_from, date_to, time_from, time_to, kind, reason, status, created_at
+		FROM worker_timeoff
+		WHERE org_id = $1
+		AND worker_id = $2
+		ORDER BY date_from DESC;
+	`
+	list := make([]*orgmodel.TimeOff, 0, 1)
+	if err = tx.SelectContext(ctx, &list, query, orgID, workerID); err != nil {
+		return nil, fmt.Errorf("failed to get time off: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return list, nil
+}
+
+// Решение организации по заявке. Менять можно только заявку на рассмотрении
+func (p *PostgresRepo) TimeOffStatus(ctx context.Context, timeoff *orgmodel.TimeOff) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE worker_timeoff
+		SET
+			status = $4
+		WHERE timeoff_id = $1
+		AND org_id = $2
+		AND worker_id = $3
+		AND status = 'pending';
+	`
+	res, err := tx.ExecContext(ctx, query, timeoff.TimeOffID, timeoff.OrgID, timeoff.WorkerID, timeoff.Status)
+	if err != nil {
+		return fmt.Errorf("failed to update time off: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrTimeOffNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresRepo) TimeOff(ctx context.Context, orgID, workerID, timeoffID int) (*orgmodel.TimeOff, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT timeoff_id, worker_id, org_id, date_from, date_to, time_from, time_to, kind, reason, status, created_at
+		FROM worker_timeoff
+		WHERE timeoff_id = $1
+		AND org_id = $2
+		AND worker_id = $3;
+	`
+	var timeoff orgmodel.TimeOff
+	if err = tx.GetContext(ctx, &timeoff, query, timeoffID, orgID, workerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrTimeOffNotFound
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get time off: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return &timeoff, nil
+}
+
+// Изменение периода и причины. pendingOnly - только пока заявка на рассмотрении
+func (p *PostgresRepo) TimeOffUpdate(ctx context.Context, timeoff *orgmodel.TimeOff, pendingOnly bool) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE worker_timeoff
+		SET
+			date_from = $4,
+			date_to = $5,
+			time_from = $6,
+			time_to = $7,
+			kind = $8,
+			reason = $9
+		WHERE timeoff_id = $1
+		AND org_id = $2
+		AND worker_id = $3
+		AND ($10 = false OR status = 'pending');
+	`
+	res, err := tx.ExecContext(ctx, query,
+		timeoff.TimeOffID,
+		timeoff.OrgID,
+		timeoff.WorkerID,
+		timeoff.DateFrom,
+		timeoff.DateTo,
+		timeoff.TimeFrom,
+		timeoff.TimeTo,
+		timeoff.Kind,
+		timeoff.Reason,
+		pendingOnly,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update time off: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrTimeOffNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// pendingOnly - удалить можно только заявку на рассмотрении
+func (p *PostgresRepo) TimeOffDelete(ctx context.Context, orgID, workerID, timeoffID int, pendingOnly bool) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE FROM worker_timeoff
+		WHERE timeoff_id = $1
+		AND org_id = $2
+		AND worker_id = $3
+		AND ($4 = false OR status = 'pending');
+	`
+	res, err := tx.ExecContext(ctx, query, timeoffID, orgID, workerID, pendingOnly)
+	if err != nil {
+		return fmt.Errorf("failed to delete time off: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrTimeOffNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Предстоящие записи клиентов, попадающие на отсутствие сотрудника
+func (p *PostgresRepo) TimeOffCollisions(ctx context.Context, orgID, workerID, timeoffID int) ([]*orgmodel.TimeOffCollision, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM worker_timeoff
+			WHERE timeoff_id = $1
+			AND org_id = $2
+			AND worker_id = $3
+		);
+	`
+	var found bool
+	if err = tx.QueryRowContext(ctx, query, timeoffID, orgID, workerID).Scan(&found); err != nil {
+		return nil, fmt.Errorf("failed to check time off: %w", err)
+	}
+	if !found {
+		err = ErrTimeOffNotFound
+		return nil, err
+	}
+	query = `
+		SELECT r.record_id, s.slot_id, u.user_id, u.first_name, u.last_name, u.email, u.telephone,
+			s.date, s.session_begin, s.session_end, o.timezone
+		FROM worker_timeoff t
+		JOIN orgs o ON o.org_id = t.org_id
+		JOIN records r ON r.worker_id = t.worker_id
+		JOIN slots s ON s.slot_id = r.slot_id
+		JOIN users u ON u.user_id = r.user_id
+		WHERE t.timeoff_id = $1
+		AND s.date BETWEEN t.date_from AND t.date_to
+		AND s.date >= org_today(t.org_id)
+		AND (t.time_from IS NULL OR (
+			(s.session_begin AT TIME ZONE o.timezone)::time < t.time_to::time
+			AND (s.session_end AT TIME ZONE o.timezone)::time > t.time_from::time
+		))
+		ORDER BY s.date, s.session_begin;
+	`
+	list := make([]*orgmodel.TimeOffCollision, 0, 1)
+	if err = tx.SelectContext(ctx, &list, query, timeoffID); err != nil {
+		return nil, fmt.Errorf("failed to get time off collisions: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return list, nil
+}
@@ -2,9 +2,19 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 	"timeline/internal/repository/models/orgmodel"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrExceptionExists   = errors.New("timetable exception for this date already exists")
+	ErrExceptionNotFound = errors.New("timetable exception not found")
 )
 
 func (p *PostgresRepo) Timetable(ctx context.Context, OrgID int) ([]*orgmodel.OpenHours, error) {
@@ -26,6 +36,9 @@ func (p *PostgresRepo) Timetable(ctx context.Context, OrgID int) ([]*orgmodel.Op
 	if err = tx.SelectContext(ctx, &timetable, query, OrgID); err != nil {
 		return nil, err
 	}
+	if err = timetableBreaks(ctx, tx, OrgID, timetable); err != nil {
+		return nil, err
+	}
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit tx: %w", err)
 	}
@@ -63,6 +76,9 @@ func (p *PostgresRepo) TimetableAdd(ctx context.Context, orgID int, new []*orgmo
 				return ErrOrgNotFound
 			}
 		}
+		if err = replaceBreaks(ctx, tx, orgID, hours); err != nil {
+			return err
+		}
 	}
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit tx: %w", err)
@@ -94,6 +110,13 @@ func (p *PostgresRepo) TimetableDelete(ctx context.Context, orgID, weekday int)
 			return ErrOrgNotFound
 		}
 	}
+	query = `DELETE FROM timetable_breaks
+			WHERE org_id = $1
+			AND ($2 <= 0 OR weekday = $2)
+	`
+	if _, err = tx.ExecContext(ctx, query, orgID, weekday); err != nil {
+		return fmt.Errorf("failed to delete timetable breaks: %w", err)
+	}
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit tx: %w", err)
 	}
@@ -138,9 +161,272 @@ func (p *PostgresRepo) TimetableUpdate(ctx context.Context, orgID int, new []*or
 				return ErrOrgNotFound
 			}
 		}
+		if err = replaceBreaks(ctx, tx, orgID, hours); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Перерывы организации раскладываются по дням недели ее расписания
+func timetableBreaks(ctx context.Context, tx *sqlx.Tx, orgID int, timetable []*orgmodel.OpenHours) error {
+	query := `
+		SELECT weekday, break_start, break_end
+		FROM timetable_breaks
+		WHERE org_id = $1
+		ORDER BY weekday, break_start::time;
+	`
+	breaks := make([]*orgmodel.Break, 0, len(timetable))
+	if err := tx.SelectContext(ctx, &breaks, query, orgID); err != nil {
+		return fmt.Errorf("failed to get timetable breaks: %w", err)
+	}
+	for _, day := range timetable {
+		for _, v := range breaks {
+			if int32(v.Weekday) == day.Weekday.Int32 {
+				day.Breaks = append(day.Breaks, v)
+			}
+		}
+	}
+	return nil
+}
+
+// Перерывы дня недели заменяются переданными
+func replaceBreaks(ctx context.Context, tx *sqlx.Tx, orgID int, hours *orgmodel.OpenHours) error {
+	query := `
+		DELETE FROM timetable_breaks
+		WHERE org_id = $1
+		AND weekday = $2;
+	`
+	if _, err := tx.ExecContext(ctx, query, orgID, hours.Weekday); err != nil {
+		return fmt.Errorf("failed to delete timetable breaks: %w", err)
+	}
+	query = `
+		INSERT INTO timetable_breaks (org_id, weekday, break_start, break_end)
+		VALUES ($1, $2, $3, $4);
+	`
+	for _, v := range hours.Breaks {
+		if _, err := tx.ExecContext(ctx, query, orgID, hours.Weekday, v.Start, v.End); err != nil {
+			return fmt.Errorf("failed to add timetable break: %w", err)
+		}
+	}
+	return nil
+}
+
+// Особые дни организации в диапазоне [from, to]
+func (p *PostgresRepo) TimetableExceptions(ctx context.Context, orgID int, from, to time.Time) ([]*orgmodel.TimetableException, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT exception_id, org_id, date, closed, open, close, break_start, break_end, reason
+		FROM timetable_exceptions
+		WHERE org_id = $1
+		AND date BETWEEN $2 AND $3
+		ORDER BY date;
+	`
+	list := make([]*orgmodel.TimetableException, 0, 1)
+	if err = tx.SelectContext(ctx, &list, query, orgID, from, to); err != nil {
+		return nil, fmt.Errorf("failed to get timetable exceptions: %w", err)
+	}
+	if err = exceptionWorkers(ctx, tx, list...); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return list, nil
+}
+
+func (p *PostgresRepo) TimetableExceptionAdd(ctx context.Context, exception *orgmodel.TimetableException) (int, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM orgs
+			WHERE is_delete = false
+			AND org_id = $1
+		);
+	`
+	var found bool
+	if err = tx.QueryRowContext(ctx, query, exception.OrgID).Scan(&found); err != nil {
+		return 0, fmt.Errorf("failed to check org: %w", err)
+	}
+	if !found {
+		err = ErrOrgNotFound
+		return 0, err
+	}
+	query = `
+		INSERT INTO timetable_exceptions (org_id, date, closed, open, close, break_start, break_end, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (org_id, date) DO NOTHING
+		RETURNING exception_id;
+	`
+	var exceptionID int
+	if err = tx.QueryRowContext(ctx, query,
+		exception.OrgID,
+		exception.Date,
+		exception.Closed,
+		exception.Open,
+		exception.Close,
+		exception.BreakStart,
+		exception.BreakEnd,
+		exception.Reason,
+	).Scan(&exceptionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrExceptionExists
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to add timetable exception: %w", err)
+	}
+	exception.ExceptionID = exceptionID
+	if err = setExceptionWorkers(ctx, tx, exception); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return exceptionID, nil
+}
+
+func (p *PostgresRepo) TimetableExceptionUpdate(ctx context.Context, exception *orgmodel.TimetableException) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		UPDATE timetable_exceptions
+		SET
+			date = $1,
+			closed = $2,
+			open = $3,
+			close = $4,
+			break_start = $5,
+			break_end = $6,
+			reason = $7
+		WHERE exception_id = $8
+		AND org_id = $9;
+	`
+	res, err := tx.ExecContext(ctx, query,
+		exception.Date,
+		exception.Closed,
+		exception.Open,
+		exception.Close,
+		exception.BreakStart,
+		exception.BreakEnd,
+		exception.Reason,
+		exception.ExceptionID,
+		exception.OrgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update timetable exception: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrExceptionNotFound
+		return err
+	}
+	if err = setExceptionWorkers(ctx, tx, exception); err != nil {
+		return err
 	}
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit tx: %w", err)
 	}
 	return nil
 }
+
+func (p *PostgresRepo) TimetableExceptionDelete(ctx context.Context, orgID, exceptionID int) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	query := `
+		DELETE FROM timetable_exceptions
+		WHERE exception_id = $1
+		AND org_id = $2;
+	`
+	res, err := tx.ExecContext(ctx, query, exceptionID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to delete timetable exception: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = ErrExceptionNotFound
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// Заменяет сотрудников, выходящих в особый день. Сотрудник другой организации или удаленный не найдется
+func setExceptionWorkers(ctx context.Context, tx *sqlx.Tx, exception *orgmodel.TimetableException) error {
+	query := `DELETE FROM timetable_exception_workers WHERE exception_id = $1;`
+	if _, err := tx.ExecContext(ctx, query, exception.ExceptionID); err != nil {
+		return fmt.Errorf("failed to delete exception workers: %w", err)
+	}
+	query = `
+		INSERT INTO timetable_exception_workers (exception_id, worker_id)
+		SELECT e.exception_id, w.worker_id
+		FROM timetable_exceptions e
+		JOIN workers w ON w.org_id = e.org_id
+		WHERE w.is_delete = false
+		AND e.exception_id = $1
+		AND w.worker_id = $2
+		AND e.org_id = $3
+		ON CONFLICT DO NOTHING;
+	`
+	for _, id := range exception.Workers {
+		res, err := tx.ExecContext(ctx, query, exception.ExceptionID, id, exception.OrgID)
+		if err != nil {
+			return fmt.Errorf("failed to add exception worker: %w", err)
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return ErrWorkerNotFound
+		}
+	}
+	return nil
+}
+
+// Сотрудники, выходящие в каждый из особых дней
+func exceptionWorkers(ctx context.Context, tx *sqlx.Tx, exceptions ...*orgmodel.TimetableException) error {
+	query := `
+		SELECT ew.worker_id
+		FROM timetable_exception_workers ew
+		JOIN workers w ON w.worker_id = ew.worker_id
+		WHERE w.is_delete = false
+		AND ew.exception_id = $1
+		ORDER BY ew.worker_id;
+	`
+	for _, v := range exceptions {
+		v.Workers = make([]int, 0)
+		if err := tx.SelectContext(ctx, &v.Workers, query, v.ExceptionID); err != nil {
+			return fmt.Errorf("failed to get exception workers: %w", err)
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"timeline/internal/repository/models/orgmodel"
@@ -284,7 +285,8 @@ func (p *PostgresRepo) WorkerList(ctx context.Context, OrgID, Limit, Offset int)
 	return Workers, found, nil
 }
 
-// Удаляет работника из организации, а также связи с предоставляемыми услугами
+// Удаляет работника из организации, а также связи с предоставляемыми услугами.
+// Учетная запись сотрудника удаляется, его сессии отзываются
 func (p *PostgresRepo) WorkerDelete(ctx context.Context, WorkerID, OrgID int) error {
 	tx, err := p.db.Beginx()
 	if err != nil {
@@ -295,19 +297,32 @@ func (p *PostgresRepo) WorkerDelete(ctx context.Context, WorkerID, OrgID int) er
 			tx.Rollback()
 		}
 	}()
+	// Триггер не дает удалить строку, а только помечает ее, поэтому наличие проверяется заранее
 	query := `
-		DELETE FROM workers
-		WHERE AND is_delete = false
+		SELECT worker_id FROM workers
+		WHERE is_delete = false
 		AND worker_id = $1
-		AND org_id = $2;
+		AND org_id = $2
+		FOR UPDATE;
 	`
-	rows, err := tx.ExecContext(ctx, query, &WorkerID, &OrgID)
-	if err != nil {
-		return fmt.Errorf("failed to delete worker: %w", err)
+	if err = tx.QueryRowContext(ctx, query, WorkerID, OrgID).Scan(&WorkerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrWorkerNotFound
+			return err
+		}
+		return fmt.Errorf("failed to get worker: %w", err)
 	}
-	if rows != nil {
-		if _, NoAffectedRows := rows.RowsAffected(); NoAffectedRows != nil {
-			return ErrServiceNotFound
+	queries := []string{
+		`UPDATE sessions SET revoked = true
+		WHERE role = 'worker'
+		AND subject_id IN (SELECT worker_id FROM worker_accounts WHERE worker_id = $1 AND org_id = $2);`,
+		`DELETE FROM worker_accounts WHERE worker_id = $1 AND org_id = $2;`,
+		// триггер помечает сотрудника, его расписание и услуги удаленными
+		`DELETE FROM workers WHERE worker_id = $1 AND org_id = $2;`,
+	}
+	for _, q := range queries {
+		if _, err = tx.ExecContext(ctx, q, WorkerID, OrgID); err != nil {
+			return fmt.Errorf("failed to delete worker: %w", err)
 		}
 	}
 	if tx.Commit() != nil {
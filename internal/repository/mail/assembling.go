@@ -29,6 +29,61 @@ var (
 		  <p style="color: #777;">Вы получили это письмо, поскольку ваш адрес был указан при регистрации в сервисе Timeline.</p>
 	  </div>`, emailFont, textColor, codeFontSize, textColor)
 
+	passwordResetTemplate = fmt.Sprintf(`
+	  <div style="font-family: %s; color: %s;">
+		  <p>Для сброса пароля используйте токен:</p>
+		  <div style="display: inline-block; padding: 10px; border: 1px solid #ddd; border-radius: 5px; background-color: #f0f0f0; cursor: pointer;" title="Скопируйте этот токен">
+			  <span style="font-weight: bold; color: %s;">%%s</span>
+		  </div>
+		  <p>Токен действует %%d минут и может быть использован только один раз.</p>
+		  <p style="color: #777;">Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.</p>
+	  </div>`, emailFont, textColor, textColor)
+
+	emailChangeTemplate = fmt.Sprintf(`
+	  <div style="font-family: %s; color: %s;">
+		  <p>Код подтверждения новой почты:</p>
+		  <div style="display: inline-block; padding: 10px; border: 1px solid #ddd; border-radius: 5px; background-color: #f0f0f0; cursor: pointer;" title="Скопируйте этот код">
+			  <span style="font-size: %s; font-weight: bold; color: %s;">%%s</span>
+		  </div>
+		  <p style="font-weight: bold;">Никому не сообщайте этот код.</p>
+		  <p style="color: #777;">Вы получили это письмо, поскольку этот адрес был указан как новая почта аккаунта в сервисе Timeline.</p>
+	  </div>`, emailFont, textColor, codeFontSize, textColor)
+
+	emailChangeNoticeTemplate = fmt.Sprintf(`
+	  <div style="font-family: %s; color: %s;">
+		  <p>Для вашего аккаунта Timeline запрошена смена почты на адрес <span style="font-weight: bold;">%%s</span>.</p>
+		  <p>Почта будет изменена только после подтверждения с нового адреса.</p>
+		  <p style="color: #777;">Если это были не вы, смените пароль и завершите все сессии аккаунта.</p>
+	  </div>`, emailFont, textColor)
+
+	lockoutTemplate = fmt.Sprintf(`
+	  <div style="font-family: %s; color: %s;">
+		  <p>Зафиксировано несколько неудачных попыток входа в ваш аккаунт Timeline.</p>
+		  <p>Вход временно заблокирован до <span style="font-weight: bold;">%%s</span> (UTC).</p>
+		  <p style="color: #777;">Если это были не вы, рекомендуем сменить пароль и включить двухфакторную аутентификацию.</p>
+	  </div>`, emailFont, textColor)
+
+	workerInviteTemplate = fmt.Sprintf(`
+	  <div style="font-family: %s; color: %s;">
+		  <p>Организация <span style="font-weight: bold;">%%s</span> приглашает вас в Timeline как сотрудника.</p>
+		  <p>Чтобы принять приглашение и задать пароль, используйте токен:</p>
+		  <div style="display: inline-block; padding: 10px; border: 1px solid #ddd; border-radius: 5px; background-color: #f0f0f0; cursor: pointer;" title="Скопируйте этот токен">
+			  <span style="font-weight: bold; color: %s;">%%s</span>
+		  </div>
+		  <p>Приглашение действует %%d ч.</p>
+		  <p style="color: #777;">Если вы не ожидали приглашения, просто проигнорируйте это письмо.</p>
+	  </div>`, emailFont, textColor, textColor)
+
+	loginLinkTemplate = fmt.Sprintf(`
+	  <div style="font-family: %s; color: %s;">
+		  <p>Для входа в Timeline без пароля перейдите по ссылке или используйте токен:</p>
+		  <div style="display: inline-block; padding: 10px; border: 1px solid #ddd; border-radius: 5px; background-color: #f0f0f0; cursor: pointer;" title="Скопируйте эту ссылку">
+			  <span style="font-weight: bold; color: %s;">%%s</span>
+		  </div>
+		  <p>Ссылка действует %%d минут и может быть использована только один раз.</p>
+		  <p style="color: #777;">Если вы не запрашивали вход, просто проигнорируйте это письмо.</p>
+	  </div>`, emailFont, textColor, textColor)
+
 	reminderTemplate = fmt.Sprintf(`
 	  <div style="font-family: %s; color: %s; line-height: 1.6; margin: 20px; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #ddd;">
 		<p>Здравствуйте!</p>
@@ -56,8 +111,18 @@ var (
 )
 
 var (
-	VerificationType = "verification"
-	ReminderType     = "reminder"
+	VerificationType  = "verification"
+	ReminderType      = "reminder"
+	PasswordResetType = "password_reset"
+	EmailChangeType   = "email_change"
+	// Уведомление на старую почту о запрошенной смене
+	EmailChangeNoticeType = "email_change_notice"
+	// Уведомление о блокировке входа после неудачных попыток
+	LockoutType = "lockout"
+	// Приглашение сотрудника организацией
+	WorkerInviteType = "worker_invite"
+	// Ссылка входа без пароля
+	LoginLinkType = "login_link"
 )
 
 // Сборка письма
@@ -72,6 +137,48 @@ func letterAssembly(data *entity.Message) (*gomail.Message, error) {
 			return nil, ErrWrongMsgType
 		}
 		body = fmt.Sprintf(verificationTemplate, code)
+	case PasswordResetType:
+		subject = "Сброс пароля в Timeline"
+		fields, ok := data.Value.(entity.PasswordResetMsg)
+		if !ok {
+			return nil, ErrWrongMsgType
+		}
+		body = fmt.Sprintf(passwordResetTemplate, fields.Token, int(fields.TTL.Minutes()))
+	case EmailChangeType:
+		subject = "Подтверждение новой почты Timeline"
+		code, ok := data.Value.(string)
+		if !ok {
+			return nil, ErrWrongMsgType
+		}
+		body = fmt.Sprintf(emailChangeTemplate, code)
+	case EmailChangeNoticeType:
+		subject = "Запрошена смена почты аккаунта Timeline"
+		newEmail, ok := data.Value.(string)
+		if !ok {
+			return nil, ErrWrongMsgType
+		}
+		body = fmt.Sprintf(emailChangeNoticeTemplate, newEmail)
+	case LockoutType:
+		subject = "Вход в аккаунт Timeline временно заблокирован"
+		until, ok := data.Value.(time.Time)
+		if !ok {
+			return nil, ErrWrongMsgType
+		}
+		body = fmt.Sprintf(lockoutTemplate, until.UTC().Format("02.01.2006 15:04"))
+	case WorkerInviteType:
+		subject = "Приглашение в Timeline"
+		fields, ok := data.Value.(entity.WorkerInviteMsg)
+		if !ok {
+			return nil, ErrWrongMsgType
+		}
+		body = fmt.Sprintf(workerInviteTemplate, fields.Organization, fields.Token, int(fields.TTL.Hours()))
+	case LoginLinkType:
+		subject = "Вход в Timeline"
+		fields, ok := data.Value.(entity.LoginLinkMsg)
+		if !ok {
+			return nil, ErrWrongMsgType
+		}
+		body = fmt.Sprintf(loginLinkTemplate, fields.Link, int(fields.TTL.Minutes()))
 	case ReminderType:
 		subject = "Напоминание о вашей записи!"
 		fields, ok := data.Value.(entity.ReminderMsg)
@@ -81,7 +188,7 @@ func letterAssembly(data *entity.Message) (*gomail.Message, error) {
 		body = fmt.Sprintf(reminderTemplate,
 			fields.Organization,
 			fields.Service,
-			fields.SessionStart.Format("15:06")+"-"+fields.SessionEnd.Format("15:06"),
+			fields.SessionStart.Format("15:04")+"-"+fields.SessionEnd.Format("15:04")+" ("+fields.SessionStart.Location().String()+")",
 		)
 		if data.IsAttach {
 			icsContent = icsCreate(fields)
@@ -15,6 +15,22 @@ type ReminderMsg struct {
 	SessionDate  time.Time
 }
 
+type PasswordResetMsg struct {
+	Token string
+	TTL   time.Duration
+}
+
+type LoginLinkMsg struct {
+	Link string // ссылка или сам токен, если адрес фронтенда не задан
+	TTL  time.Duration
+}
+
+type WorkerInviteMsg struct {
+	Organization string
+	Token        string
+	TTL          time.Duration
+}
+
 type Message struct {
 	Email    string
 	Type     string
@@ -0,0 +1,58 @@
+package adminmap
+
+import (
+	"strings"
+	"timeline/internal/entity/dto/admindto"
+	"timeline/internal/repository/models"
+)
+
+func AccountSearchToModel(dto *admindto.AccountSearchReq) *models.AccountSearch {
+	return &models.AccountSearch{
+		Query:  strings.TrimSpace(dto.Query),
+		Limit:  dto.Limit,
+		Offset: (dto.Page - 1) * dto.Limit,
+	}
+}
+
+func AccountToDTO(model *models.AccountBrief) *admindto.Account {
+	return &admindto.Account{
+		ID:            model.ID,
+		Email:         model.Email,
+		Name:          model.Name,
+		City:          model.City,
+		Verified:      model.Verified,
+		IsDelete:      model.IsDelete,
+		IsBlocked:     model.IsBlocked,
+		BlockedReason: model.BlockedReason,
+		CreatedAt:     model.CreatedAt,
+	}
+}
+
+func AuditParamsToModel(dto *admindto.AuditReq) *models.AuditParams {
+	return &models.AuditParams{
+		OrgID:    dto.OrgID,
+		Entity:   dto.Entity,
+		EntityID: dto.EntityID,
+		From:     dto.From,
+		To:       dto.To,
+		Limit:    dto.Limit,
+		Offset:   (dto.Page - 1) * dto.Limit,
+	}
+}
+
+func AuditToDTO(model *models.AuditEntry) *admindto.AuditEntry {
+	return &admindto.AuditEntry{
+		ID:        model.AuditID,
+		ActorType: model.ActorType,
+		ActorID:   model.ActorID,
+		OrgID:     int(model.OrgID.Int64),
+		Action:    model.Action,
+		Entity:    model.Entity,
+		EntityID:  model.EntityID,
+		Before:    model.Before,
+		After:     model.After,
+		IP:        model.IP,
+		UserAgent: model.UserAgent,
+		CreatedAt: model.CreatedAt,
+	}
+}
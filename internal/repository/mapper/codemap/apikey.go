@@ -0,0 +1,26 @@
+package codemap
+
+import (
+	"strings"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/repository/models"
+)
+
+func APIKeyToDTO(model *models.APIKey) *authdto.APIKey {
+	resp := &authdto.APIKey{
+		KeyID:      model.KeyID,
+		Name:       model.Name,
+		Prefix:     model.Prefix,
+		Scopes:     strings.Fields(model.Scopes),
+		CreatedAt:  model.CreatedAt,
+		LastUsedIP: model.LastUsedIP,
+		Revoked:    model.Revoked,
+	}
+	if model.ExpiresAt.Valid {
+		resp.ExpiresAt = &model.ExpiresAt.Time
+	}
+	if model.LastUsedAt.Valid {
+		resp.LastUsedAt = &model.LastUsedAt.Time
+	}
+	return resp
+}
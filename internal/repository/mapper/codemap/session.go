@@ -0,0 +1,25 @@
+package codemap
+
+import (
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/repository/models"
+)
+
+func SessionToDTO(model *models.Session, current string) *authdto.Session {
+	return &authdto.Session{
+		SessionID: model.SessionID,
+		UserAgent: model.UserAgent,
+		IP:        model.IP,
+		StartedAt: model.StartedAt,
+		LastSeen:  model.LastSeen,
+		Current:   model.Family == current,
+	}
+}
+
+func SessionListToDTO(model []*models.Session, current string) []*authdto.Session {
+	list := make([]*authdto.Session, 0, len(model))
+	for _, v := range model {
+		list = append(list, SessionToDTO(v, current))
+	}
+	return list
+}
@@ -0,0 +1,40 @@
+package orgmap
+
+import (
+	"time"
+	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/libs/custom"
+	"timeline/internal/repository/models/orgmodel"
+)
+
+func AvailabilityReqToModel(dto *orgdto.AvailabilityReq) *orgmodel.AvailabilityParams {
+	date, _ := time.Parse(isoDate, dto.Date)
+	return &orgmodel.AvailabilityParams{
+		OrgID:     dto.OrgID,
+		WorkerID:  dto.WorkerID,
+		ServiceID: dto.ServiceID,
+		Date:      date,
+	}
+}
+
+func AvailabilityToDTO(model *orgmodel.Availability) *orgdto.Availability {
+	sessions := make([]*orgdto.Session, 0, len(model.Sessions))
+	for _, v := range model.Sessions {
+		sessions = append(sessions, &orgdto.Session{
+			SlotID:    v.SlotID,
+			Begin:     zonedClock(v.Begin, model.TimeZone),
+			End:       zonedClock(v.End, model.TimeZone),
+			SeatsLeft: v.SeatsLeft,
+		})
+	}
+	return &orgdto.Availability{
+		WorkerID:     model.WorkerID,
+		ServiceID:    model.ServiceID,
+		Date:         model.Date.Format(isoDate),
+		Duration:     model.Duration,
+		BufferBefore: model.BufferBefore,
+		BufferAfter:  model.BufferAfter,
+		Sessions:     sessions,
+		TimeZone:     custom.Location(model.TimeZone).String(),
+	}
+}
@@ -24,6 +24,7 @@ func RegisterReqToModel(dto *authdto.OrgRegisterReq) *orgmodel.OrgRegister {
 			Telephone:   dto.Telephone,
 			Coordinates: *CoordsToModel(&dto.Coordinates),
 			About:       dto.About,
+			TimeZone:    dto.TimeZone,
 		},
 	}
 }
@@ -44,9 +45,10 @@ func CoordsToModel(dto *entity.Coordinates) *orgmodel.Coordinates {
 
 func OrganizationToDTO(model *orgmodel.Organization) *orgdto.Organization {
 	return &orgdto.Organization{
-		OrgID:     model.OrgID,
-		Info:      OrgInfoToEntity(&model.OrgInfo),
-		Timetable: TimetableToEntity(model.Timetable),
+		OrgID:      model.OrgID,
+		Info:       OrgInfoToEntity(&model.OrgInfo),
+		Timetable:  TimetableToEntity(model.Timetable),
+		Exceptions: TimetableExceptionListToDTO(model.Exceptions),
 	}
 }
 
@@ -72,6 +74,7 @@ func OrgInfoToEntity(model *orgmodel.OrgInfo) *entity.OrgInfo {
 		Telephone:   model.Telephone,
 		City:        model.City,
 		About:       model.About,
+		TimeZone:    model.TimeZone,
 	}
 	return resp
 }
@@ -103,6 +106,7 @@ func OrgInfoToModel(model *entity.OrgInfo) *orgmodel.OrgInfo {
 		Telephone:   model.Telephone,
 		City:        model.City,
 		About:       model.About,
+		TimeZone:    model.TimeZone,
 	}
 	return resp
 }
@@ -0,0 +1,55 @@
+package orgmap
+
+import (
+	"time"
+	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/repository/models/orgmodel"
+)
+
+// Время проверено валидатором
+func ResourceToModel(dto *orgdto.Resource) *orgmodel.Resource {
+	hours := make([]*orgmodel.ResourceHours, 0, len(dto.Hours))
+	for _, v := range dto.Hours {
+		open, _ := time.Parse(timeFormat, v.Open)
+		close, _ := time.Parse(timeFormat, v.Close)
+		hours = append(hours, &orgmodel.ResourceHours{
+			ResourceID: dto.ResourceID,
+			Weekday:    v.Weekday,
+			Open:       open,
+			Close:      close,
+		})
+	}
+	return &orgmodel.Resource{
+		ResourceID:  dto.ResourceID,
+		OrgID:       dto.OrgID,
+		Name:        dto.Name,
+		Description: dto.Description,
+		Hours:       hours,
+	}
+}
+
+func ResourceToDTO(model *orgmodel.Resource) *orgdto.Resource {
+	hours := make([]*orgdto.ResourceHours, 0, len(model.Hours))
+	for _, v := range model.Hours {
+		hours = append(hours, &orgdto.ResourceHours{
+			Weekday: v.Weekday,
+			Open:    v.Open.Format(timeFormat),
+			Close:   v.Close.Format(timeFormat),
+		})
+	}
+	return &orgdto.Resource{
+		ResourceID:  model.ResourceID,
+		OrgID:       model.OrgID,
+		Name:        model.Name,
+		Description: model.Description,
+		Hours:       hours,
+	}
+}
+
+func ResourceListToDTO(list []*orgmodel.Resource) *orgdto.ResourceList {
+	resp := &orgdto.ResourceList{List: make([]*orgdto.Resource, 0, len(list))}
+	for _, v := range list {
+		resp.List = append(resp.List, ResourceToDTO(v))
+	}
+	return resp
+}
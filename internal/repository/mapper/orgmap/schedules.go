@@ -48,6 +48,7 @@ func ScheduleParamsToModel(dto *orgdto.ScheduleParams) *orgmodel.ScheduleParams
 		WorkerID: dto.WorkerID,
 		OrgID:    dto.OrgID,
 		Weekday:  dto.Weekday,
+		Date:     dateToModel(dto.Date),
 		Limit:    dto.Limit,
 		Offset:   (dto.Page - 1) * dto.Limit,
 	}
@@ -61,6 +62,8 @@ func scheduleToModel(dto *orgdto.Schedule) *orgmodel.Schedule {
 		Weekday:          dto.Weekday,
 		Start:            start,
 		Over:             over,
+		ValidFrom:        dateToModel(dto.ValidFrom),
+		ValidTo:          dateToModel(dto.ValidTo),
 	}
 }
 
@@ -70,5 +73,7 @@ func scheduleToDTO(model *orgmodel.Schedule) *orgdto.Schedule {
 		Weekday:          model.Weekday,
 		Start:            model.Start.Format(timeFormat),
 		Over:             model.Over.Format(timeFormat),
+		ValidFrom:        dateToDTO(model.ValidFrom),
+		ValidTo:          dateToDTO(model.ValidTo),
 	}
 }
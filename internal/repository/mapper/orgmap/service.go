@@ -16,28 +16,46 @@ func ServiceToDTO(model *orgmodel.Service) *orgdto.ServiceResp {
 
 func AddServiceToModel(dto *orgdto.AddServiceReq) *orgmodel.Service {
 	return &orgmodel.Service{
-		ServiceID:   0, // zeroval
-		OrgID:       dto.OrgID,
-		Name:        dto.ServiceInfo.Name,
-		Cost:        dto.ServiceInfo.Cost,
-		Description: dto.ServiceInfo.Description,
+		ServiceID:    0, // zeroval
+		OrgID:        dto.OrgID,
+		Name:         dto.ServiceInfo.Name,
+		Cost:         dto.ServiceInfo.Cost,
+		Description:  dto.ServiceInfo.Description,
+		Duration:     dto.ServiceInfo.Duration,
+		BufferBefore: dto.ServiceInfo.BufferBefore,
+		BufferAfter:  dto.ServiceInfo.BufferAfter,
+		Capacity:     dto.ServiceInfo.Capacity,
+		Resources:    dto.ServiceInfo.Resources,
+		ResourceOnly: dto.ServiceInfo.ResourceOnly,
 	}
 }
 
 func UpdateService(dto *orgdto.UpdateServiceReq) *orgmodel.Service {
 	return &orgmodel.Service{
-		ServiceID:   dto.ServiceID,
-		OrgID:       dto.OrgID,
-		Name:        dto.ServiceInfo.Name,
-		Cost:        dto.ServiceInfo.Cost,
-		Description: dto.ServiceInfo.Description,
+		ServiceID:    dto.ServiceID,
+		OrgID:        dto.OrgID,
+		Name:         dto.ServiceInfo.Name,
+		Cost:         dto.ServiceInfo.Cost,
+		Description:  dto.ServiceInfo.Description,
+		Duration:     dto.ServiceInfo.Duration,
+		BufferBefore: dto.ServiceInfo.BufferBefore,
+		BufferAfter:  dto.ServiceInfo.BufferAfter,
+		Capacity:     dto.ServiceInfo.Capacity,
+		Resources:    dto.ServiceInfo.Resources,
+		ResourceOnly: dto.ServiceInfo.ResourceOnly,
 	}
 }
 
 func ServiceToEntity(model *orgmodel.Service) *entity.Service {
 	return &entity.Service{
-		Name:        model.Name,
-		Cost:        model.Cost,
-		Description: model.Description,
+		Name:         model.Name,
+		Cost:         model.Cost,
+		Description:  model.Description,
+		Duration:     model.Duration,
+		BufferBefore: model.BufferBefore,
+		BufferAfter:  model.BufferAfter,
+		Capacity:     model.Capacity,
+		Resources:    model.Resources,
+		ResourceOnly: model.ResourceOnly,
 	}
 }
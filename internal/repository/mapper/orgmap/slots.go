@@ -17,6 +17,7 @@ func SlotReqToModel(dto *orgdto.SlotReq) *orgmodel.SlotsMeta {
 	return &orgmodel.SlotsMeta{
 		SlotID:           dto.SlotID,
 		WorkerID:         dto.WorkerID,
+		ServiceID:        dto.ServiceID,
 		//WorkerScheduleID: dto.WorkerScheduleID,
 	}
 }
@@ -25,6 +26,8 @@ func SlotUpdateToModel(dto *orgdto.SlotUpdate) *orgmodel.SlotsMeta {
 	return &orgmodel.SlotsMeta{
 		SlotID:           dto.SlotID,
 		WorkerID:         dto.WorkerID,
+		OrgID:            dto.OrgID,
+		Capacity:         dto.Capacity,
 		//WorkerScheduleID: dto.WorkerScheduleID,
 	}
 }
@@ -34,8 +37,58 @@ func SlotInfoToDTO(model *orgmodel.Slot) *orgdto.Slot {
 		WorkerScheduleID: model.WorkerScheduleID,
 		WorkerID:         model.WorkerID,
 		Date:             strings.Fields(model.Date.String())[0],
-		Begin:            model.Begin.Format(timeFormat),
-		End:              model.End.Format(timeFormat),
+		Begin:            zonedClock(model.Begin, model.TimeZone),
+		End:              zonedClock(model.End, model.TimeZone),
 		Busy:             model.Busy,
+		Capacity:         model.Capacity,
+		SeatsLeft:        seatsLeft(model.Capacity, model.SeatsTaken, model.Busy),
+	}
+}
+
+// Закрытый организацией или заполненный слот мест не имеет
+func seatsLeft(capacity, taken int, busy bool) int {
+	if busy || taken >= capacity {
+		return 0
+	}
+	return capacity - taken
+}
+
+func SlotConflictToDTO(model *orgmodel.SlotConflict) *orgdto.SlotConflict {
+	return &orgdto.SlotConflict{
+		SlotID:   model.SlotID,
+		WorkerID: model.WorkerID,
+		RecordID: int(model.RecordID.Int64),
+		UserID:   int(model.UserID.Int64),
+		Date:     strings.Fields(model.Date.String())[0],
+		Begin:    zonedClock(model.Begin, model.TimeZone),
+		End:      zonedClock(model.End, model.TimeZone),
+	}
+}
+
+func AttendeesToDTO(model *orgmodel.Attendees) *orgdto.Attendees {
+	list := make([]*orgdto.Attendee, 0, len(model.List))
+	for _, v := range model.List {
+		attendee := &orgdto.Attendee{
+			RecordID:  v.RecordID,
+			UserID:    v.UserID,
+			FirstName: v.FirstName,
+			LastName:  v.LastName,
+			Telephone: v.Telephone,
+			ServiceID: v.ServiceID,
+		}
+		if v.Attended.Valid {
+			attendee.Attended = &v.Attended.Bool
+		}
+		list = append(list, attendee)
+	}
+	return &orgdto.Attendees{
+		SlotID:    model.SlotID,
+		WorkerID:  model.WorkerID,
+		Date:      model.Date.Format(isoDate),
+		Begin:     zonedClock(model.Begin, model.TimeZone),
+		End:       zonedClock(model.End, model.TimeZone),
+		Capacity:  model.Capacity,
+		SeatsLeft: seatsLeft(model.Capacity, model.SeatsTaken, false),
+		List:      list,
 	}
 }
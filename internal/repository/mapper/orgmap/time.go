@@ -2,8 +2,11 @@ package orgmap
 
 import (
 	"database/sql"
+	"sort"
 	"time"
 	"timeline/internal/entity"
+	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/libs/custom"
 	"timeline/internal/repository/models/orgmodel"
 )
 
@@ -18,12 +21,24 @@ func OpenHoursToModel(day *entity.OpenHours) *orgmodel.OpenHours {
 	breakstart, _ := time.Parse(timeFormat, day.BreakStart)
 	breakend, _ := time.Parse(timeFormat, day.BreakEnd)
 
+	breaks := make([]*orgmodel.Break, 0, len(day.Breaks))
+	for _, v := range DayBreaks(day) {
+		start, _ := time.Parse(timeFormat, v.Start)
+		end, _ := time.Parse(timeFormat, v.End)
+		breaks = append(breaks, &orgmodel.Break{Weekday: day.Weekday, Start: start, End: end})
+	}
+	sort.Slice(breaks, func(i, j int) bool { return breaks[i].Start.Before(breaks[j].Start) })
+	// Первый перерыв дня сохраняется и в старых полях
+	if len(breaks) > 0 {
+		breakstart, breakend = breaks[0].Start, breaks[0].End
+	}
 	return &orgmodel.OpenHours{
 		Weekday:    sql.NullInt32{Int32: int32(day.Weekday), Valid: true},
 		Open:       sql.NullTime{Time: open, Valid: true},
 		Close:      sql.NullTime{Time: close, Valid: true},
 		BreakStart: sql.NullTime{Time: breakstart, Valid: true},
 		BreakEnd:   sql.NullTime{Time: breakend, Valid: true},
+		Breaks:     breaks,
 	}
 }
 
@@ -32,11 +47,105 @@ func OpenHoursToDTO(day *orgmodel.OpenHours) *entity.OpenHours {
 	if !day.Weekday.Valid {
 		return nil
 	}
-	return &entity.OpenHours{
+	hours := &entity.OpenHours{
 		Weekday:    int(day.Weekday.Int32),
 		Open:       day.Open.Time.Format(timeFormat),
 		Close:      day.Close.Time.Format(timeFormat),
 		BreakStart: day.BreakStart.Time.Format(timeFormat),
 		BreakEnd:   day.BreakEnd.Time.Format(timeFormat),
 	}
+	for _, v := range day.Breaks {
+		hours.Breaks = append(hours.Breaks, &entity.Break{
+			Start: v.Start.Format(timeFormat),
+			End:   v.End.Format(timeFormat),
+		})
+	}
+	return hours
+}
+
+// Перерывы дня: список breaks, либо единственный break_start-break_end.
+// Пустой перерыв (00:00-00:00) означает его отсутствие
+func DayBreaks(day *entity.OpenHours) []*entity.Break {
+	if len(day.Breaks) > 0 {
+		return day.Breaks
+	}
+	if day.BreakStart == "" || day.BreakEnd == "" || day.BreakStart == day.BreakEnd {
+		return nil
+	}
+	return []*entity.Break{{Start: day.BreakStart, End: day.BreakEnd}}
+}
+
+// Время проверено валидатором. Незаданные часы остаются NULL
+func TimetableExceptionToModel(dto *orgdto.TimetableException) *orgmodel.TimetableException {
+	date, _ := time.Parse(isoDate, dto.Date)
+	return &orgmodel.TimetableException{
+		ExceptionID: dto.ExceptionID,
+		OrgID:       dto.OrgID,
+		Date:        date,
+		Closed:      dto.Closed,
+		Open:        clockToModel(dto.Open),
+		Close:       clockToModel(dto.Close),
+		BreakStart:  clockToModel(dto.BreakStart),
+		BreakEnd:    clockToModel(dto.BreakEnd),
+		Reason:      dto.Reason,
+		Workers:     dto.Workers,
+	}
+}
+
+func TimetableExceptionToDTO(model *orgmodel.TimetableException) *orgdto.TimetableException {
+	return &orgdto.TimetableException{
+		ExceptionID: model.ExceptionID,
+		OrgID:       model.OrgID,
+		Date:        model.Date.Format(isoDate),
+		Closed:      model.Closed,
+		Open:        clockToDTO(model.Open),
+		Close:       clockToDTO(model.Close),
+		BreakStart:  clockToDTO(model.BreakStart),
+		BreakEnd:    clockToDTO(model.BreakEnd),
+		Reason:      model.Reason,
+		Workers:     model.Workers,
+	}
+}
+
+func TimetableExceptionListToDTO(model []*orgmodel.TimetableException) []*orgdto.TimetableException {
+	list := make([]*orgdto.TimetableException, 0, len(model))
+	for _, v := range model {
+		list = append(list, TimetableExceptionToDTO(v))
+	}
+	return list
+}
+
+func clockToModel(clock string) sql.NullTime {
+	if clock == "" {
+		return sql.NullTime{}
+	}
+	t, _ := time.Parse(timeFormat, clock)
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+// Часы момента t в поясе организации tz
+func zonedClock(t time.Time, tz string) string {
+	return t.In(custom.Location(tz)).Format(timeFormat)
+}
+
+func clockToDTO(clock sql.NullTime) string {
+	if !clock.Valid {
+		return ""
+	}
+	return clock.Time.Format(timeFormat)
+}
+
+func dateToModel(date string) sql.NullTime {
+	if date == "" {
+		return sql.NullTime{}
+	}
+	t, _ := time.Parse(isoDate, date)
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+func dateToDTO(date sql.NullTime) string {
+	if !date.Valid {
+		return ""
+	}
+	return date.Time.Format(isoDate)
 }
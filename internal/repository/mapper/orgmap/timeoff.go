@@ -0,0 +1,81 @@
+package orgmap
+
+import (
+	"time"
+	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/repository/models/orgmodel"
+)
+
+const isoDate = "2006-01-02"
+
+// Даты проверены валидатором
+func TimeOffReqToModel(dto *orgdto.TimeOffReq) *orgmodel.TimeOff {
+	from, _ := time.Parse(isoDate, dto.From)
+	to, _ := time.Parse(isoDate, dto.To)
+	kind := dto.Kind
+	if kind == "" {
+		kind = orgmodel.TimeOffOther
+	}
+	return &orgmodel.TimeOff{
+		TimeOffID: dto.TimeOffID,
+		WorkerID:  dto.WorkerID,
+		OrgID:     dto.OrgID,
+		DateFrom:  from,
+		DateTo:    to,
+		TimeFrom:  clockToModel(dto.TimeFrom),
+		TimeTo:    clockToModel(dto.TimeTo),
+		Kind:      kind,
+		Reason:    dto.Reason,
+	}
+}
+
+func TimeOffDecisionToModel(dto *orgdto.TimeOffDecision) *orgmodel.TimeOff {
+	return &orgmodel.TimeOff{
+		TimeOffID: dto.TimeOffID,
+		WorkerID:  dto.WorkerID,
+		OrgID:     dto.OrgID,
+		Status:    dto.Status,
+	}
+}
+
+func TimeOffToDTO(model *orgmodel.TimeOff) *orgdto.TimeOff {
+	return &orgdto.TimeOff{
+		TimeOffID: model.TimeOffID,
+		WorkerID:  model.WorkerID,
+		From:      model.DateFrom.Format(isoDate),
+		To:        model.DateTo.Format(isoDate),
+		TimeFrom:  clockToDTO(model.TimeFrom),
+		TimeTo:    clockToDTO(model.TimeTo),
+		Kind:      model.Kind,
+		Reason:    model.Reason,
+		Status:    model.Status,
+		CreatedAt: model.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func TimeOffListToDTO(model []*orgmodel.TimeOff) []*orgdto.TimeOff {
+	list := make([]*orgdto.TimeOff, 0, len(model))
+	for _, v := range model {
+		list = append(list, TimeOffToDTO(v))
+	}
+	return list
+}
+
+func TimeOffCollisionsToDTO(model []*orgmodel.TimeOffCollision) []*orgdto.TimeOffCollision {
+	list := make([]*orgdto.TimeOffCollision, 0, len(model))
+	for _, v := range model {
+		list = append(list, &orgdto.TimeOffCollision{
+			RecordID:  v.RecordID,
+			SlotID:    v.SlotID,
+			UserID:    v.UserID,
+			FirstName: v.FirstName,
+			LastName:  v.LastName,
+			Email:     v.Email,
+			Telephone: v.Telephone.String,
+			Date:      v.Date.Format(isoDate),
+			Begin:     zonedClock(v.Begin, v.TimeZone),
+			End:       zonedClock(v.End, v.TimeZone),
+		})
+	}
+	return list
+}
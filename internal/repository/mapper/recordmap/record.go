@@ -1,8 +1,10 @@
 package recordmap
 
 import (
+	"database/sql"
 	"time"
 	"timeline/internal/entity/dto/recordto"
+	"timeline/internal/libs/custom"
 	"timeline/internal/repository/mail/entity"
 	"timeline/internal/repository/mapper/orgmap"
 	"timeline/internal/repository/mapper/usermap"
@@ -10,6 +12,8 @@ import (
 )
 
 func RecordToModel(dto *recordto.Record) *recordmodel.Record {
+	// Без slot_id пустое начало сеанса отклоняется на уровне usecase
+	begin, _ := time.Parse("2006-01-02 15:04", dto.Date+" "+dto.Begin)
 	return &recordmodel.Record{
 		RecordID:  dto.RecordID,
 		OrgID:     dto.OrgID,
@@ -18,6 +22,7 @@ func RecordToModel(dto *recordto.Record) *recordmodel.Record {
 		ServiceID: dto.ServiceID,
 		WorkerID:  dto.WorkerID,
 		Reviewed:  dto.Reviewed,
+		Begin:     begin,
 	}
 }
 
@@ -37,6 +42,7 @@ func RecordParamsToModel(dto *recordto.RecordListParams) *recordmodel.RecordList
 	return &recordmodel.RecordListParams{
 		OrgID:    dto.OrgID,
 		UserID:   dto.UserID,
+		WorkerID: dto.WorkerID,
 		Reviewed: dto.Reviewed,
 		Fresh:    dto.Fresh,
 	}
@@ -46,6 +52,7 @@ func RecordScrapToDTO(model *recordmodel.RecordScrap) *recordto.RecordScrap {
 	return &recordto.RecordScrap{
 		RecordID: model.RecordID,
 		Reviewed: model.Reviewed,
+		Attended: attendedToDTO(model.Attended),
 		Org:      orgmap.OrgInfoToEntity(model.Org),
 		User:     usermap.UserInfoToDTO(model.User),
 		Slot:     orgmap.SlotInfoToDTO(model.Slot),
@@ -55,6 +62,22 @@ func RecordScrapToDTO(model *recordmodel.RecordScrap) *recordto.RecordScrap {
 	}
 }
 
+func attendedToDTO(attended sql.NullBool) *bool {
+	if !attended.Valid {
+		return nil
+	}
+	return &attended.Bool
+}
+
+func AttendanceToModel(dto *recordto.AttendanceReq) *recordmodel.Attendance {
+	return &recordmodel.Attendance{
+		RecordID: dto.RecordID,
+		OrgID:    dto.OrgID,
+		WorkerID: dto.WorkerID,
+		Attended: *dto.Attended,
+	}
+}
+
 func RecordListToDTO(model []*recordmodel.RecordScrap) []*recordto.RecordScrap {
 	list := make([]*recordto.RecordScrap, 0, len(model))
 	for _, v := range model {
@@ -63,14 +86,17 @@ func RecordListToDTO(model []*recordmodel.RecordScrap) []*recordto.RecordScrap {
 	return list
 }
 
-func RecordToReminder(model *recordmodel.ReminderRecord) *entity.ReminderMsg {
-	return &entity.ReminderMsg{
+// Время сеанса переводится в пояс организации: так его видит клиент в письме,
+// а в ICS уходит тот же момент в UTC
+func RecordToReminder(model *recordmodel.ReminderRecord) entity.ReminderMsg {
+	loc := custom.Location(model.TimeZone)
+	return entity.ReminderMsg{
 		Organization: model.OrgName,
 		Service:      model.ServiceName,
 		Description:  model.ServiceDescription,
 		Address:      model.OrgAddress,
-		SessionStart: time.Date(model.Date.Year(), model.Date.Month(), model.Date.Day(), model.Begin.Hour(), model.Begin.Minute(), model.Begin.Second(), model.Begin.Nanosecond(), model.Begin.UTC().Location()),
-		SessionEnd:   time.Date(model.Date.Year(), model.Date.Month(), model.Date.Day(), model.End.Hour(), model.End.Minute(), model.End.Second(), model.End.Nanosecond(), model.End.UTC().Location()),
+		SessionStart: model.Begin.In(loc),
+		SessionEnd:   model.End.In(loc),
 		SessionDate:  model.Date,
 	}
 }
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type Admin struct {
+	AdminID    int       `db:"admin_id"`
+	Email      string    `db:"email"`
+	PasswdHash string    `db:"passwd_hash"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// Поиск аккаунтов модератором: по почте или имени, включая заблокированные и удаленные
+type AccountSearch struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// Краткие сведения об аккаунте пользователя или организации
+type AccountBrief struct {
+	ID            int       `db:"id"`
+	Email         string    `db:"email"`
+	Name          string    `db:"name"`
+	City          string    `db:"city"`
+	Verified      bool      `db:"verified"`
+	IsDelete      bool      `db:"is_delete"`
+	IsBlocked     bool      `db:"is_blocked"`
+	BlockedReason string    `db:"blocked_reason"`
+	CreatedAt     time.Time `db:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type APIKey struct {
+	KeyID      int          `db:"key_id"`
+	OrgID      int          `db:"org_id"`
+	Name       string       `db:"name"`
+	Prefix     string       `db:"prefix"`
+	KeyHash    string       `db:"key_hash"`
+	Scopes     string       `db:"scopes"` // через пробел
+	CreatedAt  time.Time    `db:"created_at"`
+	ExpiresAt  sql.NullTime `db:"expires_at"`
+	LastUsedAt sql.NullTime `db:"last_used_at"`
+	LastUsedIP string       `db:"last_used_ip"`
+	Revoked    bool         `db:"revoked"`
+}
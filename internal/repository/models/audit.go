@@ -0,0 +1,33 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Запись журнала действий. Before и After - json состояния сущности
+type AuditEntry struct {
+	AuditID   int64         `db:"audit_id"`
+	ActorType string        `db:"actor_type"`
+	ActorID   int           `db:"actor_id"`
+	OrgID     sql.NullInt64 `db:"org_id"`
+	Action    string        `db:"action"`
+	Entity    string        `db:"entity"`
+	EntityID  int           `db:"entity_id"`
+	Before    []byte        `db:"before"`
+	After     []byte        `db:"after"`
+	IP        string        `db:"ip"`
+	UserAgent string        `db:"user_agent"`
+	CreatedAt time.Time     `db:"created_at"`
+}
+
+// Фильтры журнала. Нулевые значения не ограничивают выборку
+type AuditParams struct {
+	OrgID    int
+	Entity   string
+	EntityID int
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
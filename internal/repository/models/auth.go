@@ -1,21 +1,77 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type ExpInfo struct {
 	ID        int
 	Verified  bool
+	Blocked   bool // заблокирован модератором
 	CreatedAt time.Time
 	Hash      string
 }
 
+// Назначение кода
+const (
+	CodeVerify = "verify" // подтверждение почты при регистрации
+	CodeLogin  = "login"  // одноразовая ссылка входа
+)
+
 type CodeInfo struct {
-	ID    int
-	Code  string
-	IsOrg bool
+	ID        int
+	Code      string // хеш кода
+	IsOrg     bool
+	IP        string
+	Purpose   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type VerifyCode struct {
+	CodeID    int       `db:"code_id"`
+	Code      string    `db:"code"` // хеш кода
+	Attempts  int       `db:"attempts"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// Статистика отправки кодов для ограничения частоты
+type CodeSendStats struct {
+	LastSentAt time.Time // последний код аккаунта, нулевое время если кодов не было
+	SentFromIP int       // отправлено с IP за окно
 }
 
 type HashCreds struct {
 	Email      string `db:"email"`
 	PasswdHash string `db:"passwd_hash"`
-}
\ No newline at end of file
+}
+type PasswordReset struct {
+	ResetID   int       `db:"reset_id"`
+	TokenHash string    `db:"token_hash"`
+	SubjectID int       `db:"subject_id"`
+	IsOrg     bool      `db:"is_org"`
+	ExpiresAt time.Time `db:"expires_at"`
+	Used      bool      `db:"used"`
+}
+
+type EmailChange struct {
+	ChangeID  int       `db:"change_id"`
+	SubjectID int       `db:"subject_id"`
+	IsOrg     bool      `db:"is_org"`
+	NewEmail  string    `db:"new_email"`
+	Code      string    `db:"code"` // хеш кода
+	Attempts  int       `db:"attempts"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type OrgMFA struct {
+	OrgID          int          `db:"org_id"`
+	Secret         string       `db:"secret"`
+	Enabled        bool         `db:"enabled"`
+	LastStep       int64        `db:"last_step"`
+	FailedAttempts int          `db:"failed_attempts"`
+	LastFailedAt   sql.NullTime `db:"last_failed_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Привязка учетной записи провайдера к пользователю
+type Identity struct {
+	IdentityID int       `db:"identity_id"`
+	UserID     int       `db:"user_id"`
+	Provider   string    `db:"provider"`
+	Subject    string    `db:"subject"`
+	Email      string    `db:"email"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type OIDCState struct {
+	StateHash string    `db:"state_hash"`
+	Provider  string    `db:"provider"`
+	Verifier  string    `db:"verifier"`
+	Nonce     string    `db:"nonce"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
@@ -0,0 +1,30 @@
+package orgmodel
+
+import "time"
+
+type AvailabilityParams struct {
+	OrgID     int
+	WorkerID  int
+	ServiceID int
+	Date      time.Time
+}
+
+// Свободное время сотрудника на день под конкретную услугу
+type Availability struct {
+	WorkerID     int
+	ServiceID    int
+	Date         time.Time
+	Duration     int
+	BufferBefore int
+	BufferAfter  int
+	Sessions     []*Session
+	TimeZone     string
+}
+
+// Сеанс, на который можно записаться
+type Session struct {
+	SlotID    int // начатый групповой сеанс, 0 - новый
+	Begin     time.Time
+	End       time.Time
+	SeatsLeft int
+}
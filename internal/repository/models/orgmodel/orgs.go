@@ -2,6 +2,7 @@ package orgmodel
 
 import (
 	"database/sql"
+	"time"
 	"timeline/internal/repository/models"
 )
 
@@ -16,6 +17,13 @@ type OpenHours struct {
 	Close      sql.NullTime  `db:"close"`
 	BreakStart sql.NullTime  `db:"break_start"`
 	BreakEnd   sql.NullTime  `db:"break_end"`
+	Breaks     []*Break      `db:"-"`
+}
+
+type Break struct {
+	Weekday int       `db:"weekday"`
+	Start   time.Time `db:"break_start"`
+	End     time.Time `db:"break_end"`
 }
 
 type OrgInfo struct {
@@ -27,12 +35,28 @@ type OrgInfo struct {
 	Address   string  `db:"address"`
 	Telephone string  `db:"telephone"`
 	About     string  `db:"about"`
+	TimeZone  string  `db:"timezone"`
 	Coordinates
 }
 
 type Organization struct {
 	OrgInfo
-	Timetable []*OpenHours
+	Timetable  []*OpenHours
+	Exceptions []*TimetableException
+}
+
+// Особый день организации. Closed - выходной, иначе часы вместо недельного расписания
+type TimetableException struct {
+	ExceptionID int          `db:"exception_id"`
+	OrgID       int          `db:"org_id"`
+	Date        time.Time    `db:"date"`
+	Closed      bool         `db:"closed"`
+	Open        sql.NullTime `db:"open"`
+	Close       sql.NullTime `db:"close"`
+	BreakStart  sql.NullTime `db:"break_start"`
+	BreakEnd    sql.NullTime `db:"break_end"`
+	Reason      string       `db:"reason"`
+	Workers     []int        `db:"-"` // вышедшие в рабочий день сотрудники
 }
 
 type OrgsBySearch struct {
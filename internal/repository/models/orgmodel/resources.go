@@ -0,0 +1,20 @@
+package orgmodel
+
+import "time"
+
+// Ресурс организации: кабинет, оборудование, корт
+type Resource struct {
+	ResourceID  int    `db:"resource_id"`
+	OrgID       int    `db:"org_id"`
+	Name        string `db:"name"`
+	Description string `db:"description"`
+	Hours       []*ResourceHours
+}
+
+// Часы доступности ресурса в день недели. Нет часов - часы организации
+type ResourceHours struct {
+	ResourceID int       `db:"resource_id"`
+	Weekday    int       `db:"weekday"`
+	Open       time.Time `db:"open"`
+	Close      time.Time `db:"close"`
+}
@@ -1,6 +1,9 @@
 package orgmodel
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type WorkerSchedule struct {
 	WorkerID        int `db:"worker_id"`
@@ -19,12 +22,16 @@ type Schedule struct {
 	Weekday          int       `db:"weekday"`
 	Start            time.Time `db:"start"`
 	Over             time.Time `db:"over"`
+	// Период действия версии расписания. NULL - без ограничения
+	ValidFrom sql.NullTime `db:"valid_from"`
+	ValidTo   sql.NullTime `db:"valid_to"`
 }
 
 type ScheduleParams struct {
-	WorkerID int `db:"worker_id"`
-	OrgID    int `db:"org_id"`
-	Weekday  int `db:"weekday"`
+	WorkerID int          `db:"worker_id"`
+	OrgID    int          `db:"org_id"`
+	Weekday  int          `db:"weekday"`
+	Date     sql.NullTime // день действующей версии расписания, NULL - сегодня
 	Limit    int
 	Offset   int
 }
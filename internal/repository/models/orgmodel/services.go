@@ -1,9 +1,16 @@
 package orgmodel
 
 type Service struct {
-	ServiceID   int     `db:"service_id"`
-	OrgID       int     `db:"org_id"`
-	Name        string  `db:"name"`
-	Cost        float64 `db:"cost"`
-	Description string  `db:"description"`
+	ServiceID    int     `db:"service_id"`
+	OrgID        int     `db:"org_id"`
+	Name         string  `db:"name"`
+	Cost         float64 `db:"cost"`
+	Description  string  `db:"description"`
+	Duration     int     `db:"duration"` // 0 - длительность сеанса сотрудника
+	BufferBefore int     `db:"buffer_before"`
+	BufferAfter  int     `db:"buffer_after"`
+	Capacity     int     `db:"capacity"` // мест на один сеанс
+	Resources    []int   `db:"-"`        // nil при обновлении - не менять
+	// Без сотрудника: бронируются только ресурсы
+	ResourceOnly bool `db:"resource_only"`
 }
@@ -1,6 +1,9 @@
 package orgmodel
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type Slot struct {
 	SlotID           int       `db:"slot_id"`
@@ -10,10 +13,51 @@ type Slot struct {
 	Begin            time.Time `db:"session_begin"`
 	End              time.Time `db:"session_end"`
 	Busy             bool      `db:"busy"`
+	TimeZone         string    `db:"timezone"` // пояс организации для вывода
+	Capacity         int       `db:"capacity"`
+	SeatsTaken       int       `db:"seats_taken"`
 }
 
 type SlotsMeta struct {
 	SlotID           int `db:"slot_id"`
 	WorkerID         int `db:"worker_id"`
+	OrgID            int `db:"org_id"`
+	ServiceID        int `db:"service_id"` // вместимость пустых слотов - по этой услуге
+	Capacity         int `db:"capacity"`   // 0 - не менять
 	//WorkerScheduleID int `db:"worker_schedule_id"`
 }
+
+// Занятый слот вне рабочего времени сотрудника
+type SlotConflict struct {
+	SlotID   int           `db:"slot_id"`
+	WorkerID int           `db:"worker_id"`
+	RecordID sql.NullInt64 `db:"record_id"`
+	UserID   sql.NullInt64 `db:"user_id"`
+	Date     time.Time     `db:"date"`
+	Begin    time.Time     `db:"session_begin"`
+	End      time.Time     `db:"session_end"`
+	TimeZone string        `db:"timezone"`
+}
+
+// Клиенты, записанные в слот
+type Attendees struct {
+	SlotID     int       `db:"slot_id"`
+	WorkerID   int       `db:"worker_id"`
+	Date       time.Time `db:"date"`
+	Begin      time.Time `db:"session_begin"`
+	End        time.Time `db:"session_end"`
+	Capacity   int       `db:"capacity"`
+	SeatsTaken int       `db:"seats_taken"`
+	TimeZone   string    `db:"timezone"`
+	List       []*Attendee
+}
+
+type Attendee struct {
+	RecordID  int          `db:"record_id"`
+	UserID    int          `db:"user_id"`
+	FirstName string       `db:"first_name"`
+	LastName  string       `db:"last_name"`
+	Telephone string       `db:"telephone"`
+	ServiceID int          `db:"service_id"`
+	Attended  sql.NullBool `db:"attended"`
+}
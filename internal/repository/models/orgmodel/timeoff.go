@@ -0,0 +1,50 @@
+package orgmodel
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Статусы заявки на отгул
+const (
+	TimeOffPending  = "pending"
+	TimeOffApproved = "approved"
+	TimeOffRejected = "rejected"
+)
+
+// Виды отсутствия
+const (
+	TimeOffVacation = "vacation"
+	TimeOffSick     = "sick"
+	TimeOffOther    = "other"
+)
+
+type TimeOff struct {
+	TimeOffID int       `db:"timeoff_id"`
+	WorkerID  int       `db:"worker_id"`
+	OrgID     int       `db:"org_id"`
+	DateFrom  time.Time `db:"date_from"`
+	DateTo    time.Time `db:"date_to"`
+	// Часть дня. Пустые - весь день
+	TimeFrom  sql.NullTime `db:"time_from"`
+	TimeTo    sql.NullTime `db:"time_to"`
+	Kind      string       `db:"kind"`
+	Reason    string       `db:"reason"`
+	Status    string       `db:"status"`
+	CreatedAt time.Time    `db:"created_at"`
+}
+
+// Запись клиента, попадающая на отсутствие сотрудника
+type TimeOffCollision struct {
+	RecordID  int            `db:"record_id"`
+	SlotID    int            `db:"slot_id"`
+	UserID    int            `db:"user_id"`
+	FirstName string         `db:"first_name"`
+	LastName  string         `db:"last_name"`
+	Email     string         `db:"email"`
+	Telephone sql.NullString `db:"telephone"`
+	Date      time.Time      `db:"date"`
+	Begin     time.Time      `db:"session_begin"`
+	End       time.Time      `db:"session_end"`
+	TimeZone  string         `db:"timezone"`
+}
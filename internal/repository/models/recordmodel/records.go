@@ -1,6 +1,7 @@
 package recordmodel
 
 import (
+	"database/sql"
 	"time"
 	"timeline/internal/repository/models/orgmodel"
 	"timeline/internal/repository/models/usermodel"
@@ -14,11 +15,14 @@ type Record struct {
 	ServiceID int  `db:"service_id"`
 	WorkerID  int  `db:"worker_id"`
 	Reviewed  bool `db:"reviewed"`
+	// Начало сеанса для записи без slot_id, настенное время организации
+	Begin time.Time `db:"-"`
 }
 
 type RecordListParams struct {
 	OrgID    int  `db:"org_id"`
 	UserID   int  `db:"user_id"`
+	WorkerID int  `db:"worker_id"`
 	Reviewed bool `db:"reviewed"`
 	Fresh    bool
 	Limit    int
@@ -26,8 +30,9 @@ type RecordListParams struct {
 }
 
 type RecordScrap struct {
-	RecordID int  `db:"record_id"`
-	Reviewed bool `db:"reviewed"`
+	RecordID int          `db:"record_id"`
+	Reviewed bool         `db:"reviewed"`
+	Attended sql.NullBool `db:"attended"`
 	Org      *orgmodel.OrgInfo
 	User     *usermodel.UserInfo
 	Slot     *orgmodel.Slot
@@ -36,6 +41,13 @@ type RecordScrap struct {
 	Feedback *Feedback
 }
 
+type Attendance struct {
+	RecordID int  `db:"record_id"`
+	OrgID    int  `db:"org_id"`
+	WorkerID int  `db:"worker_id"`
+	Attended bool `db:"attended"`
+}
+
 type ReminderRecord struct {
 	UserEmail          string
 	ServiceName        string
@@ -45,4 +57,5 @@ type ReminderRecord struct {
 	Date               time.Time
 	Begin              time.Time
 	End                time.Time
+	TimeZone           string
 }
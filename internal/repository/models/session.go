@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type Session struct {
+	SessionID int       `db:"session_id"`
+	JTI       string    `db:"jti"`
+	Family    string    `db:"family"`
+	SubjectID int       `db:"subject_id"`
+	IsOrg     bool      `db:"is_org"`
+	Role      string    `db:"role"`
+	UserAgent string    `db:"user_agent"`
+	IP        string    `db:"ip"`
+	StartedAt time.Time `db:"started_at"`
+	LastSeen  time.Time `db:"last_seen"`
+	ExpiresAt time.Time `db:"expires_at"`
+	Rotated   bool      `db:"rotated"`
+	Revoked   bool      `db:"revoked"`
+}
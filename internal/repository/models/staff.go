@@ -0,0 +1,18 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Учетная запись сотрудника организации
+type WorkerAccount struct {
+	WorkerID        int          `db:"worker_id"`
+	OrgID           int          `db:"org_id"`
+	Email           string       `db:"email"`
+	PasswdHash      string       `db:"passwd_hash"`
+	InviteHash      string       `db:"invite_hash"` // хеш токена приглашения
+	InviteExpiresAt sql.NullTime `db:"invite_expires_at"`
+	Activated       bool         `db:"activated"`
+	CreatedAt       time.Time    `db:"created_at"`
+}
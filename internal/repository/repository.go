@@ -23,16 +23,109 @@ type Repository interface {
 	UserRepository
 	OrgRepository
 	RecordRepository
+	SessionRepository
+	PasswordRepository
+	EmailRepository
+	MFARepository
+	StaffRepository
+	AdminRepository
+	AuditRepository
+	IdentityRepository
+	APIKeyRepository
+	PrivacyRepository
 }
 
 type CodeRepository interface {
 	SaveVerifyCode(ctx context.Context, info *models.CodeInfo) error
-	VerifyCode(ctx context.Context, info *models.CodeInfo) (time.Time, error)
+	ActiveCode(ctx context.Context, id int, isOrg bool, purpose string) (*models.VerifyCode, error)
+	CodeAttempt(ctx context.Context, codeID int, isOrg bool) error
+	CodeUse(ctx context.Context, codeID int, isOrg bool, maxAttempts int) error
+	CodeSendStats(ctx context.Context, id int, isOrg bool, ip string, since time.Time) (*models.CodeSendStats, error)
 	ActivateAccount(ctx context.Context, id int, isOrg bool) error
 	AccountExpiration(ctx context.Context, email string, isOrg bool) (*models.ExpInfo, error)
 	DeleteExpiredCodes(ctx context.Context) error
 }
 
+type SessionRepository interface {
+	SessionSave(ctx context.Context, session *models.Session) error
+	SessionByJTI(ctx context.Context, jti string) (*models.Session, error)
+	SessionRotate(ctx context.Context, oldJTI string, new *models.Session) error
+	SessionRevokeFamily(ctx context.Context, family string) error
+	SessionRevokeAll(ctx context.Context, subjectID int, isOrg bool, role, keepFamily string) error
+	SessionList(ctx context.Context, subjectID int, isOrg bool, role string) ([]*models.Session, error)
+	DeleteExpiredSessions(ctx context.Context) error
+}
+
+type PasswordRepository interface {
+	PasswordResetSave(ctx context.Context, reset *models.PasswordReset) error
+	PasswordResetUse(ctx context.Context, tokenHash, passwdHash string) (*models.PasswordReset, error)
+	PasswordHash(ctx context.Context, id int, isOrg bool) (string, error)
+	PasswordUpdate(ctx context.Context, id int, isOrg bool, hash string) error
+	DeleteExpiredPasswordResets(ctx context.Context) error
+}
+
+type EmailRepository interface {
+	AccountEmail(ctx context.Context, id int, isOrg bool) (string, error)
+	EmailInUse(ctx context.Context, email string, isOrg bool) (bool, error)
+	EmailChangeSave(ctx context.Context, change *models.EmailChange) error
+	EmailChangeLast(ctx context.Context, id int, isOrg bool) (*models.EmailChange, error)
+	EmailChangeActive(ctx context.Context, id int, isOrg bool) (*models.EmailChange, error)
+	EmailChangeAttempt(ctx context.Context, changeID int) error
+	EmailChangeApply(ctx context.Context, changeID int, maxAttempts int) (*models.EmailChange, error)
+	DeleteExpiredEmailChanges(ctx context.Context) error
+}
+
+type MFARepository interface {
+	MFASecretSave(ctx context.Context, orgID int, secret string) error
+	MFAByOrg(ctx context.Context, orgID int) (*models.OrgMFA, error)
+	MFAEnable(ctx context.Context, orgID int, step int64, recoveryHashes []string) error
+	MFADisable(ctx context.Context, orgID int) error
+	MFAStepAccept(ctx context.Context, orgID int, step int64) error
+	MFAFail(ctx context.Context, orgID int, at time.Time) error
+	MFARecoveryUse(ctx context.Context, orgID int, codeHash string) error
+	MFATokenUse(ctx context.Context, jtiHash string, expiresAt time.Time) error
+	DeleteExpiredMFATokens(ctx context.Context) error
+}
+
+type StaffRepository interface {
+	WorkerInviteSave(ctx context.Context, account *models.WorkerAccount) error
+	WorkerInviteAccept(ctx context.Context, inviteHash, passwdHash string) (*models.WorkerAccount, error)
+	WorkerAccountByEmail(ctx context.Context, email string) (*models.WorkerAccount, error)
+}
+
+type AdminRepository interface {
+	AdminSave(ctx context.Context, email, passwdHash string) (int, error)
+	AdminByEmail(ctx context.Context, email string) (*models.Admin, error)
+	AdminAccounts(ctx context.Context, isOrg bool, params *models.AccountSearch) ([]*models.AccountBrief, int, error)
+	AccountBlock(ctx context.Context, id int, isOrg bool, blocked bool, reason string) (*models.AccountBrief, error)
+	FeedbackHide(ctx context.Context, recordID int, hidden bool) error
+}
+
+type AuditRepository interface {
+	AuditSave(ctx context.Context, entry *models.AuditEntry) error
+	AuditList(ctx context.Context, params *models.AuditParams) ([]*models.AuditEntry, int, error)
+}
+
+type IdentityRepository interface {
+	OIDCStateSave(ctx context.Context, state *models.OIDCState) error
+	OIDCStateUse(ctx context.Context, stateHash string) (*models.OIDCState, error)
+	IdentityUser(ctx context.Context, provider, subject string) (int, error)
+	IdentityLink(ctx context.Context, identity *models.Identity) error
+	DeleteExpiredOIDCStates(ctx context.Context) error
+}
+
+type APIKeyRepository interface {
+	APIKeySave(ctx context.Context, key *models.APIKey) (int, error)
+	APIKeyList(ctx context.Context, orgID int) ([]*models.APIKey, error)
+	APIKeyRevoke(ctx context.Context, orgID, keyID int) error
+	APIKeyUse(ctx context.Context, keyHash, ip string) (*models.APIKey, error)
+}
+
+type PrivacyRepository interface {
+	AccountErase(ctx context.Context, id int, isOrg bool) error
+	AccountPurge(ctx context.Context, retention time.Duration) error
+}
+
 type UserRepository interface {
 	UserUpdate(ctx context.Context, new *usermodel.UserInfo) error
 	UserSave(ctx context.Context, user *usermodel.UserRegister) (int, error)
@@ -53,14 +146,19 @@ type OrgRepository interface {
 	ServiceRepository
 	SlotRepository
 	ScheduleRepository
+	TimeOffRepository
+	ResourceRepository
 }
 
 type RecordRepository interface {
 	Record(ctx context.Context, recordID int) (*recordmodel.RecordScrap, error)
+	RecordOwners(ctx context.Context, recordID int) (*recordmodel.Record, error)
+	OrgClient(ctx context.Context, orgID, userID int) (bool, error)
 	RecordList(ctx context.Context, req *recordmodel.RecordListParams) ([]*recordmodel.RecordScrap, int, error)
 	RecordAdd(ctx context.Context, req *recordmodel.Record) (*recordmodel.ReminderRecord, error)
 	RecordPatch(ctx context.Context, req *recordmodel.Record) error
 	RecordDelete(ctx context.Context, req *recordmodel.Record) error
+	RecordAttendance(ctx context.Context, req *recordmodel.Attendance) error
 	UpcomingRecords(ctx context.Context) ([]*recordmodel.ReminderRecord, error)
 	FeedbackRepository
 }
@@ -70,6 +168,10 @@ type TimetableRepository interface {
 	TimetableAdd(ctx context.Context, orgID int, new []*orgmodel.OpenHours) error
 	TimetableUpdate(ctx context.Context, orgID int, new []*orgmodel.OpenHours) error
 	TimetableDelete(ctx context.Context, orgID, weekday int) error
+	TimetableExceptions(ctx context.Context, orgID int, from, to time.Time) ([]*orgmodel.TimetableException, error)
+	TimetableExceptionAdd(ctx context.Context, exception *orgmodel.TimetableException) (int, error)
+	TimetableExceptionUpdate(ctx context.Context, exception *orgmodel.TimetableException) error
+	TimetableExceptionDelete(ctx context.Context, orgID, exceptionID int) error
 }
 
 type WorkerRepository interface {
@@ -92,11 +194,24 @@ type ServiceRepository interface {
 	ServiceDelete(ctx context.Context, ServiceID, OrgID int) error
 }
 
+type ResourceRepository interface {
+	Resources(ctx context.Context, orgID int) ([]*orgmodel.Resource, error)
+	ResourceAdd(ctx context.Context, resource *orgmodel.Resource) (int, error)
+	ResourceUpdate(ctx context.Context, resource *orgmodel.Resource) error
+	ResourceDelete(ctx context.Context, orgID, resourceID int) error
+}
+
 type SlotRepository interface {
-	GenerateSlots(ctx context.Context) error
+	GenerateSlots(ctx context.Context, params *orgmodel.SlotsMeta) (int, error)
+	ReconcileSlots(ctx context.Context, params *orgmodel.SlotsMeta) ([]*orgmodel.SlotConflict, error)
+	SlotConflicts(ctx context.Context, params *orgmodel.SlotsMeta) ([]*orgmodel.SlotConflict, error)
+	Availability(ctx context.Context, params *orgmodel.AvailabilityParams) (*orgmodel.Availability, error)
+	SlotHorizon(ctx context.Context, orgID int) (int, error)
+	SlotHorizonUpdate(ctx context.Context, orgID, days int) error
 	DeleteExpiredSlots(ctx context.Context) error
 	UpdateSlot(ctx context.Context, busy bool, params *orgmodel.SlotsMeta) error
 	Slots(ctx context.Context, params *orgmodel.SlotsMeta) ([]*orgmodel.Slot, error)
+	SlotAttendees(ctx context.Context, params *orgmodel.SlotsMeta) (*orgmodel.Attendees, error)
 }
 
 type ScheduleRepository interface {
@@ -106,6 +221,16 @@ type ScheduleRepository interface {
 	DeleteWorkerSchedule(ctx context.Context, metainfo *orgmodel.ScheduleParams) error
 }
 
+type TimeOffRepository interface {
+	TimeOffAdd(ctx context.Context, timeoff *orgmodel.TimeOff) (int, error)
+	TimeOffList(ctx context.Context, orgID, workerID int) ([]*orgmodel.TimeOff, error)
+	TimeOff(ctx context.Context, orgID, workerID, timeoffID int) (*orgmodel.TimeOff, error)
+	TimeOffUpdate(ctx context.Context, timeoff *orgmodel.TimeOff, pendingOnly bool) error
+	TimeOffDelete(ctx context.Context, orgID, workerID, timeoffID int, pendingOnly bool) error
+	TimeOffCollisions(ctx context.Context, orgID, workerID, timeoffID int) ([]*orgmodel.TimeOffCollision, error)
+	TimeOffStatus(ctx context.Context, timeoff *orgmodel.TimeOff) error
+}
+
 type FeedbackRepository interface {
 	FeedbackList(ctx context.Context, params *recordmodel.FeedbackParams) ([]*recordmodel.Feedback, int, error)
 	FeedbackSet(ctx context.Context, feedback *recordmodel.Feedback) error
@@ -0,0 +1,131 @@
+package admincase
+
+import (
+	"context"
+	"errors"
+	"timeline/internal/entity/dto/admindto"
+	"timeline/internal/repository"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/mapper/adminmap"
+	"timeline/internal/usecase/audit"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAccountNotFound  = errors.New("account not found")
+	ErrFeedbackNotFound = errors.New("feedback not found")
+)
+
+type AdminUseCase struct {
+	admin   repository.AdminRepository
+	session repository.SessionRepository
+	audit   *audit.Recorder
+	Logger  *zap.Logger
+}
+
+func New(adminRepo repository.AdminRepository, sessionRepo repository.SessionRepository, recorder *audit.Recorder, logger *zap.Logger) *AdminUseCase {
+	return &AdminUseCase{
+		admin:   adminRepo,
+		session: sessionRepo,
+		audit:   recorder,
+		Logger:  logger,
+	}
+}
+
+// Поиск пользователей или организаций, включая заблокированные и удаленные
+func (a *AdminUseCase) Accounts(ctx context.Context, isOrg bool, req *admindto.AccountSearchReq) (*admindto.AccountList, error) {
+	data, found, err := a.admin.AdminAccounts(ctx, isOrg, adminmap.AccountSearchToModel(req))
+	if err != nil {
+		a.Logger.Error(
+			"failed to search accounts",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	resp := &admindto.AccountList{
+		Found:    found,
+		Accounts: make([]*admindto.Account, 0, len(data)),
+	}
+	for _, v := range data {
+		resp.Accounts = append(resp.Accounts, adminmap.AccountToDTO(v))
+	}
+	return resp, nil
+}
+
+// Блокировка закрывает все сессии аккаунта, а у организации - еще и сессии сотрудников
+// и ключи API. Новые входы отклоняются в Login
+func (a *AdminUseCase) AccountBlock(ctx context.Context, req *admindto.BlockReq) error {
+	reason := req.Reason
+	if !req.Blocked {
+		reason = ""
+	}
+	before, err := a.admin.AccountBlock(ctx, req.ID, req.IsOrg, req.Blocked, reason)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) || errors.Is(err, postgres.ErrOrgNotFound) {
+			return ErrAccountNotFound
+		}
+		a.Logger.Error(
+			"failed to block account",
+			zap.String("AccountBlock", err.Error()),
+		)
+		return err
+	}
+	if req.Blocked {
+		if err = a.session.SessionRevokeAll(ctx, req.ID, req.IsOrg, "", ""); err != nil {
+			a.Logger.Error(
+				"failed to block account",
+				zap.String("SessionRevokeAll", err.Error()),
+			)
+			return err
+		}
+	}
+	after := *before
+	after.IsBlocked = req.Blocked
+	after.BlockedReason = reason
+	ev := &audit.Event{
+		Action:   audit.ActionUnblock,
+		Entity:   audit.EntityUser,
+		EntityID: req.ID,
+		Before:   adminmap.AccountToDTO(before),
+		After:    adminmap.AccountToDTO(&after),
+	}
+	if req.Blocked {
+		ev.Action = audit.ActionBlock
+	}
+	if req.IsOrg {
+		ev.Entity = audit.EntityOrg
+		ev.OrgID = req.ID
+	}
+	a.audit.Record(ctx, ev)
+	return nil
+}
+
+func (a *AdminUseCase) FeedbackHide(ctx context.Context, req *admindto.FeedbackHiddenReq) error {
+	if err := a.admin.FeedbackHide(ctx, req.RecordID, req.Hidden); err != nil {
+		if errors.Is(err, postgres.ErrFeedbackNotFound) {
+			return ErrFeedbackNotFound
+		}
+		a.Logger.Error(
+			"failed to hide feedback",
+			zap.Error(err),
+		)
+		return err
+	}
+	ev := &audit.Event{
+		Action:   audit.ActionShow,
+		Entity:   audit.EntityFeedback,
+		EntityID: req.RecordID,
+		After:    req,
+	}
+	if req.Hidden {
+		ev.Action = audit.ActionHide
+	}
+	a.audit.Record(ctx, ev)
+	return nil
+}
+
+// Журнал действий по всей платформе
+func (a *AdminUseCase) Audit(ctx context.Context, req *admindto.AuditReq) (*admindto.AuditList, error) {
+	return a.audit.List(ctx, req)
+}
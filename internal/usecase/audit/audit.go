@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/admindto"
+	"timeline/internal/libs/reqinfo"
+	"timeline/internal/repository"
+	"timeline/internal/repository/mapper/adminmap"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/auth/access"
+
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// Сущности журнала
+const (
+	EntityUser      = "user"
+	EntityOrg       = "org"
+	EntityFeedback  = "feedback"
+	EntityTimetable = "timetable"
+	EntityException = "timetable_exception"
+	EntityWorker    = "worker"
+	EntityService   = "service"
+	EntitySchedule  = "schedule"
+	EntitySlot      = "slot"
+	EntityRecord    = "record"
+	EntityTimeOff   = "timeoff"
+	EntityAPIKey    = "apikey"
+	EntityResource  = "resource"
+)
+
+// Действия
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionBlock    = "block"
+	ActionUnblock  = "unblock"
+	ActionHide     = "hide"
+	ActionShow     = "show"
+	ActionAssign   = "assign"
+	ActionUnassign = "unassign"
+	// Безопасность аккаунта
+	ActionLogin          = "login"
+	ActionLogoutAll      = "logout_all"
+	ActionPasswordChange = "password_change"
+	ActionPasswordReset  = "password_reset"
+	ActionEmailChange    = "email_change"
+	ActionMFAEnable      = "mfa_enable"
+	ActionMFADisable     = "mfa_disable"
+	ActionInvite         = "invite"
+	ActionRevoke         = "revoke"
+)
+
+// Исполнитель без токена, например крон
+const ActorSystem = "system"
+
+// Событие для журнала. Before и After сериализуются в json, nil - нет состояния
+type Event struct {
+	OrgID    int // организация, к которой относится действие. 0 - ни к какой
+	Action   string
+	Entity   string
+	EntityID int
+	Before   any
+	After    any
+	// Исполнитель, если в контексте еще нет токена: вход, регистрация по ссылке
+	Actor *entity.TokenMetadata
+}
+
+type Recorder struct {
+	repo   repository.AuditRepository
+	json   jsoniter.API
+	Logger *zap.Logger
+}
+
+func New(repo repository.AuditRepository, logger *zap.Logger) *Recorder {
+	return &Recorder{
+		repo:   repo,
+		json:   jsoniter.ConfigCompatibleWithStandardLibrary,
+		Logger: logger,
+	}
+}
+
+// Записывает событие от имени владельца токена из контекста.
+// Действие к этому моменту уже выполнено, поэтому ошибка журнала только логируется
+func (r *Recorder) Record(ctx context.Context, ev *Event) {
+	info := reqinfo.From(ctx)
+	entry := &models.AuditEntry{
+		ActorType: ActorSystem,
+		Action:    ev.Action,
+		Entity:    ev.Entity,
+		EntityID:  ev.EntityID,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+	}
+	md, ok := access.FromContext(ctx)
+	if ev.Actor != nil {
+		md, ok = ev.Actor, true
+	}
+	if ok {
+		entry.ActorType = actorType(md)
+		entry.ActorID = int(md.ID)
+	}
+	if ev.OrgID > 0 {
+		entry.OrgID = sql.NullInt64{Int64: int64(ev.OrgID), Valid: true}
+	}
+	var err error
+	if entry.Before, err = r.marshal(ev.Before); err != nil {
+		r.Logger.Error("failed to marshal audit state", zap.Error(err))
+	}
+	if entry.After, err = r.marshal(ev.After); err != nil {
+		r.Logger.Error("failed to marshal audit state", zap.Error(err))
+	}
+	if err = r.repo.AuditSave(ctx, entry); err != nil {
+		r.Logger.Error(
+			"failed to save audit entry",
+			zap.String("action", ev.Action),
+			zap.String("entity", ev.Entity),
+			zap.Int("entity_id", ev.EntityID),
+			zap.Error(err),
+		)
+	}
+}
+
+// Выборка журнала по фильтрам
+func (r *Recorder) List(ctx context.Context, req *admindto.AuditReq) (*admindto.AuditList, error) {
+	data, found, err := r.repo.AuditList(ctx, adminmap.AuditParamsToModel(req))
+	if err != nil {
+		r.Logger.Error(
+			"failed to get audit",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	resp := &admindto.AuditList{
+		Found:   found,
+		Entries: make([]*admindto.AuditEntry, 0, len(data)),
+	}
+	for _, v := range data {
+		resp.Entries = append(resp.Entries, adminmap.AuditToDTO(v))
+	}
+	return resp, nil
+}
+
+func (r *Recorder) marshal(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return r.json.Marshal(state)
+}
+
+func actorType(md *entity.TokenMetadata) string {
+	switch {
+	case md.Role != "":
+		return md.Role
+	case md.IsOrg:
+		return EntityOrg
+	default:
+		return EntityUser
+	}
+}
@@ -0,0 +1,42 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"timeline/internal/entity"
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("access denied")
+)
+
+type ctxKey struct{}
+
+// Политика доступа к ручке. Принимает запрос и проверенные данные токена.
+// Вернет nil, если доступ разрешен
+type Policy func(r *http.Request, md *entity.TokenMetadata) error
+
+// Кладет в контекст проверенные данные токена
+func WithMetadata(ctx context.Context, md *entity.TokenMetadata) context.Context {
+	return context.WithValue(ctx, ctxKey{}, md)
+}
+
+// Достает из контекста данные токена, положенные middleware
+func FromContext(ctx context.Context) (*entity.TokenMetadata, bool) {
+	md, ok := ctx.Value(ctxKey{}).(*entity.TokenMetadata)
+	return md, ok && md != nil
+}
+
+// Проверяет запрос по политике, используя данные токена из контекста
+func Check(r *http.Request, policy Policy) error {
+	md, ok := FromContext(r.Context())
+	if !ok {
+		return ErrUnauthorized
+	}
+	if policy == nil {
+		return nil
+	}
+	return policy(r, md)
+}
@@ -0,0 +1,301 @@
+package access
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"timeline/internal/entity"
+	"timeline/internal/repository/models/recordmodel"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Достаточно валидного токена
+func Authenticated(r *http.Request, md *entity.TokenMetadata) error {
+	return nil
+}
+
+// Токен организации, id которой указан в пути запроса
+func OrgPath(param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return owner(md, true, pathID(r, param))
+	}
+}
+
+// Токен организации, id которой указан в теле запроса
+func OrgBody(field string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return owner(md, true, bodyID(r, field))
+	}
+}
+
+// Токен организации, id которой указан в query параметрах
+func OrgQuery(param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return owner(md, true, queryID(r, param))
+	}
+}
+
+// Токен пользователя, id которого указан в пути запроса
+func UserPath(param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return owner(md, false, pathID(r, param))
+	}
+}
+
+// Токен пользователя, id которого указан в теле запроса
+func UserBody(field string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return owner(md, false, bodyID(r, field))
+	}
+}
+
+// Токен пользователя, id которого указан в query параметрах
+func UserQuery(param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return owner(md, false, queryID(r, param))
+	}
+}
+
+// Токен сотрудника, организация и id которого указаны в пути запроса
+func WorkerPath(orgParam, workerParam string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		orgID := pathID(r, orgParam)
+		if md.Role != entity.RoleWorker || orgID <= 0 || md.OrgID != uint64(orgID) {
+			return ErrForbidden
+		}
+		workerID := pathID(r, workerParam)
+		if workerID <= 0 || md.ID != uint64(workerID) {
+			return ErrForbidden
+		}
+		return nil
+	}
+}
+
+// Ключ API организации, id которой указан в пути запроса, с правом scope
+func KeyPath(scope, param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return keyOwner(md, pathID(r, param), scope)
+	}
+}
+
+// Ключ API организации, id которой указан в query параметрах, с правом scope
+func KeyQuery(scope, param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return keyOwner(md, queryID(r, param), scope)
+	}
+}
+
+// Только токен организации
+func OrgOnly(r *http.Request, md *entity.TokenMetadata) error {
+	if !md.IsOrg || md.Role != "" {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Только токен пользователя
+func UserOnly(r *http.Request, md *entity.TokenMetadata) error {
+	if md.IsOrg || md.Role != "" {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Только администратор платформы
+func AdminOnly(r *http.Request, md *entity.TokenMetadata) error {
+	if md.Role != entity.RoleAdmin {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Доступ разрешен, если выполнена хотя бы одна из политик
+func AnyOf(policies ...Policy) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		for _, policy := range policies {
+			if policy(r, md) == nil {
+				return nil
+			}
+		}
+		return ErrForbidden
+	}
+}
+
+// Доступ разрешен, если выполнены все политики
+func AllOf(policies ...Policy) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		for _, policy := range policies {
+			if err := policy(r, md); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+type RecordOwners interface {
+	RecordOwners(ctx context.Context, recordID int) (*recordmodel.Record, error)
+	OrgClient(ctx context.Context, orgID, userID int) (bool, error)
+}
+
+// Политики, которым для проверки нужны данные из хранилища
+type Access struct {
+	records RecordOwners
+}
+
+func New(records RecordOwners) *Access {
+	return &Access{
+		records: records,
+	}
+}
+
+// Профиль пользователя, id которого указан в пути запроса, доступен организации,
+// только если пользователь записывался в нее
+func (a *Access) ClientPath(param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		if OrgOnly(r, md) != nil {
+			return ErrForbidden
+		}
+		userID := pathID(r, param)
+		if userID <= 0 {
+			return ErrForbidden
+		}
+		client, err := a.records.OrgClient(r.Context(), int(md.ID), userID)
+		if err != nil || !client {
+			return ErrForbidden
+		}
+		return nil
+	}
+}
+
+// Запись доступна пользователю, который ее создал, и организации, в которую он записан
+func (a *Access) RecordPath(param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return a.recordOwner(r.Context(), md, pathID(r, param))
+	}
+}
+
+// То же, что RecordPath, но id записи берется из тела запроса
+func (a *Access) RecordBody(field string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		return a.recordOwner(r.Context(), md, bodyID(r, field))
+	}
+}
+
+// Отзыв оставляет только пользователь, создавший запись
+func (a *Access) RecordUserBody(field string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		if UserOnly(r, md) != nil {
+			return ErrForbidden
+		}
+		return a.recordOwner(r.Context(), md, bodyID(r, field))
+	}
+}
+
+// То же, что RecordUserBody, но id записи берется из пути запроса
+func (a *Access) RecordUserPath(param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		if UserOnly(r, md) != nil {
+			return ErrForbidden
+		}
+		return a.recordOwner(r.Context(), md, pathID(r, param))
+	}
+}
+
+// Запись организации, которой выдан ключ API с правом scope
+func (a *Access) RecordKeyPath(scope, param string) Policy {
+	return func(r *http.Request, md *entity.TokenMetadata) error {
+		if md.Role != entity.RoleAPIKey {
+			return ErrForbidden
+		}
+		recordID := pathID(r, param)
+		if recordID <= 0 {
+			return ErrForbidden
+		}
+		rec, err := a.records.RecordOwners(r.Context(), recordID)
+		if err != nil {
+			return ErrForbidden
+		}
+		return keyOwner(md, rec.OrgID, scope)
+	}
+}
+
+func (a *Access) recordOwner(ctx context.Context, md *entity.TokenMetadata, recordID int) error {
+	if recordID <= 0 {
+		return ErrForbidden
+	}
+	rec, err := a.records.RecordOwners(ctx, recordID)
+	if err != nil {
+		return ErrForbidden
+	}
+	if md.IsOrg {
+		return owner(md, true, rec.OrgID)
+	}
+	return owner(md, false, rec.UserID)
+}
+
+// Сравнивает владельца токена с владельцем ресурса.
+// У сотрудников свои id, поэтому их токены здесь не подходят
+func owner(md *entity.TokenMetadata, isOrg bool, id int) error {
+	if md.Role != "" || md.IsOrg != isOrg || id <= 0 || md.ID != uint64(id) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Ключ API должен принадлежать организации и иметь нужное право
+func keyOwner(md *entity.TokenMetadata, orgID int, scope string) error {
+	if md.Role != entity.RoleAPIKey || orgID <= 0 || md.OrgID != uint64(orgID) {
+		return ErrForbidden
+	}
+	if !slices.Contains(md.Scopes, scope) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func pathID(r *http.Request, param string) int {
+	id, err := strconv.Atoi(mux.Vars(r)[param])
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func queryID(r *http.Request, param string) int {
+	id, err := strconv.Atoi(r.URL.Query().Get(param))
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// Читает поле из json тела и возвращает тело обратно в запрос,
+// чтобы обработчик мог прочитать его сам. Тело разбирается так же, как в обработчиках:
+// при повторе ключа (в том числе в другом регистре) берется последнее значение
+func bodyID(r *http.Request, field string) int {
+	if r.Body == nil {
+		return 0
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 0
+	}
+	dst := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "ID",
+		Type: reflect.TypeOf(0),
+		Tag:  reflect.StructTag(`json:"` + field + `"`),
+	}}))
+	if jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(body, dst.Interface()) != nil {
+		return 0
+	}
+	return int(dst.Elem().Field(0).Int())
+}
@@ -0,0 +1,218 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"timeline/internal/entity"
+	"timeline/internal/repository/models/recordmodel"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var (
+	userMD   = &entity.TokenMetadata{ID: 7}
+	orgMD    = &entity.TokenMetadata{ID: 3, IsOrg: true}
+	workerMD = &entity.TokenMetadata{ID: 5, OrgID: 3, IsOrg: true, Role: entity.RoleWorker}
+	keyMD    = &entity.TokenMetadata{ID: 11, OrgID: 3, IsOrg: true, Role: entity.RoleAPIKey, Scopes: []string{entity.ScopeRecordsRead}}
+)
+
+func request(md *entity.TokenMetadata, vars map[string]string, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if vars != nil {
+		r = mux.SetURLVars(r, vars)
+	}
+	if md != nil {
+		r = r.WithContext(WithMetadata(r.Context(), md))
+	}
+	return r
+}
+
+func allow(t *testing.T, r *http.Request, policy Policy, want bool) {
+	t.Helper()
+	err := Check(r, policy)
+	if want && err != nil {
+		t.Fatalf("expected access, got %v", err)
+	}
+	if !want && !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected %v, got %v", ErrForbidden, err)
+	}
+}
+
+func TestCheckWithoutToken(t *testing.T) {
+	if err := Check(request(nil, nil, ""), Authenticated); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected %v, got %v", ErrUnauthorized, err)
+	}
+}
+
+func TestOwnerPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		md     *entity.TokenMetadata
+		policy Policy
+		vars   map[string]string
+		body   string
+		want   bool
+	}{
+		{"org path owner", orgMD, OrgPath("orgID"), map[string]string{"orgID": "3"}, "", true},
+		{"org path other org", orgMD, OrgPath("orgID"), map[string]string{"orgID": "4"}, "", false},
+		{"org path user token", userMD, OrgPath("orgID"), map[string]string{"orgID": "7"}, "", false},
+		{"org path worker token", workerMD, OrgPath("orgID"), map[string]string{"orgID": "3"}, "", false},
+		{"org path api key", keyMD, OrgPath("orgID"), map[string]string{"orgID": "3"}, "", false},
+		{"org path bad id", orgMD, OrgPath("orgID"), map[string]string{"orgID": "x"}, "", false},
+		{"user body owner", userMD, UserBody("user_id"), nil, `{"user_id":7}`, true},
+		{"user body other user", userMD, UserBody("user_id"), nil, `{"user_id":8}`, false},
+		{"user body org token", orgMD, UserBody("user_id"), nil, `{"user_id":3}`, false},
+		{"user body no field", userMD, UserBody("user_id"), nil, `{}`, false},
+		{"user body broken json", userMD, UserBody("user_id"), nil, `{"user_id":`, false},
+		{"org body duplicate key other org last", orgMD, OrgBody("org_id"), nil, `{"org_id":3,"name":"x","org_id":4}`, false},
+		{"org body duplicate key own last", orgMD, OrgBody("org_id"), nil, `{"org_id":4,"org_id":3}`, true},
+		{"org body duplicate key other case", orgMD, OrgBody("org_id"), nil, `{"org_id":3,"ORG_ID":4}`, false},
+		{"user body duplicate key", userMD, UserBody("user_id"), nil, `{"user_id":7,"user_id":8}`, false},
+		{"worker path", workerMD, WorkerPath("orgID", "workerID"), map[string]string{"orgID": "3", "workerID": "5"}, "", true},
+		{"worker path other worker", workerMD, WorkerPath("orgID", "workerID"), map[string]string{"orgID": "3", "workerID": "6"}, "", false},
+		{"key path with scope", keyMD, KeyPath(entity.ScopeRecordsRead, "orgID"), map[string]string{"orgID": "3"}, "", true},
+		{"key path without scope", keyMD, KeyPath(entity.ScopeRecordsWrite, "orgID"), map[string]string{"orgID": "3"}, "", false},
+		{"key path other org", keyMD, KeyPath(entity.ScopeRecordsRead, "orgID"), map[string]string{"orgID": "4"}, "", false},
+		{"key path org token", orgMD, KeyPath(entity.ScopeRecordsRead, "orgID"), map[string]string{"orgID": "3"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow(t, request(tt.md, tt.vars, tt.body), tt.policy, tt.want)
+		})
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	vars := map[string]string{"orgID": "3"}
+	deny := func(r *http.Request, md *entity.TokenMetadata) error { return ErrForbidden }
+	tests := []struct {
+		name   string
+		md     *entity.TokenMetadata
+		policy Policy
+		want   bool
+	}{
+		{"any of, first", orgMD, AnyOf(OrgPath("orgID"), deny), true},
+		{"any of, second", keyMD, AnyOf(OrgPath("orgID"), KeyPath(entity.ScopeRecordsRead, "orgID")), true},
+		{"any of, none", userMD, AnyOf(OrgPath("orgID"), KeyPath(entity.ScopeRecordsRead, "orgID")), false},
+		{"any of, empty", orgMD, AnyOf(), false},
+		{"all of, every", orgMD, AllOf(Authenticated, OrgOnly, OrgPath("orgID")), true},
+		{"all of, one fails", orgMD, AllOf(OrgPath("orgID"), deny), false},
+		{"all of, empty", userMD, AllOf(), true},
+		{"role only", workerMD, AnyOf(OrgOnly, UserOnly, AdminOnly), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow(t, request(tt.md, vars, ""), tt.policy, tt.want)
+		})
+	}
+}
+
+// Политика читает тело сама, но обработчик должен получить его целиком
+func TestBodyIDRestoresBody(t *testing.T) {
+	body := `{"org_id":3,"name":"timeline"}`
+	r := request(orgMD, nil, body)
+	allow(t, r, AllOf(OrgBody("org_id"), OrgBody("org_id")), true)
+	got, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Fatalf("body not restored: %q", got)
+	}
+}
+
+// Политика должна проверять то же значение, которое прочитает обработчик
+func TestBodyIDMatchesHandlerDecode(t *testing.T) {
+	bodies := []string{
+		`{"org_id":3}`,
+		`{"org_id":3,"org_id":4}`,
+		`{"org_id":4,"name":"x","org_id":3}`,
+		`{"org_id":3,"Org_ID":4}`,
+		`{"ORG_ID":4}`,
+	}
+	for _, body := range bodies {
+		var req struct {
+			OrgID int    `json:"org_id"`
+			Name  string `json:"name"`
+		}
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(strings.NewReader(body)).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if got := bodyID(request(orgMD, nil, body), "org_id"); got != req.OrgID {
+			t.Fatalf("%s: policy reads %d, handler reads %d", body, got, req.OrgID)
+		}
+	}
+}
+
+func TestBodyIDWithoutBody(t *testing.T) {
+	r := request(orgMD, nil, "")
+	r.Body = nil
+	if id := bodyID(r, "org_id"); id != 0 {
+		t.Fatalf("expected 0, got %d", id)
+	}
+}
+
+type fakeRecords struct {
+	records map[int]*recordmodel.Record
+	err     error
+}
+
+func (f *fakeRecords) RecordOwners(ctx context.Context, recordID int) (*recordmodel.Record, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	rec, ok := f.records[recordID]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return rec, nil
+}
+
+func (f *fakeRecords) OrgClient(ctx context.Context, orgID, userID int) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	for _, v := range f.records {
+		if v.OrgID == orgID && v.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestStoragePolicies(t *testing.T) {
+	acc := New(&fakeRecords{records: map[int]*recordmodel.Record{
+		1: {RecordID: 1, UserID: 7, OrgID: 3},
+	}})
+	broken := New(&fakeRecords{err: errors.New("db is down")})
+	tests := []struct {
+		name   string
+		md     *entity.TokenMetadata
+		policy Policy
+		vars   map[string]string
+		want   bool
+	}{
+		{"record of user", userMD, acc.RecordPath("recordID"), map[string]string{"recordID": "1"}, true},
+		{"record of org", orgMD, acc.RecordPath("recordID"), map[string]string{"recordID": "1"}, true},
+		{"record of other user", &entity.TokenMetadata{ID: 8}, acc.RecordPath("recordID"), map[string]string{"recordID": "1"}, false},
+		{"missing record", userMD, acc.RecordPath("recordID"), map[string]string{"recordID": "2"}, false},
+		{"user only record, org token", orgMD, acc.RecordUserPath("recordID"), map[string]string{"recordID": "1"}, false},
+		{"record by key", keyMD, acc.RecordKeyPath(entity.ScopeRecordsRead, "recordID"), map[string]string{"recordID": "1"}, true},
+		{"client of org", orgMD, acc.ClientPath("id"), map[string]string{"id": "7"}, true},
+		{"not a client of org", orgMD, acc.ClientPath("id"), map[string]string{"id": "8"}, false},
+		{"client path, worker token", workerMD, acc.ClientPath("id"), map[string]string{"id": "7"}, false},
+		{"client path, storage error", orgMD, broken.ClientPath("id"), map[string]string{"id": "7"}, false},
+		{"record, storage error", userMD, broken.RecordPath("recordID"), map[string]string{"recordID": "1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow(t, request(tt.md, tt.vars, ""), tt.policy, tt.want)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/passwd"
+	"timeline/internal/repository/mail"
+	mailentity "timeline/internal/repository/mail/entity"
+
+	"go.uber.org/zap"
+)
+
+// Вход администратора платформы. Попытки ограничиваются так же, как у остальных аккаунтов
+func (a *AuthUseCase) AdminLogin(ctx context.Context, req *authdto.AdminLoginReq) (*authdto.TokenPair, error) {
+	key := limitKey("login", false, entity.RoleAdmin+":"+req.Email)
+	if err := a.limits.allow(ctx, "login", key); err != nil {
+		return nil, err
+	}
+	admin, err := a.admin.AdminByEmail(ctx, req.Email)
+	if err != nil {
+		a.Logger.Error(
+			"failed login admin",
+			zap.String("AdminByEmail", err.Error()),
+		)
+		a.limits.fail(ctx, "login", key)
+		return nil, ErrInvalidCredentials
+	}
+	if passwd.CompareWithHash(req.Password, admin.PasswdHash) != nil {
+		if a.limits.fail(ctx, "login", key) {
+			a.mail.SendMsg(&mailentity.Message{
+				Email: admin.Email,
+				Type:  mail.LockoutType,
+				Value: a.now().Add(a.limits.Account.Lockout()),
+			})
+		}
+		return nil, ErrInvalidCredentials
+	}
+	a.limits.success(ctx, key)
+	tokens, err := a.newSession(ctx, &entity.TokenMetadata{
+		ID:   uint64(admin.AdminID),
+		Role: entity.RoleAdmin,
+	})
+	if err != nil {
+		a.Logger.Error(
+			"failed login admin",
+			zap.String("newSession", err.Error()),
+		)
+		return nil, err
+	}
+	return tokens, nil
+}
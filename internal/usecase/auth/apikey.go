@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/mapper/codemap"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/audit"
+
+	"go.uber.org/zap"
+)
+
+// Длина видимой в списке части ключа вместе с префиксом
+const apiKeyShown = 10
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyExpired  = errors.New("expiry must be in the future")
+)
+
+// Выпуск ключа. Ключ возвращается один раз, в БД остается только хеш
+func (a *AuthUseCase) APIKeyCreate(ctx context.Context, req *authdto.APIKeyReq) (*authdto.APIKeyCreated, error) {
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, ErrAPIKeyExpired
+		}
+		expiresAt = sql.NullTime{Time: req.ExpiresAt.UTC(), Valid: true}
+	}
+	random, err := verification.GenerateToken(32)
+	if err != nil {
+		a.Logger.Error(
+			"failed to create api key",
+			zap.String("GenerateToken", err.Error()),
+		)
+		return nil, err
+	}
+	key := entity.APIKeyPrefix + random
+	model := &models.APIKey{
+		OrgID:     req.OrgID,
+		Name:      req.Name,
+		Prefix:    key[:apiKeyShown],
+		KeyHash:   verification.HashToken(key),
+		Scopes:    strings.Join(uniqueScopes(req.Scopes), " "),
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+	if model.KeyID, err = a.apiKeys.APIKeySave(ctx, model); err != nil {
+		a.Logger.Error(
+			"failed to create api key",
+			zap.String("APIKeySave", err.Error()),
+		)
+		return nil, err
+	}
+	a.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityAPIKey,
+		EntityID: model.KeyID,
+		After:    codemap.APIKeyToDTO(model),
+	})
+	return &authdto.APIKeyCreated{
+		APIKey: *codemap.APIKeyToDTO(model),
+		Key:    key,
+	}, nil
+}
+
+func (a *AuthUseCase) APIKeyList(ctx context.Context, orgID int) (*authdto.APIKeyList, error) {
+	data, err := a.apiKeys.APIKeyList(ctx, orgID)
+	if err != nil {
+		a.Logger.Error(
+			"failed to get api keys",
+			zap.String("APIKeyList", err.Error()),
+		)
+		return nil, err
+	}
+	resp := &authdto.APIKeyList{Keys: make([]*authdto.APIKey, 0, len(data))}
+	for _, v := range data {
+		resp.Keys = append(resp.Keys, codemap.APIKeyToDTO(v))
+	}
+	return resp, nil
+}
+
+// Отозванный ключ перестает приниматься сразу
+func (a *AuthUseCase) APIKeyRevoke(ctx context.Context, orgID, keyID int) error {
+	if err := a.apiKeys.APIKeyRevoke(ctx, orgID, keyID); err != nil {
+		if errors.Is(err, postgres.ErrAPIKeyNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		a.Logger.Error(
+			"failed to revoke api key",
+			zap.String("APIKeyRevoke", err.Error()),
+		)
+		return err
+	}
+	a.audit.Record(ctx, &audit.Event{
+		OrgID:    orgID,
+		Action:   audit.ActionRevoke,
+		Entity:   audit.EntityAPIKey,
+		EntityID: keyID,
+	})
+	return nil
+}
+
+func uniqueScopes(scopes []string) []string {
+	resp := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(resp, scope) {
+			resp = append(resp, scope)
+		}
+	}
+	return resp
+}
@@ -2,21 +2,26 @@ package auth
 
 import (
 	"context"
-	"crypto/rsa"
 	"errors"
 	"strings"
+	"time"
 	"timeline/internal/config"
 	"timeline/internal/entity"
 	"timeline/internal/entity/dto/authdto"
 	jwtlib "timeline/internal/libs/jwtlib"
 	"timeline/internal/libs/passwd"
+	"timeline/internal/libs/reqinfo"
+	"timeline/internal/libs/secret"
 	"timeline/internal/libs/verification"
 	"timeline/internal/repository"
+	"timeline/internal/repository/database/postgres"
 	"timeline/internal/repository/mail"
 	mailentity "timeline/internal/repository/mail/entity"
 	"timeline/internal/repository/mapper/codemap"
 	"timeline/internal/repository/mapper/orgmap"
 	"timeline/internal/repository/mapper/usermap"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/audit"
 	"timeline/internal/usecase/auth/validation"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -27,37 +32,73 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrAccountExpired     = errors.New("account expired")
 	ErrCodeExpired        = errors.New("code expired")
+	ErrSessionRevoked     = errors.New("session revoked")
+	ErrTokenReused        = errors.New("refresh token reused")
+	ErrAccountBlocked     = errors.New("account blocked")
 )
 
 type AuthUseCase struct {
-	secret   *rsa.PrivateKey
+	keys     *secret.KeyRing
 	user     repository.UserRepository
 	org      repository.OrgRepository
 	code     repository.CodeRepository
+	session  repository.SessionRepository
+	password repository.PasswordRepository
+	email    repository.EmailRepository
+	mfa      repository.MFARepository
+	staff    repository.StaffRepository
+	admin    repository.AdminRepository
+	identity repository.IdentityRepository
+	apiKeys  repository.APIKeyRepository
+	records  repository.RecordRepository
+	privacy  repository.PrivacyRepository
+	audit    *audit.Recorder
+	limits   Limits
+	sso      SSO
 	mail     mail.Post
 	TokenCfg config.Token
 	Logger   *zap.Logger
+	now      func() time.Time // часы для проверки кодов и TOTP
 }
 
-func New(key *rsa.PrivateKey, userRepo repository.UserRepository, orgRepo repository.OrgRepository, codeRepo repository.CodeRepository, mailSrv mail.Post, cfg config.Token, logger *zap.Logger) *AuthUseCase {
+func New(keys *secret.KeyRing, userRepo repository.UserRepository, orgRepo repository.OrgRepository, codeRepo repository.CodeRepository, sessionRepo repository.SessionRepository, passwdRepo repository.PasswordRepository, emailRepo repository.EmailRepository, mfaRepo repository.MFARepository, staffRepo repository.StaffRepository, adminRepo repository.AdminRepository, identityRepo repository.IdentityRepository, apiKeyRepo repository.APIKeyRepository, recordRepo repository.RecordRepository, privacyRepo repository.PrivacyRepository, recorder *audit.Recorder, limits Limits, sso SSO, mailSrv mail.Post, cfg config.Token, logger *zap.Logger) *AuthUseCase {
 	return &AuthUseCase{
-		secret:   key,
+		keys:     keys,
 		user:     userRepo,
 		org:      orgRepo,
 		code:     codeRepo,
+		session:  sessionRepo,
+		password: passwdRepo,
+		email:    emailRepo,
+		mfa:      mfaRepo,
+		staff:    staffRepo,
+		admin:    adminRepo,
+		identity: identityRepo,
+		apiKeys:  apiKeyRepo,
+		records:  recordRepo,
+		privacy:  privacyRepo,
+		audit:    recorder,
+		limits:   limits,
+		sso:      sso,
 		mail:     mailSrv,
 		TokenCfg: cfg,
 		Logger:   logger,
+		now:      time.Now,
 	}
 }
 
-func (a *AuthUseCase) Login(ctx context.Context, req *authdto.LoginReq) (*authdto.TokenPair, error) {
+func (a *AuthUseCase) Login(ctx context.Context, req *authdto.LoginReq) (*authdto.LoginResp, error) {
+	account := limitKey("login", req.IsOrg, req.Email)
+	if err := a.limits.allow(ctx, "login", account); err != nil {
+		return nil, err
+	}
 	exp, err := a.code.AccountExpiration(ctx, req.Email, req.IsOrg)
 	if err != nil {
 		a.Logger.Error(
 			"failed login account",
 			zap.String("AccountExpiration", err.Error()),
 		)
+		a.limits.fail(ctx, "login", account)
 		return nil, err
 	}
 	// если не активирован
@@ -72,17 +113,34 @@ func (a *AuthUseCase) Login(ctx context.Context, req *authdto.LoginReq) (*authdt
 			"failed login account",
 			zap.String("CompareWithHash", err.Error()),
 		)
+		if a.limits.fail(ctx, "login", account) {
+			a.mail.SendMsg(&mailentity.Message{
+				Email: req.Email,
+				Type:  mail.LockoutType,
+				Value: a.now().Add(a.limits.Account.Lockout()),
+			})
+		}
 		return nil, err
 	}
+	a.limits.success(ctx, account)
+	// о блокировке сообщается только знающему пароль
+	if exp.Blocked {
+		return nil, ErrAccountBlocked
+	}
 
-	tokens, err := jwtlib.NewTokenPair(
-		a.secret,
-		a.TokenCfg,
-		&entity.TokenMetadata{
-			ID:    uint64(exp.ID),
-			IsOrg: req.IsOrg,
-		},
-	)
+	// при включенной MFA токены выдаются только после второго фактора
+	required, err := a.mfaRequired(ctx, exp.ID, req.IsOrg)
+	if err != nil {
+		a.Logger.Error(
+			"failed login account",
+			zap.String("mfaRequired", err.Error()),
+		)
+		return nil, err
+	}
+	if required {
+		return a.mfaChallenge(exp.ID)
+	}
+	tokens, err := a.newSession(ctx, &entity.TokenMetadata{ID: uint64(exp.ID), IsOrg: req.IsOrg})
 	if err != nil {
 		a.Logger.Error(
 			"failed login account",
@@ -90,7 +148,7 @@ func (a *AuthUseCase) Login(ctx context.Context, req *authdto.LoginReq) (*authdt
 		)
 		return nil, err
 	}
-	return tokens, nil
+	return &authdto.LoginResp{TokenPair: tokens}, nil
 }
 
 func (a *AuthUseCase) UserRegister(ctx context.Context, req *authdto.UserRegisterReq) (*authdto.RegisterResp, error) {
@@ -112,20 +170,15 @@ func (a *AuthUseCase) UserRegister(ctx context.Context, req *authdto.UserRegiste
 		)
 		return nil, err
 	}
-	// Генерируем код
-	code, err := verification.GenerateCode()
-	if err != nil {
+	a.accountEvent(ctx, &entity.TokenMetadata{ID: uint64(userID)}, audit.ActionCreate)
+	// Отправляем код подтверждения
+	if err = a.sendCode(ctx, userID, false, req.Email); err != nil {
 		a.Logger.Error(
 			"failed to register user",
-			zap.String("GenerateCode", err.Error()),
+			zap.String("sendCode", err.Error()),
 		)
 		return nil, err
 	}
-	a.mail.SendMsg(&mailentity.Message{
-		Email: req.Email,
-		Type:  mail.VerificationType,
-		Value: code,
-	})
 	return &authdto.RegisterResp{Id: userID}, nil
 }
 
@@ -148,59 +201,58 @@ func (a *AuthUseCase) OrgRegister(ctx context.Context, req *authdto.OrgRegisterR
 		)
 		return nil, err
 	}
-	// Генерируем код
-	code, err := verification.GenerateCode()
-	if err != nil {
+	a.accountEvent(ctx, &entity.TokenMetadata{ID: uint64(orgID), IsOrg: true}, audit.ActionCreate)
+	// Отправляем код подтверждения
+	if err = a.sendCode(ctx, orgID, true, req.Email); err != nil {
 		a.Logger.Error(
 			"failed to register org",
-			zap.String("GenerateCode", err.Error()),
+			zap.String("sendCode", err.Error()),
 		)
 		return nil, err
 	}
-	a.mail.SendMsg(&mailentity.Message{
-		Email: req.Email,
-		Type:  mail.VerificationType,
-		Value: code,
-	})
 	return &authdto.RegisterResp{Id: orgID}, nil
 }
 
-func (a *AuthUseCase) SendCodeRetry(ctx context.Context, req *authdto.SendCodeReq) {
-	// Генерируем код
-	code, err := verification.GenerateCode()
-	if err != nil {
+// Повторная отправка кода. Почта должна принадлежать аккаунту
+func (a *AuthUseCase) SendCodeRetry(ctx context.Context, req *authdto.SendCodeReq) error {
+	account := limitKey("sendcode", req.IsOrg, req.Email)
+	if err := a.limits.allow(ctx, "sendcode", account); err != nil {
+		return err
+	}
+	exp, err := a.code.AccountExpiration(ctx, req.Email, req.IsOrg)
+	if err != nil || exp.ID != req.ID {
+		a.limits.fail(ctx, "sendcode", account)
+		return ErrAccountMismatch
+	}
+	if exp.Verified {
+		return ErrAlreadyVerified
+	}
+	if err = a.sendCode(ctx, req.ID, req.IsOrg, req.Email); err != nil {
 		a.Logger.Error(
 			"retry send code failed",
-			zap.String("GenerateCode", err.Error()),
+			zap.String("sendCode", err.Error()),
 		)
-		return
+		return err
 	}
-	a.mail.SendMsg(&mailentity.Message{
-		Email: req.Email,
-		Type:  mail.VerificationType,
-		Value: code,
-	})
+	return nil
 }
 
 func (a *AuthUseCase) VerifyCode(ctx context.Context, req *authdto.VerifyCodeReq) (*authdto.TokenPair, error) {
-	exp, err := a.code.VerifyCode(ctx, codemap.ToModel(req))
-	if err != nil {
-		a.Logger.Error(
-			"failed to verify code",
-			zap.String("VerifyCode", err.Error()),
-		)
+	account := limitKeyID("verify", req.IsOrg, req.ID)
+	if err := a.limits.allow(ctx, "verify", account); err != nil {
 		return nil, err
 	}
-
-	if ok := validation.IsCodeExpired(exp); ok {
+	if err := a.checkCode(ctx, req.ID, req.IsOrg, req.Code); err != nil {
 		a.Logger.Error(
 			"failed to verify code",
-			zap.String("IsCodeExpired", ErrCodeExpired.Error()),
+			zap.String("checkCode", err.Error()),
 		)
-		return nil, ErrCodeExpired
+		a.limits.fail(ctx, "verify", account)
+		return nil, err
 	}
+	a.limits.success(ctx, account)
 
-	if err = a.code.ActivateAccount(ctx, req.ID, req.IsOrg); err != nil {
+	if err := a.code.ActivateAccount(ctx, req.ID, req.IsOrg); err != nil {
 		a.Logger.Error(
 			"failed to verify code",
 			zap.String("UserActivateAccount", err.Error()),
@@ -208,42 +260,181 @@ func (a *AuthUseCase) VerifyCode(ctx context.Context, req *authdto.VerifyCodeReq
 		return nil, err
 	}
 	// Генерим токен
-	tokens, err := jwtlib.NewTokenPair(
-		a.secret,
-		a.TokenCfg,
-		&entity.TokenMetadata{
-			ID:    uint64(req.ID),
-			IsOrg: req.IsOrg,
-		},
-	)
+	tokens, err := a.newSession(ctx, &entity.TokenMetadata{ID: uint64(req.ID), IsOrg: req.IsOrg})
 	if err != nil {
 		a.Logger.Error(
 			"failed to register user",
-			zap.String("NewTokenPair", err.Error()),
+			zap.String("newSession", err.Error()),
 		)
 		return nil, err
 	}
 	return tokens, nil
 }
 
-func (a *AuthUseCase) UpdateAccessToken(ctx context.Context, req *jwt.Token) (*authdto.AccessToken, error) {
+// Ротация refresh токена: старый помечается использованным, выдается новая пара.
+// Повторное предъявление уже использованного токена отзывает всю сессию
+func (a *AuthUseCase) UpdateAccessToken(ctx context.Context, req *jwt.Token) (*authdto.TokenPair, error) {
 	// Валидируем Claims токена. Есть ли они и нормальные ли.
-	err := validation.ValidateTokenClaims(req)
+	err := validation.ValidateRefreshClaims(req)
 	if err != nil {
 		return nil, err
 	}
 	// Здесь уже спокойно кастую если выше проблем не возникло
-	tmp := req.Claims.(jwt.MapClaims)["id"].(float64)
-	id := uint64(tmp)
-	metadata := &entity.TokenMetadata{
-		ID:    id,
-		IsOrg: req.Claims.(jwt.MapClaims)["is_org"].(bool),
+	claims := req.Claims.(jwt.MapClaims)
+	session, err := a.session.SessionByJTI(ctx, claims["jti"].(string))
+	if err != nil {
+		a.Logger.Error(
+			"failed to refresh token",
+			zap.String("SessionByJTI", err.Error()),
+		)
+		return nil, err
 	}
-	token, err := jwtlib.NewToken(a.secret, a.TokenCfg, metadata, "access")
+	if session.Revoked {
+		return nil, ErrSessionRevoked
+	}
+	metadata := jwtlib.Metadata(claims)
+	metadata.SessionID = session.Family
+	jti, err := verification.GenerateToken(32)
 	if err != nil {
+		a.Logger.Error(
+			"failed to refresh token",
+			zap.String("GenerateToken", err.Error()),
+		)
+		return nil, err
+	}
+	info := reqinfo.From(ctx)
+	rotated := &models.Session{
+		JTI:       jti,
+		SubjectID: session.SubjectID,
+		IsOrg:     session.IsOrg,
+		Role:      session.Role,
+		UserAgent: info.UserAgent,
+		IP:        info.IP,
+		ExpiresAt: time.Now().UTC().Add(a.TokenCfg.RefreshTTL),
+	}
+	if err = a.session.SessionRotate(ctx, session.JTI, rotated); err != nil {
+		if errors.Is(err, postgres.ErrSessionReused) {
+			// токен мог быть украден, поэтому сессию закрываем целиком
+			a.Logger.Warn(
+				"refresh token reuse detected",
+				zap.Int("subject_id", session.SubjectID),
+				zap.Bool("is_org", session.IsOrg),
+			)
+			if err = a.session.SessionRevokeFamily(ctx, session.Family); err != nil {
+				a.Logger.Error(
+					"failed to refresh token",
+					zap.String("SessionRevokeFamily", err.Error()),
+				)
+			}
+			return nil, ErrTokenReused
+		}
+		a.Logger.Error(
+			"failed to refresh token",
+			zap.String("SessionRotate", err.Error()),
+		)
 		return nil, err
 	}
-	return &authdto.AccessToken{
-		Token: token,
+	tokens, err := jwtlib.NewTokenPair(a.keys, a.TokenCfg, metadata, jti)
+	if err != nil {
+		a.Logger.Error(
+			"failed to refresh token",
+			zap.String("NewTokenPair", err.Error()),
+		)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Завершает текущую сессию
+func (a *AuthUseCase) Logout(ctx context.Context, metadata *entity.TokenMetadata) error {
+	if metadata.SessionID == "" {
+		return ErrSessionRevoked
+	}
+	if err := a.session.SessionRevokeFamily(ctx, metadata.SessionID); err != nil {
+		a.Logger.Error(
+			"failed to logout",
+			zap.String("SessionRevokeFamily", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// Завершает все сессии аккаунта на всех устройствах
+func (a *AuthUseCase) LogoutAll(ctx context.Context, metadata *entity.TokenMetadata) error {
+	if err := a.session.SessionRevokeAll(ctx, int(metadata.ID), metadata.IsOrg, metadata.Role, ""); err != nil {
+		a.Logger.Error(
+			"failed to logout all",
+			zap.String("SessionRevokeAll", err.Error()),
+		)
+		return err
+	}
+	a.accountEvent(ctx, metadata, audit.ActionLogoutAll)
+	return nil
+}
+
+func (a *AuthUseCase) Sessions(ctx context.Context, metadata *entity.TokenMetadata) (*authdto.SessionList, error) {
+	sessions, err := a.session.SessionList(ctx, int(metadata.ID), metadata.IsOrg, metadata.Role)
+	if err != nil {
+		a.Logger.Error(
+			"failed to get sessions",
+			zap.String("SessionList", err.Error()),
+		)
+		return nil, err
+	}
+	return &authdto.SessionList{
+		List: codemap.SessionListToDTO(sessions, metadata.SessionID),
 	}, nil
 }
+
+// Открывает новую сессию субъекта и выдает первую пару токенов
+func (a *AuthUseCase) newSession(ctx context.Context, metadata *entity.TokenMetadata) (*authdto.TokenPair, error) {
+	family, err := verification.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	jti, err := verification.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	info := reqinfo.From(ctx)
+	err = a.session.SessionSave(ctx, &models.Session{
+		JTI:       jti,
+		Family:    family,
+		SubjectID: int(metadata.ID),
+		IsOrg:     metadata.IsOrg,
+		Role:      metadata.Role,
+		UserAgent: info.UserAgent,
+		IP:        info.IP,
+		ExpiresAt: time.Now().UTC().Add(a.TokenCfg.RefreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	metadata.SessionID = family
+	a.accountEvent(ctx, metadata, audit.ActionLogin)
+	return jwtlib.NewTokenPair(a.keys, a.TokenCfg, metadata, jti)
+}
+
+// Событие безопасности аккаунта. Исполнитель - сам владелец аккаунта
+func (a *AuthUseCase) accountEvent(ctx context.Context, metadata *entity.TokenMetadata, action string) {
+	ev := &audit.Event{
+		Action:   action,
+		Entity:   audit.EntityUser,
+		EntityID: int(metadata.ID),
+		Actor:    metadata,
+	}
+	switch {
+	case metadata.Role != "":
+		ev.Entity = metadata.Role
+		ev.OrgID = int(metadata.OrgID)
+	case metadata.IsOrg:
+		ev.Entity = audit.EntityOrg
+		ev.OrgID = int(metadata.ID)
+	}
+	a.audit.Record(ctx, ev)
+}
+
+func (a *AuthUseCase) JWKS(ctx context.Context) *secret.JWKSet {
+	return a.keys.JWKS()
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+	"timeline/internal/libs/reqinfo"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/mail"
+	mailentity "timeline/internal/repository/mail/entity"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/auth/validation"
+)
+
+const (
+	codeTTL         = 5 * time.Minute // время жизни кода
+	codeMaxAttempts = 5               // неверных вводов до блокировки кода
+	codeCooldown    = time.Minute     // пауза между отправками кода на одну почту
+	codeIPWindow    = time.Hour       // окно подсчета кодов с одного IP
+	codeIPLimit     = 10              // кодов с одного IP за окно
+)
+
+var (
+	ErrCodeMismatch    = errors.New("wrong code")
+	ErrCodeLocked      = errors.New("too many attempts, request a new code")
+	ErrCodeCooldown    = errors.New("code was sent recently, try again later")
+	ErrAlreadyVerified = errors.New("account already verified")
+	ErrAccountMismatch = errors.New("account not found")
+)
+
+// Генерирует код, сохраняет его хеш и отправляет код на почту.
+// Частота отправки ограничена на почту и на IP
+func (a *AuthUseCase) sendCode(ctx context.Context, id int, isOrg bool, email string) error {
+	ip := reqinfo.From(ctx).IP
+	now := a.now().UTC()
+	stats, err := a.code.CodeSendStats(ctx, id, isOrg, ip, now.Add(-codeIPWindow))
+	if err != nil {
+		return err
+	}
+	if now.Sub(stats.LastSentAt) < codeCooldown || stats.SentFromIP >= codeIPLimit {
+		return ErrCodeCooldown
+	}
+	code, err := verification.GenerateCode()
+	if err != nil {
+		return err
+	}
+	err = a.code.SaveVerifyCode(ctx, &models.CodeInfo{
+		ID:        id,
+		Code:      a.codeHash(code),
+		IsOrg:     isOrg,
+		IP:        ip,
+		Purpose:   models.CodeVerify,
+		CreatedAt: now,
+		ExpiresAt: now.Add(codeTTL),
+	})
+	if err != nil {
+		return err
+	}
+	a.mail.SendMsg(&mailentity.Message{
+		Email: email,
+		Type:  mail.VerificationType,
+		Value: code,
+	})
+	return nil
+}
+
+// Проверяет код аккаунта. Неверный ввод расходует попытку,
+// после codeMaxAttempts попыток код блокируется
+func (a *AuthUseCase) checkCode(ctx context.Context, id int, isOrg bool, code string) error {
+	active, err := a.code.ActiveCode(ctx, id, isOrg, models.CodeVerify)
+	if err != nil {
+		return err
+	}
+	if active.Attempts >= codeMaxAttempts {
+		return ErrCodeLocked
+	}
+	if validation.IsCodeExpired(active.ExpiresAt) {
+		return ErrCodeExpired
+	}
+	if subtle.ConstantTimeCompare([]byte(active.Code), []byte(a.codeHash(code))) != 1 {
+		if err = a.code.CodeAttempt(ctx, active.CodeID, isOrg); err != nil {
+			return err
+		}
+		return ErrCodeMismatch
+	}
+	// код мог быть заблокирован параллельными попытками
+	if err = a.code.CodeUse(ctx, active.CodeID, isOrg, codeMaxAttempts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Хеш кода из письма с секретом сервера
+func (a *AuthUseCase) codeHash(code string) string {
+	return verification.HashCode([]byte(a.TokenCfg.CodeSecret), code)
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"timeline/internal/config"
+	"timeline/internal/libs/reqinfo"
+	"timeline/internal/repository"
+	"timeline/internal/repository/database/postgres"
+	mailentity "timeline/internal/repository/mail/entity"
+	"timeline/internal/repository/models"
+
+	"go.uber.org/zap"
+)
+
+var testNow = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// Коды в памяти: по одному действующему коду на аккаунт
+type fakeCodes struct {
+	repository.CodeRepository
+	codes map[int]*models.VerifyCode
+	saved []*models.CodeInfo
+	stats models.CodeSendStats
+	used  bool
+}
+
+func (f *fakeCodes) SaveVerifyCode(ctx context.Context, info *models.CodeInfo) error {
+	f.saved = append(f.saved, info)
+	f.codes[info.ID] = &models.VerifyCode{
+		CodeID:    len(f.saved),
+		Code:      info.Code,
+		CreatedAt: info.CreatedAt,
+		ExpiresAt: info.ExpiresAt,
+	}
+	return nil
+}
+
+func (f *fakeCodes) ActiveCode(ctx context.Context, id int, isOrg bool, purpose string) (*models.VerifyCode, error) {
+	code, ok := f.codes[id]
+	if !ok || f.used {
+		return nil, postgres.ErrCodeNotFound
+	}
+	return code, nil
+}
+
+func (f *fakeCodes) CodeAttempt(ctx context.Context, codeID int, isOrg bool) error {
+	for _, code := range f.codes {
+		if code.CodeID == codeID {
+			code.Attempts++
+		}
+	}
+	return nil
+}
+
+func (f *fakeCodes) CodeUse(ctx context.Context, codeID int, isOrg bool, maxAttempts int) error {
+	for _, code := range f.codes {
+		if code.CodeID == codeID && code.Attempts < maxAttempts && !f.used {
+			f.used = true
+			return nil
+		}
+	}
+	return postgres.ErrCodeNotFound
+}
+
+func (f *fakeCodes) CodeSendStats(ctx context.Context, id int, isOrg bool, ip string, since time.Time) (*models.CodeSendStats, error) {
+	stats := f.stats
+	return &stats, nil
+}
+
+type fakeMail struct {
+	sent []*mailentity.Message
+}
+
+func (f *fakeMail) SendMsg(msg *mailentity.Message) error {
+	f.sent = append(f.sent, msg)
+	return nil
+}
+func (f *fakeMail) Start()    {}
+func (f *fakeMail) Shutdown() {}
+
+func newCodeCase(codes *fakeCodes) (*AuthUseCase, *fakeMail) {
+	post := &fakeMail{}
+	return &AuthUseCase{
+		code:     codes,
+		mail:     post,
+		TokenCfg: config.Token{CodeSecret: "test secret"},
+		Logger:   zap.NewNop(),
+		now:      func() time.Time { return testNow },
+	}, post
+}
+
+func TestCheckCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		attempts     int
+		expired      bool
+		input        []string
+		want         error
+		wantAttempts int
+	}{
+		{"right code", 0, false, []string{"123456"}, nil, 0},
+		{"wrong code", 0, false, []string{"654321"}, ErrCodeMismatch, 1},
+		{"right code after mistakes", 0, false, []string{"1", "2", "123456"}, nil, 2},
+		{"last attempt", codeMaxAttempts - 1, false, []string{"123456"}, nil, codeMaxAttempts - 1},
+		{"locked after mistakes", 0, false, []string{"1", "2", "3", "4", "5", "123456"}, ErrCodeLocked, codeMaxAttempts},
+		{"locked code", codeMaxAttempts, false, []string{"123456"}, ErrCodeLocked, codeMaxAttempts},
+		{"expired code", 0, true, []string{"123456"}, ErrCodeExpired, 0},
+		{"used code", 0, false, []string{"123456", "123456"}, postgres.ErrCodeNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := &fakeCodes{codes: map[int]*models.VerifyCode{}}
+			uc, _ := newCodeCase(codes)
+			// срок кода сверяется с реальными часами
+			expiresAt := time.Now().UTC().Add(codeTTL)
+			if tt.expired {
+				expiresAt = time.Now().UTC().Add(-time.Second)
+			}
+			codes.codes[1] = &models.VerifyCode{
+				CodeID:    1,
+				Code:      uc.codeHash("123456"),
+				Attempts:  tt.attempts,
+				ExpiresAt: expiresAt,
+			}
+			var err error
+			for _, code := range tt.input {
+				err = uc.checkCode(context.Background(), 1, false, code)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if got := codes.codes[1].Attempts; got != tt.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d", tt.wantAttempts, got)
+			}
+		})
+	}
+}
+
+func TestSendCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats models.CodeSendStats
+		want  error
+	}{
+		{"first code", models.CodeSendStats{}, nil},
+		{"cooldown passed", models.CodeSendStats{LastSentAt: testNow.Add(-codeCooldown)}, nil},
+		{"inside cooldown", models.CodeSendStats{LastSentAt: testNow.Add(-codeCooldown + time.Second)}, ErrCodeCooldown},
+		{"just sent", models.CodeSendStats{LastSentAt: testNow}, ErrCodeCooldown},
+		{"ip limit", models.CodeSendStats{SentFromIP: codeIPLimit}, ErrCodeCooldown},
+		{"below ip limit", models.CodeSendStats{SentFromIP: codeIPLimit - 1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := &fakeCodes{codes: map[int]*models.VerifyCode{}, stats: tt.stats}
+			uc, post := newCodeCase(codes)
+			ctx := reqinfo.With(context.Background(), reqinfo.Info{IP: "10.0.0.1"})
+			err := uc.sendCode(ctx, 1, false, "user@example.com")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if tt.want != nil {
+				if len(codes.saved) != 0 || len(post.sent) != 0 {
+					t.Fatal("code must not be sent")
+				}
+				return
+			}
+			if len(codes.saved) != 1 || len(post.sent) != 1 {
+				t.Fatalf("expected one code, got %d saved and %d sent", len(codes.saved), len(post.sent))
+			}
+			saved, code := codes.saved[0], post.sent[0].Value.(string)
+			if len(code) != 6 {
+				t.Fatalf("expected 6 digit code, got %q", code)
+			}
+			if saved.Code == code || saved.Code != uc.codeHash(code) {
+				t.Fatal("code must be stored as hmac")
+			}
+			if !saved.CreatedAt.Equal(testNow) || saved.CreatedAt.Location() != time.UTC {
+				t.Fatalf("created_at must be set in UTC, got %v", saved.CreatedAt)
+			}
+			if !saved.ExpiresAt.Equal(testNow.Add(codeTTL)) || saved.IP != "10.0.0.1" {
+				t.Fatalf("unexpected code info %+v", saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/passwd"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/mail"
+	mailentity "timeline/internal/repository/mail/entity"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/audit"
+	"timeline/internal/usecase/auth/validation"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrEmailTaken = errors.New("email already in use")
+)
+
+// Заявка на смену почты: код уходит на новый адрес, уведомление - на старый.
+// Почта меняется только в EmailChangeConfirm
+func (a *AuthUseCase) EmailChangeRequest(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.EmailChangeReq) error {
+	id := int(metadata.ID)
+	hash, err := a.password.PasswordHash(ctx, id, metadata.IsOrg)
+	if err != nil {
+		a.Logger.Error(
+			"failed to request email change",
+			zap.String("PasswordHash", err.Error()),
+		)
+		return err
+	}
+	if passwd.CompareWithHash(req.Password, hash) != nil {
+		return ErrInvalidCredentials
+	}
+	newEmail := strings.TrimSpace(req.NewEmail)
+	inUse, err := a.email.EmailInUse(ctx, newEmail, metadata.IsOrg)
+	if err != nil {
+		a.Logger.Error(
+			"failed to request email change",
+			zap.String("EmailInUse", err.Error()),
+		)
+		return err
+	}
+	if inUse {
+		return ErrEmailTaken
+	}
+	if last, err := a.email.EmailChangeLast(ctx, id, metadata.IsOrg); err == nil && time.Since(last.CreatedAt) < codeCooldown {
+		return ErrCodeCooldown
+	}
+	oldEmail, err := a.email.AccountEmail(ctx, id, metadata.IsOrg)
+	if err != nil {
+		a.Logger.Error(
+			"failed to request email change",
+			zap.String("AccountEmail", err.Error()),
+		)
+		return err
+	}
+	code, err := verification.GenerateCode()
+	if err != nil {
+		a.Logger.Error(
+			"failed to request email change",
+			zap.String("GenerateCode", err.Error()),
+		)
+		return err
+	}
+	err = a.email.EmailChangeSave(ctx, &models.EmailChange{
+		SubjectID: id,
+		IsOrg:     metadata.IsOrg,
+		NewEmail:  newEmail,
+		Code:      a.codeHash(code),
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().UTC().Add(codeTTL),
+	})
+	if err != nil {
+		a.Logger.Error(
+			"failed to request email change",
+			zap.String("EmailChangeSave", err.Error()),
+		)
+		return err
+	}
+	a.mail.SendMsg(&mailentity.Message{
+		Email: newEmail,
+		Type:  mail.EmailChangeType,
+		Value: code,
+	})
+	a.mail.SendMsg(&mailentity.Message{
+		Email: oldEmail,
+		Type:  mail.EmailChangeNoticeType,
+		Value: newEmail,
+	})
+	return nil
+}
+
+// Подтверждение новой почты кодом из письма
+func (a *AuthUseCase) EmailChangeConfirm(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.EmailConfirmReq) error {
+	id := int(metadata.ID)
+	change, err := a.email.EmailChangeActive(ctx, id, metadata.IsOrg)
+	if err != nil {
+		a.Logger.Error(
+			"failed to confirm email change",
+			zap.String("EmailChangeActive", err.Error()),
+		)
+		return err
+	}
+	if change.Attempts >= codeMaxAttempts {
+		return ErrCodeLocked
+	}
+	if validation.IsCodeExpired(change.ExpiresAt) {
+		return ErrCodeExpired
+	}
+	if subtle.ConstantTimeCompare([]byte(change.Code), []byte(a.codeHash(req.Code))) != 1 {
+		if err = a.email.EmailChangeAttempt(ctx, change.ChangeID); err != nil {
+			a.Logger.Error(
+				"failed to confirm email change",
+				zap.String("EmailChangeAttempt", err.Error()),
+			)
+			return err
+		}
+		return ErrCodeMismatch
+	}
+	if _, err = a.email.EmailChangeApply(ctx, change.ChangeID, codeMaxAttempts); err != nil {
+		a.Logger.Error(
+			"failed to confirm email change",
+			zap.String("EmailChangeApply", err.Error()),
+		)
+		if errors.Is(err, postgres.ErrEmailTaken) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+	a.accountEvent(ctx, metadata, audit.ActionEmailChange)
+	return nil
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/url"
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/jwtlib"
+	"timeline/internal/libs/reqinfo"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/mail"
+	mailentity "timeline/internal/repository/mail/entity"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/auth/validation"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+var ErrLinkInvalid = errors.New("invalid or expired login link")
+
+// Отправляет пользователю одноразовую ссылку входа.
+// Неподтвержденным, удаленным и заблокированным аккаунтам письмо не отправляется,
+// но наружу об этом не сообщается
+func (a *AuthUseCase) LoginLinkSend(ctx context.Context, req *authdto.LoginLinkReq) error {
+	account := limitKey("link", false, req.Email)
+	if err := a.limits.allow(ctx, "link", account); err != nil {
+		return err
+	}
+	exp, err := a.code.AccountExpiration(ctx, req.Email, false)
+	if err != nil {
+		a.Logger.Info(
+			"login link requested for unknown account",
+			zap.String("AccountExpiration", err.Error()),
+		)
+		return nil
+	}
+	if !exp.Verified || exp.Blocked {
+		return nil
+	}
+	ip := reqinfo.From(ctx).IP
+	stats, err := a.code.CodeSendStats(ctx, exp.ID, false, ip, time.Now().UTC().Add(-codeIPWindow))
+	if err != nil {
+		a.Logger.Error(
+			"failed to send login link",
+			zap.String("CodeSendStats", err.Error()),
+		)
+		return err
+	}
+	if time.Since(stats.LastSentAt) < codeCooldown || stats.SentFromIP >= codeIPLimit {
+		return nil
+	}
+	jti, err := verification.GenerateToken(32)
+	if err != nil {
+		a.Logger.Error(
+			"failed to send login link",
+			zap.String("GenerateToken", err.Error()),
+		)
+		return err
+	}
+	token, err := jwtlib.NewLinkToken(a.keys, a.TokenCfg, &entity.TokenMetadata{ID: uint64(exp.ID)}, jti)
+	if err != nil {
+		a.Logger.Error(
+			"failed to send login link",
+			zap.String("NewLinkToken", err.Error()),
+		)
+		return err
+	}
+	err = a.code.SaveVerifyCode(ctx, &models.CodeInfo{
+		ID:        exp.ID,
+		Code:      verification.HashToken(jti),
+		IP:        ip,
+		Purpose:   models.CodeLogin,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().UTC().Add(a.TokenCfg.LinkTTL),
+	})
+	if err != nil {
+		a.Logger.Error(
+			"failed to send login link",
+			zap.String("SaveVerifyCode", err.Error()),
+		)
+		return err
+	}
+	a.mail.SendMsg(&mailentity.Message{
+		Email: req.Email,
+		Type:  mail.LoginLinkType,
+		Value: mailentity.LoginLinkMsg{
+			Link: a.loginLink(token),
+			TTL:  a.TokenCfg.LinkTTL,
+		},
+	})
+	return nil
+}
+
+// Обмен токена из ссылки на пару токенов. Ссылка погашается при первом успешном входе
+func (a *AuthUseCase) LoginLinkVerify(ctx context.Context, req *authdto.LoginLinkVerifyReq) (*authdto.TokenPair, error) {
+	token, err := jwtlib.ParseToken(a.keys, req.Token)
+	if err != nil || !token.Valid || validation.ValidateTokenClaims(token) != nil {
+		return nil, ErrLinkInvalid
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	jti, ok := claims["jti"].(string)
+	if !ok || claims["type"].(string) != "link" || claims["is_org"].(bool) {
+		return nil, ErrLinkInvalid
+	}
+	userID := int(claims["id"].(float64))
+	account := limitKeyID("link", false, userID)
+	if err = a.limits.allow(ctx, "link", account); err != nil {
+		return nil, err
+	}
+	if err = a.useLoginLink(ctx, userID, jti); err != nil {
+		a.Logger.Error(
+			"failed login by link",
+			zap.Int("user_id", userID),
+			zap.String("useLoginLink", err.Error()),
+		)
+		a.limits.fail(ctx, "link", account)
+		return nil, ErrLinkInvalid
+	}
+	a.limits.success(ctx, account)
+	// за время жизни ссылки аккаунт могли удалить или заблокировать
+	email, err := a.email.AccountEmail(ctx, userID, false)
+	if err != nil {
+		return nil, ErrLinkInvalid
+	}
+	exp, err := a.code.AccountExpiration(ctx, email, false)
+	if err != nil || !exp.Verified {
+		return nil, ErrLinkInvalid
+	}
+	if exp.Blocked {
+		return nil, ErrAccountBlocked
+	}
+	tokens, err := a.newSession(ctx, &entity.TokenMetadata{ID: uint64(userID)})
+	if err != nil {
+		a.Logger.Error(
+			"failed login by link",
+			zap.String("newSession", err.Error()),
+		)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Ссылка действительна, если ее jti совпадает с последней выданной и не погашенной ссылкой
+func (a *AuthUseCase) useLoginLink(ctx context.Context, userID int, jti string) error {
+	active, err := a.code.ActiveCode(ctx, userID, false, models.CodeLogin)
+	if err != nil {
+		return err
+	}
+	if validation.IsCodeExpired(active.ExpiresAt) {
+		return ErrCodeExpired
+	}
+	if subtle.ConstantTimeCompare([]byte(active.Code), []byte(verification.HashToken(jti))) != 1 {
+		return ErrCodeMismatch
+	}
+	return a.code.CodeUse(ctx, active.CodeID, false, codeMaxAttempts)
+}
+
+func (a *AuthUseCase) loginLink(token string) string {
+	if a.TokenCfg.LinkURL == "" {
+		return token
+	}
+	link, err := url.Parse(a.TokenCfg.LinkURL)
+	if err != nil {
+		return token
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
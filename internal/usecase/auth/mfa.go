@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/jwtlib"
+	"timeline/internal/libs/totp"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/usecase/audit"
+	"timeline/internal/usecase/auth/validation"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	totpIssuer       = "Timeline"
+	totpSkew         = 1                // допуск рассинхронизации часов в шагах
+	mfaMaxFails      = 5                // неверных вводов до блокировки
+	mfaLockout       = 15 * time.Minute // время блокировки
+	recoveryCodesNum = 10
+)
+
+var (
+	ErrMFAInvalid    = errors.New("invalid second factor")
+	ErrMFALocked     = errors.New("too many attempts, try again later")
+	ErrMFADisabled   = errors.New("mfa not enabled")
+	ErrMFAAlreadyOn  = errors.New("mfa already enabled")
+	ErrMFAOnlyForOrg = errors.New("mfa available only for organizations")
+)
+
+// Нужен ли организации второй фактор при входе
+func (a *AuthUseCase) mfaRequired(ctx context.Context, id int, isOrg bool) (bool, error) {
+	if !isOrg {
+		return false, nil
+	}
+	mfa, err := a.mfa.MFAByOrg(ctx, id)
+	if err != nil {
+		if errors.Is(err, postgres.ErrMFANotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return mfa.Enabled, nil
+}
+
+// Второй шаг входа: обмен mfa_token и кода на пару токенов
+func (a *AuthUseCase) LoginMFA(ctx context.Context, req *authdto.MFALoginReq) (*authdto.TokenPair, error) {
+	token, err := jwtlib.ParseToken(a.keys, req.MFAToken)
+	if err != nil || !token.Valid {
+		return nil, ErrMFAInvalid
+	}
+	if validation.ValidateTokenClaims(token) != nil {
+		return nil, ErrMFAInvalid
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["type"].(string) != "mfa" || !claims["is_org"].(bool) {
+		return nil, ErrMFAInvalid
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return nil, ErrMFAInvalid
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, ErrMFAInvalid
+	}
+	orgID := int(claims["id"].(float64))
+	if err = a.checkSecondFactor(ctx, orgID, req.Code); err != nil {
+		a.Logger.Error(
+			"failed login mfa",
+			zap.Int("org_id", orgID),
+			zap.String("checkSecondFactor", err.Error()),
+		)
+		return nil, err
+	}
+	// токен обменивается на сессию только один раз
+	if err = a.mfa.MFATokenUse(ctx, verification.HashToken(jti), exp.Time); err != nil {
+		a.Logger.Error(
+			"failed login mfa",
+			zap.Int("org_id", orgID),
+			zap.String("MFATokenUse", err.Error()),
+		)
+		if errors.Is(err, postgres.ErrMFATokenUsed) {
+			return nil, ErrMFAInvalid
+		}
+		return nil, err
+	}
+	tokens, err := a.newSession(ctx, &entity.TokenMetadata{ID: uint64(orgID), IsOrg: true})
+	if err != nil {
+		a.Logger.Error(
+			"failed login mfa",
+			zap.String("newSession", err.Error()),
+		)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Выпуск нового секрета. MFA включится только после TOTPConfirm
+func (a *AuthUseCase) TOTPSetup(ctx context.Context, metadata *entity.TokenMetadata) (*authdto.TOTPSetupResp, error) {
+	if !metadata.IsOrg {
+		return nil, ErrMFAOnlyForOrg
+	}
+	orgID := int(metadata.ID)
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		a.Logger.Error(
+			"failed to setup totp",
+			zap.String("GenerateSecret", err.Error()),
+		)
+		return nil, err
+	}
+	if err = a.mfa.MFASecretSave(ctx, orgID, secret); err != nil {
+		if errors.Is(err, postgres.ErrMFAAlreadyOn) {
+			return nil, ErrMFAAlreadyOn
+		}
+		a.Logger.Error(
+			"failed to setup totp",
+			zap.String("MFASecretSave", err.Error()),
+		)
+		return nil, err
+	}
+	email, err := a.email.AccountEmail(ctx, orgID, true)
+	if err != nil {
+		a.Logger.Error(
+			"failed to setup totp",
+			zap.String("AccountEmail", err.Error()),
+		)
+		return nil, err
+	}
+	return &authdto.TOTPSetupResp{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, email, secret),
+	}, nil
+}
+
+// Подтверждение настройки первым кодом из приложения. Возвращает коды восстановления,
+// они показываются один раз
+func (a *AuthUseCase) TOTPConfirm(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.TOTPCodeReq) (*authdto.RecoveryCodes, error) {
+	if !metadata.IsOrg {
+		return nil, ErrMFAOnlyForOrg
+	}
+	orgID := int(metadata.ID)
+	mfa, err := a.mfa.MFAByOrg(ctx, orgID)
+	if err != nil {
+		a.Logger.Error(
+			"failed to confirm totp",
+			zap.String("MFAByOrg", err.Error()),
+		)
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, ErrMFAAlreadyOn
+	}
+	step, ok := totp.Validate(mfa.Secret, req.Code, a.now(), totpSkew)
+	if !ok {
+		return nil, ErrMFAInvalid
+	}
+	codes := make([]string, 0, recoveryCodesNum)
+	hashes := make([]string, 0, recoveryCodesNum)
+	for range recoveryCodesNum {
+		code, err := newRecoveryCode()
+		if err != nil {
+			a.Logger.Error(
+				"failed to confirm totp",
+				zap.String("newRecoveryCode", err.Error()),
+			)
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, verification.HashToken(normalizeRecoveryCode(code)))
+	}
+	if err = a.mfa.MFAEnable(ctx, orgID, step, hashes); err != nil {
+		a.Logger.Error(
+			"failed to confirm totp",
+			zap.String("MFAEnable", err.Error()),
+		)
+		return nil, err
+	}
+	a.accountEvent(ctx, metadata, audit.ActionMFAEnable)
+	return &authdto.RecoveryCodes{Codes: codes}, nil
+}
+
+// Отключение MFA. Требует действующий второй фактор
+func (a *AuthUseCase) TOTPDisable(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.TOTPCodeReq) error {
+	if !metadata.IsOrg {
+		return ErrMFAOnlyForOrg
+	}
+	orgID := int(metadata.ID)
+	if err := a.checkSecondFactor(ctx, orgID, req.Code); err != nil {
+		return err
+	}
+	if err := a.mfa.MFADisable(ctx, orgID); err != nil {
+		a.Logger.Error(
+			"failed to disable totp",
+			zap.String("MFADisable", err.Error()),
+		)
+		return err
+	}
+	a.accountEvent(ctx, metadata, audit.ActionMFADisable)
+	return nil
+}
+
+// Проверка TOTP кода или кода восстановления с учетом блокировки после mfaMaxFails ошибок
+func (a *AuthUseCase) checkSecondFactor(ctx context.Context, orgID int, code string) error {
+	mfa, err := a.mfa.MFAByOrg(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrMFANotFound) {
+			return ErrMFADisabled
+		}
+		return err
+	}
+	if !mfa.Enabled {
+		return ErrMFADisabled
+	}
+	now := a.now()
+	if mfa.FailedAttempts >= mfaMaxFails && mfa.LastFailedAt.Valid && now.Sub(mfa.LastFailedAt.Time) < mfaLockout {
+		return ErrMFALocked
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		if step, ok := totp.Validate(mfa.Secret, code, now, totpSkew); ok {
+			return a.mfa.MFAStepAccept(ctx, orgID, step)
+		}
+	} else if a.mfa.MFARecoveryUse(ctx, orgID, verification.HashToken(normalizeRecoveryCode(code))) == nil {
+		return nil
+	}
+	if err = a.mfa.MFAFail(ctx, orgID, now); err != nil {
+		return err
+	}
+	return ErrMFAInvalid
+}
+
+// Код восстановления вида XXXXXXXX-XXXXXXXX
+func newRecoveryCode() (string, error) {
+	raw, err := verification.GenerateToken(12)
+	if err != nil {
+		return "", err
+	}
+	raw = strings.ToUpper(strings.NewReplacer("-", "X", "_", "Y").Replace(raw))
+	return raw[:8] + "-" + raw[8:16], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// mfa_token подписывается тем же ключом, что и access, но имеет тип mfa и короткое время жизни
+func (a *AuthUseCase) mfaChallenge(orgID int) (*authdto.LoginResp, error) {
+	jti, err := verification.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwtlib.NewMFAToken(a.keys, a.TokenCfg, &entity.TokenMetadata{
+		ID:    uint64(orgID),
+		IsOrg: true,
+	}, jti)
+	if err != nil {
+		return nil, err
+	}
+	return &authdto.LoginResp{
+		MFARequired: true,
+		MFAToken:    token,
+	}, nil
+}
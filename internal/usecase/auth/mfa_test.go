@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+	"timeline/internal/config"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/jwtlib"
+	"timeline/internal/libs/secret"
+	"timeline/internal/libs/totp"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/audit"
+
+	"go.uber.org/zap"
+)
+
+// MFA одной организации в памяти, повторяет условия запросов postgres
+type fakeMFA struct {
+	repository.MFARepository
+	mfa      models.OrgMFA
+	recovery map[string]bool
+	tokens   map[string]bool
+}
+
+func (f *fakeMFA) MFAByOrg(ctx context.Context, orgID int) (*models.OrgMFA, error) {
+	if f.mfa.OrgID != orgID {
+		return nil, postgres.ErrMFANotFound
+	}
+	mfa := f.mfa
+	return &mfa, nil
+}
+
+func (f *fakeMFA) MFAStepAccept(ctx context.Context, orgID int, step int64) error {
+	if f.mfa.LastStep >= step {
+		return postgres.ErrMFAStepUsed
+	}
+	f.mfa.LastStep = step
+	f.mfa.FailedAttempts = 0
+	return nil
+}
+
+func (f *fakeMFA) MFAFail(ctx context.Context, orgID int, at time.Time) error {
+	f.mfa.FailedAttempts++
+	f.mfa.LastFailedAt = sql.NullTime{Time: at, Valid: true}
+	return nil
+}
+
+func (f *fakeMFA) MFARecoveryUse(ctx context.Context, orgID int, codeHash string) error {
+	if used, ok := f.recovery[codeHash]; !ok || used {
+		return postgres.ErrRecoveryNotFound
+	}
+	f.recovery[codeHash] = true
+	f.mfa.FailedAttempts = 0
+	return nil
+}
+
+func (f *fakeMFA) MFATokenUse(ctx context.Context, jtiHash string, expiresAt time.Time) error {
+	if f.tokens[jtiHash] {
+		return postgres.ErrMFATokenUsed
+	}
+	f.tokens[jtiHash] = true
+	return nil
+}
+
+type fakeSessions struct {
+	repository.SessionRepository
+	saved   []*models.Session
+	revoked []int // субъекты, чьи сессии завершены
+}
+
+func (f *fakeSessions) SessionSave(ctx context.Context, session *models.Session) error {
+	f.saved = append(f.saved, session)
+	return nil
+}
+
+func (f *fakeSessions) SessionRevokeAll(ctx context.Context, subjectID int, isOrg bool, role, keepFamily string) error {
+	f.revoked = append(f.revoked, subjectID)
+	return nil
+}
+
+type fakeAudit struct {
+	repository.AuditRepository
+}
+
+func (fakeAudit) AuditSave(ctx context.Context, entry *models.AuditEntry) error {
+	return nil
+}
+
+const (
+	mfaOrgID      = 3
+	mfaRecovery   = "ABCDEFGH-12345678"
+	rfcTestSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // секрет из RFC 6238 в base32
+)
+
+func newMFACase(t *testing.T, clock *time.Time) (*AuthUseCase, *fakeMFA, *fakeSessions) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := secret.SingleKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfa := &fakeMFA{
+		mfa: models.OrgMFA{OrgID: mfaOrgID, Secret: rfcTestSecret, Enabled: true},
+		recovery: map[string]bool{
+			verification.HashToken(normalizeRecoveryCode(mfaRecovery)): false,
+		},
+		tokens: map[string]bool{},
+	}
+	sessions := &fakeSessions{}
+	return &AuthUseCase{
+		keys:     keys,
+		mfa:      mfa,
+		session:  sessions,
+		audit:    audit.New(fakeAudit{}, zap.NewNop()),
+		TokenCfg: config.Token{AccessTTL: time.Minute, RefreshTTL: time.Hour, MFATTL: 5 * time.Minute},
+		Logger:   zap.NewNop(),
+		now:      func() time.Time { return *clock },
+	}, mfa, sessions
+}
+
+func totpCode(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.Code(rfcTestSecret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestSecondFactorStepReplay(t *testing.T) {
+	clock := testNow
+	uc, mfa, _ := newMFACase(t, &clock)
+	ctx := context.Background()
+
+	code := totpCode(t, clock)
+	if err := uc.checkSecondFactor(ctx, mfaOrgID, code); err != nil {
+		t.Fatalf("expected code to be accepted, got %v", err)
+	}
+	if mfa.mfa.LastStep != totp.Step(clock) {
+		t.Fatalf("expected last step %d, got %d", totp.Step(clock), mfa.mfa.LastStep)
+	}
+	// тот же код в том же шаге
+	if err := uc.checkSecondFactor(ctx, mfaOrgID, code); !errors.Is(err, postgres.ErrMFAStepUsed) {
+		t.Fatalf("expected %v, got %v", postgres.ErrMFAStepUsed, err)
+	}
+	// код предыдущего шага входит в допуск, но старше принятого
+	if err := uc.checkSecondFactor(ctx, mfaOrgID, totpCode(t, clock.Add(-totp.Period))); !errors.Is(err, postgres.ErrMFAStepUsed) {
+		t.Fatalf("expected %v, got %v", postgres.ErrMFAStepUsed, err)
+	}
+	// тот же код через шаг все еще в допуске, но уже принят
+	clock = clock.Add(totp.Period)
+	if err := uc.checkSecondFactor(ctx, mfaOrgID, code); !errors.Is(err, postgres.ErrMFAStepUsed) {
+		t.Fatalf("expected %v, got %v", postgres.ErrMFAStepUsed, err)
+	}
+	if err := uc.checkSecondFactor(ctx, mfaOrgID, totpCode(t, clock)); err != nil {
+		t.Fatalf("expected next step to be accepted, got %v", err)
+	}
+}
+
+func TestSecondFactorLockout(t *testing.T) {
+	clock := testNow
+	uc, mfa, _ := newMFACase(t, &clock)
+	ctx := context.Background()
+
+	for i := range mfaMaxFails {
+		if err := uc.checkSecondFactor(ctx, mfaOrgID, "000000"); !errors.Is(err, ErrMFAInvalid) {
+			t.Fatalf("attempt %d: expected %v, got %v", i, ErrMFAInvalid, err)
+		}
+	}
+	tests := []struct {
+		name  string
+		after time.Duration
+		code  func() string
+		want  error
+	}{
+		{"right code while locked", 0, func() string { return totpCode(t, clock) }, ErrMFALocked},
+		{"recovery code while locked", 0, func() string { return mfaRecovery }, ErrMFALocked},
+		{"right before lockout ends", mfaLockout - time.Second, func() string { return totpCode(t, clock) }, ErrMFALocked},
+		{"lockout ended", mfaLockout, func() string { return totpCode(t, clock) }, nil},
+	}
+	failedAt := clock
+	for _, tt := range tests {
+		clock = failedAt.Add(tt.after)
+		if err := uc.checkSecondFactor(ctx, mfaOrgID, tt.code()); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+	if mfa.mfa.FailedAttempts != 0 {
+		t.Fatalf("accepted code must reset fails, got %d", mfa.mfa.FailedAttempts)
+	}
+}
+
+func TestSecondFactorRecoveryCode(t *testing.T) {
+	clock := testNow
+	uc, _, _ := newMFACase(t, &clock)
+	ctx := context.Background()
+
+	if err := uc.checkSecondFactor(ctx, mfaOrgID, "abcdefgh 12345678"); err != nil {
+		t.Fatalf("expected recovery code to be accepted, got %v", err)
+	}
+	if err := uc.checkSecondFactor(ctx, mfaOrgID, mfaRecovery); !errors.Is(err, ErrMFAInvalid) {
+		t.Fatalf("expected used recovery code to be refused, got %v", err)
+	}
+}
+
+func TestLoginMFATokenSingleUse(t *testing.T) {
+	clock := time.Now()
+	uc, _, sessions := newMFACase(t, &clock)
+	ctx := context.Background()
+
+	challenge, err := uc.mfaChallenge(mfaOrgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &authdto.MFALoginReq{MFAToken: challenge.MFAToken, Code: totpCode(t, clock)}
+	if _, err = uc.LoginMFA(ctx, req); err != nil {
+		t.Fatalf("expected login, got %v", err)
+	}
+	// новый код не дает обменять тот же токен еще раз
+	clock = clock.Add(totp.Period)
+	req.Code = totpCode(t, clock)
+	if _, err = uc.LoginMFA(ctx, req); !errors.Is(err, ErrMFAInvalid) {
+		t.Fatalf("expected %v, got %v", ErrMFAInvalid, err)
+	}
+	if len(sessions.saved) != 1 {
+		t.Fatalf("expected one session, got %d", len(sessions.saved))
+	}
+	// access токен не заменяет mfa_token
+	access, err := jwtlib.NewToken(uc.keys, uc.TokenCfg, &entity.TokenMetadata{ID: mfaOrgID, IsOrg: true}, "access")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(totp.Period)
+	if _, err = uc.LoginMFA(ctx, &authdto.MFALoginReq{MFAToken: access, Code: totpCode(t, clock)}); !errors.Is(err, ErrMFAInvalid) {
+		t.Fatalf("expected %v, got %v", ErrMFAInvalid, err)
+	}
+}
@@ -1,13 +1,22 @@
 package middleware
 
 import (
-	"crypto/rsa"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+	"timeline/internal/entity"
+	"timeline/internal/libs/jwtlib"
+	"timeline/internal/libs/reqinfo"
+	"timeline/internal/libs/secret"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/auth/access"
+	"timeline/internal/usecase/auth/validation"
 
 	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
@@ -35,15 +44,24 @@ func (rw *respWriterCustom) Header() http.Header {
 	return rw.ResponseWriter.Header()
 }
 
+// Проверка ключей API организаций
+type APIKeys interface {
+	APIKeyUse(ctx context.Context, keyHash, ip string) (*models.APIKey, error)
+}
+
 type Middleware struct {
-	secret *rsa.PrivateKey
-	logger *zap.Logger
+	keys    *secret.KeyRing
+	apiKeys APIKeys
+	proxies reqinfo.TrustedProxies
+	logger  *zap.Logger
 }
 
-func New(key *rsa.PrivateKey, logger *zap.Logger) *Middleware {
+func New(keys *secret.KeyRing, apiKeys APIKeys, proxies reqinfo.TrustedProxies, logger *zap.Logger) *Middleware {
 	return &Middleware{
-		secret: key,
-		logger: logger,
+		keys:    keys,
+		apiKeys: apiKeys,
+		proxies: proxies,
+		logger:  logger,
 	}
 }
 
@@ -69,49 +87,95 @@ func (m *Middleware) HandlerLogs(next http.Handler) http.Handler {
 	})
 }
 
+// Сведения о клиенте (IP, User-Agent) кладутся в контекст запроса
+func (m *Middleware) ClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(reqinfo.With(r.Context(), reqinfo.FromRequest(r, m.proxies))))
+	})
+}
+
 func (m *Middleware) ExtractToken(w http.ResponseWriter, r *http.Request) (*jwt.Token, error) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
 		return nil, ErrAuthHeaderEmpty
 	}
-	tokenString := strings.Split(authHeader, " ")[1]
-	if tokenString == "" {
+	tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found || tokenString == "" {
 		return nil, ErrTokenNotFound
 	}
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Проверяем алгоритм подписи токена
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("неверный метод подписи: %v", token.Header["alg"])
-		}
-		publicKey := &m.secret.PublicKey
-		return publicKey, nil
-	})
-	return token, err
+	return jwtlib.ParseToken(m.keys, tokenString)
 }
 
-// Валидацпия access токена
+// Валидация access токена или ключа API. Данные токена кладутся в контекст запроса
 func (m *Middleware) IsTokenValid(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := m.extractAPIKey(r); ok {
+			metadata, err := m.apiKeyMetadata(r.Context(), key)
+			if err != nil {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(access.WithMetadata(r.Context(), metadata)))
+			return
+		}
 		token, err := m.ExtractToken(w, r)
 		// в виду безопасности ошибка не уточняется
 		if err != nil || !token.Valid {
 			http.Error(w, "", http.StatusUnauthorized)
 			return
 		}
-		if token.Claims.(jwt.MapClaims)["type"].(string) != "access" {
-			http.Error(w, "access not", http.StatusUnauthorized)
+		if validation.ValidateTokenClaims(token) != nil {
+			http.Error(w, "", http.StatusUnauthorized)
 			return
 		}
-		_, ok := token.Claims.(jwt.MapClaims)["id"]
-		if !ok {
-			http.Error(w, "", http.StatusBadRequest)
+		claims := token.Claims.(jwt.MapClaims)
+		if claims["type"].(string) != "access" {
+			http.Error(w, "access not", http.StatusUnauthorized)
 			return
 		}
-		_, ok = token.Claims.(jwt.MapClaims)["is_org"]
-		if !ok {
-			http.Error(w, "", http.StatusBadRequest)
+		metadata := jwtlib.Metadata(claims)
+		next.ServeHTTP(w, r.WithContext(access.WithMetadata(r.Context(), metadata)))
+	})
+}
+
+func (m *Middleware) extractAPIKey(r *http.Request) (string, bool) {
+	key, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || !strings.HasPrefix(key, entity.APIKeyPrefix) {
+		return "", false
+	}
+	return key, true
+}
+
+// Ключ действует от имени организации, но только в пределах своих прав
+func (m *Middleware) apiKeyMetadata(ctx context.Context, key string) (*entity.TokenMetadata, error) {
+	apiKey, err := m.apiKeys.APIKeyUse(ctx, verification.HashToken(key), reqinfo.From(ctx).IP)
+	if err != nil {
+		if !errors.Is(err, postgres.ErrAPIKeyNotFound) {
+			m.logger.Error("failed to check api key", zap.Error(err))
+		}
+		return nil, err
+	}
+	return &entity.TokenMetadata{
+		ID:     uint64(apiKey.KeyID),
+		IsOrg:  true,
+		Role:   entity.RoleAPIKey,
+		OrgID:  uint64(apiKey.OrgID),
+		Scopes: strings.Fields(apiKey.Scopes),
+	}, nil
+}
+
+// Проверка прав доступа к ручке по заданной политике.
+// Используется после IsTokenValid
+func (m *Middleware) Authorize(policy access.Policy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := access.Check(r, policy); err != nil {
+			if errors.Is(err, access.ErrUnauthorized) {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, "Access denied", http.StatusForbidden)
 			return
 		}
-		next.ServeHTTP(w, r)
-	})
+		next(w, r)
+	}
 }
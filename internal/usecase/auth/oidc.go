@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/oidc"
+	"timeline/internal/libs/passwd"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/models"
+	"timeline/internal/repository/models/usermodel"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrProviderNotFound = errors.New("identity provider not found")
+	ErrOIDCState        = errors.New("invalid or expired oidc state")
+	ErrOIDCFailed       = errors.New("identity provider rejected the login")
+	ErrOIDCEmail        = errors.New("identity provider did not confirm the email")
+)
+
+// Провайдеры OpenID Connect, доступные для входа
+type SSO struct {
+	Providers []*oidc.Provider
+	StateTTL  time.Duration // время на возврат пользователя от провайдера
+}
+
+func (s SSO) provider(name string) (*oidc.Provider, bool) {
+	for _, p := range s.Providers {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (a *AuthUseCase) OIDCProviders(ctx context.Context) *authdto.OIDCProviders {
+	resp := &authdto.OIDCProviders{Providers: make([]string, 0, len(a.sso.Providers))}
+	for _, p := range a.sso.Providers {
+		resp.Providers = append(resp.Providers, p.Name())
+	}
+	sort.Strings(resp.Providers)
+	return resp
+}
+
+// Начало входа: сохраняет state с PKCE verifier и nonce, возвращает адрес страницы провайдера
+func (a *AuthUseCase) OIDCStart(ctx context.Context, name string) (*authdto.OIDCStartResp, error) {
+	provider, ok := a.sso.provider(name)
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+	var state, nonce, verifier string
+	var err error
+	for _, v := range []*string{&state, &nonce, &verifier} {
+		if *v, err = verification.GenerateToken(32); err != nil {
+			a.Logger.Error(
+				"failed to start oidc login",
+				zap.String("GenerateToken", err.Error()),
+			)
+			return nil, err
+		}
+	}
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		a.Logger.Error(
+			"failed to start oidc login",
+			zap.String("provider", name),
+			zap.String("AuthCodeURL", err.Error()),
+		)
+		return nil, err
+	}
+	err = a.identity.OIDCStateSave(ctx, &models.OIDCState{
+		StateHash: verification.HashToken(state),
+		Provider:  name,
+		Verifier:  verifier,
+		Nonce:     nonce,
+		ExpiresAt: time.Now().UTC().Add(a.sso.StateTTL),
+	})
+	if err != nil {
+		a.Logger.Error(
+			"failed to start oidc login",
+			zap.String("OIDCStateSave", err.Error()),
+		)
+		return nil, err
+	}
+	return &authdto.OIDCStartResp{AuthURL: authURL}, nil
+}
+
+// Завершение входа: обмен кода у провайдера и поиск или создание пользователя.
+// Учетная запись привязывается к пользователю с той же почтой, только если провайдер ее подтвердил
+func (a *AuthUseCase) OIDCCallback(ctx context.Context, req *authdto.OIDCCallbackReq) (*authdto.TokenPair, error) {
+	provider, ok := a.sso.provider(req.Provider)
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+	state, err := a.identity.OIDCStateUse(ctx, verification.HashToken(req.State))
+	if err != nil || state.Provider != req.Provider {
+		return nil, ErrOIDCState
+	}
+	identity, err := provider.Exchange(ctx, req.Code, state.Verifier)
+	if err != nil {
+		a.Logger.Error(
+			"failed oidc login",
+			zap.String("provider", req.Provider),
+			zap.String("Exchange", err.Error()),
+		)
+		return nil, ErrOIDCFailed
+	}
+	if identity.Nonce != state.Nonce {
+		return nil, ErrOIDCFailed
+	}
+	userID, err := a.identityUser(ctx, req.Provider, identity)
+	if err != nil {
+		if !errors.Is(err, ErrOIDCEmail) && !errors.Is(err, ErrAccountBlocked) {
+			a.Logger.Error(
+				"failed oidc login",
+				zap.String("provider", req.Provider),
+				zap.String("identityUser", err.Error()),
+			)
+		}
+		return nil, err
+	}
+	tokens, err := a.newSession(ctx, &entity.TokenMetadata{ID: uint64(userID)})
+	if err != nil {
+		a.Logger.Error(
+			"failed oidc login",
+			zap.String("newSession", err.Error()),
+		)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (a *AuthUseCase) identityUser(ctx context.Context, provider string, identity *oidc.Identity) (int, error) {
+	userID, err := a.identity.IdentityUser(ctx, provider, identity.Subject)
+	switch {
+	case err == nil:
+		email, err := a.email.AccountEmail(ctx, userID, false)
+		if err != nil {
+			return 0, err
+		}
+		exp, err := a.code.AccountExpiration(ctx, email, false)
+		if err != nil {
+			return 0, err
+		}
+		if exp.Blocked {
+			return 0, ErrAccountBlocked
+		}
+		return userID, nil
+	case !errors.Is(err, postgres.ErrIdentityNotFound):
+		return 0, err
+	}
+	// без подтвержденной провайдером почты нельзя ни привязать, ни создать аккаунт
+	if identity.Email == "" || !identity.EmailVerified {
+		return 0, ErrOIDCEmail
+	}
+	exp, err := a.code.AccountExpiration(ctx, identity.Email, false)
+	switch {
+	case err == nil:
+		if exp.Blocked {
+			return 0, ErrAccountBlocked
+		}
+		userID = exp.ID
+		// Неподтвержденный аккаунт мог зарегистрировать кто угодно, не владея почтой.
+		// Его пароль и сессии сбрасываются, иначе регистрант сохранит доступ к аккаунту владельца
+		if !exp.Verified {
+			if err = a.oidcReclaim(ctx, userID); err != nil {
+				return 0, err
+			}
+		}
+	case errors.Is(err, postgres.ErrUserNotFound):
+		if userID, err = a.oidcRegister(ctx, identity); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, err
+	}
+	err = a.identity.IdentityLink(ctx, &models.Identity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// Передает неподтвержденный аккаунт владельцу почты: пароль заменяется случайным,
+// сессии завершаются, аккаунт активируется
+func (a *AuthUseCase) oidcReclaim(ctx context.Context, userID int) error {
+	hash, err := randomPasswordHash()
+	if err != nil {
+		return err
+	}
+	if err = a.password.PasswordUpdate(ctx, userID, false, hash); err != nil {
+		return err
+	}
+	if err = a.session.SessionRevokeAll(ctx, userID, false, "", ""); err != nil {
+		return err
+	}
+	return a.code.ActivateAccount(ctx, userID, false)
+}
+
+// Новый пользователь без пароля: войти можно через провайдера, по ссылке или после сброса пароля
+func (a *AuthUseCase) oidcRegister(ctx context.Context, identity *oidc.Identity) (int, error) {
+	hash, err := randomPasswordHash()
+	if err != nil {
+		return 0, err
+	}
+	firstName := identity.GivenName
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(identity.Email, "@")
+	}
+	userID, err := a.user.UserSave(ctx, &usermodel.UserRegister{
+		HashCreds: models.HashCreds{
+			Email:      identity.Email,
+			PasswdHash: hash,
+		},
+		UserInfo: usermodel.UserInfo{
+			FirstName: firstName,
+			LastName:  identity.FamilyName,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err = a.code.ActivateAccount(ctx, userID, false); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// Хеш случайного пароля, который никто не знает
+func randomPasswordHash() (string, error) {
+	random, err := verification.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+	return passwd.GetHash(random)
+}
@@ -0,0 +1,471 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"timeline/internal/config"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/oidc"
+	"timeline/internal/libs/secret"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/models"
+	"timeline/internal/repository/models/usermodel"
+	"timeline/internal/usecase/audit"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	fakeProviderName = "fake"
+	fakeClientID     = "timeline"
+	fakeKID          = "fake-key"
+)
+
+// Разрешение, выданное провайдером на странице входа: код обменивается на id_token
+// только с verifier, соответствующим code_challenge
+type fakeGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+// Провайдер OpenID Connect в процессе: discovery, JWKS и token endpoint
+type fakeProvider struct {
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]fakeGrant
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp := &fakeProvider{key: key, grants: map[string]fakeGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 fp.srv.URL,
+			"authorization_endpoint": fp.srv.URL + "/authorize",
+			"token_endpoint":         fp.srv.URL + "/token",
+			"jwks_uri":               fp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": fakeKID,
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", fp.token)
+	fp.srv = httptest.NewServer(mux)
+	t.Cleanup(fp.srv.Close)
+	return fp
+}
+
+func (fp *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	fp.mu.Lock()
+	grant, ok := fp.grants[r.PostForm.Get("code")]
+	delete(fp.grants, r.PostForm.Get("code"))
+	fp.mu.Unlock()
+	if !ok ||
+		r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != fakeClientID ||
+		oidc.Challenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+	claims := jwt.MapClaims{
+		"iss": fp.srv.URL,
+		"aud": fakeClientID,
+		"exp": time.Now().Add(5 * time.Minute).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = fakeKID
+	signed, err := token.SignedString(fp.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+}
+
+// Пользователь вошел у провайдера: выдается код для адреса из OIDCStart.
+// Возвращает state, с которым провайдер вернет пользователя
+func (fp *fakeProvider) authorize(t *testing.T, authURL, code string, claims jwt.MapClaims) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("login must use PKCE S256: %s", authURL)
+	}
+	if q.Get("client_id") != fakeClientID || q.Get("response_type") != "code" {
+		t.Fatalf("unexpected auth request: %s", authURL)
+	}
+	withNonce := jwt.MapClaims{"nonce": q.Get("nonce")}
+	for k, v := range claims {
+		withNonce[k] = v
+	}
+	fp.mu.Lock()
+	fp.grants[code] = fakeGrant{challenge: q.Get("code_challenge"), claims: withNonce}
+	fp.mu.Unlock()
+	return q.Get("state")
+}
+
+type fakeAccount struct {
+	email    string
+	hash     string
+	verified bool
+	blocked  bool
+}
+
+// Пользователи в памяти для всех репозиториев, которые трогает вход через провайдера
+type fakeUsers struct {
+	repository.UserRepository
+	repository.CodeRepository
+	repository.EmailRepository
+	repository.PasswordRepository
+	accounts map[int]*fakeAccount
+}
+
+func (f *fakeUsers) UserSave(ctx context.Context, user *usermodel.UserRegister) (int, error) {
+	id := len(f.accounts) + 1
+	f.accounts[id] = &fakeAccount{email: user.Email, hash: user.PasswdHash}
+	return id, nil
+}
+
+func (f *fakeUsers) AccountEmail(ctx context.Context, id int, isOrg bool) (string, error) {
+	account, ok := f.accounts[id]
+	if !ok {
+		return "", postgres.ErrUserNotFound
+	}
+	return account.email, nil
+}
+
+func (f *fakeUsers) AccountExpiration(ctx context.Context, email string, isOrg bool) (*models.ExpInfo, error) {
+	for id, account := range f.accounts {
+		if account.email == email {
+			return &models.ExpInfo{ID: id, Verified: account.verified, Blocked: account.blocked, Hash: account.hash}, nil
+		}
+	}
+	return nil, postgres.ErrUserNotFound
+}
+
+func (f *fakeUsers) ActivateAccount(ctx context.Context, id int, isOrg bool) error {
+	f.accounts[id].verified = true
+	return nil
+}
+
+func (f *fakeUsers) PasswordUpdate(ctx context.Context, id int, isOrg bool, hash string) error {
+	f.accounts[id].hash = hash
+	return nil
+}
+
+type fakeIdentities struct {
+	repository.IdentityRepository
+	states map[string]*models.OIDCState
+	links  map[string]int // provider/subject -> user_id
+}
+
+func (f *fakeIdentities) OIDCStateSave(ctx context.Context, state *models.OIDCState) error {
+	f.states[state.StateHash] = state
+	return nil
+}
+
+func (f *fakeIdentities) OIDCStateUse(ctx context.Context, stateHash string) (*models.OIDCState, error) {
+	state, ok := f.states[stateHash]
+	if !ok || !state.ExpiresAt.After(time.Now()) {
+		return nil, postgres.ErrOIDCStateNotFound
+	}
+	delete(f.states, stateHash)
+	return state, nil
+}
+
+func (f *fakeIdentities) IdentityUser(ctx context.Context, provider, subject string) (int, error) {
+	userID, ok := f.links[provider+"/"+subject]
+	if !ok {
+		return 0, postgres.ErrIdentityNotFound
+	}
+	return userID, nil
+}
+
+func (f *fakeIdentities) IdentityLink(ctx context.Context, identity *models.Identity) error {
+	f.links[identity.Provider+"/"+identity.Subject] = identity.UserID
+	return nil
+}
+
+type oidcCase struct {
+	uc         *AuthUseCase
+	provider   *fakeProvider
+	users      *fakeUsers
+	identities *fakeIdentities
+	sessions   *fakeSessions
+}
+
+func newOIDCCase(t *testing.T) *oidcCase {
+	t.Helper()
+	fp := newFakeProvider(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := secret.SingleKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &fakeUsers{accounts: map[int]*fakeAccount{}}
+	identities := &fakeIdentities{states: map[string]*models.OIDCState{}, links: map[string]int{}}
+	sessions := &fakeSessions{}
+	provider := oidc.New(oidc.Config{
+		Name:        fakeProviderName,
+		Issuer:      fp.srv.URL,
+		ClientID:    fakeClientID,
+		RedirectURL: "http://localhost:3000/login/oidc/fake",
+	}, fp.srv.Client())
+	return &oidcCase{
+		uc: &AuthUseCase{
+			keys:     keys,
+			user:     users,
+			code:     users,
+			email:    users,
+			password: users,
+			session:  sessions,
+			identity: identities,
+			audit:    audit.New(fakeAudit{}, zap.NewNop()),
+			sso:      SSO{Providers: []*oidc.Provider{provider}, StateTTL: time.Minute},
+			TokenCfg: config.Token{AccessTTL: time.Minute, RefreshTTL: time.Hour},
+			Logger:   zap.NewNop(),
+			now:      time.Now,
+		},
+		provider:   fp,
+		users:      users,
+		identities: identities,
+		sessions:   sessions,
+	}
+}
+
+// Полный вход: начало, вход у провайдера с заданными claims и возврат
+func (c *oidcCase) login(t *testing.T, claims jwt.MapClaims) (*authdto.TokenPair, error) {
+	t.Helper()
+	start, err := c.uc.OIDCStart(context.Background(), fakeProviderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := c.provider.authorize(t, start.AuthURL, "code-"+claims["sub"].(string), claims)
+	return c.uc.OIDCCallback(context.Background(), &authdto.OIDCCallbackReq{
+		Provider: fakeProviderName,
+		Code:     "code-" + claims["sub"].(string),
+		State:    state,
+	})
+}
+
+func identityClaims(sub, email string, verified bool) jwt.MapClaims {
+	return jwt.MapClaims{"sub": sub, "email": email, "email_verified": verified, "given_name": "Ann"}
+}
+
+func TestOIDCNewAccount(t *testing.T) {
+	c := newOIDCCase(t)
+	tokens, err := c.login(t, identityClaims("sub-1", "ann@example.com", true))
+	if err != nil {
+		t.Fatalf("expected login, got %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatal("expected token pair")
+	}
+	if len(c.users.accounts) != 1 {
+		t.Fatalf("expected one new account, got %d", len(c.users.accounts))
+	}
+	account := c.users.accounts[1]
+	if account.email != "ann@example.com" || !account.verified {
+		t.Fatalf("new account must be verified: %+v", account)
+	}
+	if c.identities.links[fakeProviderName+"/sub-1"] != 1 {
+		t.Fatal("identity must be linked to the new account")
+	}
+	// повторный вход находит аккаунт по привязке
+	if _, err = c.login(t, identityClaims("sub-1", "ann@example.com", true)); err != nil {
+		t.Fatalf("expected second login, got %v", err)
+	}
+	if len(c.users.accounts) != 1 || len(c.sessions.saved) != 2 {
+		t.Fatalf("expected the same account, got %d accounts", len(c.users.accounts))
+	}
+}
+
+func TestOIDCLinkVerifiedEmail(t *testing.T) {
+	c := newOIDCCase(t)
+	c.users.accounts[1] = &fakeAccount{email: "ann@example.com", hash: "owner hash", verified: true}
+	if _, err := c.login(t, identityClaims("sub-1", "ann@example.com", true)); err != nil {
+		t.Fatalf("expected login, got %v", err)
+	}
+	if c.identities.links[fakeProviderName+"/sub-1"] != 1 {
+		t.Fatal("identity must be linked to the existing account")
+	}
+	if c.users.accounts[1].hash != "owner hash" || len(c.sessions.revoked) != 0 {
+		t.Fatal("verified account must keep password and sessions")
+	}
+}
+
+// Аккаунт на чужую почту, зарегистрированный до входа владельца, не должен оставить регистранту доступ
+func TestOIDCLinkPreRegisteredAccount(t *testing.T) {
+	c := newOIDCCase(t)
+	c.users.accounts[1] = &fakeAccount{email: "ann@example.com", hash: "attacker hash"}
+	if _, err := c.login(t, identityClaims("sub-1", "ann@example.com", true)); err != nil {
+		t.Fatalf("expected login, got %v", err)
+	}
+	account := c.users.accounts[1]
+	if account.hash == "attacker hash" {
+		t.Fatal("password of pre-registered account must be reset")
+	}
+	if len(c.sessions.revoked) != 1 || c.sessions.revoked[0] != 1 {
+		t.Fatalf("sessions of pre-registered account must be revoked, got %v", c.sessions.revoked)
+	}
+	if !account.verified || c.identities.links[fakeProviderName+"/sub-1"] != 1 {
+		t.Fatal("account must be verified and linked")
+	}
+}
+
+func TestOIDCRefuseUnverifiedEmail(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{"email not verified", identityClaims("sub-1", "ann@example.com", false)},
+		{"no email", jwt.MapClaims{"sub": "sub-1"}},
+		{"verified as string false", jwt.MapClaims{"sub": "sub-1", "email": "ann@example.com", "email_verified": "false"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newOIDCCase(t)
+			c.users.accounts[1] = &fakeAccount{email: "ann@example.com", hash: "owner hash", verified: true}
+			if _, err := c.login(t, tt.claims); !errors.Is(err, ErrOIDCEmail) {
+				t.Fatalf("expected %v, got %v", ErrOIDCEmail, err)
+			}
+			if len(c.identities.links) != 0 || len(c.users.accounts) != 1 || len(c.sessions.saved) != 0 {
+				t.Fatal("nothing must be linked or created")
+			}
+		})
+	}
+}
+
+func TestOIDCBlockedAccount(t *testing.T) {
+	c := newOIDCCase(t)
+	c.users.accounts[1] = &fakeAccount{email: "ann@example.com", verified: true, blocked: true}
+	if _, err := c.login(t, identityClaims("sub-1", "ann@example.com", true)); !errors.Is(err, ErrAccountBlocked) {
+		t.Fatalf("expected %v, got %v", ErrAccountBlocked, err)
+	}
+}
+
+func TestOIDCState(t *testing.T) {
+	ctx := context.Background()
+	c := newOIDCCase(t)
+	start, err := c.uc.OIDCStart(ctx, fakeProviderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := c.provider.authorize(t, start.AuthURL, "code-1", identityClaims("sub-1", "ann@example.com", true))
+	tests := []struct {
+		name string
+		req  authdto.OIDCCallbackReq
+		want error
+	}{
+		{"unknown provider", authdto.OIDCCallbackReq{Provider: "other", Code: "code-1", State: state}, ErrProviderNotFound},
+		{"unknown state", authdto.OIDCCallbackReq{Provider: fakeProviderName, Code: "code-1", State: "forged"}, ErrOIDCState},
+		{"valid state", authdto.OIDCCallbackReq{Provider: fakeProviderName, Code: "code-1", State: state}, nil},
+		{"reused state", authdto.OIDCCallbackReq{Provider: fakeProviderName, Code: "code-1", State: state}, ErrOIDCState},
+	}
+	for _, tt := range tests {
+		if _, err := c.uc.OIDCCallback(ctx, &tt.req); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// state от другого провайдера
+	other := verification.HashToken("other")
+	c.identities.states[other] = &models.OIDCState{StateHash: other, Provider: "other", ExpiresAt: time.Now().Add(time.Minute)}
+	if _, err = c.uc.OIDCCallback(ctx, &authdto.OIDCCallbackReq{Provider: fakeProviderName, Code: "code-1", State: "other"}); !errors.Is(err, ErrOIDCState) {
+		t.Fatalf("expected %v, got %v", ErrOIDCState, err)
+	}
+
+	// стухший state
+	start, err = c.uc.OIDCStart(ctx, fakeProviderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state = c.provider.authorize(t, start.AuthURL, "code-2", identityClaims("sub-2", "bob@example.com", true))
+	for _, v := range c.identities.states {
+		v.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	if _, err = c.uc.OIDCCallback(ctx, &authdto.OIDCCallbackReq{Provider: fakeProviderName, Code: "code-2", State: state}); !errors.Is(err, ErrOIDCState) {
+		t.Fatalf("expected %v, got %v", ErrOIDCState, err)
+	}
+}
+
+// Код, выданный для одного входа, не обменивается по state другого входа: verifier не совпадет
+func TestOIDCPKCE(t *testing.T) {
+	ctx := context.Background()
+	c := newOIDCCase(t)
+	attacker, err := c.uc.OIDCStart(ctx, fakeProviderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.provider.authorize(t, attacker.AuthURL, "attacker-code", identityClaims("sub-1", "eve@example.com", true))
+	victim, err := c.uc.OIDCStart(ctx, fakeProviderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	victimState := c.provider.authorize(t, victim.AuthURL, "victim-code", identityClaims("sub-2", "ann@example.com", true))
+	_, err = c.uc.OIDCCallback(ctx, &authdto.OIDCCallbackReq{Provider: fakeProviderName, Code: "attacker-code", State: victimState})
+	if !errors.Is(err, ErrOIDCFailed) {
+		t.Fatalf("expected %v, got %v", ErrOIDCFailed, err)
+	}
+	if len(c.identities.links) != 0 {
+		t.Fatal("nothing must be linked")
+	}
+}
+
+func TestOIDCNonce(t *testing.T) {
+	ctx := context.Background()
+	c := newOIDCCase(t)
+	start, err := c.uc.OIDCStart(ctx, fakeProviderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := c.provider.authorize(t, start.AuthURL, "code-1", identityClaims("sub-1", "ann@example.com", true))
+	// провайдер вернул токен, выпущенный для другого входа
+	c.provider.grants["code-1"].claims["nonce"] = "replayed"
+	_, err = c.uc.OIDCCallback(ctx, &authdto.OIDCCallbackReq{Provider: fakeProviderName, Code: "code-1", State: state})
+	if !errors.Is(err, ErrOIDCFailed) {
+		t.Fatalf("expected %v, got %v", ErrOIDCFailed, err)
+	}
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/passwd"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/mail"
+	mailentity "timeline/internal/repository/mail/entity"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/audit"
+
+	"go.uber.org/zap"
+)
+
+// Время жизни токена сброса пароля
+const passwordResetTTL = 15 * time.Minute
+
+// Отправляет на почту одноразовый токен сброса пароля.
+// Существует ли аккаунт - наружу не сообщается
+func (a *AuthUseCase) ForgotPassword(ctx context.Context, req *authdto.ForgotPasswordReq) error {
+	// Пауза выдерживается и для несуществующих аккаунтов, чтобы ответ их не выдавал
+	if err := a.limits.send(ctx, "forgot", limitKey("forgot", req.IsOrg, req.Email)); err != nil {
+		return err
+	}
+	exp, err := a.code.AccountExpiration(ctx, req.Email, req.IsOrg)
+	if err != nil {
+		a.Logger.Info(
+			"password reset requested for unknown account",
+			zap.String("AccountExpiration", err.Error()),
+		)
+		return nil
+	}
+	token, err := verification.GenerateToken(32)
+	if err != nil {
+		a.Logger.Error(
+			"failed to send reset token",
+			zap.String("GenerateToken", err.Error()),
+		)
+		return nil
+	}
+	err = a.password.PasswordResetSave(ctx, &models.PasswordReset{
+		TokenHash: verification.HashToken(token),
+		SubjectID: exp.ID,
+		IsOrg:     req.IsOrg,
+		ExpiresAt: time.Now().UTC().Add(passwordResetTTL),
+	})
+	if err != nil {
+		a.Logger.Error(
+			"failed to send reset token",
+			zap.String("PasswordResetSave", err.Error()),
+		)
+		return nil
+	}
+	a.mail.SendMsg(&mailentity.Message{
+		Email: req.Email,
+		Type:  mail.PasswordResetType,
+		Value: mailentity.PasswordResetMsg{
+			Token: token,
+			TTL:   passwordResetTTL,
+		},
+	})
+	return nil
+}
+
+// Устанавливает новый пароль по токену из письма и завершает все сессии аккаунта
+func (a *AuthUseCase) ResetPassword(ctx context.Context, req *authdto.ResetPasswordReq) error {
+	hash, err := passwd.GetHash(req.Password)
+	if err != nil {
+		a.Logger.Error(
+			"failed to reset password",
+			zap.String("GetHash", err.Error()),
+		)
+		return err
+	}
+	reset, err := a.password.PasswordResetUse(ctx, verification.HashToken(req.Token), hash)
+	if err != nil {
+		a.Logger.Error(
+			"failed to reset password",
+			zap.String("PasswordResetUse", err.Error()),
+		)
+		return err
+	}
+	if err = a.session.SessionRevokeAll(ctx, reset.SubjectID, reset.IsOrg, "", ""); err != nil {
+		a.Logger.Error(
+			"failed to reset password",
+			zap.String("SessionRevokeAll", err.Error()),
+		)
+		return err
+	}
+	a.accountEvent(ctx, &entity.TokenMetadata{ID: uint64(reset.SubjectID), IsOrg: reset.IsOrg}, audit.ActionPasswordReset)
+	return nil
+}
+
+// Смена пароля по старому паролю. Остальные сессии аккаунта завершаются, текущая сохраняется
+func (a *AuthUseCase) ChangePassword(ctx context.Context, metadata *entity.TokenMetadata, req *authdto.ChangePasswordReq) error {
+	id := int(metadata.ID)
+	oldHash, err := a.password.PasswordHash(ctx, id, metadata.IsOrg)
+	if err != nil {
+		a.Logger.Error(
+			"failed to change password",
+			zap.String("PasswordHash", err.Error()),
+		)
+		return err
+	}
+	if passwd.CompareWithHash(req.OldPassword, oldHash) != nil {
+		return ErrInvalidCredentials
+	}
+	hash, err := passwd.GetHash(req.NewPassword)
+	if err != nil {
+		a.Logger.Error(
+			"failed to change password",
+			zap.String("GetHash", err.Error()),
+		)
+		return err
+	}
+	if err = a.password.PasswordUpdate(ctx, id, metadata.IsOrg, hash); err != nil {
+		a.Logger.Error(
+			"failed to change password",
+			zap.String("PasswordUpdate", err.Error()),
+		)
+		return err
+	}
+	if err = a.session.SessionRevokeAll(ctx, id, metadata.IsOrg, metadata.Role, metadata.SessionID); err != nil {
+		a.Logger.Error(
+			"failed to change password",
+			zap.String("SessionRevokeAll", err.Error()),
+		)
+		return err
+	}
+	a.accountEvent(ctx, metadata, audit.ActionPasswordChange)
+	return nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/reqinfo"
+	"timeline/internal/libs/throttle"
+	"timeline/internal/repository/models"
+
+	"go.uber.org/zap"
+)
+
+// Токены сброса в памяти поверх пользователей
+type fakeResets struct {
+	*fakeUsers
+	saved []*models.PasswordReset
+}
+
+func (f *fakeResets) PasswordResetSave(ctx context.Context, reset *models.PasswordReset) error {
+	f.saved = append(f.saved, reset)
+	return nil
+}
+
+func newForgotCase(ipMax int) (*AuthUseCase, *fakeResets, *fakeMail) {
+	resets := &fakeResets{fakeUsers: &fakeUsers{accounts: map[int]*fakeAccount{
+		1: {email: "known@example.com", verified: true},
+	}}}
+	post := &fakeMail{}
+	return &AuthUseCase{
+		code:     resets,
+		password: resets,
+		mail:     post,
+		limits: Limits{
+			IP:       throttle.New(throttle.NewMemoryStore(), throttle.Policy{MaxFailures: ipMax, Lockout: time.Minute}),
+			Cooldown: throttle.New(throttle.NewMemoryStore(), throttle.Policy{MaxFailures: 1, Lockout: time.Minute}),
+		},
+		Logger: zap.NewNop(),
+		now:    time.Now,
+	}, resets, post
+}
+
+func forgotFrom(ip string) context.Context {
+	return reqinfo.With(context.Background(), reqinfo.Info{IP: ip})
+}
+
+func TestForgotPasswordCooldown(t *testing.T) {
+	uc, resets, post := newForgotCase(50)
+	req := &authdto.ForgotPasswordReq{Email: "known@example.com"}
+	if err := uc.ForgotPassword(forgotFrom("10.0.0.1"), req); err != nil {
+		t.Fatal(err)
+	}
+	// Повтор на ту же почту, в том числе в другом регистре и с другого IP, ждет паузу
+	for _, email := range []string{"known@example.com", "KNOWN@example.com"} {
+		err := uc.ForgotPassword(forgotFrom("10.0.0.2"), &authdto.ForgotPasswordReq{Email: email})
+		var limited *throttle.Error
+		if !errors.As(err, &limited) {
+			t.Fatalf("%s: expected throttle error, got %v", email, err)
+		}
+	}
+	if len(resets.saved) != 1 || len(post.sent) != 1 {
+		t.Fatalf("expected one reset email, got %d tokens and %d emails", len(resets.saved), len(post.sent))
+	}
+	// Пауза у организации с той же почтой своя
+	if err := uc.ForgotPassword(forgotFrom("10.0.0.1"), &authdto.ForgotPasswordReq{Email: "known@example.com", IsOrg: true}); err != nil {
+		t.Fatalf("org: %v", err)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	uc, resets, post := newForgotCase(50)
+	req := &authdto.ForgotPasswordReq{Email: "unknown@example.com"}
+	if err := uc.ForgotPassword(forgotFrom("10.0.0.1"), req); err != nil {
+		t.Fatal(err)
+	}
+	// Несуществующая почта ограничивается так же, как существующая
+	var limited *throttle.Error
+	if err := uc.ForgotPassword(forgotFrom("10.0.0.1"), req); !errors.As(err, &limited) {
+		t.Fatalf("expected throttle error, got %v", err)
+	}
+	if len(resets.saved) != 0 || len(post.sent) != 0 {
+		t.Fatalf("expected no emails, got %d tokens and %d emails", len(resets.saved), len(post.sent))
+	}
+}
+
+func TestForgotPasswordIP(t *testing.T) {
+	uc, _, _ := newForgotCase(3)
+	ctx := forgotFrom("10.0.0.1")
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if err := uc.ForgotPassword(ctx, &authdto.ForgotPasswordReq{Email: email}); err != nil {
+			t.Fatalf("%s: %v", email, err)
+		}
+	}
+	// Перебор почт с одного IP упирается в его лимит
+	var limited *throttle.Error
+	if err := uc.ForgotPassword(ctx, &authdto.ForgotPasswordReq{Email: "d@example.com"}); !errors.As(err, &limited) || !limited.Locked {
+		t.Fatalf("expected ip lockout, got %v", err)
+	}
+	if err := uc.ForgotPassword(forgotFrom("10.0.0.2"), &authdto.ForgotPasswordReq{Email: "d@example.com"}); err != nil {
+		t.Fatalf("other ip: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/entity/dto/recordto"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/mapper/codemap"
+	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/repository/mapper/recordmap"
+	"timeline/internal/repository/mapper/usermap"
+	"timeline/internal/repository/models/recordmodel"
+	"timeline/internal/usecase/audit"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrActiveRecords   = errors.New("cancel upcoming records before deleting the account")
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+// Выгрузка всех персональных данных аккаунта: профиль, записи с отзывами и сессии
+func (a *AuthUseCase) AccountExport(ctx context.Context, metadata *entity.TokenMetadata) (*authdto.AccountExport, error) {
+	id := int(metadata.ID)
+	email, err := a.email.AccountEmail(ctx, id, metadata.IsOrg)
+	if err != nil {
+		a.Logger.Error(
+			"failed to export account",
+			zap.String("AccountEmail", err.Error()),
+		)
+		return nil, err
+	}
+	resp := &authdto.AccountExport{
+		ExportedAt: time.Now().UTC(),
+		Email:      email,
+		Records:    make([]*recordto.RecordScrap, 0),
+		Feedbacks:  make([]*recordto.Feedback, 0),
+	}
+	params := &recordmodel.RecordListParams{}
+	switch metadata.IsOrg {
+	case false:
+		user, err := a.user.UserByID(ctx, id)
+		if err != nil {
+			a.Logger.Error(
+				"failed to export account",
+				zap.String("UserByID", err.Error()),
+			)
+			return nil, err
+		}
+		resp.User = usermap.UserInfoToDTO(user)
+		params.UserID = id
+	case true:
+		org, err := a.org.OrgByID(ctx, id)
+		if err != nil {
+			a.Logger.Error(
+				"failed to export account",
+				zap.String("OrgByID", err.Error()),
+			)
+			return nil, err
+		}
+		resp.Org = orgmap.OrganizationToDTO(org)
+		params.OrgID = id
+	}
+	// RecordList отдает либо прошедшие, либо предстоящие записи
+	for _, fresh := range []bool{false, true} {
+		params.Fresh = fresh
+		records, _, err := a.records.RecordList(ctx, params)
+		if err != nil && !errors.Is(err, postgres.ErrRecordsNotFound) {
+			a.Logger.Error(
+				"failed to export account",
+				zap.String("RecordList", err.Error()),
+			)
+			return nil, err
+		}
+		resp.Records = append(resp.Records, recordmap.RecordListToDTO(records)...)
+	}
+	for _, rec := range resp.Records {
+		if rec.Feedback != nil {
+			rec.Feedback.RecordID = rec.RecordID
+			resp.Feedbacks = append(resp.Feedbacks, rec.Feedback)
+		}
+	}
+	sessions, err := a.session.SessionList(ctx, id, metadata.IsOrg, metadata.Role)
+	if err != nil {
+		a.Logger.Error(
+			"failed to export account",
+			zap.String("SessionList", err.Error()),
+		)
+		return nil, err
+	}
+	resp.Sessions = codemap.SessionListToDTO(sessions, metadata.SessionID)
+	return resp, nil
+}
+
+// Удаление аккаунта по запросу владельца. Персональные поля обезличиваются сразу,
+// остатки (сессии, сам аккаунт без записей) удаляются по крону после срока хранения
+func (a *AuthUseCase) AccountErase(ctx context.Context, metadata *entity.TokenMetadata) error {
+	if err := a.privacy.AccountErase(ctx, int(metadata.ID), metadata.IsOrg); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrActiveRecords):
+			return ErrActiveRecords
+		case errors.Is(err, postgres.ErrUserNotFound), errors.Is(err, postgres.ErrOrgNotFound):
+			return ErrAccountNotFound
+		}
+		a.Logger.Error(
+			"failed to erase account",
+			zap.String("AccountErase", err.Error()),
+		)
+		return err
+	}
+	a.accountEvent(ctx, metadata, audit.ActionDelete)
+	return nil
+}
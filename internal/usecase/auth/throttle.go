@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"timeline/internal/libs/reqinfo"
+	"timeline/internal/libs/throttle"
+)
+
+// Ограничители неудачных попыток: по аккаунту (почта/ID) и по IP клиента.
+// Cooldown - пауза между письмами на одну почту
+type Limits struct {
+	Account  *throttle.Limiter
+	IP       *throttle.Limiter
+	Cooldown *throttle.Limiter
+}
+
+// Ключи ограничителей вида <операция>:<org|user>:<субъект>
+func limitKey(op string, isOrg bool, subject string) string {
+	kind := "user"
+	if isOrg {
+		kind = "org"
+	}
+	return op + ":" + kind + ":" + strings.ToLower(strings.TrimSpace(subject))
+}
+
+func limitKeyID(op string, isOrg bool, id int) string {
+	return limitKey(op, isOrg, strconv.Itoa(id))
+}
+
+func (l Limits) allow(ctx context.Context, op, account string) error {
+	if err := l.Account.Allow(ctx, account); err != nil {
+		return err
+	}
+	return l.IP.Allow(ctx, op+":ip:"+reqinfo.From(ctx).IP)
+}
+
+// Учитывает неудачу. locked - аккаунт только что заблокирован
+func (l Limits) fail(ctx context.Context, op, account string) bool {
+	l.IP.Fail(ctx, op+":ip:"+reqinfo.From(ctx).IP)
+	locked, _ := l.Account.Fail(ctx, account)
+	return locked
+}
+
+func (l Limits) success(ctx context.Context, account string) {
+	l.Account.Success(ctx, account)
+}
+
+// Учитывает запрос письма на почту account: следующее - не раньше, чем через паузу,
+// а частые запросы с одного IP замедляются, как неудачные попытки
+func (l Limits) send(ctx context.Context, op, account string) error {
+	ip := op + ":ip:" + reqinfo.From(ctx).IP
+	if err := l.Cooldown.Allow(ctx, account); err != nil {
+		return err
+	}
+	if err := l.IP.Allow(ctx, ip); err != nil {
+		return err
+	}
+	l.Cooldown.Fail(ctx, account)
+	l.IP.Fail(ctx, ip)
+	return nil
+}
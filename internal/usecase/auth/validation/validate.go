@@ -13,12 +13,9 @@ var (
 	ErrClaimsNotFound = errors.New("not found token claims")
 )
 
-func IsCodeExpired(created_at time.Time) bool {
-	currentTime := time.Now().UTC()
-	if currentTime.Sub(created_at) > 5*time.Minute {
-		return true
-	}
-	return false
+// Код сгорает в момент expires_at
+func IsCodeExpired(expires_at time.Time) bool {
+	return !time.Now().UTC().Before(expires_at)
 }
 
 // Проверяем что в формате UTC, что между текущей датой и датой создания не больше дня.
@@ -51,3 +48,17 @@ func ValidateTokenClaims(req *jwt.Token) error {
 	}
 	return nil
 }
+
+// Refresh токен дополнительно должен содержать идентификатор и сессию
+func ValidateRefreshClaims(req *jwt.Token) error {
+	if err := ValidateTokenClaims(req); err != nil {
+		return err
+	}
+	if _, ok := req.Claims.(jwt.MapClaims)["jti"].(string); !ok {
+		return ErrWrongClaims
+	}
+	if _, ok := req.Claims.(jwt.MapClaims)["sid"].(string); !ok {
+		return ErrWrongClaims
+	}
+	return nil
+}
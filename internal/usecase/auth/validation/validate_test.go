@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsCodeExpired(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      bool
+	}{
+		{"far before expiration", now.Add(5 * time.Minute), false},
+		{"right before expiration", now.Add(time.Second), false},
+		{"at expiration", now, true},
+		{"right after expiration", now.Add(-time.Nanosecond), true},
+		{"long expired", now.Add(-time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCodeExpired(tt.expiresAt); got != tt.want {
+				t.Fatalf("IsCodeExpired(%v) = %v, want %v", tt.expiresAt, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/authdto"
+	"timeline/internal/libs/passwd"
+	"timeline/internal/libs/verification"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/mail"
+	mailentity "timeline/internal/repository/mail/entity"
+	"timeline/internal/repository/models"
+	"timeline/internal/usecase/audit"
+
+	"go.uber.org/zap"
+)
+
+// Время жизни приглашения сотрудника
+const workerInviteTTL = 72 * time.Hour
+
+var ErrWorkerNotFound = errors.New("worker not found")
+
+// Приглашает сотрудника организации: на почту уходит одноразовый токен,
+// по которому сотрудник задает пароль
+func (a *AuthUseCase) WorkerInvite(ctx context.Context, req *authdto.WorkerInviteReq) error {
+	org, err := a.org.OrgByID(ctx, req.OrgID)
+	if err != nil {
+		a.Logger.Error(
+			"failed to invite worker",
+			zap.String("OrgByID", err.Error()),
+		)
+		return err
+	}
+	token, err := verification.GenerateToken(32)
+	if err != nil {
+		a.Logger.Error(
+			"failed to invite worker",
+			zap.String("GenerateToken", err.Error()),
+		)
+		return err
+	}
+	err = a.staff.WorkerInviteSave(ctx, &models.WorkerAccount{
+		WorkerID:        req.WorkerID,
+		OrgID:           req.OrgID,
+		Email:           req.Email,
+		InviteHash:      verification.HashToken(token),
+		InviteExpiresAt: sql.NullTime{Time: time.Now().UTC().Add(workerInviteTTL), Valid: true},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrWorkerNotFound):
+			return ErrWorkerNotFound
+		case errors.Is(err, postgres.ErrEmailTaken):
+			return ErrEmailTaken
+		}
+		a.Logger.Error(
+			"failed to invite worker",
+			zap.String("WorkerInviteSave", err.Error()),
+		)
+		return err
+	}
+	// прежние сессии сотрудника завершаются: приглашение могло уйти на другую почту
+	if err = a.session.SessionRevokeAll(ctx, req.WorkerID, false, entity.RoleWorker, ""); err != nil {
+		a.Logger.Error(
+			"failed to invite worker",
+			zap.String("SessionRevokeAll", err.Error()),
+		)
+		return err
+	}
+	a.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionInvite,
+		Entity:   audit.EntityWorker,
+		EntityID: req.WorkerID,
+	})
+	a.mail.SendMsg(&mailentity.Message{
+		Email: req.Email,
+		Type:  mail.WorkerInviteType,
+		Value: mailentity.WorkerInviteMsg{
+			Organization: org.Name,
+			Token:        token,
+			TTL:          workerInviteTTL,
+		},
+	})
+	return nil
+}
+
+// Принимает приглашение, задает пароль и сразу открывает сессию сотрудника
+func (a *AuthUseCase) WorkerAccept(ctx context.Context, req *authdto.WorkerAcceptReq) (*authdto.TokenPair, error) {
+	hash, err := passwd.GetHash(req.Password)
+	if err != nil {
+		a.Logger.Error(
+			"failed to accept invite",
+			zap.String("GetHash", err.Error()),
+		)
+		return nil, err
+	}
+	account, err := a.staff.WorkerInviteAccept(ctx, verification.HashToken(req.Token), hash)
+	if err != nil {
+		a.Logger.Error(
+			"failed to accept invite",
+			zap.String("WorkerInviteAccept", err.Error()),
+		)
+		return nil, err
+	}
+	tokens, err := a.newSession(ctx, workerMetadata(account))
+	if err != nil {
+		a.Logger.Error(
+			"failed to accept invite",
+			zap.String("newSession", err.Error()),
+		)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (a *AuthUseCase) WorkerLogin(ctx context.Context, req *authdto.WorkerLoginReq) (*authdto.TokenPair, error) {
+	key := limitKey("login", false, entity.RoleWorker+":"+req.Email)
+	if err := a.limits.allow(ctx, "login", key); err != nil {
+		return nil, err
+	}
+	account, err := a.staff.WorkerAccountByEmail(ctx, req.Email)
+	if err != nil {
+		a.Logger.Error(
+			"failed login worker",
+			zap.String("WorkerAccountByEmail", err.Error()),
+		)
+		a.limits.fail(ctx, "login", key)
+		return nil, ErrInvalidCredentials
+	}
+	if passwd.CompareWithHash(req.Password, account.PasswdHash) != nil {
+		if a.limits.fail(ctx, "login", key) {
+			a.mail.SendMsg(&mailentity.Message{
+				Email: account.Email,
+				Type:  mail.LockoutType,
+				Value: a.now().Add(a.limits.Account.Lockout()),
+			})
+		}
+		return nil, ErrInvalidCredentials
+	}
+	a.limits.success(ctx, key)
+	tokens, err := a.newSession(ctx, workerMetadata(account))
+	if err != nil {
+		a.Logger.Error(
+			"failed login worker",
+			zap.String("newSession", err.Error()),
+		)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func workerMetadata(account *models.WorkerAccount) *entity.TokenMetadata {
+	return &entity.TokenMetadata{
+		ID:    uint64(account.WorkerID),
+		Role:  entity.RoleWorker,
+		OrgID: uint64(account.OrgID),
+	}
+}
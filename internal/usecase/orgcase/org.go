@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"timeline/internal/entity/dto/admindto"
 	"timeline/internal/entity/dto/orgdto"
 	"timeline/internal/repository"
 	"timeline/internal/repository/database/postgres"
 	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
@@ -15,13 +17,17 @@ import (
 type OrgUseCase struct {
 	user   repository.UserRepository
 	org    repository.OrgRepository
+	record repository.RecordRepository
+	audit  *audit.Recorder
 	Logger *zap.Logger
 }
 
-func New(userRepo repository.UserRepository, orgRepo repository.OrgRepository, logger *zap.Logger) *OrgUseCase {
+func New(userRepo repository.UserRepository, orgRepo repository.OrgRepository, recordRepo repository.RecordRepository, recorder *audit.Recorder, logger *zap.Logger) *OrgUseCase {
 	return &OrgUseCase{
 		user:   userRepo,
 		org:    orgRepo,
+		record: recordRepo,
+		audit:  recorder,
 		Logger: logger,
 	}
 }
@@ -42,6 +48,10 @@ func (o *OrgUseCase) Organization(ctx context.Context, id int) (*orgdto.Organiza
 }
 
 func (o *OrgUseCase) OrgUpdate(ctx context.Context, newOrg *orgdto.OrgUpdateReq) error {
+	var before any
+	if data, err := o.org.OrgByID(ctx, newOrg.OrgID); err == nil {
+		before = orgmap.OrganizationToDTO(data)
+	}
 	if err := o.org.OrgUpdate(ctx, orgmap.OrgUpdateToModel(newOrg)); err != nil {
 		if errors.Is(err, postgres.ErrOrgNotFound) {
 			return err
@@ -52,5 +62,22 @@ func (o *OrgUseCase) OrgUpdate(ctx context.Context, newOrg *orgdto.OrgUpdateReq)
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    newOrg.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityOrg,
+		EntityID: newOrg.OrgID,
+		Before:   before,
+		After:    newOrg,
+	})
+	// Смена часового пояса сдвигает моменты всех будущих сеансов
+	if prev, ok := before.(*orgdto.Organization); newOrg.TimeZone != "" && (!ok || prev.Info.TimeZone != newOrg.TimeZone) {
+		o.reconcileSlots(ctx, newOrg.OrgID, 0)
+	}
 	return nil
 }
+
+// Журнал действий внутри организации
+func (o *OrgUseCase) Audit(ctx context.Context, req *admindto.AuditReq) (*admindto.AuditList, error) {
+	return o.audit.List(ctx, req)
+}
@@ -0,0 +1,122 @@
+package orgcase
+
+import (
+	"context"
+	"errors"
+	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/usecase/audit"
+
+	"go.uber.org/zap"
+)
+
+var ErrResourceHours = errors.New("resource hours need open < close and must not overlap within a day")
+
+func (o *OrgUseCase) Resources(ctx context.Context, orgID int) (*orgdto.ResourceList, error) {
+	data, err := o.org.Resources(ctx, orgID)
+	if err != nil {
+		o.Logger.Error(
+			"failed to get resources",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return orgmap.ResourceListToDTO(data), nil
+}
+
+func (o *OrgUseCase) ResourceAdd(ctx context.Context, req *orgdto.Resource) (*orgdto.ResourceResp, error) {
+	if !resourceHoursValid(req) {
+		return nil, ErrResourceHours
+	}
+	resourceID, err := o.org.ResourceAdd(ctx, orgmap.ResourceToModel(req))
+	if err != nil {
+		o.Logger.Error(
+			"failed to add resource",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	req.ResourceID = resourceID
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityResource,
+		EntityID: resourceID,
+		After:    req,
+	})
+	return &orgdto.ResourceResp{
+		ResourceID: resourceID,
+	}, nil
+}
+
+func (o *OrgUseCase) ResourceUpdate(ctx context.Context, req *orgdto.Resource) error {
+	if !resourceHoursValid(req) {
+		return ErrResourceHours
+	}
+	before := o.resourceState(ctx, req.OrgID, req.ResourceID)
+	if err := o.org.ResourceUpdate(ctx, orgmap.ResourceToModel(req)); err != nil {
+		if errors.Is(err, postgres.ErrResourceNotFound) {
+			return err
+		}
+		o.Logger.Error(
+			"failed to update resource",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityResource,
+		EntityID: req.ResourceID,
+		Before:   before,
+		After:    req,
+	})
+	return nil
+}
+
+func (o *OrgUseCase) ResourceDelete(ctx context.Context, orgID, resourceID int) error {
+	before := o.resourceState(ctx, orgID, resourceID)
+	if err := o.org.ResourceDelete(ctx, orgID, resourceID); err != nil {
+		if errors.Is(err, postgres.ErrResourceNotFound) {
+			return err
+		}
+		o.Logger.Error(
+			"failed to delete resource",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    orgID,
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntityResource,
+		EntityID: resourceID,
+		Before:   before,
+	})
+	return nil
+}
+
+// Часы ресурса проверяются так же, как смены сотрудника
+func resourceHoursValid(req *orgdto.Resource) bool {
+	periods := make([]workPeriod, 0, len(req.Hours))
+	for _, v := range req.Hours {
+		periods = append(periods, workPeriod{Weekday: v.Weekday, Start: v.Open, Over: v.Close})
+	}
+	return workPeriodValid(periods...)
+}
+
+// Ресурс до изменения для журнала
+func (o *OrgUseCase) resourceState(ctx context.Context, orgID, resourceID int) any {
+	list, err := o.org.Resources(ctx, orgID)
+	if err != nil {
+		return nil
+	}
+	for _, v := range list {
+		if v.ResourceID == resourceID {
+			return orgmap.ResourceToDTO(v)
+		}
+	}
+	return nil
+}
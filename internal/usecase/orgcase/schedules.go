@@ -4,21 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 	"timeline/internal/entity"
 	"timeline/internal/entity/dto/orgdto"
 	"timeline/internal/repository/database/postgres"
 	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
 
-// Если over >= start - false
-func workPeriodValid(start, over string) bool {
-	overTime, errover := time.Parse("15:06", over)
-	startTime, errstart := time.Parse("15:06", start)
-	if overTime.Compare(startTime) <= 0 || errover != nil || errstart != nil {
-		return false
+// Рабочий интервал дня недели: смена сотрудника, часы или перерыв организации.
+// Version - начало действия версии расписания, интервалы разных версий не сравниваются
+type workPeriod struct {
+	Weekday     int
+	Version     string
+	Start, Over string
+}
+
+// Если over <= start хотя бы у одного интервала
+// или интервалы одного дня пересекаются - false
+func workPeriodValid(periods ...workPeriod) bool {
+	type dayKey struct {
+		weekday int
+		version string
+	}
+	type clock struct {
+		start, over time.Time
+	}
+	days := make(map[dayKey][]clock, len(periods))
+	for _, v := range periods {
+		overTime, errover := time.Parse("15:04", v.Over)
+		startTime, errstart := time.Parse("15:04", v.Start)
+		if errover != nil || errstart != nil || overTime.Compare(startTime) <= 0 {
+			return false
+		}
+		key := dayKey{weekday: v.Weekday, version: v.Version}
+		days[key] = append(days[key], clock{start: startTime, over: overTime})
+	}
+	for _, day := range days {
+		sort.Slice(day, func(i, j int) bool { return day[i].start.Before(day[j].start) })
+		for i := 1; i < len(day); i++ {
+			if day[i].start.Before(day[i-1].over) {
+				return false
+			}
+		}
 	}
 	return true
 }
@@ -36,13 +67,11 @@ func (o *OrgUseCase) WorkerSchedule(ctx context.Context, params *orgdto.Schedule
 }
 
 func (o *OrgUseCase) AddWorkerSchedule(ctx context.Context, schedule *orgdto.WorkerSchedule) error {
-	for i := range schedule.Schedule {
-		if !workPeriodValid(schedule.Schedule[i].Start, schedule.Schedule[i].Over) {
-			o.Logger.Error(
-				"failed cause given time is somehow incorrect",
-			)
-			return fmt.Errorf("some of the provided time is incorrect")
-		}
+	if !scheduleValid(schedule) {
+		o.Logger.Error(
+			"failed cause given time is somehow incorrect",
+		)
+		return fmt.Errorf("some of the provided time is incorrect")
 	}
 	if err := o.org.AddWorkerSchedule(ctx, orgmap.WorkerScheduleToModel(schedule)); err != nil {
 		o.Logger.Error(
@@ -51,10 +80,24 @@ func (o *OrgUseCase) AddWorkerSchedule(ctx context.Context, schedule *orgdto.Wor
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    schedule.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntitySchedule,
+		EntityID: schedule.WorkerID,
+		After:    schedule,
+	})
+	o.reconcileSlots(ctx, schedule.OrgID, schedule.WorkerID)
 	return nil
 }
 
 func (o *OrgUseCase) UpdateWorkerSchedule(ctx context.Context, schedule *orgdto.WorkerSchedule) error {
+	if !scheduleValid(schedule) {
+		o.Logger.Error(
+			"failed cause given time is somehow incorrect",
+		)
+		return fmt.Errorf("some of the provided time is incorrect")
+	}
 	worker := &orgdto.UpdateWorkerReq{
 		WorkerID: schedule.WorkerID,
 		OrgID:    schedule.OrgID,
@@ -62,9 +105,11 @@ func (o *OrgUseCase) UpdateWorkerSchedule(ctx context.Context, schedule *orgdto.
 			SessionDuration: schedule.SessionDuration,
 		},
 	}
-	if err := o.WorkerPatch(ctx, worker); err != nil {
+	// слоты пересчитываются один раз, уже по новому расписанию
+	if _, err := o.workerPatch(ctx, worker); err != nil {
 		return err
 	}
+	before := o.scheduleState(ctx, schedule.OrgID, schedule.WorkerID)
 	if err := o.org.UpdateWorkerSchedule(ctx, orgmap.WorkerScheduleToModel(schedule)); err != nil {
 		o.Logger.Error(
 			"failed to get worker schedule",
@@ -72,10 +117,20 @@ func (o *OrgUseCase) UpdateWorkerSchedule(ctx context.Context, schedule *orgdto.
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    schedule.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntitySchedule,
+		EntityID: schedule.WorkerID,
+		Before:   before,
+		After:    schedule,
+	})
+	o.reconcileSlots(ctx, schedule.OrgID, schedule.WorkerID)
 	return nil
 }
 
 func (o *OrgUseCase) DeleteWorkerSchedule(ctx context.Context, params *orgdto.ScheduleParams) error {
+	before := o.scheduleState(ctx, params.OrgID, params.WorkerID)
 	if err := o.org.DeleteWorkerSchedule(ctx, orgmap.ScheduleParamsToModel(params)); err != nil {
 		if errors.Is(err, postgres.ErrScheduleNotFound) {
 			return err
@@ -86,5 +141,42 @@ func (o *OrgUseCase) DeleteWorkerSchedule(ctx context.Context, params *orgdto.Sc
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    params.OrgID,
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntitySchedule,
+		EntityID: params.WorkerID,
+		Before:   before,
+		After:    params,
+	})
+	o.reconcileSlots(ctx, params.OrgID, params.WorkerID)
 	return nil
 }
+
+// Смены сотрудника в один день недели одной версии не пересекаются,
+// а версия не заканчивается раньше, чем начинается
+func scheduleValid(schedule *orgdto.WorkerSchedule) bool {
+	periods := make([]workPeriod, 0, len(schedule.Schedule))
+	for _, v := range schedule.Schedule {
+		// даты в формате 2006-01-02 сравниваются как строки
+		if v.ValidFrom != "" && v.ValidTo != "" && v.ValidTo < v.ValidFrom {
+			return false
+		}
+		periods = append(periods, workPeriod{Weekday: v.Weekday, Version: v.ValidFrom, Start: v.Start, Over: v.Over})
+	}
+	return workPeriodValid(periods...)
+}
+
+// Расписание сотрудника до изменения для журнала
+func (o *OrgUseCase) scheduleState(ctx context.Context, orgID, workerID int) any {
+	data, err := o.org.WorkerSchedule(ctx, orgmap.ScheduleParamsToModel(&orgdto.ScheduleParams{
+		OrgID:    orgID,
+		WorkerID: workerID,
+		Limit:    1,
+		Page:     1,
+	}))
+	if err != nil {
+		return nil
+	}
+	return orgmap.ScheduleListToDTO(data)
+}
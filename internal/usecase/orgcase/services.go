@@ -4,6 +4,7 @@ import (
 	"context"
 	"timeline/internal/entity/dto/orgdto"
 	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
@@ -37,7 +38,7 @@ func (o *OrgUseCase) ServiceWorkerList(ctx context.Context, ServiceID, OrgID int
 	return workers, nil
 }
 func (o *OrgUseCase) ServiceAdd(ctx context.Context, Service *orgdto.AddServiceReq) error {
-	_, err := o.org.ServiceAdd(ctx, orgmap.AddServiceToModel(Service))
+	serviceID, err := o.org.ServiceAdd(ctx, orgmap.AddServiceToModel(Service))
 	if err != nil {
 		o.Logger.Error(
 			"failed to add service",
@@ -45,10 +46,18 @@ func (o *OrgUseCase) ServiceAdd(ctx context.Context, Service *orgdto.AddServiceR
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    Service.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityService,
+		EntityID: serviceID,
+		After:    Service,
+	})
 	return nil
 }
 
 func (o *OrgUseCase) ServiceUpdate(ctx context.Context, Service *orgdto.UpdateServiceReq) error {
+	before := o.serviceState(ctx, Service.ServiceID, Service.OrgID)
 	if err := o.org.ServiceUpdate(ctx, orgmap.UpdateService(Service)); err != nil {
 		o.Logger.Error(
 			"failed to update service",
@@ -56,6 +65,14 @@ func (o *OrgUseCase) ServiceUpdate(ctx context.Context, Service *orgdto.UpdateSe
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    Service.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityService,
+		EntityID: Service.ServiceID,
+		Before:   before,
+		After:    Service,
+	})
 	return nil
 }
 
@@ -81,11 +98,29 @@ func (o *OrgUseCase) ServiceList(ctx context.Context, OrgID int, Limit int, Page
 }
 
 func (o *OrgUseCase) ServiceDelete(ctx context.Context, ServiceID, OrgID int) error {
+	before := o.serviceState(ctx, ServiceID, OrgID)
 	if err := o.org.ServiceDelete(ctx, ServiceID, OrgID); err != nil {
 		o.Logger.Error(
 			"failed to delete service",
 			zap.Error(err),
 		)
+		return nil
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    OrgID,
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntityService,
+		EntityID: ServiceID,
+		Before:   before,
+	})
 	return nil
 }
+
+// Состояние услуги до изменения для журнала
+func (o *OrgUseCase) serviceState(ctx context.Context, serviceID, orgID int) any {
+	service, err := o.org.Service(ctx, serviceID, orgID)
+	if err != nil {
+		return nil
+	}
+	return orgmap.ServiceToDTO(service)
+}
@@ -2,8 +2,12 @@ package orgcase
 
 import (
 	"context"
+	"errors"
 	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/repository/database/postgres"
 	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/repository/models/orgmodel"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
@@ -16,6 +20,13 @@ func (o *OrgUseCase) UpdateSlot(ctx context.Context, req *orgdto.SlotUpdate) err
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntitySlot,
+		EntityID: req.SlotID,
+		After:    req,
+	})
 	return nil
 }
 
@@ -35,3 +46,131 @@ func (o *OrgUseCase) Slots(ctx context.Context, req *orgdto.SlotReq) ([]*orgdto.
 	}
 	return resp, nil
 }
+
+// Пересчет будущих слотов после изменения расписания, рабочих часов или длительности сеанса.
+// Само изменение уже сохранено, поэтому ошибка только логируется, слоты поправит следующий пересчет
+func (o *OrgUseCase) reconcileSlots(ctx context.Context, orgID, workerID int) {
+	conflicts, err := o.org.ReconcileSlots(ctx, &orgmodel.SlotsMeta{OrgID: orgID, WorkerID: workerID})
+	if err != nil {
+		o.Logger.Error(
+			"failed to reconcile slots",
+			zap.Int("org_id", orgID),
+			zap.Int("worker_id", workerID),
+			zap.Error(err),
+		)
+		return
+	}
+	if len(conflicts) > 0 {
+		o.Logger.Warn(
+			"busy slots are outside of working hours",
+			zap.Int("org_id", orgID),
+			zap.Int("worker_id", workerID),
+			zap.Int("conflicts", len(conflicts)),
+		)
+	}
+}
+
+// Занятые слоты, не попадающие в текущее расписание: клиентов нужно перенести
+func (o *OrgUseCase) SlotConflicts(ctx context.Context, req *orgdto.SlotReq) ([]*orgdto.SlotConflict, error) {
+	data, err := o.org.SlotConflicts(ctx, &orgmodel.SlotsMeta{WorkerID: req.WorkerID, OrgID: req.OrgID})
+	if err != nil {
+		o.Logger.Error(
+			"failed to get slot conflicts",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	resp := make([]*orgdto.SlotConflict, 0, len(data))
+	for _, v := range data {
+		resp = append(resp, orgmap.SlotConflictToDTO(v))
+	}
+	return resp, nil
+}
+
+// Свободное время сотрудника под услугу, считается на лету без заранее нарезанных слотов
+func (o *OrgUseCase) Availability(ctx context.Context, req *orgdto.AvailabilityReq) (*orgdto.Availability, error) {
+	data, err := o.org.Availability(ctx, orgmap.AvailabilityReqToModel(req))
+	if err != nil {
+		if errors.Is(err, postgres.ErrServiceNotFound) {
+			return nil, err
+		}
+		o.Logger.Error(
+			"failed to get worker availability",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return orgmap.AvailabilityToDTO(data), nil
+}
+
+// Список записанных в слот клиентов для организации
+func (o *OrgUseCase) SlotAttendees(ctx context.Context, req *orgdto.AttendeesReq) (*orgdto.Attendees, error) {
+	data, err := o.org.SlotAttendees(ctx, &orgmodel.SlotsMeta{OrgID: req.OrgID, SlotID: req.SlotID})
+	if err != nil {
+		if errors.Is(err, postgres.ErrSlotNotFound) {
+			return nil, err
+		}
+		o.Logger.Error(
+			"failed to get slot attendees",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return orgmap.AttendeesToDTO(data), nil
+}
+
+// Генерация слотов сотрудника по требованию, не дожидаясь крона
+func (o *OrgUseCase) GenerateSlots(ctx context.Context, req *orgdto.SlotReq) (*orgdto.SlotGenerateResp, error) {
+	created, err := o.org.GenerateSlots(ctx, &orgmodel.SlotsMeta{WorkerID: req.WorkerID, OrgID: req.OrgID})
+	if err != nil {
+		o.Logger.Error(
+			"failed to generate slots",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &orgdto.SlotGenerateResp{Created: created}, nil
+}
+
+func (o *OrgUseCase) SlotHorizon(ctx context.Context, orgID int) (*orgdto.SlotHorizon, error) {
+	days, err := o.org.SlotHorizon(ctx, orgID)
+	if err != nil {
+		o.Logger.Error(
+			"failed to get slot horizon",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &orgdto.SlotHorizon{OrgID: orgID, Days: days}, nil
+}
+
+// Новый горизонт сразу дозаполняется слотами. Слоты за пределами уменьшенного горизонта остаются
+func (o *OrgUseCase) SlotHorizonUpdate(ctx context.Context, req *orgdto.SlotHorizon) error {
+	var before any
+	if days, err := o.org.SlotHorizon(ctx, req.OrgID); err == nil {
+		before = &orgdto.SlotHorizon{OrgID: req.OrgID, Days: days}
+	}
+	if err := o.org.SlotHorizonUpdate(ctx, req.OrgID, req.Days); err != nil {
+		o.Logger.Error(
+			"failed to update slot horizon",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityOrg,
+		EntityID: req.OrgID,
+		Before:   before,
+		After:    req,
+	})
+	if _, err := o.org.GenerateSlots(ctx, &orgmodel.SlotsMeta{OrgID: req.OrgID}); err != nil {
+		// горизонт сохранен, слоты догенерирует крон
+		o.Logger.Error(
+			"failed to generate slots",
+			zap.Error(err),
+		)
+	}
+	return nil
+}
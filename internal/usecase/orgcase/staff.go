@@ -0,0 +1,237 @@
+package orgcase
+
+import (
+	"context"
+	"errors"
+	"timeline/internal/entity"
+	"timeline/internal/entity/dto/orgdto"
+	"timeline/internal/entity/dto/recordto"
+	"timeline/internal/repository/database/postgres"
+	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/repository/mapper/recordmap"
+	"timeline/internal/repository/models/orgmodel"
+	"timeline/internal/usecase/audit"
+	"timeline/internal/usecase/auth/access"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTimeOffRange    = errors.New("time off must end not earlier than it starts")
+	ErrTimeOffInterval = errors.New("time_from and time_to must be set together and time_from < time_to")
+)
+
+// Записи клиентов к сотруднику
+func (o *OrgUseCase) WorkerRecords(ctx context.Context, params *recordto.RecordListParams) (*recordto.RecordList, error) {
+	data, found, err := o.record.RecordList(ctx, recordmap.RecordParamsToModel(params))
+	if err != nil {
+		o.Logger.Error(
+			"failed to get worker records",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &recordto.RecordList{
+		List:  recordmap.RecordListToDTO(data),
+		Found: found,
+	}, nil
+}
+
+func (o *OrgUseCase) RecordAttendance(ctx context.Context, req *recordto.AttendanceReq) error {
+	if err := o.record.RecordAttendance(ctx, recordmap.AttendanceToModel(req)); err != nil {
+		o.Logger.Error(
+			"failed to mark attendance",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityRecord,
+		EntityID: req.RecordID,
+		After:    req,
+	})
+	return nil
+}
+
+// Отсутствие сотрудника. Заявка самого сотрудника ждет решения организации,
+// отсутствие от организации одобрено сразу и снимает слоты
+func (o *OrgUseCase) TimeOffRequest(ctx context.Context, req *orgdto.TimeOffReq) (*orgdto.TimeOffResp, error) {
+	timeoff, err := timeOffToModel(req)
+	if err != nil {
+		return nil, err
+	}
+	timeoff.Status = orgmodel.TimeOffApproved
+	if byWorker(ctx) {
+		timeoff.Status = orgmodel.TimeOffPending
+	}
+	timeoffID, err := o.org.TimeOffAdd(ctx, timeoff)
+	if err != nil {
+		o.Logger.Error(
+			"failed to request time off",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityTimeOff,
+		EntityID: timeoffID,
+		After:    req,
+	})
+	if timeoff.Status == orgmodel.TimeOffApproved {
+		o.reconcileSlots(ctx, req.OrgID, req.WorkerID)
+	}
+	return &orgdto.TimeOffResp{
+		TimeOffID: timeoffID,
+	}, nil
+}
+
+func (o *OrgUseCase) TimeOffList(ctx context.Context, orgID, workerID int) (*orgdto.TimeOffList, error) {
+	data, err := o.org.TimeOffList(ctx, orgID, workerID)
+	if err != nil {
+		o.Logger.Error(
+			"failed to get time off",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &orgdto.TimeOffList{
+		List: orgmap.TimeOffListToDTO(data),
+	}, nil
+}
+
+func (o *OrgUseCase) TimeOffDecide(ctx context.Context, req *orgdto.TimeOffDecision) error {
+	if err := o.org.TimeOffStatus(ctx, orgmap.TimeOffDecisionToModel(req)); err != nil {
+		o.Logger.Error(
+			"failed to decide time off",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityTimeOff,
+		EntityID: req.TimeOffID,
+		After:    req,
+	})
+	if req.Status == orgmodel.TimeOffApproved {
+		o.reconcileSlots(ctx, req.OrgID, req.WorkerID)
+	}
+	return nil
+}
+
+func (o *OrgUseCase) TimeOff(ctx context.Context, orgID, workerID, timeoffID int) (*orgdto.TimeOff, error) {
+	data, err := o.org.TimeOff(ctx, orgID, workerID, timeoffID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrTimeOffNotFound) {
+			return nil, err
+		}
+		o.Logger.Error(
+			"failed to get time off",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return orgmap.TimeOffToDTO(data), nil
+}
+
+// Сотрудник меняет только свою заявку на рассмотрении, организация - любую
+func (o *OrgUseCase) TimeOffUpdate(ctx context.Context, req *orgdto.TimeOffReq) error {
+	timeoff, err := timeOffToModel(req)
+	if err != nil {
+		return err
+	}
+	var before any
+	if data, err := o.org.TimeOff(ctx, req.OrgID, req.WorkerID, req.TimeOffID); err == nil {
+		before = orgmap.TimeOffToDTO(data)
+	}
+	if err := o.org.TimeOffUpdate(ctx, timeoff, byWorker(ctx)); err != nil {
+		if errors.Is(err, postgres.ErrTimeOffNotFound) {
+			return err
+		}
+		o.Logger.Error(
+			"failed to update time off",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityTimeOff,
+		EntityID: req.TimeOffID,
+		Before:   before,
+		After:    req,
+	})
+	o.reconcileSlots(ctx, req.OrgID, req.WorkerID)
+	return nil
+}
+
+// Сотрудник отзывает только заявку на рассмотрении, организация удаляет любую, освобождая слоты
+func (o *OrgUseCase) TimeOffDelete(ctx context.Context, orgID, workerID, timeoffID int) error {
+	var before any
+	if data, err := o.org.TimeOff(ctx, orgID, workerID, timeoffID); err == nil {
+		before = orgmap.TimeOffToDTO(data)
+	}
+	if err := o.org.TimeOffDelete(ctx, orgID, workerID, timeoffID, byWorker(ctx)); err != nil {
+		if errors.Is(err, postgres.ErrTimeOffNotFound) {
+			return err
+		}
+		o.Logger.Error(
+			"failed to delete time off",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    orgID,
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntityTimeOff,
+		EntityID: timeoffID,
+		Before:   before,
+	})
+	o.reconcileSlots(ctx, orgID, workerID)
+	return nil
+}
+
+// Клиенты, чьи записи попадают на отсутствие: их нужно предупредить или перенести
+func (o *OrgUseCase) TimeOffCollisions(ctx context.Context, orgID, workerID, timeoffID int) (*orgdto.TimeOffCollisionList, error) {
+	data, err := o.org.TimeOffCollisions(ctx, orgID, workerID, timeoffID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrTimeOffNotFound) {
+			return nil, err
+		}
+		o.Logger.Error(
+			"failed to get time off collisions",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &orgdto.TimeOffCollisionList{
+		List: orgmap.TimeOffCollisionsToDTO(data),
+	}, nil
+}
+
+func timeOffToModel(req *orgdto.TimeOffReq) (*orgmodel.TimeOff, error) {
+	timeoff := orgmap.TimeOffReqToModel(req)
+	if timeoff.DateTo.Before(timeoff.DateFrom) {
+		return nil, ErrTimeOffRange
+	}
+	if timeoff.TimeFrom.Valid != timeoff.TimeTo.Valid {
+		return nil, ErrTimeOffInterval
+	}
+	if timeoff.TimeFrom.Valid && !timeoff.TimeFrom.Time.Before(timeoff.TimeTo.Time) {
+		return nil, ErrTimeOffInterval
+	}
+	return timeoff, nil
+}
+
+// Действует ли сам сотрудник, а не организация
+func byWorker(ctx context.Context) bool {
+	md, ok := access.FromContext(ctx)
+	return ok && md.Role == entity.RoleWorker
+}
@@ -4,23 +4,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 	"timeline/internal/entity"
 	"timeline/internal/entity/dto/orgdto"
 	"timeline/internal/repository/database/postgres"
 	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/repository/models/orgmodel"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
 
 func (o *OrgUseCase) TimetableAdd(ctx context.Context, newTimetable *orgdto.Timetable) error {
-	// Валидация начала и конца работы организации
-	for i := range newTimetable.Timetable {
-		if !workPeriodValid(newTimetable.Timetable[i].Open, newTimetable.Timetable[i].Close) {
-			o.Logger.Error(
-				"failed cause given time is somehow incorrect",
-			)
-			return fmt.Errorf("some of the provided time is incorrect")
-		}
+	// Валидация начала и конца работы организации и перерывов
+	if !timetableValid(newTimetable.Timetable) {
+		o.Logger.Error(
+			"failed cause given time is somehow incorrect",
+		)
+		return fmt.Errorf("some of the provided time is incorrect")
 	}
 	if err := o.org.TimetableAdd(ctx, newTimetable.OrgID, orgmap.TimetableToModel(newTimetable.Timetable)); err != nil {
 		if errors.Is(err, postgres.ErrOrgNotFound) {
@@ -32,17 +33,27 @@ func (o *OrgUseCase) TimetableAdd(ctx context.Context, newTimetable *orgdto.Time
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    newTimetable.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityTimetable,
+		EntityID: newTimetable.OrgID,
+		After:    newTimetable,
+	})
+	o.reconcileSlots(ctx, newTimetable.OrgID, 0)
 	return nil
 }
 
 func (o *OrgUseCase) TimetableUpdate(ctx context.Context, newTimetable *orgdto.Timetable) error {
-	for i := range newTimetable.Timetable {
-		if !workPeriodValid(newTimetable.Timetable[i].Open, newTimetable.Timetable[i].Close) {
-			o.Logger.Error(
-				"failed cause given time is somehow incorrect",
-			)
-			return fmt.Errorf("some of the provided time is incorrect")
-		}
+	if !timetableValid(newTimetable.Timetable) {
+		o.Logger.Error(
+			"failed cause given time is somehow incorrect",
+		)
+		return fmt.Errorf("some of the provided time is incorrect")
+	}
+	var before any
+	if data, err := o.org.Timetable(ctx, newTimetable.OrgID); err == nil {
+		before = orgmap.TimetableToEntity(data)
 	}
 	if err := o.org.TimetableUpdate(ctx, newTimetable.OrgID, orgmap.TimetableToModel(newTimetable.Timetable)); err != nil {
 		if errors.Is(err, postgres.ErrOrgNotFound) {
@@ -54,10 +65,51 @@ func (o *OrgUseCase) TimetableUpdate(ctx context.Context, newTimetable *orgdto.T
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    newTimetable.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityTimetable,
+		EntityID: newTimetable.OrgID,
+		Before:   before,
+		After:    newTimetable,
+	})
+	o.reconcileSlots(ctx, newTimetable.OrgID, 0)
 	return nil
 }
 
+// Один интервал работы на день недели, перерывы не пересекаются
+// и лежат внутри часов работы
+func timetableValid(timetable []*entity.OpenHours) bool {
+	days := make([]workPeriod, 0, len(timetable))
+	for _, day := range timetable {
+		days = append(days, workPeriod{Weekday: day.Weekday, Start: day.Open, Over: day.Close})
+		open, _ := time.Parse("15:04", day.Open)
+		close, _ := time.Parse("15:04", day.Close)
+		breaks := make([]workPeriod, 0, len(day.Breaks))
+		for _, v := range orgmap.DayBreaks(day) {
+			start, _ := time.Parse("15:04", v.Start)
+			end, _ := time.Parse("15:04", v.End)
+			if start.Before(open) || end.After(close) {
+				return false
+			}
+			breaks = append(breaks, workPeriod{Weekday: day.Weekday, Start: v.Start, Over: v.End})
+		}
+		if !workPeriodValid(breaks...) {
+			return false
+		}
+	}
+	return workPeriodValid(days...)
+}
+
 func (o *OrgUseCase) TimetableDelete(ctx context.Context, orgID, weekday int) error {
+	var before any
+	if data, err := o.org.Timetable(ctx, orgID); err == nil {
+		for _, v := range data {
+			if int(v.Weekday.Int32) == weekday {
+				before = orgmap.OpenHoursToDTO(v)
+			}
+		}
+	}
 	if err := o.org.TimetableDelete(ctx, orgID, weekday); err != nil {
 		if errors.Is(err, postgres.ErrOrgNotFound) {
 			return err
@@ -68,6 +120,14 @@ func (o *OrgUseCase) TimetableDelete(ctx context.Context, orgID, weekday int) er
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    orgID,
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntityTimetable,
+		EntityID: orgID,
+		Before:   before,
+	})
+	o.reconcileSlots(ctx, orgID, 0)
 	return nil
 }
 
@@ -92,3 +152,132 @@ func (o *OrgUseCase) Timetable(ctx context.Context, orgID int) (*orgdto.Timetabl
 	}
 	return resp, nil
 }
+
+var ErrExceptionHours = errors.New("open day needs open < close and a break inside working hours")
+
+// Особые дни организации. По умолчанию - на год вперед от текущего дня
+func (o *OrgUseCase) TimetableExceptions(ctx context.Context, params *orgdto.TimetableExceptionParams) (*orgdto.TimetableExceptionList, error) {
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	if params.From != "" {
+		from, _ = time.Parse(time.DateOnly, params.From)
+	}
+	to := from.AddDate(1, 0, 0)
+	if params.To != "" {
+		to, _ = time.Parse(time.DateOnly, params.To)
+	}
+	data, err := o.org.TimetableExceptions(ctx, params.OrgID, from, to)
+	if err != nil {
+		o.Logger.Error(
+			"failed to get timetable exceptions",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &orgdto.TimetableExceptionList{
+		List: orgmap.TimetableExceptionListToDTO(data),
+	}, nil
+}
+
+func (o *OrgUseCase) TimetableExceptionAdd(ctx context.Context, req *orgdto.TimetableException) (*orgdto.TimetableExceptionResp, error) {
+	exception, err := exceptionToModel(req)
+	if err != nil {
+		return nil, err
+	}
+	exceptionID, err := o.org.TimetableExceptionAdd(ctx, exception)
+	if err != nil {
+		if errors.Is(err, postgres.ErrOrgNotFound) || errors.Is(err, postgres.ErrExceptionExists) ||
+			errors.Is(err, postgres.ErrWorkerNotFound) {
+			return nil, err
+		}
+		o.Logger.Error(
+			"failed to add timetable exception",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	req.ExceptionID = exceptionID
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityException,
+		EntityID: exceptionID,
+		After:    req,
+	})
+	o.reconcileSlots(ctx, req.OrgID, 0)
+	return &orgdto.TimetableExceptionResp{
+		ExceptionID: exceptionID,
+	}, nil
+}
+
+func (o *OrgUseCase) TimetableExceptionUpdate(ctx context.Context, req *orgdto.TimetableException) error {
+	exception, err := exceptionToModel(req)
+	if err != nil {
+		return err
+	}
+	if err := o.org.TimetableExceptionUpdate(ctx, exception); err != nil {
+		if errors.Is(err, postgres.ErrExceptionNotFound) || errors.Is(err, postgres.ErrWorkerNotFound) {
+			return err
+		}
+		o.Logger.Error(
+			"failed to update timetable exception",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    req.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityException,
+		EntityID: req.ExceptionID,
+		After:    req,
+	})
+	o.reconcileSlots(ctx, req.OrgID, 0)
+	return nil
+}
+
+func (o *OrgUseCase) TimetableExceptionDelete(ctx context.Context, orgID, exceptionID int) error {
+	if err := o.org.TimetableExceptionDelete(ctx, orgID, exceptionID); err != nil {
+		if errors.Is(err, postgres.ErrExceptionNotFound) {
+			return err
+		}
+		o.Logger.Error(
+			"failed to delete timetable exception",
+			zap.Error(err),
+		)
+		return err
+	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    orgID,
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntityException,
+		EntityID: exceptionID,
+	})
+	o.reconcileSlots(ctx, orgID, 0)
+	return nil
+}
+
+// В выходной часы и сотрудники не хранятся. В рабочий нужны open < close, перерыв задается целиком и внутри часов
+func exceptionToModel(req *orgdto.TimetableException) (*orgmodel.TimetableException, error) {
+	if req.Closed {
+		req.Open, req.Close, req.BreakStart, req.BreakEnd = "", "", "", ""
+		req.Workers = nil
+	}
+	exception := orgmap.TimetableExceptionToModel(req)
+	if exception.Closed {
+		return exception, nil
+	}
+	if !exception.Open.Valid || !exception.Close.Valid || !exception.Open.Time.Before(exception.Close.Time) {
+		return nil, ErrExceptionHours
+	}
+	if exception.BreakStart.Valid != exception.BreakEnd.Valid {
+		return nil, ErrExceptionHours
+	}
+	if exception.BreakStart.Valid {
+		if exception.BreakStart.Time.Before(exception.Open.Time) ||
+			exception.BreakEnd.Time.After(exception.Close.Time) ||
+			!exception.BreakStart.Time.Before(exception.BreakEnd.Time) {
+			return nil, ErrExceptionHours
+		}
+	}
+	return exception, nil
+}
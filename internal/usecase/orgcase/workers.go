@@ -4,6 +4,7 @@ import (
 	"context"
 	"timeline/internal/entity/dto/orgdto"
 	"timeline/internal/repository/mapper/orgmap"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
@@ -28,11 +29,19 @@ func (o *OrgUseCase) WorkerAdd(ctx context.Context, worker *orgdto.AddWorkerReq)
 		)
 		return nil, err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    worker.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityWorker,
+		EntityID: workerID,
+		After:    worker,
+	})
 	return &orgdto.WorkerResp{
 		WorkerID: workerID,
 	}, nil
 }
 func (o *OrgUseCase) WorkerUpdate(ctx context.Context, worker *orgdto.UpdateWorkerReq) error {
+	before := o.workerState(ctx, worker.WorkerID, worker.OrgID)
 	if err := o.org.WorkerUpdate(ctx, orgmap.UpdateWorkerToModel(worker)); err != nil {
 		o.Logger.Error(
 			"failed to update worker",
@@ -40,18 +49,50 @@ func (o *OrgUseCase) WorkerUpdate(ctx context.Context, worker *orgdto.UpdateWork
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    worker.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityWorker,
+		EntityID: worker.WorkerID,
+		Before:   before,
+		After:    worker,
+	})
+	if durationChanged(before, worker) {
+		o.reconcileSlots(ctx, worker.OrgID, worker.WorkerID)
+	}
 	return nil
 }
 
 func (o *OrgUseCase) WorkerPatch(ctx context.Context, worker *orgdto.UpdateWorkerReq) error {
+	changed, err := o.workerPatch(ctx, worker)
+	if err != nil {
+		return err
+	}
+	if changed {
+		o.reconcileSlots(ctx, worker.OrgID, worker.WorkerID)
+	}
+	return nil
+}
+
+// Частичное обновление сотрудника. Сообщает, изменилась ли длительность сеанса
+func (o *OrgUseCase) workerPatch(ctx context.Context, worker *orgdto.UpdateWorkerReq) (bool, error) {
+	before := o.workerState(ctx, worker.WorkerID, worker.OrgID)
 	if err := o.org.WorkerPatch(ctx, orgmap.UpdateWorkerToModel(worker)); err != nil {
 		o.Logger.Error(
 			"failed to update worker",
 			zap.Error(err),
 		)
-		return err
+		return false, err
 	}
-	return nil
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    worker.OrgID,
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityWorker,
+		EntityID: worker.WorkerID,
+		Before:   before,
+		After:    worker,
+	})
+	return durationChanged(before, worker), nil
 }
 
 func (o *OrgUseCase) WorkerAssignService(ctx context.Context, assignInfo *orgdto.AssignWorkerReq) error {
@@ -62,6 +103,13 @@ func (o *OrgUseCase) WorkerAssignService(ctx context.Context, assignInfo *orgdto
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    assignInfo.OrgID,
+		Action:   audit.ActionAssign,
+		Entity:   audit.EntityWorker,
+		EntityID: assignInfo.WorkerID,
+		After:    assignInfo,
+	})
 	return nil
 }
 
@@ -73,6 +121,13 @@ func (o *OrgUseCase) WorkerUnAssignService(ctx context.Context, assignInfo *orgd
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    assignInfo.OrgID,
+		Action:   audit.ActionUnassign,
+		Entity:   audit.EntityWorker,
+		EntityID: assignInfo.WorkerID,
+		Before:   assignInfo,
+	})
 	return nil
 }
 
@@ -97,6 +152,7 @@ func (o *OrgUseCase) WorkerList(ctx context.Context, OrgID, Limit, Page int) (*o
 	return resp, nil
 }
 func (o *OrgUseCase) WorkerDelete(ctx context.Context, WorkerID, OrgID int) error {
+	before := o.workerState(ctx, WorkerID, OrgID)
 	if err := o.org.WorkerDelete(ctx, WorkerID, OrgID); err != nil {
 		o.Logger.Error(
 			"failed to delete worker",
@@ -104,5 +160,31 @@ func (o *OrgUseCase) WorkerDelete(ctx context.Context, WorkerID, OrgID int) erro
 		)
 		return err
 	}
+	o.audit.Record(ctx, &audit.Event{
+		OrgID:    OrgID,
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntityWorker,
+		EntityID: WorkerID,
+		Before:   before,
+	})
+	o.reconcileSlots(ctx, OrgID, WorkerID)
 	return nil
 }
+
+// Состояние сотрудника до изменения для журнала. Ошибка чтения не мешает самому изменению
+func (o *OrgUseCase) workerState(ctx context.Context, workerID, orgID int) any {
+	worker, err := o.org.Worker(ctx, workerID, orgID)
+	if err != nil {
+		return nil
+	}
+	return orgmap.WorkerToDTO(worker)
+}
+
+// Меняет ли запрос длительность сеанса сотрудника относительно состояния до изменения
+func durationChanged(before any, worker *orgdto.UpdateWorkerReq) bool {
+	if worker.WorkerInfo.SessionDuration == 0 {
+		return false
+	}
+	prev, ok := before.(*orgdto.WorkerResp)
+	return !ok || prev.WorkerInfo == nil || prev.WorkerInfo.SessionDuration != worker.WorkerInfo.SessionDuration
+}
@@ -6,6 +6,7 @@ import (
 	"timeline/internal/entity/dto/recordto"
 	"timeline/internal/repository/database/postgres"
 	"timeline/internal/repository/mapper/recordmap"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
@@ -41,6 +42,13 @@ func (r *RecordUseCase) FeedbackSet(ctx context.Context, feedback *recordto.Feed
 		)
 		return err
 	}
+	r.audit.Record(ctx, &audit.Event{
+		OrgID:    recordOrg(r.recordState(ctx, feedback.RecordID)),
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityFeedback,
+		EntityID: feedback.RecordID,
+		After:    feedback,
+	})
 	return nil
 }
 
@@ -52,6 +60,13 @@ func (r *RecordUseCase) FeedbackUpdate(ctx context.Context, feedback *recordto.F
 		)
 		return err
 	}
+	r.audit.Record(ctx, &audit.Event{
+		OrgID:    recordOrg(r.recordState(ctx, feedback.RecordID)),
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityFeedback,
+		EntityID: feedback.RecordID,
+		After:    feedback,
+	})
 	return nil
 }
 
@@ -63,6 +78,11 @@ func (r *RecordUseCase) FeedbackDelete(ctx context.Context, params *recordto.Fee
 		)
 		return err
 	}
-	//
+	r.audit.Record(ctx, &audit.Event{
+		OrgID:    recordOrg(r.recordState(ctx, params.RecordID)),
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntityFeedback,
+		EntityID: params.RecordID,
+	})
 	return nil
 }
@@ -2,28 +2,36 @@ package recordcase
 
 import (
 	"context"
+	"errors"
 	"timeline/internal/entity/dto/recordto"
 	"timeline/internal/repository"
+	"timeline/internal/repository/database/postgres"
 	"timeline/internal/repository/mail"
 	"timeline/internal/repository/mail/entity"
 	"timeline/internal/repository/mapper/recordmap"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
 
+var ErrSessionRequired = errors.New("slot_id or date with begin is required")
+
 type RecordUseCase struct {
 	users   repository.UserRepository
 	orgs    repository.OrgRepository
 	records repository.RecordRepository
 	mail    mail.Post
+	audit   *audit.Recorder
 	Logger  *zap.Logger
 }
 
-func New(userRepo repository.UserRepository, orgRepo repository.OrgRepository, recordRepo repository.RecordRepository, logger *zap.Logger) *RecordUseCase {
+func New(userRepo repository.UserRepository, orgRepo repository.OrgRepository, recordRepo repository.RecordRepository, recorder *audit.Recorder, mailSrv mail.Post, logger *zap.Logger) *RecordUseCase {
 	return &RecordUseCase{
 		users:   userRepo,
 		orgs:    orgRepo,
 		records: recordRepo,
+		mail:    mailSrv,
+		audit:   recorder,
 		Logger:  logger,
 	}
 }
@@ -57,14 +65,31 @@ func (r *RecordUseCase) RecordList(ctx context.Context, params *recordto.RecordL
 }
 
 func (r *RecordUseCase) RecordAdd(ctx context.Context, rec *recordto.Record) error {
-	record, err := r.records.RecordAdd(ctx, recordmap.RecordToModel(rec))
+	model := recordmap.RecordToModel(rec)
+	if model.SlotID == 0 && model.Begin.IsZero() {
+		return ErrSessionRequired
+	}
+	record, err := r.records.RecordAdd(ctx, model)
 	if err != nil {
+		if errors.Is(err, postgres.ErrSessionUnavailable) || errors.Is(err, postgres.ErrServiceNotFound) ||
+			errors.Is(err, postgres.ErrSlotFull) || errors.Is(err, postgres.ErrResourceBusy) ||
+			errors.Is(err, postgres.ErrSlotNotFound) {
+			return err
+		}
 		r.Logger.Error(
 			"failed to add record",
 			zap.Error(err),
 		)
 		return err
 	}
+	rec.RecordID = model.RecordID
+	r.audit.Record(ctx, &audit.Event{
+		OrgID:    rec.OrgID,
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityRecord,
+		EntityID: rec.RecordID,
+		After:    rec,
+	})
 	r.mail.SendMsg(&entity.Message{
 		Email:    record.UserEmail,
 		Type:     mail.ReminderType,
@@ -75,6 +100,7 @@ func (r *RecordUseCase) RecordAdd(ctx context.Context, rec *recordto.Record) err
 }
 
 func (r *RecordUseCase) RecordPatch(ctx context.Context, rec *recordto.Record) error {
+	before := r.recordState(ctx, rec.RecordID)
 	if err := r.records.RecordPatch(ctx, recordmap.RecordToModel(rec)); err != nil {
 		r.Logger.Error(
 			"failed to add record",
@@ -82,10 +108,19 @@ func (r *RecordUseCase) RecordPatch(ctx context.Context, rec *recordto.Record) e
 		)
 		return err
 	}
+	r.audit.Record(ctx, &audit.Event{
+		OrgID:    recordOrg(before),
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityRecord,
+		EntityID: rec.RecordID,
+		Before:   before,
+		After:    rec,
+	})
 	return nil
 }
 
 func (r *RecordUseCase) RecordDelete(ctx context.Context, rec *recordto.Record) error {
+	before := r.recordState(ctx, rec.RecordID)
 	if err := r.records.RecordDelete(ctx, recordmap.RecordToModel(rec)); err != nil {
 		r.Logger.Error(
 			"failed to add record",
@@ -93,5 +128,28 @@ func (r *RecordUseCase) RecordDelete(ctx context.Context, rec *recordto.Record)
 		)
 		return err
 	}
+	r.audit.Record(ctx, &audit.Event{
+		OrgID:    recordOrg(before),
+		Action:   audit.ActionDelete,
+		Entity:   audit.EntityRecord,
+		EntityID: rec.RecordID,
+		Before:   before,
+	})
 	return nil
 }
+
+// Участники записи до изменения для журнала. Ошибка чтения не мешает самому изменению
+func (r *RecordUseCase) recordState(ctx context.Context, recordID int) *recordto.Record {
+	owners, err := r.records.RecordOwners(ctx, recordID)
+	if err != nil {
+		return nil
+	}
+	return recordmap.RecordToDTO(owners)
+}
+
+func recordOrg(rec *recordto.Record) int {
+	if rec == nil {
+		return 0
+	}
+	return rec.OrgID
+}
@@ -15,6 +15,7 @@ import (
 	"timeline/internal/repository/mapper/orgmap"
 	"timeline/internal/repository/mapper/recordmap"
 	"timeline/internal/repository/mapper/usermap"
+	"timeline/internal/usecase/audit"
 
 	"go.uber.org/zap"
 )
@@ -28,14 +29,17 @@ type UserUseCase struct {
 	org     repository.OrgRepository
 	records repository.RecordRepository
 	mail    mail.Post
+	audit   *audit.Recorder
 	Logger  *zap.Logger
 }
 
-func New(userRepo repository.UserRepository, orgRepo repository.OrgRepository, recRepo repository.RecordRepository, logger *zap.Logger) *UserUseCase {
+func New(userRepo repository.UserRepository, orgRepo repository.OrgRepository, recRepo repository.RecordRepository, recorder *audit.Recorder, logger *zap.Logger) *UserUseCase {
 	return &UserUseCase{
-		user:   userRepo,
-		org:    orgRepo,
-		Logger: logger,
+		user:    userRepo,
+		org:     orgRepo,
+		records: recRepo,
+		audit:   recorder,
+		Logger:  logger,
 	}
 }
 
@@ -52,6 +56,10 @@ func (u *UserUseCase) User(ctx context.Context, id int) (*entity.User, error) {
 }
 
 func (u *UserUseCase) UserUpdate(ctx context.Context, newUser *userdto.UserUpdateReq) error {
+	var before any
+	if data, err := u.user.UserByID(ctx, newUser.UserID); err == nil {
+		before = usermap.UserInfoToDTO(data)
+	}
 	if err := u.user.UserUpdate(ctx, usermap.UserUpdateToModel(newUser)); err != nil {
 		if errors.Is(err, postgres.ErrUserNotFound) {
 			return err
@@ -62,6 +70,13 @@ func (u *UserUseCase) UserUpdate(ctx context.Context, newUser *userdto.UserUpdat
 		)
 		return err
 	}
+	u.audit.Record(ctx, &audit.Event{
+		Action:   audit.ActionUpdate,
+		Entity:   audit.EntityUser,
+		EntityID: newUser.UserID,
+		Before:   before,
+		After:    newUser,
+	})
 	return nil
 }
 
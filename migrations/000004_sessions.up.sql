@@ -0,0 +1,19 @@
+-- Каждая строка - один выданный refresh токен.
+-- Токены одной сессии (цепочки ротаций) объединены общим family
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id SERIAL PRIMARY KEY,
+    jti VARCHAR(64) UNIQUE NOT NULL,
+    family VARCHAR(64) NOT NULL,
+    subject_id INT NOT NULL,
+    is_org BOOLEAN NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    rotated BOOLEAN DEFAULT FALSE,
+    revoked BOOLEAN DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS sessions_family_idx ON sessions(family);
+CREATE INDEX IF NOT EXISTS sessions_subject_idx ON sessions(subject_id, is_org);
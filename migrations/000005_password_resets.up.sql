@@ -0,0 +1,12 @@
+-- Одноразовые токены сброса пароля. Хранится только хеш токена
+CREATE TABLE IF NOT EXISTS password_resets (
+    reset_id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    subject_id INT NOT NULL,
+    is_org BOOLEAN NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    used BOOLEAN DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS password_resets_subject_idx ON password_resets(subject_id, is_org);
@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS users_verify_user_idx;
+DROP INDEX IF EXISTS orgs_verify_org_idx;
+DROP INDEX IF EXISTS users_verify_ip_idx;
+DROP INDEX IF EXISTS orgs_verify_ip_idx;
+
+DELETE FROM users_verify;
+DELETE FROM orgs_verify;
+
+ALTER TABLE users_verify DROP COLUMN IF EXISTS attempts;
+ALTER TABLE users_verify DROP COLUMN IF EXISTS used;
+ALTER TABLE users_verify DROP COLUMN IF EXISTS ip;
+ALTER TABLE users_verify DROP COLUMN IF EXISTS created_at;
+ALTER TABLE users_verify ALTER COLUMN code TYPE VARCHAR(6);
+
+ALTER TABLE orgs_verify DROP COLUMN IF EXISTS attempts;
+ALTER TABLE orgs_verify DROP COLUMN IF EXISTS used;
+ALTER TABLE orgs_verify DROP COLUMN IF EXISTS ip;
+ALTER TABLE orgs_verify DROP COLUMN IF EXISTS created_at;
+ALTER TABLE orgs_verify ALTER COLUMN code TYPE VARCHAR(6);
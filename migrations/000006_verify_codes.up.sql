@@ -0,0 +1,21 @@
+-- Коды хранятся в виде хеша, expires_at - реальное время сгорания кода
+ALTER TABLE users_verify ALTER COLUMN code TYPE VARCHAR(64);
+ALTER TABLE users_verify ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users_verify ADD COLUMN IF NOT EXISTS used BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users_verify ADD COLUMN IF NOT EXISTS ip VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users_verify ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE orgs_verify ALTER COLUMN code TYPE VARCHAR(64);
+ALTER TABLE orgs_verify ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE orgs_verify ADD COLUMN IF NOT EXISTS used BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE orgs_verify ADD COLUMN IF NOT EXISTS ip VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE orgs_verify ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+-- Старые коды хранились в открытом виде, поэтому недействительны
+UPDATE users_verify SET used = TRUE;
+UPDATE orgs_verify SET used = TRUE;
+
+CREATE INDEX IF NOT EXISTS users_verify_user_idx ON users_verify(user_id);
+CREATE INDEX IF NOT EXISTS orgs_verify_org_idx ON orgs_verify(org_id);
+CREATE INDEX IF NOT EXISTS users_verify_ip_idx ON users_verify(ip, created_at);
+CREATE INDEX IF NOT EXISTS orgs_verify_ip_idx ON orgs_verify(ip, created_at);
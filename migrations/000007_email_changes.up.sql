@@ -0,0 +1,14 @@
+-- Заявки на смену почты. Почта меняется только после ввода кода, отправленного на новый адрес
+CREATE TABLE IF NOT EXISTS email_changes (
+    change_id SERIAL PRIMARY KEY,
+    subject_id INT NOT NULL,
+    is_org BOOLEAN NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    code VARCHAR(64) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    used BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS email_changes_subject_idx ON email_changes(subject_id, is_org);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS orgs_recovery_codes;
+DROP TABLE IF EXISTS orgs_mfa;
@@ -0,0 +1,21 @@
+-- TOTP организаций. last_step - последний принятый шаг, чтобы код нельзя было использовать повторно
+CREATE TABLE IF NOT EXISTS orgs_mfa (
+    org_id INT PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS orgs_recovery_codes (
+    recovery_id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS orgs_recovery_codes_org_idx ON orgs_recovery_codes(org_id);
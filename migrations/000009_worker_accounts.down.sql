@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS worker_timeoff;
+ALTER TABLE records DROP COLUMN IF EXISTS attended;
+DROP INDEX IF EXISTS sessions_subject_idx;
+ALTER TABLE sessions DROP COLUMN IF EXISTS role;
+CREATE INDEX IF NOT EXISTS sessions_subject_idx ON sessions(subject_id, is_org);
+DROP TABLE IF EXISTS worker_accounts;
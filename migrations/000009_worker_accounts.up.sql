@@ -0,0 +1,39 @@
+-- Учетные записи сотрудников. Создаются приглашением от организации,
+-- пароль задается при принятии приглашения. Хранится только хеш токена
+CREATE TABLE IF NOT EXISTS worker_accounts (
+    worker_id INT PRIMARY KEY,
+    org_id INT NOT NULL,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    passwd_hash TEXT NOT NULL DEFAULT '',
+    invite_hash VARCHAR(64) UNIQUE,
+    invite_expires_at TIMESTAMP,
+    activated BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (worker_id) REFERENCES workers(worker_id) ON DELETE CASCADE,
+    FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE
+);
+
+-- Сессии сотрудников хранятся вместе с остальными, роль отделяет их от пользователей
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT '';
+DROP INDEX IF EXISTS sessions_subject_idx;
+CREATE INDEX IF NOT EXISTS sessions_subject_idx ON sessions(subject_id, is_org, role);
+
+-- Отметка о посещении: NULL - не отмечено
+ALTER TABLE records ADD COLUMN IF NOT EXISTS attended BOOLEAN;
+
+-- Заявки сотрудников на отгул. Организация одобряет или отклоняет
+CREATE TABLE IF NOT EXISTS worker_timeoff (
+    timeoff_id SERIAL PRIMARY KEY,
+    worker_id INT NOT NULL,
+    org_id INT NOT NULL,
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (worker_id) REFERENCES workers(worker_id) ON DELETE CASCADE,
+    FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE,
+    CONSTRAINT timeoff_range CHECK (date_from <= date_to)
+);
+
+CREATE INDEX IF NOT EXISTS worker_timeoff_worker_idx ON worker_timeoff(worker_id, date_from);
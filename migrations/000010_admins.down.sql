@@ -0,0 +1,6 @@
+ALTER TABLE feedbacks DROP COLUMN IF EXISTS is_hidden;
+ALTER TABLE orgs DROP COLUMN IF EXISTS blocked_reason;
+ALTER TABLE orgs DROP COLUMN IF EXISTS is_blocked;
+ALTER TABLE users DROP COLUMN IF EXISTS blocked_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS is_blocked;
+DROP TABLE IF EXISTS admins;
@@ -0,0 +1,16 @@
+-- Администраторы платформы. Первый создается командой cmd/admin
+CREATE TABLE IF NOT EXISTS admins (
+    admin_id SERIAL PRIMARY KEY,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    passwd_hash TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Блокировка модератором: вход запрещен, организация скрыта из выдачи
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_blocked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blocked_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE orgs ADD COLUMN IF NOT EXISTS is_blocked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE orgs ADD COLUMN IF NOT EXISTS blocked_reason TEXT NOT NULL DEFAULT '';
+
+-- Скрытые модератором отзывы не попадают в выдачу
+ALTER TABLE feedbacks ADD COLUMN IF NOT EXISTS is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
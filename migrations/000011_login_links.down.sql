@@ -0,0 +1,4 @@
+DELETE FROM users_verify WHERE purpose <> 'verify';
+DELETE FROM orgs_verify WHERE purpose <> 'verify';
+ALTER TABLE orgs_verify DROP COLUMN IF EXISTS purpose;
+ALTER TABLE users_verify DROP COLUMN IF EXISTS purpose;
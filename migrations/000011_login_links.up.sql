@@ -0,0 +1,3 @@
+-- Коды подтверждения и ссылки входа хранятся вместе, различаются назначением
+ALTER TABLE users_verify ADD COLUMN IF NOT EXISTS purpose VARCHAR(16) NOT NULL DEFAULT 'verify';
+ALTER TABLE orgs_verify ADD COLUMN IF NOT EXISTS purpose VARCHAR(16) NOT NULL DEFAULT 'verify';
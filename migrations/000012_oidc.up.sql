@@ -0,0 +1,22 @@
+-- Внешние учетные записи (OpenID Connect), привязанные к пользователям
+CREATE TABLE IF NOT EXISTS user_identities (
+    identity_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_idx ON user_identities(user_id);
+
+-- Незавершенные входы через провайдера: state хранится хешем, verifier нужен для PKCE
+CREATE TABLE IF NOT EXISTS oidc_states (
+    state_hash VARCHAR(64) PRIMARY KEY,
+    provider VARCHAR(64) NOT NULL,
+    verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,17 @@
+-- Ключи API организаций. Сам ключ не хранится, только хеш и префикс для списка
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) UNIQUE NOT NULL,
+    scopes TEXT NOT NULL, -- через пробел
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(64) NOT NULL DEFAULT '',
+    revoked BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_org_idx ON api_keys(org_id);
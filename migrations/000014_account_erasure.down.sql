@@ -0,0 +1,42 @@
+CREATE OR REPLACE FUNCTION soft_delete_service()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE services SET is_delete = TRUE WHERE service_id = OLD.service_id;
+    UPDATE worker_services SET is_delete = TRUE WHERE service_id = OLD.service_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION soft_delete_worker()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE workers SET is_delete = TRUE WHERE worker_id = OLD.worker_id;
+    UPDATE worker_schedules SET is_delete = TRUE WHERE worker_id = OLD.worker_id;
+    UPDATE worker_services SET is_delete = TRUE WHERE worker_id = OLD.worker_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION soft_delete_org()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE orgs SET is_delete = TRUE WHERE org_id = OLD.org_id;
+    UPDATE services SET is_delete = TRUE WHERE org_id = OLD.org_id;
+    UPDATE workers SET is_delete = TRUE WHERE org_id = OLD.org_id;
+    DELETE FROM orgs_verify WHERE org_id = OLD.org_id;
+    DELETE FROM timetables WHERE org_id = OLD.org_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION soft_delete_user()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE users SET is_delete = TRUE WHERE user_id = OLD.user_id;
+    DELETE FROM users_verify WHERE user_id = OLD.user_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE orgs DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
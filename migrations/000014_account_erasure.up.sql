@@ -0,0 +1,57 @@
+-- Момент удаления аккаунта. От него отсчитывается срок хранения до окончательной очистки
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE orgs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Первый DELETE помечает строку удаленной, повторный (очистка) удаляет ее по-настоящему
+CREATE OR REPLACE FUNCTION soft_delete_user()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.is_delete THEN
+        RETURN OLD;
+    END IF;
+    UPDATE users SET is_delete = TRUE, deleted_at = CURRENT_TIMESTAMP WHERE user_id = OLD.user_id;
+    DELETE FROM users_verify WHERE user_id = OLD.user_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION soft_delete_org()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.is_delete THEN
+        RETURN OLD;
+    END IF;
+    UPDATE orgs SET is_delete = TRUE, deleted_at = CURRENT_TIMESTAMP WHERE org_id = OLD.org_id;
+    UPDATE services SET is_delete = TRUE WHERE org_id = OLD.org_id;
+    UPDATE workers SET is_delete = TRUE WHERE org_id = OLD.org_id;
+    DELETE FROM orgs_verify WHERE org_id = OLD.org_id;
+    DELETE FROM timetables WHERE org_id = OLD.org_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Каскадное удаление организации проходит через уже помеченных сотрудников и услуги
+CREATE OR REPLACE FUNCTION soft_delete_worker()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.is_delete THEN
+        RETURN OLD;
+    END IF;
+    UPDATE workers SET is_delete = TRUE WHERE worker_id = OLD.worker_id;
+    UPDATE worker_schedules SET is_delete = TRUE WHERE worker_id = OLD.worker_id;
+    UPDATE worker_services SET is_delete = TRUE WHERE worker_id = OLD.worker_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION soft_delete_service()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.is_delete THEN
+        RETURN OLD;
+    END IF;
+    UPDATE services SET is_delete = TRUE WHERE service_id = OLD.service_id;
+    UPDATE worker_services SET is_delete = TRUE WHERE service_id = OLD.service_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
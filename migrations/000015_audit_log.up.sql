@@ -0,0 +1,18 @@
+-- Журнал действий. actor_type: user, org, worker, admin
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id BIGSERIAL PRIMARY KEY,
+    actor_type VARCHAR(16) NOT NULL,
+    actor_id INT NOT NULL,
+    org_id INT,
+    action VARCHAR(64) NOT NULL,
+    entity VARCHAR(32) NOT NULL,
+    entity_id INT NOT NULL DEFAULT 0,
+    before JSONB,
+    after JSONB,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_org_idx ON audit_log(org_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log(entity, entity_id);
@@ -0,0 +1,3 @@
+ALTER TABLE slots DROP CONSTRAINT IF EXISTS unique_worker_session;
+
+ALTER TABLE orgs DROP COLUMN IF EXISTS slot_horizon;
@@ -0,0 +1,59 @@
+-- На сколько дней вперед генерируются слоты организации
+ALTER TABLE orgs ADD COLUMN IF NOT EXISTS slot_horizon INT NOT NULL DEFAULT 14
+    CONSTRAINT slot_horizon_range CHECK (slot_horizon BETWEEN 1 AND 90);
+
+-- Начало и конец сеанса хранились как время без даты. Переносим дату слота внутрь,
+-- чтобы сеанс сотрудника был уникален и повторная генерация не плодила дубли
+UPDATE slots SET
+    session_begin = date + session_begin::time,
+    session_end = date + session_end::time
+WHERE date IS NOT NULL;
+
+-- Дубли от прошлых перезапусков. В каждой группе остается занятый или самый ранний слот.
+-- Записи с остальных дублей переносятся на него, чтобы не потерять ни одну бронь
+WITH dup AS (
+    SELECT
+        s.slot_id,
+        first_value(s.slot_id) OVER (
+            PARTITION BY s.worker_id, s.session_begin
+            ORDER BY (COALESCE(s.busy, false) OR EXISTS (SELECT 1 FROM records r WHERE r.slot_id = s.slot_id)) DESC, s.slot_id
+        ) AS keep_id
+    FROM slots s
+    WHERE s.worker_id IS NOT NULL
+    AND s.session_begin IS NOT NULL
+)
+UPDATE records r
+SET slot_id = dup.keep_id
+FROM dup
+WHERE r.slot_id = dup.slot_id
+AND dup.slot_id <> dup.keep_id;
+
+UPDATE slots s
+SET busy = true
+WHERE COALESCE(s.busy, false) = false
+AND EXISTS (SELECT 1 FROM records r WHERE r.slot_id = s.slot_id)
+AND EXISTS (
+    SELECT 1 FROM slots d
+    WHERE d.worker_id = s.worker_id
+    AND d.session_begin = s.session_begin
+    AND d.slot_id <> s.slot_id
+);
+
+WITH dup AS (
+    SELECT
+        slot_id,
+        first_value(slot_id) OVER (
+            PARTITION BY worker_id, session_begin
+            ORDER BY COALESCE(busy, false) DESC, slot_id
+        ) AS keep_id
+    FROM slots
+    WHERE worker_id IS NOT NULL
+    AND session_begin IS NOT NULL
+)
+DELETE FROM slots s
+USING dup
+WHERE s.slot_id = dup.slot_id
+AND dup.slot_id <> dup.keep_id
+AND NOT EXISTS (SELECT 1 FROM records r WHERE r.slot_id = s.slot_id);
+
+ALTER TABLE slots ADD CONSTRAINT unique_worker_session UNIQUE (worker_id, session_begin);
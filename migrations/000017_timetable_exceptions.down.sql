@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS org_day_hours(INT, DATE);
+
+DROP TABLE IF EXISTS timetable_exceptions;
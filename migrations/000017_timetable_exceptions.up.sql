@@ -0,0 +1,37 @@
+-- Особые дни организации: праздники, сокращенные дни, разовые рабочие дни.
+-- closed = true - выходной, иначе часы этого дня заменяют недельное расписание
+CREATE TABLE IF NOT EXISTS timetable_exceptions (
+    exception_id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL,
+    date DATE NOT NULL,
+    closed BOOLEAN NOT NULL DEFAULT FALSE,
+    open TIMESTAMP,
+    close TIMESTAMP,
+    break_start TIMESTAMP,
+    break_end TIMESTAMP,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE,
+    CONSTRAINT unique_org_date UNIQUE (org_id, date),
+    CONSTRAINT exception_hours CHECK (closed OR (open IS NOT NULL AND close IS NOT NULL))
+);
+
+-- Часы работы организации в конкретный день с учетом особых дней.
+-- Пустой результат - организация в этот день не работает
+CREATE OR REPLACE FUNCTION org_day_hours(p_org_id INT, p_day DATE)
+RETURNS TABLE (weekday INT, open TIMESTAMP, close TIMESTAMP, break_start TIMESTAMP, break_end TIMESTAMP) AS $$
+    SELECT EXTRACT(ISODOW FROM p_day)::INT, e.open, e.close, e.break_start, e.break_end
+    FROM timetable_exceptions e
+    WHERE e.org_id = p_org_id
+    AND e.date = p_day
+    AND e.closed = false
+    UNION ALL
+    SELECT t.weekday, t.open, t.close, t.break_start, t.break_end
+    FROM timetables t
+    WHERE t.org_id = p_org_id
+    AND t.weekday = EXTRACT(ISODOW FROM p_day)
+    AND NOT EXISTS (
+        SELECT 1 FROM timetable_exceptions e
+        WHERE e.org_id = p_org_id
+        AND e.date = p_day
+    );
+$$ LANGUAGE sql STABLE;
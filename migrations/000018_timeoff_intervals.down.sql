@@ -0,0 +1,4 @@
+ALTER TABLE worker_timeoff DROP CONSTRAINT IF EXISTS timeoff_interval;
+ALTER TABLE worker_timeoff DROP COLUMN IF EXISTS time_to;
+ALTER TABLE worker_timeoff DROP COLUMN IF EXISTS time_from;
+ALTER TABLE worker_timeoff DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,9 @@
+-- Вид отсутствия и часть дня. Без time_from/time_to отсутствие занимает дни целиком,
+-- иначе интервал повторяется в каждый день периода
+ALTER TABLE worker_timeoff ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'other';
+ALTER TABLE worker_timeoff ADD COLUMN IF NOT EXISTS time_from TIMESTAMP;
+ALTER TABLE worker_timeoff ADD COLUMN IF NOT EXISTS time_to TIMESTAMP;
+ALTER TABLE worker_timeoff ADD CONSTRAINT timeoff_interval CHECK (
+    (time_from IS NULL AND time_to IS NULL)
+    OR (time_from IS NOT NULL AND time_to IS NOT NULL AND time_from::time < time_to::time)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE services DROP CONSTRAINT IF EXISTS service_duration;
+ALTER TABLE services DROP COLUMN IF EXISTS buffer_after;
+ALTER TABLE services DROP COLUMN IF EXISTS buffer_before;
+ALTER TABLE services DROP COLUMN IF EXISTS duration;
@@ -0,0 +1,10 @@
+-- Длительность услуги и буферы до/после сеанса в минутах.
+-- Без длительности берется длительность сеанса сотрудника
+ALTER TABLE services ADD COLUMN IF NOT EXISTS duration INT;
+ALTER TABLE services ADD COLUMN IF NOT EXISTS buffer_before INT NOT NULL DEFAULT 0;
+ALTER TABLE services ADD COLUMN IF NOT EXISTS buffer_after INT NOT NULL DEFAULT 0;
+ALTER TABLE services ADD CONSTRAINT service_duration CHECK (
+    (duration IS NULL OR duration > 0)
+    AND buffer_before >= 0
+    AND buffer_after >= 0
+);
@@ -0,0 +1,5 @@
+ALTER TABLE worker_timeoff ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE slots ALTER COLUMN session_end TYPE TIMESTAMP USING session_end AT TIME ZONE 'Europe/Moscow';
+ALTER TABLE slots ALTER COLUMN session_begin TYPE TIMESTAMP USING session_begin AT TIME ZONE 'Europe/Moscow';
+DROP FUNCTION IF EXISTS org_today(INT);
+ALTER TABLE orgs DROP COLUMN IF EXISTS timezone;
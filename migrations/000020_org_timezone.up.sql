@@ -0,0 +1,17 @@
+-- Часовой пояс организации (имя IANA). Часы работы, расписания сотрудников и отсутствия
+-- остаются настенным временем организации, а сеансы хранятся как моменты времени
+ALTER TABLE orgs ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow';
+
+-- Текущий день по часам организации
+CREATE OR REPLACE FUNCTION org_today(p_org_id INT)
+RETURNS DATE AS $$
+    SELECT (now() AT TIME ZONE COALESCE(
+        (SELECT timezone FROM orgs WHERE org_id = p_org_id),
+        'Europe/Moscow'
+    ))::date;
+$$ LANGUAGE sql STABLE;
+
+-- До сих пор сеансы писались настенным временем, а все организации были по Москве
+ALTER TABLE slots ALTER COLUMN session_begin TYPE TIMESTAMPTZ USING session_begin AT TIME ZONE 'Europe/Moscow';
+ALTER TABLE slots ALTER COLUMN session_end TYPE TIMESTAMPTZ USING session_end AT TIME ZONE 'Europe/Moscow';
+ALTER TABLE worker_timeoff ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS unique_worker_weekday_start;
+-- В один день недели остается только первый интервал сотрудника
+DELETE FROM worker_schedules ws
+USING worker_schedules other
+WHERE ws.worker_id = other.worker_id
+AND ws.weekday = other.weekday
+AND ws.worker_schedule_id > other.worker_schedule_id;
+ALTER TABLE worker_schedules ADD CONSTRAINT unique_worker_weekday UNIQUE (worker_id, weekday);
+
+DROP FUNCTION IF EXISTS org_day_breaks(INT, DATE);
+DROP TABLE IF EXISTS timetable_breaks;
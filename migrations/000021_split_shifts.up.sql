@@ -0,0 +1,46 @@
+-- Перерывы организации: у дня недели их может быть несколько (обед, технические перерывы).
+-- break_start/break_end в timetables остаются первым перерывом дня для старых клиентов
+CREATE TABLE IF NOT EXISTS timetable_breaks (
+    break_id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL,
+    weekday INT NOT NULL,
+    break_start TIMESTAMP NOT NULL,
+    break_end TIMESTAMP NOT NULL,
+    FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE,
+    CONSTRAINT break_period CHECK (break_start::time < break_end::time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_timetable_breaks_org_weekday ON timetable_breaks (org_id, weekday);
+
+-- Пустой перерыв (00:00-00:00) означал его отсутствие
+INSERT INTO timetable_breaks (org_id, weekday, break_start, break_end)
+SELECT org_id, weekday, break_start, break_end
+FROM timetables
+WHERE break_start::time < break_end::time;
+
+-- Перерывы организации в конкретный день с учетом особых дней
+CREATE OR REPLACE FUNCTION org_day_breaks(p_org_id INT, p_day DATE)
+RETURNS TABLE (break_start TIMESTAMP, break_end TIMESTAMP) AS $$
+    SELECT e.break_start, e.break_end
+    FROM timetable_exceptions e
+    WHERE e.org_id = p_org_id
+    AND e.date = p_day
+    AND e.closed = false
+    AND e.break_start IS NOT NULL
+    AND e.break_end IS NOT NULL
+    UNION ALL
+    SELECT b.break_start, b.break_end
+    FROM timetable_breaks b
+    WHERE b.org_id = p_org_id
+    AND b.weekday = EXTRACT(ISODOW FROM p_day)
+    AND NOT EXISTS (
+        SELECT 1 FROM timetable_exceptions e
+        WHERE e.org_id = p_org_id
+        AND e.date = p_day
+    );
+$$ LANGUAGE sql STABLE;
+
+-- Сменная работа: несколько интервалов сотрудника в один день недели.
+-- Пересечение интервалов проверяется приложением
+ALTER TABLE worker_schedules DROP CONSTRAINT IF EXISTS unique_worker_weekday;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_worker_weekday_start ON worker_schedules (worker_id, weekday, start) WHERE is_delete = false;
@@ -0,0 +1,14 @@
+DROP FUNCTION IF EXISTS worker_day_schedule(INT, DATE);
+
+-- Остается только версия, действующая сегодня
+UPDATE worker_schedules
+SET is_delete = true
+WHERE is_delete = false
+AND (COALESCE(valid_from, DATE '-infinity') > CURRENT_DATE OR COALESCE(valid_to, DATE 'infinity') < CURRENT_DATE);
+
+DROP INDEX IF EXISTS unique_worker_weekday_start;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_worker_weekday_start ON worker_schedules (worker_id, weekday, start) WHERE is_delete = false;
+
+ALTER TABLE worker_schedules DROP CONSTRAINT IF EXISTS schedule_validity;
+ALTER TABLE worker_schedules DROP COLUMN IF EXISTS valid_to;
+ALTER TABLE worker_schedules DROP COLUMN IF EXISTS valid_from;
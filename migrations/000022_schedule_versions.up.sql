@@ -0,0 +1,30 @@
+-- Версии расписания сотрудника: строки действуют с valid_from по valid_to включительно.
+-- NULL - без ограничения. Так организация публикует график следующего месяца заранее
+ALTER TABLE worker_schedules ADD COLUMN IF NOT EXISTS valid_from DATE;
+ALTER TABLE worker_schedules ADD COLUMN IF NOT EXISTS valid_to DATE;
+ALTER TABLE worker_schedules ADD CONSTRAINT schedule_validity CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to);
+
+DROP INDEX IF EXISTS unique_worker_weekday_start;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_worker_weekday_start ON worker_schedules
+    (worker_id, weekday, start, COALESCE(valid_from, DATE '-infinity')) WHERE is_delete = false;
+
+-- Расписание сотрудника, действующее в день p_day (все дни недели версии).
+-- Из действующих в этот день версий выбирается начавшаяся позже всех:
+-- она целиком заменяет предыдущую, пока не закончится
+CREATE OR REPLACE FUNCTION worker_day_schedule(p_worker_id INT, p_day DATE)
+RETURNS SETOF worker_schedules AS $$
+    SELECT ws.*
+    FROM worker_schedules ws
+    WHERE ws.worker_id = p_worker_id
+    AND ws.is_delete = false
+    AND COALESCE(ws.valid_from, DATE '-infinity') <= p_day
+    AND COALESCE(ws.valid_to, DATE 'infinity') >= p_day
+    AND COALESCE(ws.valid_from, DATE '-infinity') = (
+        SELECT MAX(COALESCE(x.valid_from, DATE '-infinity'))
+        FROM worker_schedules x
+        WHERE x.worker_id = p_worker_id
+        AND x.is_delete = false
+        AND COALESCE(x.valid_from, DATE '-infinity') <= p_day
+        AND COALESCE(x.valid_to, DATE 'infinity') >= p_day
+    );
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,9 @@
+DROP FUNCTION IF EXISTS slot_capacity(INT, INT);
+
+ALTER TABLE slots DROP CONSTRAINT IF EXISTS slot_seats_taken;
+ALTER TABLE slots DROP CONSTRAINT IF EXISTS slot_seats;
+ALTER TABLE slots DROP COLUMN IF EXISTS seats_taken;
+ALTER TABLE slots DROP COLUMN IF EXISTS capacity;
+
+ALTER TABLE services DROP CONSTRAINT IF EXISTS service_capacity;
+ALTER TABLE services DROP COLUMN IF EXISTS capacity;
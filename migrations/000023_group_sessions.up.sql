@@ -0,0 +1,30 @@
+-- Групповые занятия: услуга принимает до capacity клиентов на один сеанс
+ALTER TABLE services ADD COLUMN IF NOT EXISTS capacity INT NOT NULL DEFAULT 1;
+ALTER TABLE services ADD CONSTRAINT service_capacity CHECK (capacity >= 1);
+
+-- capacity слота задает организация, NULL - вместимость услуги записей слота.
+-- seats_taken ведется записями, busy - мест нет или слот закрыт организацией
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS capacity INT;
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS seats_taken INT NOT NULL DEFAULT 0;
+
+UPDATE slots s
+SET seats_taken = (SELECT COUNT(*) FROM records r WHERE r.slot_id = s.slot_id);
+
+-- Вместимость слота: заданная организацией, иначе услуги уже записанных клиентов
+-- (в один слот записываются на одну услугу), иначе услуги p_service_id
+CREATE OR REPLACE FUNCTION slot_capacity(p_slot_id INT, p_service_id INT)
+RETURNS INT AS $$
+    SELECT COALESCE(
+        (SELECT s.capacity FROM slots s WHERE s.slot_id = p_slot_id),
+        (SELECT srvc.capacity
+            FROM records r
+            JOIN services srvc ON srvc.service_id = r.service_id
+            WHERE r.slot_id = p_slot_id
+            LIMIT 1),
+        (SELECT srvc.capacity FROM services srvc WHERE srvc.service_id = p_service_id),
+        1
+    );
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE slots ADD CONSTRAINT slot_seats CHECK (capacity IS NULL OR (capacity >= 1 AND seats_taken <= capacity));
+ALTER TABLE slots ADD CONSTRAINT slot_seats_taken CHECK (seats_taken >= 0);
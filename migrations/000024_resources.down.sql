@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS slot_resources;
+DROP TABLE IF EXISTS service_resources;
+DROP TABLE IF EXISTS resource_hours;
+DROP TABLE IF EXISTS resources;
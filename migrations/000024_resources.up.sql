@@ -0,0 +1,47 @@
+-- Ресурсы организации: кабинеты, оборудование, корты. Услуга может требовать
+-- несколько ресурсов, тогда запись занимает сотрудника и все ресурсы сразу
+CREATE TABLE IF NOT EXISTS resources (
+    resource_id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_delete BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_resources_org ON resources (org_id) WHERE is_delete = false;
+
+-- Часы доступности ресурса, настенное время организации.
+-- Нет ни одной строки - ресурс доступен все часы работы организации
+CREATE TABLE IF NOT EXISTS resource_hours (
+    resource_hours_id SERIAL PRIMARY KEY,
+    resource_id INT NOT NULL,
+    weekday INT NOT NULL,
+    open TIMESTAMP NOT NULL,
+    close TIMESTAMP NOT NULL,
+    FOREIGN KEY (resource_id) REFERENCES resources(resource_id) ON DELETE CASCADE,
+    CONSTRAINT resource_weekday CHECK (weekday BETWEEN 1 AND 7),
+    CONSTRAINT resource_period CHECK (open::time < close::time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_resource_hours_resource ON resource_hours (resource_id);
+
+-- Ресурсы, без которых услугу не оказать
+CREATE TABLE IF NOT EXISTS service_resources (
+    service_id INT NOT NULL,
+    resource_id INT NOT NULL,
+    PRIMARY KEY (service_id, resource_id),
+    FOREIGN KEY (service_id) REFERENCES services(service_id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(resource_id) ON DELETE CASCADE
+);
+
+-- Ресурсы, занятые слотом, пока в нем есть записи
+CREATE TABLE IF NOT EXISTS slot_resources (
+    slot_id INT NOT NULL,
+    resource_id INT NOT NULL,
+    PRIMARY KEY (slot_id, resource_id),
+    FOREIGN KEY (slot_id) REFERENCES slots(slot_id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(resource_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_slot_resources_resource ON slot_resources (resource_id);
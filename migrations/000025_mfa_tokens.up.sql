@@ -0,0 +1,5 @@
+-- Погашенные mfa_token. Хранятся до истечения срока токена, чтобы его нельзя было обменять повторно
+CREATE TABLE IF NOT EXISTS mfa_used_tokens (
+    jti_hash VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS worker_day_shifts(INT, DATE);
+DROP TABLE IF EXISTS timetable_exception_workers;
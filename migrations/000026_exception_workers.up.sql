@@ -0,0 +1,36 @@
+-- Сотрудники, которые выходят в особый рабочий день. В этот день они принимают
+-- в часы особого дня, даже если по недельному расписанию не работают,
+-- например в разовую рабочую субботу
+CREATE TABLE IF NOT EXISTS timetable_exception_workers (
+    exception_id INT NOT NULL,
+    worker_id INT NOT NULL,
+    PRIMARY KEY (exception_id, worker_id),
+    FOREIGN KEY (exception_id) REFERENCES timetable_exceptions(exception_id) ON DELETE CASCADE,
+    FOREIGN KEY (worker_id) REFERENCES workers(worker_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_exception_workers_worker ON timetable_exception_workers (worker_id);
+
+-- Смены сотрудника в день p_day. Вышедший в особый день работает в его часы
+-- (смена без строки расписания), остальные - по действующей версии расписания
+CREATE OR REPLACE FUNCTION worker_day_shifts(p_worker_id INT, p_day DATE)
+RETURNS TABLE (worker_schedule_id INT, org_id INT, start TIMESTAMP, over TIMESTAMP) AS $$
+    SELECT NULL::INT, e.org_id, e.open, e.close
+    FROM timetable_exceptions e
+    JOIN timetable_exception_workers ew ON ew.exception_id = e.exception_id
+    WHERE ew.worker_id = p_worker_id
+    AND e.date = p_day
+    AND e.closed = false
+    UNION ALL
+    SELECT ws.worker_schedule_id, ws.org_id, ws.start, ws.over
+    FROM worker_day_schedule(p_worker_id, p_day) ws
+    WHERE ws.weekday = EXTRACT(ISODOW FROM p_day)
+    AND NOT EXISTS (
+        SELECT 1
+        FROM timetable_exceptions e
+        JOIN timetable_exception_workers ew ON ew.exception_id = e.exception_id
+        WHERE ew.worker_id = p_worker_id
+        AND e.date = p_day
+        AND e.closed = false
+    );
+$$ LANGUAGE sql STABLE;
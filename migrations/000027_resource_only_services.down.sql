@@ -0,0 +1,13 @@
+-- Записи без сотрудника в прежней схеме не выразить
+DELETE FROM records r
+USING slots s
+WHERE s.slot_id = r.slot_id
+AND s.worker_id IS NULL;
+
+DELETE FROM slots WHERE worker_id IS NULL;
+
+DROP INDEX IF EXISTS idx_slots_org_date;
+ALTER TABLE slots DROP CONSTRAINT IF EXISTS slots_org_id_fkey;
+ALTER TABLE slots DROP COLUMN IF EXISTS org_id;
+
+ALTER TABLE services DROP COLUMN IF EXISTS resource_only;
@@ -0,0 +1,14 @@
+-- Услуга без сотрудника: бронируются только нужные ей ресурсы (корт, переговорная).
+-- Длительность у такой услуги своя: сеанса сотрудника, из которого ее взять, нет
+ALTER TABLE services ADD COLUMN IF NOT EXISTS resource_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Слот без сотрудника относится к организации напрямую
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS org_id INT;
+ALTER TABLE slots ADD CONSTRAINT slots_org_id_fkey FOREIGN KEY (org_id) REFERENCES orgs(org_id) ON DELETE CASCADE;
+
+UPDATE slots s
+SET org_id = w.org_id
+FROM workers w
+WHERE w.worker_id = s.worker_id;
+
+CREATE INDEX IF NOT EXISTS idx_slots_org_date ON slots (org_id, date) WHERE worker_id IS NULL;